@@ -52,7 +52,11 @@ func main() {
 	gradingSvc := services.NewGradingService()
 	pdfSvc := services.NewPDFService()
 	emailSvc := services.NewEmailService(cfg.ResendAPIKey, cfg.EmailFromAddr)
-	aiSvc := services.NewAIService(cfg.ClaudeAPIKey, cfg.ClaudeModel)
+	var aiSvc services.AIProvider = services.NewClaudeProvider(cfg.ClaudeAPIKey, cfg.ClaudeModel, cfg.ClaudeBaseURL)
+	if cfg.AIProvider == "fake" {
+		log.Println("ai: using the fake provider; AI replies are scripted placeholders")
+		aiSvc = services.NewFakeAIProvider()
+	}
 	_ = gradingSvc
 	_ = pdfSvc
 	_ = emailSvc
@@ -80,16 +84,38 @@ func main() {
 	authH := handlers.NewAuthHandler(db.Pool, jwtSvc, loginEncryptor)
 	gradesH := handlers.NewGradesHandler(db.Pool, gradingSvc)
 	assignmentsH := handlers.NewAssignmentsHandler(db.Pool, storageSvc)
-	adminH := handlers.NewAdminHandler(db.Pool, emailSvc)
-	dashboardH := handlers.NewDashboardHandler(db.Pool)
-	aiH := handlers.NewAIHandler(db.Pool, aiSvc)
-	documentsH := handlers.NewDocumentsHandler(db.Pool, pdfSvc, storageSvc, verificationSvc, cfg.FrontendOrigin)
+	adminH := handlers.NewAdminHandler(db.Pool, emailSvc, cfg.FrontendOrigin)
+	dashboardH := handlers.NewDashboardHandler(db.Pool, gradingSvc)
+	aiGateway := services.NewAIGateway(aiSvc)
+	aiH := handlers.NewAIHandler(db.Pool, aiGateway)
+	documentsH := handlers.NewDocumentsHandler(db.Pool, pdfSvc, storageSvc, verificationSvc, gradingSvc, cfg.FrontendOrigin)
 	digitalIDH := handlers.NewDigitalIDHandler(db.Pool, storageSvc, verificationSvc, cfg.FrontendOrigin)
 	scheduleH := handlers.NewScheduleHandler(db.Pool)
 	reportsH := handlers.NewReportsHandler(db.Pool, pdfSvc, storageSvc, gradingSvc)
+	gradeChangesH := handlers.NewGradeChangesHandler(db.Pool, reportsH)
+	oneRosterH := handlers.NewOneRosterHandler(db.Pool)
 	coursesH := handlers.NewCoursesHandler(db.Pool)
+	enrollmentsH := handlers.NewEnrollmentsHandler(db.Pool)
 	studentsH := handlers.NewStudentsHandler(db.Pool)
 	superAdminH := handlers.NewSuperAdminHandler(db.Pool, emailSvc)
+	attendanceH := handlers.NewAttendanceHandler(db.Pool)
+	termsH := handlers.NewTermsHandler(db.Pool)
+	templatesH := handlers.NewTemplatesHandler(db.Pool, pdfSvc, storageSvc)
+	brandingH := handlers.NewBrandingHandler(db.Pool, storageSvc)
+	standardsH := handlers.NewStandardsHandler(db.Pool, gradingSvc)
+	submissionsH := handlers.NewSubmissionsHandler(db.Pool, storageSvc)
+	extensionsH := handlers.NewExtensionsHandler(db.Pool)
+	rubricsH := handlers.NewRubricsHandler(db.Pool)
+	alertsH := handlers.NewAlertsHandler(db.Pool, aiGateway)
+
+	// Pick up batch report jobs interrupted by a previous shutdown or crash.
+	go reportsH.ResumeReportJobs(context.Background())
+
+	// Flag work that was never turned in once it is past due.
+	go submissionsH.RunMissingWorkSweep(context.Background(), time.Hour)
+
+	// Nightly Student Insights: at-risk alerts for the teacher dashboard.
+	go alertsH.RunNightlyInsights(context.Background())
 
 	// Build router.
 	r := chi.NewRouter()
@@ -124,6 +150,7 @@ func main() {
 		r.Use(apimiddleware.RateLimitLogin)
 		r.Post("/auth/login", authH.Login)
 		r.Post("/auth/register", authH.Register)
+		r.Post("/auth/password-setup", authH.SetUpPassword)
 	})
 
 	// Authenticated routes.
@@ -146,26 +173,105 @@ func main() {
 			r.Use(apimiddleware.RequireRoles("teacher", "admin", "super_admin"))
 			r.Get("/", gradesH.ListGrades)
 			r.Post("/", gradesH.UpsertGrade)
+			r.Post("/batch", gradesH.BatchUpsertGrades)
+			r.Get("/summary", gradesH.GetCourseGradeSummary)
+			r.Get("/policy", gradesH.GetGradePolicy)
+			r.Put("/policy", gradesH.UpdateGradePolicy)
+			r.Get("/history", gradesH.GetGradeHistory)
+			r.Get("/as-of", gradesH.GetGradebookAsOf)
+			r.Get("/export", gradesH.ExportGradebook)
+			r.Post("/import", gradesH.ImportGradebook)
+			r.Post("/ai-review", gradesH.ReviewAISuggestion)
 		})
+		r.With(apimiddleware.RequireRoles("teacher", "admin", "super_admin")).
+			Get("/grade-change-requests", gradeChangesH.ListGradeChangeRequests)
 		r.Route("/students/{studentId}/grades", func(r chi.Router) {
 			r.Get("/", gradesH.GetStudentGrades)
 		})
+		r.Route("/students/{studentId}/gpa", func(r chi.Router) {
+			r.Get("/", gradesH.GetStudentGPA)
+			r.With(apimiddleware.RequireRoles("admin", "super_admin")).
+				Post("/", gradesH.RecordStudentGPA)
+		})
 
 		// Assignments.
 		r.Route("/assignments", func(r chi.Router) {
 			r.Use(apimiddleware.RequireRoles("teacher", "admin", "super_admin"))
 			r.Get("/", assignmentsH.ListAssignments)
 			r.Post("/", assignmentsH.CreateAssignment)
+			r.Put("/{assignmentId}", assignmentsH.UpdateAssignment)
+			r.Patch("/{assignmentId}", assignmentsH.PatchAssignment)
+			r.Delete("/{assignmentId}", assignmentsH.DeleteAssignment)
+			r.Post("/{assignmentId}/duplicate", assignmentsH.DuplicateAssignment)
+			r.Post("/{assignmentId}/copy", assignmentsH.CopyAssignment)
 		})
 
 		// Students self-service (any authenticated student can look up their own record).
 		r.Get("/students/me", studentsH.GetMyRecord)
+		r.Route("/assignments/{assignmentId}/standards", func(r chi.Router) {
+			r.Use(apimiddleware.RequireRoles("teacher", "admin", "super_admin"))
+			r.Get("/", standardsH.ListAssignmentStandards)
+			r.Put("/", standardsH.SetAssignmentStandards)
+		})
 		r.Route("/assignments/{assignmentId}/attachments", func(r chi.Router) {
 			r.Get("/", assignmentsH.ListAttachments)
 			r.With(apimiddleware.RequireRoles("teacher", "admin", "super_admin")).
 				With(apimiddleware.RateLimitFileUpload).
 				Post("/upload-url", assignmentsH.RequestUploadURL)
 		})
+		r.Route("/assignments/{assignmentId}/submission", func(r chi.Router) {
+			r.Use(apimiddleware.RequireRoles("student"))
+			r.Get("/", submissionsH.GetMySubmissions)
+			r.Post("/", submissionsH.Submit)
+			r.With(apimiddleware.RateLimitFileUpload).
+				Post("/upload-url", submissionsH.RequestSubmissionUploadURL)
+			r.Delete("/files/{fileId}", submissionsH.DeleteSubmissionFile)
+		})
+		r.Route("/assignments/{assignmentId}/submissions", func(r chi.Router) {
+			r.Use(apimiddleware.RequireRoles("teacher", "admin", "super_admin"))
+			r.Get("/", submissionsH.ListSubmissionQueue)
+			r.Get("/{studentId}", submissionsH.GetStudentSubmissions)
+		})
+
+		// Due date extensions and accommodations.
+		r.Group(func(r chi.Router) {
+			r.Use(apimiddleware.RequireRoles("teacher", "admin", "super_admin"))
+			r.Get("/students/{studentId}/extensions", extensionsH.ListStudentExtensions)
+			r.Post("/students/{studentId}/extensions", extensionsH.GrantExtension)
+			r.Delete("/extensions/{extensionId}", extensionsH.RevokeExtension)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(apimiddleware.RequireRoles("admin", "super_admin"))
+			r.Post("/students/{studentId}/accommodations", extensionsH.CreateAccommodation)
+			r.Put("/accommodations/{accommodationId}", extensionsH.UpdateAccommodation)
+			r.Delete("/accommodations/{accommodationId}", extensionsH.DeleteAccommodation)
+		})
+
+		// Rubrics.
+		r.Route("/rubrics", func(r chi.Router) {
+			r.Use(apimiddleware.RequireRoles("teacher", "admin", "super_admin"))
+			r.Get("/", rubricsH.ListRubrics)
+			r.Post("/", rubricsH.CreateRubric)
+			r.Get("/{rubricId}", rubricsH.GetRubric)
+			r.Put("/{rubricId}", rubricsH.UpdateRubric)
+			r.Delete("/{rubricId}", rubricsH.DeleteRubric)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(apimiddleware.RequireRoles("teacher", "admin", "super_admin"))
+			r.Put("/assignments/{assignmentId}/rubric", rubricsH.SetAssignmentRubric)
+			r.Get("/assignments/{assignmentId}/rubric-scores", rubricsH.ListRubricScores)
+			r.Post("/assignments/{assignmentId}/rubric-scores", rubricsH.ScoreRubric)
+		})
+
+		// At-risk alerts.
+		r.Route("/alerts", func(r chi.Router) {
+			r.Use(apimiddleware.RequireRoles("teacher", "admin", "super_admin"))
+			r.Get("/", alertsH.ListAlerts)
+			r.Post("/{alertId}/dismiss", alertsH.DismissAlert)
+			r.Post("/{alertId}/action", alertsH.ActOnAlert)
+			r.With(apimiddleware.RequireRoles("admin", "super_admin")).
+				Post("/refresh", alertsH.RefreshAlerts)
+		})
 
 		// AI.
 		r.Route("/ai", func(r chi.Router) {
@@ -183,6 +289,62 @@ func main() {
 			r.Post("/students/{studentId}/lock", adminH.LockGrade)
 			r.Delete("/students/{studentId}/lock", adminH.UnlockGrade)
 			r.Post("/grade-locks/bulk", adminH.BulkLockGrades)
+			r.Get("/users/pending-credentials", adminH.ListPendingCredentials)
+			r.Post("/users/password-setup", adminH.SendPasswordSetup)
+
+			// Attendance: whole-day marks and corrections.
+			r.Post("/attendance/daily", attendanceH.RecordDailyAttendance)
+			r.Put("/attendance/{recordId}", attendanceH.CorrectAttendance)
+
+			// Academic terms.
+			r.Post("/terms", termsH.CreateTerm)
+			r.Put("/terms/{termId}", termsH.UpdateTerm)
+			r.Delete("/terms/{termId}", termsH.DeleteTerm)
+			r.Post("/terms/{termId}/close", termsH.CloseTerm)
+			r.Post("/terms/{termId}/reopen", termsH.ReopenTerm)
+
+			// Branding images for generated PDFs.
+			r.Get("/branding", brandingH.GetBranding)
+			r.With(apimiddleware.RateLimitFileUpload).
+				Post("/branding/{image}/upload-url", brandingH.RequestBrandingUploadURL)
+			r.Put("/branding/{image}", brandingH.SetBrandingImage)
+
+			// Report card and document templates (versioned).
+			r.Get("/templates", templatesH.ListTemplates)
+			r.Post("/templates", templatesH.CreateTemplate)
+			r.Post("/templates/preview", templatesH.PreviewTemplate)
+			r.Get("/templates/builtin/{kind}", templatesH.GetBuiltinTemplate)
+			r.Get("/templates/{templateId}", templatesH.GetTemplate)
+			r.Post("/templates/{templateId}/activate", templatesH.ActivateTemplate)
+			r.Get("/documents/{documentId}/render", templatesH.RenderDocument)
+			r.Get("/report-cards/{reportCardId}/render", templatesH.RenderReportCard)
+			r.Post("/report-cards/{reportCardId}/finalize", reportsH.FinalizeReportCard)
+
+			// Changes to final grades.
+			r.Post("/grade-change-requests/{requestId}/approve", gradeChangesH.ApproveGradeChangeRequest)
+			r.Post("/grade-change-requests/{requestId}/reject", gradeChangesH.RejectGradeChangeRequest)
+			r.Post("/grade-change-requests/{requestId}/regenerate", gradeChangesH.RegenerateGradeChangeReportCards)
+
+			// OneRoster roster import and gradebook export.
+			r.Post("/oneroster/import", oneRosterH.ImportOneRoster)
+			r.Get("/oneroster/imports", oneRosterH.ListOneRosterImports)
+			r.Get("/oneroster/imports/{importId}", oneRosterH.GetOneRosterImport)
+			r.Get("/oneroster/export", oneRosterH.ExportOneRosterGradebook)
+
+			// Learning standards and standards-based grading.
+			r.Post("/standards", standardsH.CreateStandard)
+			r.Put("/standards/{standardId}", standardsH.UpdateStandard)
+			r.Put("/courses/{courseId}/grading", standardsH.UpdateCourseGrading)
+			r.Put("/courses/{courseId}/credits", coursesH.UpdateCourseCredits)
+		})
+
+		// Standards (read-only for all roles).
+		r.Get("/standards", standardsH.ListStandards)
+
+		// Terms (read-only for all roles).
+		r.Route("/terms", func(r chi.Router) {
+			r.Get("/", termsH.ListTerms)
+			r.Get("/current", termsH.GetCurrentTerm)
 		})
 
 		// Documents (rate limited per spec: 5/day).
@@ -213,6 +375,8 @@ func main() {
 				Post("/", reportsH.GenerateReportCard)
 			r.With(apimiddleware.RequireRoles("admin", "super_admin")).
 				Post("/batch", reportsH.BatchGenerateReports)
+			r.With(apimiddleware.RequireRoles("admin", "super_admin")).
+				Get("/batch/{jobId}", reportsH.GetBatchJob)
 		})
 		r.Route("/students/{studentId}/reports", func(r chi.Router) {
 			r.Get("/", reportsH.ListReportCards)
@@ -227,11 +391,36 @@ func main() {
 		})
 		r.Route("/courses/{courseId}/students", func(r chi.Router) {
 			r.Get("/", coursesH.GetEnrolledStudents)
+			r.Get("/{studentId}/mastery", standardsH.GetStudentMastery)
+			r.Get("/{studentId}/grade", gradesH.GetStudentCourseGrade)
+		})
+		r.Route("/courses/{courseId}/enrollments", func(r chi.Router) {
+			r.Use(apimiddleware.RequireRoles("teacher", "admin", "super_admin"))
+			r.Get("/", enrollmentsH.ListEnrollments)
+			r.Group(func(r chi.Router) {
+				r.Use(apimiddleware.RequireRoles("admin", "super_admin"))
+				r.Post("/", enrollmentsH.EnrollStudent)
+				r.Post("/bulk", enrollmentsH.BulkEnroll)
+				r.Post("/{studentId}/drop", enrollmentsH.DropStudent)
+				r.Post("/{studentId}/transfer", enrollmentsH.TransferStudent)
+			})
 		})
+		r.With(apimiddleware.RequireRoles("teacher", "admin", "super_admin")).
+			Post("/courses/{courseId}/standard-scores", standardsH.RecordStandardScores)
 		r.Route("/courses/{courseId}/assignments", func(r chi.Router) {
 			r.Use(apimiddleware.RequireRoles("teacher", "admin", "super_admin"))
 			r.Get("/", assignmentsH.ListCourseAssignments)
 		})
+
+		// Attendance.
+		r.Route("/courses/{courseId}/attendance", func(r chi.Router) {
+			r.Use(apimiddleware.RequireRoles("teacher", "admin", "super_admin"))
+			r.Get("/", attendanceH.ListCourseAttendance)
+			r.Post("/", attendanceH.TakeCourseAttendance)
+		})
+		r.Route("/students/{studentId}/attendance", func(r chi.Router) {
+			r.Get("/", attendanceH.GetStudentAttendance)
+		})
 	})
 
 	// Platform routes — super_admin only, no tenant scoping.
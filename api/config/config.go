@@ -26,9 +26,13 @@ type Config struct {
 	R2BucketName      string
 	R2Endpoint        string
 
+	// AI provider: "claude", or "fake" for scripted offline replies
+	AIProvider string
+
 	// Anthropic Claude API
-	ClaudeAPIKey string
-	ClaudeModel  string
+	ClaudeAPIKey  string
+	ClaudeModel   string
+	ClaudeBaseURL string
 
 	// Resend (transactional email)
 	ResendAPIKey  string
@@ -62,8 +66,9 @@ func Load() (*Config, error) {
 		R2SecretAccessKey: requireEnv("R2_SECRET_ACCESS_KEY"),
 		R2BucketName:      requireEnv("R2_BUCKET_NAME"),
 		R2Endpoint:        requireEnv("R2_ENDPOINT"),
-		ClaudeAPIKey:      requireEnv("CLAUDE_API_KEY"),
+		AIProvider:        getEnv("AI_PROVIDER", "claude"),
 		ClaudeModel:       getEnv("CLAUDE_MODEL", "claude-sonnet-4-5-20250929"),
+		ClaudeBaseURL:     getEnv("CLAUDE_BASE_URL", "https://api.anthropic.com/v1"),
 		ResendAPIKey:      requireEnv("RESEND_API_KEY"),
 		EmailFromAddr:     getEnv("EMAIL_FROM_ADDR", "noreply@pragmagrading.com"),
 		FrontendOrigin:    strings.TrimRight(requireEnv("FRONTEND_ORIGIN"), "/"),
@@ -71,6 +76,16 @@ func Load() (*Config, error) {
 		HIBPAPIKey:        getEnv("HIBP_API_KEY", ""),
 		LoginEncryptionKey: requireEnv("LOGIN_ENCRYPTION_KEY"),
 	}
+	switch cfg.AIProvider {
+	case "claude":
+		cfg.ClaudeAPIKey = requireEnv("CLAUDE_API_KEY")
+	case "fake":
+		if cfg.Env == "production" {
+			return nil, fmt.Errorf("AI_PROVIDER=fake is not allowed in production")
+		}
+	default:
+		return nil, fmt.Errorf("unknown AI_PROVIDER %q (want claude or fake)", cfg.AIProvider)
+	}
 	return cfg, nil
 }
 
@@ -0,0 +1,43 @@
+-- 023_create_attendance.sql
+-- Attendance records: one row per student per day (daily) or per student per
+-- course meeting (per-period). Daily rows have course_id IS NULL.
+CREATE TABLE IF NOT EXISTS attendance_records (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    short_id          VARCHAR(8) NOT NULL DEFAULT left(md5(gen_random_uuid()::text), 8),
+    school_id         UUID NOT NULL REFERENCES schools(id),
+    student_id        UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    course_id         UUID REFERENCES courses(id) ON DELETE CASCADE,
+    schedule_block_id UUID REFERENCES schedule_blocks(id) ON DELETE SET NULL,
+    attendance_date   DATE NOT NULL,
+    status            TEXT NOT NULL CHECK (status IN ('present', 'absent', 'tardy', 'excused')),
+    note              TEXT,
+    recorded_by       UUID NOT NULL REFERENCES users(id),
+    corrected_by      UUID REFERENCES users(id),
+    corrected_at      TIMESTAMPTZ,
+    correction_reason TEXT,
+    created_at        TIMESTAMPTZ DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attendance_short_id ON attendance_records(short_id);
+
+-- One per-period record per student, course, and day.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attendance_period_unique
+    ON attendance_records(student_id, course_id, attendance_date) WHERE course_id IS NOT NULL;
+
+-- One daily record per student and day.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attendance_daily_unique
+    ON attendance_records(student_id, attendance_date) WHERE course_id IS NULL;
+
+CREATE INDEX idx_attendance_student ON attendance_records(student_id, school_id, attendance_date);
+CREATE INDEX idx_attendance_course ON attendance_records(course_id, attendance_date);
+CREATE INDEX idx_attendance_school ON attendance_records(school_id);
+
+CREATE TRIGGER attendance_records_updated_at
+    BEFORE UPDATE ON attendance_records
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+ALTER TABLE attendance_records ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_attendance_records ON attendance_records
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
@@ -0,0 +1,38 @@
+-- 024_create_terms.sql
+-- Academic terms: school years nest semesters, semesters nest quarters.
+-- Nesting rules (type order, dates within the parent) are enforced by the API.
+CREATE TABLE IF NOT EXISTS terms (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    short_id    VARCHAR(8) NOT NULL DEFAULT left(md5(gen_random_uuid()::text), 8),
+    school_id   UUID NOT NULL REFERENCES schools(id),
+    parent_id   UUID REFERENCES terms(id) ON DELETE RESTRICT,
+    name        TEXT NOT NULL,
+    term_type   TEXT NOT NULL CHECK (term_type IN ('year', 'semester', 'quarter')),
+    start_date  DATE NOT NULL,
+    end_date    DATE NOT NULL,
+    created_at  TIMESTAMPTZ DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (end_date >= start_date),
+    CHECK ((term_type = 'year') = (parent_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_terms_short_id ON terms(short_id);
+CREATE INDEX idx_terms_school ON terms(school_id, start_date);
+CREATE INDEX idx_terms_parent ON terms(parent_id);
+
+CREATE TRIGGER terms_updated_at
+    BEFORE UPDATE ON terms
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+ALTER TABLE terms ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_terms ON terms
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
+
+-- Courses and report cards may now point at a term. The free-text
+-- academic_year/semester and academic_period columns are kept for display.
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS term_id UUID REFERENCES terms(id);
+ALTER TABLE report_cards ADD COLUMN IF NOT EXISTS term_id UUID REFERENCES terms(id);
+
+CREATE INDEX idx_courses_term ON courses(term_id);
+CREATE INDEX idx_report_cards_term ON report_cards(term_id);
@@ -0,0 +1,59 @@
+-- 025_create_report_card_jobs.sql
+-- Batch report card generation runs in the background. The job row tracks
+-- progress for polling; one item row per student lets a restarted server pick
+-- up where it left off.
+CREATE TABLE IF NOT EXISTS report_card_jobs (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    short_id        VARCHAR(8) NOT NULL DEFAULT left(md5(gen_random_uuid()::text), 8),
+    school_id       UUID NOT NULL REFERENCES schools(id),
+    term_id         UUID REFERENCES terms(id),
+    academic_period TEXT NOT NULL,
+    grade_level     TEXT,
+    status          TEXT NOT NULL DEFAULT 'pending'
+                    CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    total_count     INT NOT NULL DEFAULT 0,
+    completed_count INT NOT NULL DEFAULT 0,
+    failed_count    INT NOT NULL DEFAULT 0,
+    bundle          BOOLEAN NOT NULL DEFAULT FALSE,
+    bundle_url      TEXT,
+    error           TEXT,
+    requested_by    UUID NOT NULL REFERENCES users(id),
+    started_at      TIMESTAMPTZ,
+    finished_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_report_card_jobs_short_id ON report_card_jobs(short_id);
+CREATE INDEX idx_report_card_jobs_school ON report_card_jobs(school_id, created_at DESC);
+CREATE INDEX idx_report_card_jobs_unfinished ON report_card_jobs(status)
+    WHERE status IN ('pending', 'running');
+
+CREATE TRIGGER report_card_jobs_updated_at
+    BEFORE UPDATE ON report_card_jobs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE TABLE IF NOT EXISTS report_card_job_items (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_id         UUID NOT NULL REFERENCES report_card_jobs(id) ON DELETE CASCADE,
+    school_id      UUID NOT NULL REFERENCES schools(id),
+    student_id     UUID NOT NULL REFERENCES students(id),
+    status         TEXT NOT NULL DEFAULT 'pending'
+                   CHECK (status IN ('pending', 'completed', 'failed')),
+    report_card_id UUID REFERENCES report_cards(id),
+    error          TEXT,
+    updated_at     TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (job_id, student_id)
+);
+
+CREATE INDEX idx_report_card_job_items_pending ON report_card_job_items(job_id)
+    WHERE status = 'pending';
+
+ALTER TABLE report_card_jobs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE report_card_job_items ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_report_card_jobs ON report_card_jobs
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
+
+CREATE POLICY tenant_isolation_report_card_job_items ON report_card_job_items
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
@@ -0,0 +1,36 @@
+-- 026_create_document_templates.sql
+-- School-defined templates for report cards and official documents. Each save
+-- inserts a new version; versions are never edited, so a document or report
+-- card can always be re-rendered with the exact template that produced it.
+-- At most one version per (school, kind, name) is active.
+CREATE TABLE IF NOT EXISTS document_templates (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    short_id   VARCHAR(8) NOT NULL DEFAULT left(md5(gen_random_uuid()::text), 8),
+    school_id  UUID NOT NULL REFERENCES schools(id),
+    kind       TEXT NOT NULL CHECK (kind IN ('report_card', 'enrollment_certificate', 'attendance_letter',
+                                             'academic_standing', 'tuition_confirmation', 'custom')),
+    name       TEXT NOT NULL DEFAULT 'default',
+    version    INT NOT NULL,
+    body       TEXT NOT NULL,
+    is_active  BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (school_id, kind, name, version)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_document_templates_short_id ON document_templates(short_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_document_templates_active
+    ON document_templates(school_id, kind, name) WHERE is_active;
+
+ALTER TABLE document_templates ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_document_templates ON document_templates
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
+
+-- The template version and the exact data each output was rendered from.
+-- template_id is NULL when the built-in template (or a legacy settings
+-- template) was used.
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS template_id UUID REFERENCES document_templates(id);
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS render_data JSONB;
+ALTER TABLE report_cards ADD COLUMN IF NOT EXISTS template_id UUID REFERENCES document_templates(id);
+ALTER TABLE report_cards ADD COLUMN IF NOT EXISTS render_data JSONB;
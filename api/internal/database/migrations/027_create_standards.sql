@@ -0,0 +1,70 @@
+-- 027_create_standards.sql
+-- Standards-based (mastery) grading. Assignments are aligned to a school's
+-- learning standards and each student receives a 1–4 proficiency score per
+-- aligned standard. A course's grading mode and mastery rule override the
+-- school defaults in settings when set.
+CREATE TABLE IF NOT EXISTS standards (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    short_id    VARCHAR(8) NOT NULL DEFAULT left(md5(gen_random_uuid()::text), 8),
+    school_id   UUID NOT NULL REFERENCES schools(id),
+    code        TEXT NOT NULL,
+    description TEXT NOT NULL,
+    subject     TEXT,
+    grade_level TEXT,
+    is_active   BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (school_id, code)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_standards_short_id ON standards(short_id);
+
+CREATE TRIGGER standards_updated_at
+    BEFORE UPDATE ON standards
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE TABLE IF NOT EXISTS assignment_standards (
+    assignment_id UUID NOT NULL REFERENCES assignments(id) ON DELETE CASCADE,
+    standard_id   UUID NOT NULL REFERENCES standards(id),
+    school_id     UUID NOT NULL REFERENCES schools(id),
+    PRIMARY KEY (assignment_id, standard_id)
+);
+
+CREATE INDEX idx_assignment_standards_standard ON assignment_standards(standard_id);
+
+CREATE TABLE IF NOT EXISTS standard_scores (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    assignment_id UUID NOT NULL REFERENCES assignments(id) ON DELETE CASCADE,
+    standard_id   UUID NOT NULL REFERENCES standards(id),
+    student_id    UUID NOT NULL REFERENCES students(id),
+    school_id     UUID NOT NULL REFERENCES schools(id),
+    score         DECIMAL(3,2) NOT NULL CHECK (score >= 1 AND score <= 4),
+    comment       TEXT,
+    graded_by     UUID REFERENCES users(id),
+    graded_at     TIMESTAMPTZ DEFAULT NOW(),
+    created_at    TIMESTAMPTZ DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (assignment_id, standard_id, student_id)
+);
+
+CREATE INDEX idx_standard_scores_student ON standard_scores(student_id, school_id);
+
+CREATE TRIGGER standard_scores_updated_at
+    BEFORE UPDATE ON standard_scores
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS grading_mode TEXT
+    CHECK (grading_mode IN ('points', 'standards'));
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS mastery_rule TEXT
+    CHECK (mastery_rule IN ('most_recent', 'highest', 'decaying_average', 'mode'));
+
+ALTER TABLE standards ENABLE ROW LEVEL SECURITY;
+ALTER TABLE assignment_standards ENABLE ROW LEVEL SECURITY;
+ALTER TABLE standard_scores ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_standards ON standards
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
+CREATE POLICY tenant_isolation_assignment_standards ON assignment_standards
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
+CREATE POLICY tenant_isolation_standard_scores ON standard_scores
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
@@ -0,0 +1,8 @@
+-- 028_add_grade_policies.sql
+-- Per-course grade policy (drop lowest, missing as zero, late penalties,
+-- rounding) stored as JSONB; NULL keeps the plain points calculation.
+-- Extra-credit assignments add earned points without adding to the total.
+-- days_late feeds per-day late penalties; NULL on a late grade counts as one day.
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS grade_policy JSONB;
+ALTER TABLE assignments ADD COLUMN IF NOT EXISTS is_extra_credit BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE grades ADD COLUMN IF NOT EXISTS days_late INT CHECK (days_late >= 0);
@@ -0,0 +1,37 @@
+-- 029_add_credits_and_term_gpas.sql
+-- Credit hours and course levels for credit-weighted and honors/AP/IB
+-- weighted GPA, and a per-student, per-term GPA record carrying the
+-- cumulative GPA through that term.
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS credits DECIMAL(4,2) NOT NULL DEFAULT 1.0
+    CHECK (credits >= 0);
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS course_level TEXT NOT NULL DEFAULT 'regular'
+    CHECK (course_level IN ('regular', 'honors', 'ap', 'ib'));
+
+CREATE TABLE IF NOT EXISTS student_term_gpas (
+    id                           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    student_id                   UUID NOT NULL REFERENCES students(id),
+    school_id                    UUID NOT NULL REFERENCES schools(id),
+    term_id                      UUID NOT NULL REFERENCES terms(id),
+    method                       TEXT NOT NULL CHECK (method IN ('unweighted', 'weighted')),
+    gpa                          DECIMAL(5,3) NOT NULL,
+    unweighted_gpa               DECIMAL(5,3) NOT NULL,
+    weighted_gpa                 DECIMAL(5,3) NOT NULL,
+    credits_attempted            DECIMAL(6,2) NOT NULL,
+    credits_earned               DECIMAL(6,2) NOT NULL,
+    quality_points               DECIMAL(8,3) NOT NULL,
+    weighted_quality_points      DECIMAL(8,3) NOT NULL,
+    cumulative_gpa               DECIMAL(5,3) NOT NULL,
+    cumulative_unweighted_gpa    DECIMAL(5,3) NOT NULL,
+    cumulative_weighted_gpa      DECIMAL(5,3) NOT NULL,
+    cumulative_credits_attempted DECIMAL(7,2) NOT NULL,
+    cumulative_credits_earned    DECIMAL(7,2) NOT NULL,
+    computed_at                  TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (student_id, term_id)
+);
+
+CREATE INDEX idx_student_term_gpas_student ON student_term_gpas(student_id, school_id);
+
+ALTER TABLE student_term_gpas ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_student_term_gpas ON student_term_gpas
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
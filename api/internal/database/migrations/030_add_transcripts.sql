@@ -0,0 +1,12 @@
+-- 030_add_transcripts.sql
+-- Official transcripts are generated and verified like other documents and
+-- may have school-defined templates.
+ALTER TABLE documents DROP CONSTRAINT IF EXISTS documents_type_check;
+ALTER TABLE documents ADD CONSTRAINT documents_type_check
+    CHECK (type IN ('enrollment_certificate', 'attendance_letter', 'academic_standing',
+                    'tuition_confirmation', 'transcript', 'custom'));
+
+ALTER TABLE document_templates DROP CONSTRAINT IF EXISTS document_templates_kind_check;
+ALTER TABLE document_templates ADD CONSTRAINT document_templates_kind_check
+    CHECK (kind IN ('report_card', 'enrollment_certificate', 'attendance_letter',
+                    'academic_standing', 'tuition_confirmation', 'transcript', 'custom'));
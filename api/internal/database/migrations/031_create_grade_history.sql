@@ -0,0 +1,51 @@
+-- 031_create_grade_history.sql
+-- Append-only history of every grade version, written in the same
+-- transaction as the change to grades. Like audit_logs, the application
+-- role should be granted no UPDATE or DELETE on this table:
+--   REVOKE UPDATE, DELETE ON grade_history FROM app_role;
+-- grade_id and assignment_id carry no foreign keys so history survives the
+-- deletion of the grade or assignment it describes.
+CREATE TABLE IF NOT EXISTS grade_history (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    grade_id          UUID NOT NULL,
+    assignment_id     UUID NOT NULL,
+    student_id        UUID NOT NULL REFERENCES students(id),
+    school_id         UUID NOT NULL REFERENCES schools(id),
+    version           INT NOT NULL,
+    old_points_earned DECIMAL(8,2),
+    new_points_earned DECIMAL(8,2),
+    old_is_excused    BOOLEAN NOT NULL DEFAULT FALSE,
+    new_is_excused    BOOLEAN NOT NULL DEFAULT FALSE,
+    old_is_missing    BOOLEAN NOT NULL DEFAULT FALSE,
+    new_is_missing    BOOLEAN NOT NULL DEFAULT FALSE,
+    old_is_late       BOOLEAN NOT NULL DEFAULT FALSE,
+    new_is_late       BOOLEAN NOT NULL DEFAULT FALSE,
+    old_days_late     INT,
+    new_days_late     INT,
+    comment           TEXT,
+    reason            TEXT,
+    changed_by        UUID REFERENCES users(id),
+    changed_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (grade_id, version)
+);
+
+CREATE INDEX idx_grade_history_grade ON grade_history(assignment_id, student_id, changed_at);
+CREATE INDEX idx_grade_history_school ON grade_history(school_id, changed_at DESC);
+
+-- Existing grades start their history at version 1 with their current values.
+INSERT INTO grade_history
+    (grade_id, assignment_id, student_id, school_id, version,
+     new_points_earned, new_is_excused, new_is_missing, new_is_late, new_days_late,
+     comment, reason, changed_by, changed_at)
+SELECT g.id, g.assignment_id, g.student_id, g.school_id, 1,
+       g.points_earned, COALESCE(g.is_excused, FALSE), COALESCE(g.is_missing, FALSE),
+       COALESCE(g.is_late, FALSE), g.days_late,
+       g.comment, 'Recorded before grade history was kept', g.graded_by,
+       COALESCE(g.graded_at, g.created_at, NOW())
+FROM grades g
+WHERE NOT EXISTS (SELECT 1 FROM grade_history h WHERE h.grade_id = g.id);
+
+ALTER TABLE grade_history ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_grade_history ON grade_history
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
@@ -0,0 +1,51 @@
+-- 032_create_grade_change_requests.sql
+-- Grades become final when their term is closed or when a report card
+-- covering them is finalized. After that a grade change is filed as a
+-- request for an administrator to approve or reject; on approval the grade
+-- is written and the affected report cards are regenerated, the new card
+-- superseding the old one.
+ALTER TABLE terms ADD COLUMN IF NOT EXISTS is_closed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE terms ADD COLUMN IF NOT EXISTS closed_at TIMESTAMPTZ;
+ALTER TABLE terms ADD COLUMN IF NOT EXISTS closed_by UUID REFERENCES users(id);
+
+ALTER TABLE report_cards ADD COLUMN IF NOT EXISTS finalized_at TIMESTAMPTZ;
+ALTER TABLE report_cards ADD COLUMN IF NOT EXISTS finalized_by UUID REFERENCES users(id);
+ALTER TABLE report_cards ADD COLUMN IF NOT EXISTS superseded_by UUID REFERENCES report_cards(id);
+
+CREATE TABLE IF NOT EXISTS grade_change_requests (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    short_id      VARCHAR(8) NOT NULL DEFAULT left(md5(gen_random_uuid()::text), 8),
+    school_id     UUID NOT NULL REFERENCES schools(id),
+    assignment_id UUID NOT NULL REFERENCES assignments(id) ON DELETE CASCADE,
+    student_id    UUID NOT NULL REFERENCES students(id),
+    course_id     UUID NOT NULL REFERENCES courses(id),
+    requested_by  UUID NOT NULL REFERENCES users(id),
+    -- Proposed grade values, applied as-is on approval.
+    points_earned DECIMAL(8,2),
+    comment       TEXT,
+    is_excused    BOOLEAN NOT NULL DEFAULT FALSE,
+    is_missing    BOOLEAN NOT NULL DEFAULT FALSE,
+    is_late       BOOLEAN NOT NULL DEFAULT FALSE,
+    days_late     INT CHECK (days_late >= 0),
+    reason        TEXT,
+    locked_by     TEXT NOT NULL, -- why the grade was final, e.g. "Fall 2025 is closed"
+    status        TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    reviewed_by   UUID REFERENCES users(id),
+    reviewed_at   TIMESTAMPTZ,
+    review_note   TEXT,
+    created_at    TIMESTAMPTZ DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_grade_change_requests_short_id ON grade_change_requests(short_id);
+CREATE INDEX idx_grade_change_requests_school ON grade_change_requests(school_id, status, created_at);
+CREATE INDEX idx_grade_change_requests_grade ON grade_change_requests(assignment_id, student_id);
+
+CREATE TRIGGER grade_change_requests_updated_at
+    BEFORE UPDATE ON grade_change_requests
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+ALTER TABLE grade_change_requests ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_grade_change_requests ON grade_change_requests
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
@@ -0,0 +1,51 @@
+-- 033_create_oneroster.sql
+-- OneRoster 1.2 CSV rosters from a school's SIS. oneroster_sources maps each
+-- OneRoster sourcedId to the row it created, with a hash of the source
+-- record so re-imports can tell what changed. A bulk import deactivates
+-- records that earlier imports created and the new bundle no longer lists.
+CREATE TABLE IF NOT EXISTS oneroster_imports (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    short_id       VARCHAR(8) NOT NULL DEFAULT left(md5(gen_random_uuid()::text), 8),
+    school_id      UUID NOT NULL REFERENCES schools(id),
+    org_sourced_id TEXT,
+    dry_run        BOOLEAN NOT NULL DEFAULT FALSE,
+    status         TEXT NOT NULL CHECK (status IN ('completed', 'failed')),
+    report         JSONB NOT NULL DEFAULT '{}',
+    error          TEXT,
+    imported_by    UUID NOT NULL REFERENCES users(id),
+    created_at     TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oneroster_imports_short_id ON oneroster_imports(short_id);
+CREATE INDEX idx_oneroster_imports_school ON oneroster_imports(school_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS oneroster_sources (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id    UUID NOT NULL REFERENCES schools(id),
+    kind         TEXT NOT NULL
+                 CHECK (kind IN ('org', 'academicSession', 'user', 'class', 'enrollment', 'parentLink')),
+    sourced_id   TEXT NOT NULL,
+    -- schools, terms, users, courses, enrollments, or parent_students row.
+    entity_id    UUID NOT NULL,
+    row_hash     TEXT NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (school_id, kind, sourced_id)
+);
+
+CREATE INDEX idx_oneroster_sources_entity ON oneroster_sources(school_id, kind, entity_id);
+
+CREATE TRIGGER oneroster_sources_updated_at
+    BEFORE UPDATE ON oneroster_sources
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+ALTER TABLE oneroster_imports ENABLE ROW LEVEL SECURITY;
+ALTER TABLE oneroster_sources ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_oneroster_imports ON oneroster_imports
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
+
+CREATE POLICY tenant_isolation_oneroster_sources ON oneroster_sources
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
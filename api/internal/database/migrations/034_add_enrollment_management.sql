@@ -0,0 +1,12 @@
+-- 034_add_enrollment_management.sql
+-- Enrollments are now created and changed through the API. A drop records
+-- its date and reason; a section transfer leaves the old enrollment as
+-- 'transferred' and points it at the new section.
+ALTER TABLE enrollments DROP CONSTRAINT IF EXISTS enrollments_status_check;
+ALTER TABLE enrollments ADD CONSTRAINT enrollments_status_check
+    CHECK (status IN ('active', 'dropped', 'completed', 'transferred'));
+
+ALTER TABLE enrollments ADD COLUMN IF NOT EXISTS enrolled_by UUID REFERENCES users(id);
+ALTER TABLE enrollments ADD COLUMN IF NOT EXISTS dropped_by UUID REFERENCES users(id);
+ALTER TABLE enrollments ADD COLUMN IF NOT EXISTS drop_reason TEXT;
+ALTER TABLE enrollments ADD COLUMN IF NOT EXISTS transferred_to UUID REFERENCES courses(id);
@@ -0,0 +1,63 @@
+-- 035_create_submissions.sql
+-- Student submissions. Each turn-in is a numbered attempt; resubmitting
+-- adds an attempt and keeps the earlier ones. A student has at most one
+-- open draft per assignment, which collects uploads until it is submitted.
+ALTER TABLE assignments ADD COLUMN IF NOT EXISTS accepts_submissions BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS submissions (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    short_id      VARCHAR(8) NOT NULL DEFAULT left(md5(gen_random_uuid()::text), 8),
+    assignment_id UUID NOT NULL REFERENCES assignments(id) ON DELETE CASCADE,
+    student_id    UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    school_id     UUID NOT NULL REFERENCES schools(id),
+    attempt       INT NOT NULL,
+    status        TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'submitted')),
+    text_entry    TEXT,
+    submitted_at  TIMESTAMPTZ,
+    due_at        TIMESTAMPTZ, -- the student's effective due date when submitted
+    is_late       BOOLEAN NOT NULL DEFAULT FALSE,
+    days_late     INT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (assignment_id, student_id, attempt)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_submissions_short_id ON submissions(short_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_submissions_one_draft
+    ON submissions(assignment_id, student_id) WHERE status = 'draft';
+CREATE INDEX IF NOT EXISTS idx_submissions_assignment ON submissions(assignment_id, status);
+CREATE INDEX IF NOT EXISTS idx_submissions_student ON submissions(student_id);
+
+CREATE TRIGGER submissions_updated_at
+    BEFORE UPDATE ON submissions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE TABLE IF NOT EXISTS submission_files (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    submission_id UUID NOT NULL REFERENCES submissions(id) ON DELETE CASCADE,
+    school_id     UUID NOT NULL REFERENCES schools(id),
+    file_name     TEXT NOT NULL,
+    file_key      TEXT NOT NULL,
+    file_size     BIGINT NOT NULL,
+    mime_type     TEXT NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_submission_files_submission ON submission_files(submission_id);
+
+-- effective_due_date is when a student's work on an assignment is due.
+-- Late and missing detection go through it rather than reading
+-- assignments.due_date directly.
+CREATE OR REPLACE FUNCTION effective_due_date(p_assignment_id UUID, p_student_id UUID)
+RETURNS TIMESTAMPTZ AS $$
+    SELECT due_date FROM assignments WHERE id = p_assignment_id;
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE submissions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE submission_files ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_submissions ON submissions
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
+
+CREATE POLICY tenant_isolation_submission_files ON submission_files
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
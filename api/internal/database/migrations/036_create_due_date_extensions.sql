@@ -0,0 +1,86 @@
+-- 036_create_due_date_extensions.sql
+-- Per-student due date changes. An extension covers one assignment (a new
+-- due date or extra days) or a whole course (extra days). An accommodation
+-- is a standing IEP/504 rule adding days to every assignment due while it
+-- is in effect.
+CREATE TABLE IF NOT EXISTS due_date_extensions (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    short_id      VARCHAR(8) NOT NULL DEFAULT left(md5(gen_random_uuid()::text), 8),
+    school_id     UUID NOT NULL REFERENCES schools(id),
+    student_id    UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    assignment_id UUID REFERENCES assignments(id) ON DELETE CASCADE,
+    course_id     UUID REFERENCES courses(id) ON DELETE CASCADE,
+    due_date      TIMESTAMPTZ,
+    extra_days    INT CHECK (extra_days > 0),
+    reason        TEXT,
+    granted_by    UUID REFERENCES users(id),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((assignment_id IS NULL) <> (course_id IS NULL)),
+    CHECK ((due_date IS NULL) <> (extra_days IS NULL)),
+    CHECK (due_date IS NULL OR assignment_id IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_due_date_extensions_short_id ON due_date_extensions(short_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_due_date_extensions_assignment
+    ON due_date_extensions(student_id, assignment_id) WHERE assignment_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_due_date_extensions_course
+    ON due_date_extensions(student_id, course_id) WHERE course_id IS NOT NULL;
+
+CREATE TRIGGER due_date_extensions_updated_at
+    BEFORE UPDATE ON due_date_extensions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE TABLE IF NOT EXISTS student_accommodations (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    short_id   VARCHAR(8) NOT NULL DEFAULT left(md5(gen_random_uuid()::text), 8),
+    school_id  UUID NOT NULL REFERENCES schools(id),
+    student_id UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    plan       TEXT NOT NULL CHECK (plan IN ('iep', '504', 'other')),
+    extra_days INT NOT NULL CHECK (extra_days > 0),
+    starts_on  DATE,
+    ends_on    DATE,
+    notes      TEXT,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_on IS NULL OR starts_on IS NULL OR ends_on >= starts_on)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_student_accommodations_short_id ON student_accommodations(short_id);
+CREATE INDEX IF NOT EXISTS idx_student_accommodations_student ON student_accommodations(student_id);
+
+CREATE TRIGGER student_accommodations_updated_at
+    BEFORE UPDATE ON student_accommodations
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- An assignment extension wins outright. Otherwise the student gets the
+-- larger of their course extension and any accommodation in effect on the
+-- original due date; the two don't add up.
+CREATE OR REPLACE FUNCTION effective_due_date(p_assignment_id UUID, p_student_id UUID)
+RETURNS TIMESTAMPTZ AS $$
+    SELECT CASE
+        WHEN ax.id IS NOT NULL THEN COALESCE(ax.due_date, a.due_date + make_interval(days => ax.extra_days))
+        ELSE a.due_date + make_interval(days => GREATEST(COALESCE(cx.extra_days, 0), COALESCE(acc.extra_days, 0)))
+    END
+    FROM assignments a
+    LEFT JOIN due_date_extensions ax ON ax.assignment_id = a.id AND ax.student_id = p_student_id
+    LEFT JOIN due_date_extensions cx ON cx.course_id = a.course_id AND cx.student_id = p_student_id
+    LEFT JOIN LATERAL (
+        SELECT MAX(sa.extra_days) AS extra_days
+        FROM student_accommodations sa
+        WHERE sa.student_id = p_student_id
+          AND (sa.starts_on IS NULL OR sa.starts_on <= a.due_date::date)
+          AND (sa.ends_on IS NULL OR sa.ends_on >= a.due_date::date)
+    ) acc ON TRUE
+    WHERE a.id = p_assignment_id;
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE due_date_extensions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE student_accommodations ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_due_date_extensions ON due_date_extensions
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
+
+CREATE POLICY tenant_isolation_student_accommodations ON student_accommodations
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
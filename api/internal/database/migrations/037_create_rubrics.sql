@@ -0,0 +1,81 @@
+-- 037_create_rubrics.sql
+-- Reusable rubrics: criteria, each with scored performance levels. A
+-- rubric is private to its author or shared with their department or the
+-- whole school. Assignments may use one rubric; teachers then score each
+-- criterion and the total becomes the grade.
+CREATE TABLE IF NOT EXISTS rubrics (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    short_id    VARCHAR(8) NOT NULL DEFAULT left(md5(gen_random_uuid()::text), 8),
+    school_id   UUID NOT NULL REFERENCES schools(id),
+    title       TEXT NOT NULL,
+    description TEXT,
+    visibility  TEXT NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'department', 'school')),
+    department  TEXT, -- the author's department, for department sharing
+    created_by  UUID NOT NULL REFERENCES users(id),
+    is_archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rubrics_short_id ON rubrics(short_id);
+CREATE INDEX IF NOT EXISTS idx_rubrics_school ON rubrics(school_id, visibility);
+
+CREATE TRIGGER rubrics_updated_at
+    BEFORE UPDATE ON rubrics
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE TABLE IF NOT EXISTS rubric_criteria (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rubric_id   UUID NOT NULL REFERENCES rubrics(id) ON DELETE CASCADE,
+    school_id   UUID NOT NULL REFERENCES schools(id),
+    position    INT NOT NULL,
+    title       TEXT NOT NULL,
+    description TEXT,
+    UNIQUE (rubric_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS rubric_levels (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    criterion_id UUID NOT NULL REFERENCES rubric_criteria(id) ON DELETE CASCADE,
+    school_id    UUID NOT NULL REFERENCES schools(id),
+    position     INT NOT NULL,
+    title        TEXT NOT NULL,
+    description  TEXT,
+    points       DECIMAL(8,2) NOT NULL CHECK (points >= 0),
+    UNIQUE (criterion_id, position)
+);
+
+ALTER TABLE assignments ADD COLUMN IF NOT EXISTS rubric_id UUID REFERENCES rubrics(id);
+
+CREATE TABLE IF NOT EXISTS rubric_scores (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id     UUID NOT NULL REFERENCES schools(id),
+    assignment_id UUID NOT NULL REFERENCES assignments(id) ON DELETE CASCADE,
+    student_id    UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    criterion_id  UUID NOT NULL REFERENCES rubric_criteria(id),
+    level_id      UUID REFERENCES rubric_levels(id),
+    points        DECIMAL(8,2) NOT NULL CHECK (points >= 0),
+    comment       TEXT,
+    graded_by     UUID REFERENCES users(id),
+    graded_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (assignment_id, student_id, criterion_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rubric_scores_assignment ON rubric_scores(assignment_id, student_id);
+
+ALTER TABLE rubrics ENABLE ROW LEVEL SECURITY;
+ALTER TABLE rubric_criteria ENABLE ROW LEVEL SECURITY;
+ALTER TABLE rubric_levels ENABLE ROW LEVEL SECURITY;
+ALTER TABLE rubric_scores ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_rubrics ON rubrics
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
+
+CREATE POLICY tenant_isolation_rubric_criteria ON rubric_criteria
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
+
+CREATE POLICY tenant_isolation_rubric_levels ON rubric_levels
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
+
+CREATE POLICY tenant_isolation_rubric_scores ON rubric_scores
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
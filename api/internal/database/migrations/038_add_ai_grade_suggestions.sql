@@ -0,0 +1,10 @@
+-- 038_add_ai_grade_suggestions.sql
+-- Grading assistant suggestions are stored on the grade row next to, never
+-- in, points_earned. Each suggestion remembers the ai_interactions row that
+-- produced it so a teacher's accept or reject can be recorded there;
+-- ai_interactions.accepted is TRUE once any of its suggestions is accepted
+-- and FALSE when every decided one was rejected.
+ALTER TABLE grades ADD COLUMN IF NOT EXISTS ai_reasoning TEXT;
+ALTER TABLE grades ADD COLUMN IF NOT EXISTS ai_interaction_id UUID REFERENCES ai_interactions(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_grades_ai_interaction ON grades(ai_interaction_id) WHERE ai_interaction_id IS NOT NULL;
@@ -0,0 +1,4 @@
+-- 039_add_ai_redactions.sql
+-- What the AI gateway redacted before each call: kind, placeholder, and
+-- count per value. The values themselves are never stored here.
+ALTER TABLE ai_interactions ADD COLUMN IF NOT EXISTS redactions JSONB NOT NULL DEFAULT '[]';
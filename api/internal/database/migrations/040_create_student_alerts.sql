@@ -0,0 +1,40 @@
+-- 040_create_student_alerts.sql
+-- At-risk alerts from the nightly Student Insights job: one per student and
+-- course while open. Alerts come from the AI service when the school has AI
+-- enabled, otherwise from the local rules. Teachers dismiss an alert or
+-- record the action they took; the job resolves open alerts whose trend
+-- has recovered.
+CREATE TABLE IF NOT EXISTS student_alerts (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    short_id    VARCHAR(8) NOT NULL DEFAULT left(md5(gen_random_uuid()::text), 8),
+    school_id   UUID NOT NULL REFERENCES schools(id),
+    student_id  UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    course_id   UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    severity    TEXT NOT NULL CHECK (severity IN ('low', 'medium', 'high')),
+    source      TEXT NOT NULL CHECK (source IN ('ai', 'rules')),
+    message     TEXT NOT NULL,
+    trend       JSONB NOT NULL DEFAULT '{}',
+    status      TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'actioned', 'resolved')),
+    action_note TEXT,
+    resolved_by UUID REFERENCES users(id),
+    resolved_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_student_alerts_short_id ON student_alerts(short_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_student_alerts_open
+    ON student_alerts(student_id, course_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_student_alerts_course ON student_alerts(course_id, status);
+
+CREATE TRIGGER student_alerts_updated_at
+    BEFORE UPDATE ON student_alerts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- The nightly job calls the AI service with no user behind it.
+ALTER TABLE ai_interactions ALTER COLUMN user_id DROP NOT NULL;
+
+ALTER TABLE student_alerts ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_student_alerts ON student_alerts
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
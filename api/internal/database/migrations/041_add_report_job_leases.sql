@@ -0,0 +1,6 @@
+-- 041_add_report_job_leases.sql
+-- A batch job is run by whichever replica holds its lease. The runner renews
+-- the lease while it works; a job whose lease lapses (its replica stopped)
+-- is claimed by another replica and resumed from its pending items.
+ALTER TABLE report_card_jobs ADD COLUMN IF NOT EXISTS lease_owner UUID;
+ALTER TABLE report_card_jobs ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMPTZ;
@@ -0,0 +1,13 @@
+-- 042_add_grade_history_course.sql
+-- History rows carry their course and assignment title so a past gradebook
+-- can be rebuilt without joining assignments, including for assignments
+-- deleted since. Like the other ids, course_id has no foreign key.
+ALTER TABLE grade_history ADD COLUMN IF NOT EXISTS course_id UUID;
+ALTER TABLE grade_history ADD COLUMN IF NOT EXISTS assignment_title TEXT;
+
+UPDATE grade_history h
+SET course_id = a.course_id, assignment_title = a.title
+FROM assignments a
+WHERE a.id = h.assignment_id AND h.course_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_grade_history_course ON grade_history(course_id, changed_at);
@@ -0,0 +1,6 @@
+-- 043_add_grade_change_pending_cards.sql
+-- Approving a grade change records the report cards to regenerate in the
+-- same transaction as the grade write. Each card is removed once its
+-- replacement is saved, so a failed regeneration can be retried.
+ALTER TABLE grade_change_requests ADD COLUMN IF NOT EXISTS pending_report_cards UUID[] NOT NULL DEFAULT '{}';
+ALTER TABLE grade_change_requests ADD COLUMN IF NOT EXISTS regeneration_error TEXT;
@@ -0,0 +1,11 @@
+-- 044_create_background_jobs.sql
+-- Periodic jobs (the missing work sweep, nightly insights) are scheduled on
+-- every replica, but each run is done by the one replica that takes the
+-- job's lease. last_run_at is when the last completed run started, so a run
+-- can skip when another replica just did it, and pick up from there.
+CREATE TABLE IF NOT EXISTS background_jobs (
+    name             TEXT PRIMARY KEY,
+    lease_owner      UUID,
+    lease_expires_at TIMESTAMPTZ,
+    last_run_at      TIMESTAMPTZ
+);
@@ -0,0 +1,5 @@
+-- 045_add_grade_change_rubric_scores.sql
+-- Rubric scoring on a final grade files a grade change request for the new
+-- total. The criterion scores are kept with the request and saved when it
+-- is approved, so the rubric breakdown matches the approved grade.
+ALTER TABLE grade_change_requests ADD COLUMN IF NOT EXISTS rubric_scores JSONB;
@@ -0,0 +1,41 @@
+-- 046_create_standard_score_history.sql
+-- Append-only history of standard scores, written in the same transaction as
+-- the change to standard_scores, as grade_history is for grades. In
+-- standards-based courses these scores are the grade, so scoring work whose
+-- grade is final files a grade change request; the scores are kept on the
+-- request and saved when it is approved.
+CREATE TABLE IF NOT EXISTS standard_score_history (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    assignment_id UUID NOT NULL,
+    standard_id   UUID NOT NULL,
+    student_id    UUID NOT NULL REFERENCES students(id),
+    school_id     UUID NOT NULL REFERENCES schools(id),
+    version       INT NOT NULL,
+    old_score     DECIMAL(3,2),
+    new_score     DECIMAL(3,2) NOT NULL,
+    comment       TEXT,
+    reason        TEXT,
+    changed_by    UUID REFERENCES users(id),
+    changed_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (assignment_id, standard_id, student_id, version)
+);
+
+CREATE INDEX idx_standard_score_history_student ON standard_score_history(student_id, changed_at);
+
+-- Existing scores start their history at version 1 with their current values.
+INSERT INTO standard_score_history
+    (assignment_id, standard_id, student_id, school_id, version, new_score, comment, reason, changed_by, changed_at)
+SELECT s.assignment_id, s.standard_id, s.student_id, s.school_id, 1, s.score, s.comment,
+       'Recorded before standard score history was kept', s.graded_by, COALESCE(s.graded_at, s.created_at, NOW())
+FROM standard_scores s
+WHERE NOT EXISTS (
+    SELECT 1 FROM standard_score_history h
+    WHERE h.assignment_id = s.assignment_id AND h.standard_id = s.standard_id AND h.student_id = s.student_id
+);
+
+ALTER TABLE standard_score_history ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_standard_score_history ON standard_score_history
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
+
+ALTER TABLE grade_change_requests ADD COLUMN IF NOT EXISTS standard_scores JSONB;
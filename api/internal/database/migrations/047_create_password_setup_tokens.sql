@@ -0,0 +1,21 @@
+-- 047_create_password_setup_tokens.sql
+-- Single-use links that let a user with no password (imported from a SIS)
+-- set one. Only the token's hash is stored; used_at is set once it is
+-- redeemed, and issuing a new link for a user expires the old ones.
+CREATE TABLE IF NOT EXISTS password_setup_tokens (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    school_id   UUID NOT NULL REFERENCES schools(id),
+    token_hash  TEXT NOT NULL UNIQUE,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_by  UUID REFERENCES users(id),
+    created_at  TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_setup_tokens_user ON password_setup_tokens(user_id, expires_at);
+
+ALTER TABLE password_setup_tokens ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_password_setup_tokens ON password_setup_tokens
+    USING (school_id = current_setting('app.current_school_id', TRUE)::UUID);
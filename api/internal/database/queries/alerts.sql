@@ -0,0 +1,49 @@
+-- alerts.sql: Student Insights trajectories and at-risk alerts
+
+-- name: GetGradeTrajectories :many
+SELECT e.student_id, e.course_id, g.points_earned / a.max_points * 100, COALESCE(g.is_missing, FALSE)
+FROM enrollments e
+JOIN courses c ON c.id = e.course_id
+JOIN assignments a ON a.course_id = e.course_id
+JOIN grades g ON g.assignment_id = a.id AND g.student_id = e.student_id
+WHERE c.school_id = $1 AND e.status = 'active' AND COALESCE(c.is_active, TRUE)
+  AND COALESCE(a.is_published, FALSE) AND NOT a.is_extra_credit AND a.max_points > 0
+  AND NOT COALESCE(g.is_excused, FALSE)
+  AND (g.points_earned IS NOT NULL OR COALESCE(g.is_missing, FALSE))
+ORDER BY e.student_id, e.course_id, COALESCE(a.due_date, g.graded_at, a.created_at);
+
+-- name: UpsertOpenAlert :one
+INSERT INTO student_alerts (school_id, student_id, course_id, severity, source, message, trend)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (student_id, course_id) WHERE status = 'open'
+DO UPDATE SET severity = EXCLUDED.severity, source = EXCLUDED.source,
+              message = EXCLUDED.message, trend = EXCLUDED.trend
+RETURNING xmax = 0;
+
+-- name: ResolveRecoveredAlerts :execrows
+UPDATE student_alerts a SET status = 'resolved', resolved_at = NOW()
+WHERE a.school_id = $1 AND a.status = 'open'
+  AND NOT EXISTS (
+    SELECT 1 FROM unnest($2::uuid[], $3::uuid[]) AS f(student_id, course_id)
+    WHERE f.student_id = a.student_id AND f.course_id = a.course_id
+  );
+
+-- name: ListStudentAlerts :many
+SELECT al.id, al.short_id, al.school_id, al.student_id, al.course_id, al.severity, al.source,
+       al.message, al.trend, al.status, al.action_note, al.resolved_by, al.resolved_at,
+       al.created_at, al.updated_at, u.first_name || ' ' || u.last_name, c.short_id, c.name
+FROM student_alerts al
+JOIN students s ON s.id = al.student_id
+JOIN users u ON u.id = s.user_id
+JOIN courses c ON c.id = al.course_id
+LEFT JOIN teachers t ON t.id = c.teacher_id
+WHERE al.school_id = $1
+  AND ($2::uuid IS NULL OR t.user_id = $2)
+  AND ($3::uuid IS NULL OR al.course_id = $3)
+  AND ($4 = '' OR al.status = $4)
+ORDER BY CASE al.severity WHEN 'high' THEN 3 WHEN 'medium' THEN 2 ELSE 1 END DESC, al.created_at DESC
+LIMIT $5 OFFSET $6;
+
+-- name: ResolveAlert :exec
+UPDATE student_alerts SET status = $1, action_note = $2, resolved_by = $3, resolved_at = NOW()
+WHERE id = $4;
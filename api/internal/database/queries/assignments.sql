@@ -3,8 +3,8 @@
 -- name: CreateAssignment :one
 INSERT INTO assignments
     (course_id, school_id, title, description, due_date,
-     max_points, category, weight, is_published)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+     max_points, category, weight, is_published, is_extra_credit, accepts_submissions)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 RETURNING id, created_at;
 
 -- name: GetAssignmentByID :one
@@ -16,13 +16,33 @@ LIMIT 1;
 
 -- name: UpdateAssignment :exec
 UPDATE assignments
-SET title = $1, description = $2, due_date = $3, max_points = $4,
-    category = $5, weight = $6, is_published = $7, updated_at = NOW()
-WHERE id = $8 AND school_id = $9;
+SET title = $1, description = $2, due_date = $3, max_points = $4, category = $5,
+    weight = $6, is_published = $7, is_extra_credit = $8, accepts_submissions = $9
+WHERE id = $10 AND school_id = $11;
+
+-- name: ListGradesOverMax :many
+-- Scores a lowered max_points would leave above the maximum.
+SELECT student_id, points_earned, COALESCE(comment, ''),
+       COALESCE(is_excused, FALSE), COALESCE(is_missing, FALSE), COALESCE(is_late, FALSE), days_late
+FROM grades
+WHERE assignment_id = $1 AND school_id = $2 AND points_earned > $3;
 
 -- name: DeleteAssignment :exec
+-- Grades, attachments, and standards alignments cascade; grade_history is kept.
 DELETE FROM assignments WHERE id = $1 AND school_id = $2;
 
+-- name: CopyAssignment :one
+INSERT INTO assignments
+    (course_id, school_id, title, description, due_date, max_points, category, weight, is_published,
+     is_extra_credit, accepts_submissions, short_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, FALSE, $9, $10, $11)
+ON CONFLICT (short_id) DO NOTHING
+RETURNING id;
+
+-- name: CopyAssignmentStandards :exec
+INSERT INTO assignment_standards (assignment_id, standard_id, school_id)
+SELECT $1, standard_id, school_id FROM assignment_standards WHERE assignment_id = $2;
+
 -- name: ListAssignmentsByCourse :many
 SELECT id, title, description, due_date, max_points, category, weight, is_published, created_at
 FROM assignments
@@ -0,0 +1,67 @@
+-- attendance.sql: Daily and per-period attendance queries
+
+-- name: UpsertCourseAttendance :exec
+-- Admin-corrected records are left untouched.
+INSERT INTO attendance_records
+    (school_id, student_id, course_id, schedule_block_id, attendance_date, status, note, recorded_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (student_id, course_id, attendance_date) WHERE course_id IS NOT NULL
+DO UPDATE SET
+    status            = EXCLUDED.status,
+    note              = EXCLUDED.note,
+    schedule_block_id = EXCLUDED.schedule_block_id,
+    recorded_by       = EXCLUDED.recorded_by,
+    updated_at        = NOW()
+WHERE attendance_records.corrected_at IS NULL;
+
+-- name: UpsertDailyAttendance :exec
+INSERT INTO attendance_records
+    (school_id, student_id, attendance_date, status, note, recorded_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (student_id, attendance_date) WHERE course_id IS NULL
+DO UPDATE SET
+    status      = EXCLUDED.status,
+    note        = EXCLUDED.note,
+    recorded_by = EXCLUDED.recorded_by,
+    updated_at  = NOW();
+
+-- name: CorrectAttendance :exec
+UPDATE attendance_records
+SET status = $1, note = $2, corrected_by = $3, corrected_at = NOW(), correction_reason = $4
+WHERE id = $5 AND school_id = $6;
+
+-- name: GetCourseAttendanceRoster :many
+SELECT s.id, u.first_name, u.last_name, s.student_number,
+       ar.short_id, ar.status, ar.note, ar.corrected_at
+FROM enrollments e
+JOIN students s ON s.id = e.student_id
+JOIN users u ON u.id = s.user_id
+LEFT JOIN attendance_records ar
+       ON ar.student_id = e.student_id AND ar.course_id = e.course_id AND ar.attendance_date = $3
+WHERE e.course_id = $1 AND e.school_id = $2 AND e.status = 'active'
+ORDER BY u.last_name, u.first_name;
+
+-- name: GetStudentAttendanceSummary :one
+-- One status per day: a daily record wins, otherwise derived from period records.
+WITH days AS (
+    SELECT attendance_date,
+           COALESCE(
+               MAX(status) FILTER (WHERE course_id IS NULL),
+               CASE
+                   WHEN bool_and(status = 'excused') THEN 'excused'
+                   WHEN bool_and(status IN ('absent', 'excused')) THEN 'absent'
+                   WHEN bool_or(status IN ('tardy', 'absent')) THEN 'tardy'
+                   ELSE 'present'
+               END) AS day_status
+    FROM attendance_records
+    WHERE student_id = $1 AND school_id = $2
+      AND ($3::date IS NULL OR attendance_date >= $3)
+      AND ($4::date IS NULL OR attendance_date <= $4)
+    GROUP BY attendance_date
+)
+SELECT COUNT(*)::int AS days_recorded,
+       COUNT(*) FILTER (WHERE day_status = 'present')::int AS present,
+       COUNT(*) FILTER (WHERE day_status = 'absent')::int AS absent,
+       COUNT(*) FILTER (WHERE day_status = 'tardy')::int AS tardy,
+       COUNT(*) FILTER (WHERE day_status = 'excused')::int AS excused
+FROM days;
@@ -2,10 +2,13 @@
 
 -- name: CreateCourse :one
 INSERT INTO courses
-    (school_id, teacher_id, name, subject, period, room, academic_year, semester)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    (school_id, teacher_id, name, subject, period, room, academic_year, semester, credits, course_level)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 RETURNING id;
 
+-- name: UpdateCourseCredits :exec
+UPDATE courses SET credits = $1, course_level = $2 WHERE id = $3 AND school_id = $4;
+
 -- name: GetCourseByID :one
 SELECT c.id, c.school_id, c.teacher_id, c.name, c.subject,
        c.period, c.room, c.academic_year, c.semester, c.is_active,
@@ -34,15 +37,38 @@ JOIN users u ON u.id = t.user_id
 WHERE c.school_id = $1
 ORDER BY c.name;
 
--- name: EnrollStudent :exec
-INSERT INTO enrollments (student_id, course_id, school_id)
-VALUES ($1, $2, $3)
-ON CONFLICT (student_id, course_id) DO UPDATE SET status = 'active', dropped_at = NULL;
+-- name: EnrollStudent :one
+-- Returns no row when the student is already actively enrolled.
+INSERT INTO enrollments (student_id, course_id, school_id, enrolled_at, enrolled_by)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (student_id, course_id) DO UPDATE
+SET status = 'active', enrolled_at = EXCLUDED.enrolled_at, enrolled_by = EXCLUDED.enrolled_by,
+    dropped_at = NULL, dropped_by = NULL, drop_reason = NULL, transferred_to = NULL
+WHERE enrollments.status <> 'active'
+RETURNING id, xmax = 0 AS inserted;
 
 -- name: DropStudentFromCourse :exec
 UPDATE enrollments
-SET status = 'dropped', dropped_at = NOW()
-WHERE student_id = $1 AND course_id = $2 AND school_id = $3;
+SET status = 'dropped', dropped_at = $1, dropped_by = $2, drop_reason = $3
+WHERE id = $4 AND status = 'active';
+
+-- name: TransferEnrollment :one
+UPDATE enrollments
+SET status = 'transferred', dropped_at = $1, dropped_by = $2, drop_reason = $3, transferred_to = $4
+WHERE course_id = $5 AND student_id = $6 AND school_id = $7 AND status = 'active'
+RETURNING id;
+
+-- name: ListCourseEnrollments :many
+SELECT e.id, e.student_id, e.course_id, e.school_id, e.enrolled_at, e.dropped_at, e.status,
+       e.drop_reason, e.transferred_to,
+       s.short_id, u.first_name || ' ' || u.last_name AS student_name, s.student_number, s.grade_level,
+       tc.short_id AS transferred_to_short_id
+FROM enrollments e
+JOIN students s ON s.id = e.student_id
+JOIN users u ON u.id = s.user_id
+LEFT JOIN courses tc ON tc.id = e.transferred_to
+WHERE e.course_id = $1 AND e.school_id = $2 AND ($3 = 'all' OR e.status = $3)
+ORDER BY u.last_name, u.first_name;
 
 -- name: GetEnrolledStudents :many
 SELECT s.id, u.first_name, u.last_name, s.student_number, s.grade_level
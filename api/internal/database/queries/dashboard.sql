@@ -8,10 +8,14 @@ SELECT
      JOIN courses c ON c.id = a.course_id
      JOIN teachers t ON t.id = c.teacher_id
      WHERE t.user_id = $1 AND a.school_id = $2
-       AND a.due_date < NOW()
+       AND a.due_date IS NOT NULL
        AND EXISTS (
            SELECT 1 FROM enrollments e WHERE e.course_id = a.course_id AND e.status = 'active'
-           AND NOT EXISTS (SELECT 1 FROM grades g WHERE g.assignment_id = a.id AND g.student_id = e.student_id)
+           AND effective_due_date(a.id, e.student_id) < NOW()
+           AND NOT EXISTS (
+               SELECT 1 FROM grades g WHERE g.assignment_id = a.id AND g.student_id = e.student_id
+               AND (g.points_earned IS NOT NULL OR COALESCE(g.is_excused, FALSE))
+           )
        )) AS ungraded_assignments;
 
 -- name: GetAdminDashboard :one
@@ -0,0 +1,39 @@
+-- extensions.sql: Per-student due date extensions and accommodations
+
+-- name: CreateDueDateExtension :one
+INSERT INTO due_date_extensions
+    (school_id, student_id, assignment_id, course_id, due_date, extra_days, reason, granted_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, short_id, created_at, updated_at;
+
+-- name: DeleteDueDateExtensionsForScope :exec
+DELETE FROM due_date_extensions
+WHERE student_id = $1 AND school_id = $2 AND (assignment_id = $3 OR course_id = $4);
+
+-- name: ListStudentExtensions :many
+SELECT x.id, x.short_id, x.school_id, x.student_id, x.assignment_id, x.course_id, x.due_date,
+       x.extra_days, x.reason, x.granted_by, x.created_at, x.updated_at, a.title, c.name
+FROM due_date_extensions x
+LEFT JOIN assignments a ON a.id = x.assignment_id
+JOIN courses c ON c.id = COALESCE(x.course_id, a.course_id)
+LEFT JOIN teachers t ON t.id = c.teacher_id
+WHERE x.student_id = $1 AND x.school_id = $2 AND ($3::uuid IS NULL OR t.user_id = $3)
+ORDER BY x.created_at DESC;
+
+-- name: CreateAccommodation :one
+INSERT INTO student_accommodations
+    (school_id, student_id, plan, extra_days, starts_on, ends_on, notes, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, short_id;
+
+-- name: UpdateAccommodation :exec
+UPDATE student_accommodations
+SET plan = $1, extra_days = $2, starts_on = $3, ends_on = $4, notes = $5
+WHERE id = $6;
+
+-- name: ListAccommodations :many
+SELECT id, short_id, school_id, student_id, plan, extra_days, starts_on, ends_on, notes,
+       created_by, created_at, updated_at
+FROM student_accommodations
+WHERE student_id = $1 AND school_id = $2
+ORDER BY starts_on NULLS FIRST, created_at;
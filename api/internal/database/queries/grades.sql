@@ -3,8 +3,8 @@
 -- name: UpsertGrade :one
 INSERT INTO grades
     (assignment_id, student_id, school_id, points_earned, comment,
-     is_excused, is_missing, is_late, ai_accepted, graded_by, graded_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+     is_excused, is_missing, is_late, days_late, ai_accepted, graded_by, graded_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
 ON CONFLICT (assignment_id, student_id)
 DO UPDATE SET
     points_earned = EXCLUDED.points_earned,
@@ -12,6 +12,7 @@ DO UPDATE SET
     is_excused    = EXCLUDED.is_excused,
     is_missing    = EXCLUDED.is_missing,
     is_late       = EXCLUDED.is_late,
+    days_late     = EXCLUDED.days_late,
     ai_accepted   = EXCLUDED.ai_accepted,
     graded_by     = EXCLUDED.graded_by,
     graded_at     = NOW(),
@@ -20,8 +21,8 @@ RETURNING id, updated_at;
 
 -- name: GetGradesByCourse :many
 SELECT g.id, g.assignment_id, g.student_id, g.points_earned,
-       g.letter_grade, g.comment, g.is_excused, g.is_missing, g.is_late,
-       g.ai_suggested, g.ai_accepted, g.graded_at, g.updated_at
+       g.letter_grade, g.comment, g.is_excused, g.is_missing, g.is_late, g.days_late,
+       g.ai_suggested, g.ai_reasoning, g.ai_accepted, g.graded_at, g.updated_at
 FROM grades g
 JOIN assignments a ON a.id = g.assignment_id
 WHERE a.course_id = $1 AND g.school_id = $2
@@ -52,7 +53,23 @@ WHERE t.user_id = $1 AND a.school_id = $2
   );
 
 -- name: StoreAISuggestion :exec
-UPDATE grades SET ai_suggested = $1 WHERE assignment_id = $2 AND student_id = $3 AND school_id = $4;
+INSERT INTO grades (assignment_id, student_id, school_id, ai_suggested, ai_reasoning, ai_interaction_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (assignment_id, student_id)
+DO UPDATE SET ai_suggested      = EXCLUDED.ai_suggested,
+              ai_reasoning      = EXCLUDED.ai_reasoning,
+              ai_interaction_id = EXCLUDED.ai_interaction_id,
+              ai_accepted       = NULL;
+
+-- name: RejectAISuggestion :one
+UPDATE grades SET ai_accepted = FALSE
+WHERE assignment_id = $1 AND student_id = $2 AND school_id = $3
+RETURNING id;
+
+-- name: SyncAIInteractionAccepted :exec
+UPDATE ai_interactions i
+SET accepted = (SELECT bool_or(g.ai_accepted) FROM grades g WHERE g.ai_interaction_id = i.id)
+WHERE i.id = (SELECT ai_interaction_id FROM grades WHERE assignment_id = $1 AND student_id = $2);
 
 -- name: GetCourseGradeSummary :many
 -- Returns average percentage per assignment for the teacher dashboard.
@@ -65,3 +82,142 @@ WHERE a.course_id = $1 AND a.school_id = $2
 GROUP BY a.id, a.title, a.max_points
 ORDER BY a.updated_at DESC
 LIMIT $3;
+
+-- name: GetCourseGradePolicy :one
+SELECT grade_policy FROM courses WHERE id = $1 AND school_id = $2;
+
+-- name: UpdateCourseGradePolicy :exec
+UPDATE courses SET grade_policy = $1 WHERE id = $2 AND school_id = $3;
+
+-- name: ListCourseGradesForCalculation :many
+-- Inputs for GradingService.CalculateCourseGrade across a whole course.
+SELECT g.id, g.assignment_id, g.student_id, g.points_earned,
+       g.is_excused, g.is_missing, g.is_late, g.days_late
+FROM grades g
+JOIN assignments a ON a.id = g.assignment_id
+WHERE a.course_id = $1 AND g.school_id = $2;
+
+-- name: UpsertStudentTermGPA :exec
+INSERT INTO student_term_gpas
+    (student_id, school_id, term_id, method, gpa, unweighted_gpa, weighted_gpa,
+     credits_attempted, credits_earned, quality_points, weighted_quality_points,
+     cumulative_gpa, cumulative_unweighted_gpa, cumulative_weighted_gpa,
+     cumulative_credits_attempted, cumulative_credits_earned)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $5, $6, $7, $8, $9)
+ON CONFLICT (student_id, term_id)
+DO UPDATE SET
+    method                  = EXCLUDED.method,
+    gpa                     = EXCLUDED.gpa,
+    unweighted_gpa          = EXCLUDED.unweighted_gpa,
+    weighted_gpa            = EXCLUDED.weighted_gpa,
+    credits_attempted       = EXCLUDED.credits_attempted,
+    credits_earned          = EXCLUDED.credits_earned,
+    quality_points          = EXCLUDED.quality_points,
+    weighted_quality_points = EXCLUDED.weighted_quality_points,
+    computed_at             = NOW();
+
+-- name: ListStudentTermGPAs :many
+SELECT g.*, t.short_id AS term_short_id, t.name AS term_name, t.term_type, t.end_date
+FROM student_term_gpas g
+JOIN terms t ON t.id = g.term_id
+WHERE g.student_id = $1 AND g.school_id = $2
+ORDER BY t.end_date, t.start_date DESC;
+
+-- name: UpdateStudentTermCumulativeGPA :exec
+UPDATE student_term_gpas
+SET cumulative_gpa = $1, cumulative_unweighted_gpa = $2, cumulative_weighted_gpa = $3,
+    cumulative_credits_attempted = $4, cumulative_credits_earned = $5
+WHERE id = $6;
+
+-- name: GetGradeForUpdate :one
+SELECT id, points_earned, letter_grade, comment,
+       COALESCE(is_excused, FALSE), COALESCE(is_missing, FALSE), COALESCE(is_late, FALSE), days_late
+FROM grades WHERE assignment_id = $1 AND student_id = $2 AND school_id = $3
+FOR UPDATE;
+
+-- name: InsertGradeHistory :exec
+-- Append-only; the version is one past the grade's latest.
+INSERT INTO grade_history
+    (grade_id, assignment_id, student_id, school_id, version,
+     old_points_earned, new_points_earned, old_is_excused, new_is_excused,
+     old_is_missing, new_is_missing, old_is_late, new_is_late,
+     old_days_late, new_days_late, comment, reason, changed_by)
+SELECT $1, $2, $3, $4, COALESCE(MAX(version), 0) + 1,
+       $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+FROM grade_history WHERE grade_id = $1;
+
+-- name: GetGradeHistory :many
+SELECT h.id, h.grade_id, h.assignment_id, h.student_id, h.school_id, h.version,
+       h.old_points_earned, h.new_points_earned, h.old_is_excused, h.new_is_excused,
+       h.old_is_missing, h.new_is_missing, h.old_is_late, h.new_is_late,
+       h.old_days_late, h.new_days_late, h.comment, h.reason,
+       h.changed_by, COALESCE(u.first_name || ' ' || u.last_name, ''), h.changed_at
+FROM grade_history h
+JOIN assignments a ON a.id = h.assignment_id
+LEFT JOIN users u ON u.id = h.changed_by
+WHERE h.assignment_id = $1 AND h.student_id = $2 AND a.course_id = $3 AND h.school_id = $4
+ORDER BY h.version;
+
+-- name: GetCourseGradesAsOf :many
+-- Each grade's latest version at a point in time.
+SELECT DISTINCT ON (h.assignment_id, h.student_id)
+       h.id, h.grade_id, h.assignment_id, h.student_id, h.school_id, h.version,
+       h.old_points_earned, h.new_points_earned, h.old_is_excused, h.new_is_excused,
+       h.old_is_missing, h.new_is_missing, h.old_is_late, h.new_is_late,
+       h.old_days_late, h.new_days_late, h.comment, h.reason,
+       h.changed_by, COALESCE(u.first_name || ' ' || u.last_name, ''), h.changed_at
+FROM grade_history h
+JOIN assignments a ON a.id = h.assignment_id
+LEFT JOIN users u ON u.id = h.changed_by
+WHERE a.course_id = $1 AND h.school_id = $2 AND h.changed_at <= $3
+ORDER BY h.assignment_id, h.student_id, h.version DESC;
+
+-- name: CreateGradeChangeRequest :one
+INSERT INTO grade_change_requests
+    (school_id, assignment_id, student_id, course_id, requested_by,
+     points_earned, comment, is_excused, is_missing, is_late, days_late, reason, locked_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+RETURNING id, short_id, status, created_at;
+
+-- name: ListGradeChangeRequests :many
+SELECT r.id, r.short_id, r.school_id, r.assignment_id, r.student_id, r.course_id, r.requested_by,
+       r.points_earned, r.comment, r.is_excused, r.is_missing, r.is_late, r.days_late,
+       r.reason, r.locked_by, r.status, r.reviewed_by, r.reviewed_at, r.review_note, r.created_at,
+       c.short_id, a.title, su.first_name || ' ' || su.last_name, ru.first_name || ' ' || ru.last_name
+FROM grade_change_requests r
+JOIN courses c ON c.id = r.course_id
+JOIN assignments a ON a.id = r.assignment_id
+JOIN students s ON s.id = r.student_id
+JOIN users su ON su.id = s.user_id
+JOIN users ru ON ru.id = r.requested_by
+WHERE r.school_id = $1 AND r.status = $2 AND ($3::UUID IS NULL OR r.requested_by = $3)
+ORDER BY r.created_at DESC
+LIMIT $4 OFFSET $5;
+
+-- name: ReviewGradeChangeRequest :execrows
+UPDATE grade_change_requests
+SET status = $1, reviewed_by = $2, reviewed_at = NOW(), review_note = $3
+WHERE id = $4 AND status = 'pending';
+
+-- name: ListCourseAssignmentMaxPoints :many
+SELECT id, max_points FROM assignments WHERE course_id = $1 AND school_id = $2;
+
+-- name: ListActiveEnrollmentStudentIDs :many
+SELECT student_id FROM enrollments WHERE course_id = $1 AND status = 'active';
+
+-- name: ListGradebookAssignments :many
+SELECT id, short_id, title, max_points
+FROM assignments
+WHERE course_id = $1 AND school_id = $2
+ORDER BY due_date, created_at;
+
+-- name: ListGradebookStudents :many
+SELECT s.id, s.short_id, s.student_number, u.first_name, u.last_name, u.email
+FROM students s
+JOIN users u ON u.id = s.user_id
+WHERE s.school_id = $2
+  AND (EXISTS (SELECT 1 FROM enrollments e
+               WHERE e.student_id = s.id AND e.course_id = $1 AND e.status = 'active')
+    OR EXISTS (SELECT 1 FROM grades g JOIN assignments a ON a.id = g.assignment_id
+               WHERE g.student_id = s.id AND a.course_id = $1))
+ORDER BY u.last_name, u.first_name;
@@ -0,0 +1,73 @@
+-- oneroster.sql: OneRoster import and gradebook export queries
+
+-- name: ListOneRosterSources :many
+SELECT kind, sourced_id, entity_id, row_hash, deleted_at IS NOT NULL
+FROM oneroster_sources WHERE school_id = $1;
+
+-- name: UpsertOneRosterSource :exec
+INSERT INTO oneroster_sources (school_id, kind, sourced_id, entity_id, row_hash)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (school_id, kind, sourced_id)
+DO UPDATE SET entity_id = EXCLUDED.entity_id, row_hash = EXCLUDED.row_hash,
+              last_seen_at = NOW(), deleted_at = NULL;
+
+-- name: TouchOneRosterSource :exec
+UPDATE oneroster_sources SET last_seen_at = NOW()
+WHERE school_id = $1 AND kind = $2 AND sourced_id = $3;
+
+-- name: DeleteOneRosterSource :exec
+UPDATE oneroster_sources SET deleted_at = NOW(), last_seen_at = NOW()
+WHERE school_id = $1 AND kind = $2 AND sourced_id = $3;
+
+-- name: ListStaleOneRosterSources :many
+-- Rows an import in the current transaction did not see.
+SELECT sourced_id FROM oneroster_sources
+WHERE school_id = $1 AND kind = $2 AND deleted_at IS NULL AND last_seen_at < NOW();
+
+-- name: GetLastOneRosterOrg :one
+SELECT sourced_id FROM oneroster_sources
+WHERE school_id = $1 AND kind = 'org' AND deleted_at IS NULL
+ORDER BY last_seen_at DESC LIMIT 1;
+
+-- name: CreateOneRosterImport :one
+INSERT INTO oneroster_imports (school_id, org_sourced_id, dry_run, status, report, error, imported_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, short_id, created_at;
+
+-- name: ListOneRosterImports :many
+SELECT id, short_id, school_id, org_sourced_id, dry_run, status, report, error, imported_by, created_at
+FROM oneroster_imports
+WHERE school_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: GetOneRosterImportByShortID :one
+SELECT id, short_id, school_id, org_sourced_id, dry_run, status, report, error, imported_by, created_at
+FROM oneroster_imports
+WHERE short_id = $1 AND school_id = $2;
+
+-- name: ListOneRosterLineItems :many
+SELECT a.id, a.title, COALESCE(a.description, ''), a.created_at, a.due_date, a.category,
+       a.max_points, a.updated_at,
+       COALESCE(cs.sourced_id, c.id::text), COALESCE(ts.sourced_id, c.term_id::text, '')
+FROM assignments a
+JOIN courses c ON c.id = a.course_id
+LEFT JOIN oneroster_sources cs
+       ON cs.school_id = a.school_id AND cs.kind = 'class' AND cs.entity_id = c.id AND cs.deleted_at IS NULL
+LEFT JOIN oneroster_sources ts
+       ON ts.school_id = a.school_id AND ts.kind = 'academicSession' AND ts.entity_id = c.term_id
+       AND ts.deleted_at IS NULL
+WHERE a.school_id = $1 AND a.is_published = TRUE AND ($2::UUID IS NULL OR a.course_id = $2)
+ORDER BY c.name, a.due_date;
+
+-- name: ListOneRosterResults :many
+SELECT g.id, g.assignment_id, COALESCE(us.sourced_id, s.user_id::text),
+       g.points_earned, COALESCE(g.is_excused, FALSE), COALESCE(g.comment, ''),
+       COALESCE(g.graded_at, g.updated_at), g.updated_at
+FROM grades g
+JOIN assignments a ON a.id = g.assignment_id
+JOIN students s ON s.id = g.student_id
+LEFT JOIN oneroster_sources us
+       ON us.school_id = g.school_id AND us.kind = 'user' AND us.entity_id = s.user_id AND us.deleted_at IS NULL
+WHERE g.school_id = $1 AND a.is_published = TRUE AND ($2::UUID IS NULL OR a.course_id = $2)
+ORDER BY a.id, g.student_id;
@@ -18,12 +18,13 @@ UPDATE report_cards SET is_finalized = TRUE WHERE id = $1 AND school_id = $2;
 
 -- name: CreateDocument :one
 INSERT INTO documents
-    (id, school_id, student_id, type, verification_code, pdf_url, generated_by, expires_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    (id, school_id, student_id, type, verification_code, pdf_url, generated_by, expires_at,
+     template_id, render_data)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 RETURNING id, created_at;
 
 -- name: GetDocumentByVerificationCode :one
-SELECT d.id, d.type, d.student_id, d.expires_at, d.created_at,
+SELECT d.id, d.type, d.student_id, d.expires_at, d.created_at, d.render_data,
        u.first_name, u.last_name
 FROM documents d
 JOIN students s ON s.id = d.student_id
@@ -43,3 +44,50 @@ FROM documents
 WHERE generated_by = $1
   AND created_at >= NOW()::date
   AND created_at < NOW()::date + INTERVAL '1 day';
+
+-- name: CreateReportCardJob :one
+INSERT INTO report_card_jobs
+    (school_id, term_id, academic_period, grade_level, total_count, bundle, requested_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, short_id, status, created_at;
+
+-- name: CreateReportCardJobItems :exec
+INSERT INTO report_card_job_items (job_id, school_id, student_id)
+SELECT $1, $2, unnest($3::uuid[]);
+
+-- name: ListUnfinishedReportCardJobs :many
+SELECT id FROM report_card_jobs WHERE status IN ('pending', 'running') ORDER BY created_at;
+
+-- name: ListPendingReportCardJobItems :many
+SELECT id, student_id FROM report_card_job_items WHERE job_id = $1 AND status = 'pending';
+
+-- name: CompleteReportCardJobItem :exec
+UPDATE report_card_job_items SET status = 'completed', report_card_id = $1, updated_at = NOW()
+WHERE id = $2;
+
+-- name: GetReportCardJobByShortID :one
+SELECT id, short_id, school_id, academic_period, grade_level, status,
+       total_count, completed_count, failed_count, bundle, bundle_url, error,
+       requested_by, started_at, finished_at, created_at
+FROM report_card_jobs
+WHERE short_id = $1 AND school_id = $2;
+
+-- name: ListTranscriptCourses :many
+-- Completed courses: enrollments marked completed, or active enrollments whose term has ended.
+SELECT c.short_id, c.academic_year, c.semester,
+       t.id, t.short_id, t.name, t.term_type, t.start_date, t.end_date
+FROM enrollments e
+JOIN courses c ON c.id = e.course_id
+LEFT JOIN terms t ON t.id = c.term_id
+WHERE e.student_id = $1 AND c.school_id = $2
+  AND (e.status = 'completed' OR (e.status = 'active' AND t.end_date < CURRENT_DATE))
+ORDER BY c.academic_year, t.end_date NULLS LAST, t.start_date DESC, t.id, c.semester, c.name;
+
+-- name: FinalizeReportCard :one
+UPDATE report_cards
+SET is_finalized = TRUE, finalized_at = COALESCE(finalized_at, NOW()), finalized_by = COALESCE(finalized_by, $1)
+WHERE id = $2 AND school_id = $3 AND superseded_by IS NULL
+RETURNING id, student_id;
+
+-- name: SupersedeReportCard :exec
+UPDATE report_cards SET superseded_by = $1 WHERE id = $2;
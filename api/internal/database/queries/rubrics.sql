@@ -0,0 +1,62 @@
+-- rubrics.sql: Reusable rubrics and per-criterion scores
+
+-- name: CreateRubric :one
+INSERT INTO rubrics (school_id, title, description, visibility, department, created_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id;
+
+-- name: CreateRubricCriterion :one
+INSERT INTO rubric_criteria (rubric_id, school_id, position, title, description)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: CreateRubricLevel :exec
+INSERT INTO rubric_levels (criterion_id, school_id, position, title, description, points)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetRubricCriteria :many
+SELECT c.id, c.position, c.title, c.description, l.id, l.position, l.title, l.description, l.points
+FROM rubric_criteria c
+JOIN rubric_levels l ON l.criterion_id = c.id
+WHERE c.rubric_id = $1
+ORDER BY c.position, l.position;
+
+-- name: ListRubrics :many
+SELECT r.id, r.short_id, r.school_id, r.title, r.description, r.visibility, r.department,
+       r.created_by, r.is_archived, r.created_at, r.updated_at,
+       COALESCE(u.first_name || ' ' || u.last_name, '')
+FROM rubrics r
+LEFT JOIN users u ON u.id = r.created_by
+WHERE r.school_id = $1
+  AND ($2 OR NOT r.is_archived)
+  AND (r.created_by = $3 OR (NOT $4 AND (
+        $5
+        OR r.visibility = 'school'
+        OR (r.visibility = 'department' AND r.department = $6))))
+ORDER BY r.created_at DESC
+LIMIT $7 OFFSET $8;
+
+-- name: UpdateRubric :exec
+UPDATE rubrics SET title = $1, description = $2, visibility = $3, department = $4
+WHERE id = $5;
+
+-- name: ArchiveRubric :exec
+UPDATE rubrics SET is_archived = TRUE WHERE id = $1;
+
+-- name: SetAssignmentRubric :exec
+UPDATE assignments SET rubric_id = $1 WHERE id = $2;
+
+-- name: UpsertRubricScore :one
+INSERT INTO rubric_scores
+    (school_id, assignment_id, student_id, criterion_id, level_id, points, comment, graded_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (assignment_id, student_id, criterion_id)
+DO UPDATE SET level_id = EXCLUDED.level_id, points = EXCLUDED.points, comment = EXCLUDED.comment,
+              graded_by = EXCLUDED.graded_by, graded_at = NOW()
+RETURNING id, graded_at;
+
+-- name: ListRubricScores :many
+SELECT id, assignment_id, student_id, criterion_id, level_id, points, comment, graded_by, graded_at
+FROM rubric_scores
+WHERE assignment_id = $1 AND school_id = $2 AND ($3::uuid IS NULL OR student_id = $3)
+ORDER BY student_id, graded_at;
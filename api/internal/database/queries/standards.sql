@@ -0,0 +1,57 @@
+-- standards.sql: Learning standards and standards-based grading
+
+-- name: ListStandards :many
+SELECT id, short_id, school_id, code, description, subject, grade_level, is_active, created_at, updated_at
+FROM standards
+WHERE school_id = $1
+  AND ($2 = '' OR subject = $2)
+  AND ($3 = '' OR grade_level = $3)
+  AND (is_active OR $4)
+ORDER BY code;
+
+-- name: CreateStandard :one
+INSERT INTO standards (school_id, code, description, subject, grade_level)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, short_id, school_id, code, description, subject, grade_level, is_active, created_at, updated_at;
+
+-- name: UpdateStandard :exec
+UPDATE standards SET description = $1, subject = $2, grade_level = $3, is_active = $4
+WHERE id = $5 AND school_id = $6;
+
+-- name: ClearAssignmentStandards :exec
+DELETE FROM assignment_standards WHERE assignment_id = $1;
+
+-- name: AlignAssignmentStandards :exec
+INSERT INTO assignment_standards (assignment_id, standard_id, school_id)
+SELECT $1, unnest($2::uuid[]), $3
+ON CONFLICT DO NOTHING;
+
+-- name: UpsertStandardScore :exec
+INSERT INTO standard_scores
+    (assignment_id, standard_id, student_id, school_id, score, comment, graded_by, graded_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+ON CONFLICT (assignment_id, standard_id, student_id)
+DO UPDATE SET
+    score     = EXCLUDED.score,
+    comment   = EXCLUDED.comment,
+    graded_by = EXCLUDED.graded_by,
+    graded_at = NOW();
+
+-- name: GetCourseStandards :many
+SELECT DISTINCT a.course_id, s.id, s.short_id, s.code, s.description
+FROM assignment_standards ast
+JOIN assignments a ON a.id = ast.assignment_id
+JOIN standards s ON s.id = ast.standard_id
+WHERE a.course_id = ANY($1) AND a.school_id = $2 AND a.is_published = TRUE
+ORDER BY a.course_id, s.code;
+
+-- name: GetStudentStandardScores :many
+SELECT a.course_id, ss.id, ss.assignment_id, ss.standard_id, s.short_id, ss.student_id,
+       ss.score, ss.comment, ss.graded_by, ss.graded_at, COALESCE(a.due_date, ss.graded_at)
+FROM standard_scores ss
+JOIN assignments a ON a.id = ss.assignment_id
+JOIN standards s ON s.id = ss.standard_id
+WHERE ss.student_id = $1 AND ss.school_id = $2 AND a.course_id = ANY($3) AND a.is_published = TRUE;
+
+-- name: UpdateCourseGrading :exec
+UPDATE courses SET grading_mode = $1, mastery_rule = $2 WHERE id = $3 AND school_id = $4;
@@ -0,0 +1,62 @@
+-- submissions.sql: Student submissions, the submission queue, and missing work
+
+-- name: GetEffectiveDueDate :one
+SELECT effective_due_date($1, $2);
+
+-- name: CreateDraftSubmission :one
+INSERT INTO submissions (assignment_id, student_id, school_id, attempt)
+SELECT $1, $2, $3, COALESCE(MAX(attempt), 0) + 1
+FROM submissions WHERE assignment_id = $1 AND student_id = $2
+RETURNING id;
+
+-- name: CreateSubmissionFile :exec
+INSERT INTO submission_files (id, submission_id, school_id, file_name, file_key, file_size, mime_type)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: SubmitDraft :one
+UPDATE submissions
+SET status = 'submitted', text_entry = $1, submitted_at = $2, due_at = $3, is_late = $4, days_late = $5
+WHERE id = $6
+RETURNING attempt;
+
+-- name: ListStudentSubmissions :many
+SELECT id, short_id, assignment_id, student_id, school_id, attempt, status, text_entry,
+       submitted_at, due_at, is_late, days_late, created_at, updated_at
+FROM submissions
+WHERE assignment_id = $1 AND student_id = $2 AND school_id = $3 AND ($4 OR status = 'submitted')
+ORDER BY attempt;
+
+-- name: ListSubmissionQueue :many
+SELECT s.id, s.short_id, u.first_name || ' ' || u.last_name AS student_name, effective_due_date($1, s.id) AS due_at,
+       ls.attempt, ls.submitted_at, COALESCE(ls.is_late, FALSE), ls.days_late,
+       (SELECT COUNT(*) FROM submissions x
+        WHERE x.assignment_id = $1 AND x.student_id = s.id AND x.status = 'submitted') AS attempts,
+       g.points_earned, COALESCE(g.is_excused, FALSE), COALESCE(g.is_missing, FALSE)
+FROM enrollments e
+JOIN students s ON s.id = e.student_id
+JOIN users u ON u.id = s.user_id
+LEFT JOIN LATERAL (
+    SELECT attempt, submitted_at, is_late, days_late FROM submissions x
+    WHERE x.assignment_id = $1 AND x.student_id = s.id AND x.status = 'submitted'
+    ORDER BY attempt DESC LIMIT 1
+) ls ON TRUE
+LEFT JOIN grades g ON g.assignment_id = $1 AND g.student_id = s.id
+WHERE e.course_id = $2 AND e.status = 'active' AND e.school_id = $3
+ORDER BY ls.submitted_at DESC NULLS LAST, u.last_name, u.first_name;
+
+-- name: ListMissingWork :many
+-- Overdue, never-submitted work not yet graded, excused, or flagged missing.
+SELECT a.school_id, a.id, e.student_id
+FROM assignments a
+JOIN enrollments e ON e.course_id = a.course_id AND e.status = 'active'
+LEFT JOIN grades g ON g.assignment_id = a.id AND g.student_id = e.student_id
+WHERE a.accepts_submissions AND COALESCE(a.is_published, FALSE)
+  AND ($1::uuid IS NULL OR a.id = $1)
+  AND effective_due_date(a.id, e.student_id) < NOW()
+  AND NOT EXISTS (
+    SELECT 1 FROM submissions s
+    WHERE s.assignment_id = a.id AND s.student_id = e.student_id AND s.status = 'submitted'
+  )
+  AND (g.id IS NULL OR (g.points_earned IS NULL
+       AND NOT COALESCE(g.is_excused, FALSE) AND NOT COALESCE(g.is_missing, FALSE)))
+ORDER BY a.school_id;
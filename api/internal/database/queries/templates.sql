@@ -0,0 +1,41 @@
+-- templates.sql: Versioned report card and document templates
+
+-- name: GetActiveTemplate :one
+SELECT id, body FROM document_templates
+WHERE school_id = $1 AND kind = $2 AND name = $3 AND is_active;
+
+-- name: GetTemplateVersionBody :one
+SELECT body FROM document_templates WHERE id = $1 AND school_id = $2;
+
+-- name: DeactivateTemplates :exec
+UPDATE document_templates SET is_active = FALSE
+WHERE school_id = $1 AND kind = $2 AND name = $3 AND is_active;
+
+-- name: CreateTemplateVersion :one
+INSERT INTO document_templates (school_id, kind, name, version, body, is_active, created_by)
+SELECT $1, $2, $3, COALESCE(MAX(version), 0) + 1, $4, $5, $6
+FROM document_templates
+WHERE school_id = $1 AND kind = $2 AND name = $3
+RETURNING id, short_id, school_id, kind, name, version, is_active, created_by, created_at;
+
+-- name: ListTemplates :many
+SELECT id, short_id, school_id, kind, name, version, is_active, created_by, created_at
+FROM document_templates
+WHERE school_id = $1
+  AND ($2 = '' OR kind = $2)
+  AND ($3 = '' OR name = $3)
+ORDER BY kind, name, version DESC;
+
+-- name: GetTemplateByShortID :one
+SELECT id, short_id, school_id, kind, name, version, body, is_active, created_by, created_at
+FROM document_templates
+WHERE short_id = $1 AND school_id = $2;
+
+-- name: ActivateTemplate :exec
+UPDATE document_templates SET is_active = TRUE WHERE id = $1;
+
+-- name: GetDocumentRenderData :one
+SELECT type, template_id, render_data FROM documents WHERE id = $1 AND school_id = $2;
+
+-- name: GetReportCardRenderData :one
+SELECT template_id, render_data FROM report_cards WHERE id = $1 AND school_id = $2;
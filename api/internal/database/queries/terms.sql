@@ -0,0 +1,44 @@
+-- terms.sql: Academic term queries
+
+-- name: ListTerms :many
+SELECT id, short_id, school_id, parent_id, name, term_type, start_date, end_date, is_closed, closed_at,
+       created_at, updated_at
+FROM terms
+WHERE school_id = $1
+ORDER BY start_date, term_type;
+
+-- name: GetTermByShortID :one
+SELECT id, short_id, school_id, parent_id, name, term_type, start_date, end_date, is_closed, closed_at,
+       created_at, updated_at
+FROM terms
+WHERE short_id = $1 AND school_id = $2;
+
+-- name: CreateTerm :one
+INSERT INTO terms (school_id, parent_id, name, term_type, start_date, end_date)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: UpdateTerm :exec
+UPDATE terms SET name = $1, start_date = $2, end_date = $3
+WHERE id = $4 AND school_id = $5;
+
+-- name: TermInUse :one
+SELECT EXISTS (SELECT 1 FROM terms WHERE parent_id = $1)
+    OR EXISTS (SELECT 1 FROM courses WHERE term_id = $1)
+    OR EXISTS (SELECT 1 FROM report_cards WHERE term_id = $1);
+
+-- name: DeleteTerm :exec
+DELETE FROM terms WHERE id = $1 AND school_id = $2;
+
+-- name: SetTermClosed :exec
+-- Applies to the term and every term nested in it.
+WITH RECURSIVE nested AS (
+    SELECT id FROM terms WHERE id = $1
+    UNION ALL
+    SELECT t.id FROM terms t JOIN nested n ON t.parent_id = n.id
+)
+UPDATE terms
+SET is_closed = $2,
+    closed_at = CASE WHEN $2 THEN NOW() END,
+    closed_by = CASE WHEN $2 THEN $3::UUID END
+WHERE id IN (SELECT id FROM nested) AND school_id = $4;
@@ -15,13 +15,14 @@ import (
 
 // AdminHandler handles administrative operations.
 type AdminHandler struct {
-	db    *pgxpool.Pool
-	email *services.EmailService
+	db             *pgxpool.Pool
+	email          *services.EmailService
+	frontendOrigin string
 }
 
 // NewAdminHandler creates an AdminHandler.
-func NewAdminHandler(db *pgxpool.Pool, email *services.EmailService) *AdminHandler {
-	return &AdminHandler{db: db, email: email}
+func NewAdminHandler(db *pgxpool.Pool, email *services.EmailService, frontendOrigin string) *AdminHandler {
+	return &AdminHandler{db: db, email: email, frontendOrigin: frontendOrigin}
 }
 
 // LockGrade locks a single student's grade access.
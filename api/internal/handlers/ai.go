@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,28 +10,32 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pragma-proto/api/internal/auth"
 	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
 	"github.com/pragma-proto/api/internal/services"
 )
 
-// AIHandler proxies AI requests to Claude with anonymization.
+// AIHandler proxies AI requests through the redacting gateway.
 type AIHandler struct {
 	db  *pgxpool.Pool
-	ai  *services.AIService
+	ai  *services.AIGateway
 }
 
 // NewAIHandler creates an AIHandler.
-func NewAIHandler(db *pgxpool.Pool, ai *services.AIService) *AIHandler {
+func NewAIHandler(db *pgxpool.Pool, ai *services.AIGateway) *AIHandler {
 	return &AIHandler{db: db, ai: ai}
 }
 
 // GradingAssistant handles AI-assisted grading suggestions.
-// Student names and PII are anonymized before being sent to Claude.
+// Student names and PII are anonymized before being sent to Claude. Valid
+// suggestions are stored in grades.ai_suggested for the teacher to accept
+// or reject; the rest are reported per student under "errors".
 func (h *AIHandler) GradingAssistant(w http.ResponseWriter, r *http.Request) {
 	claims, _ := auth.ClaimsFromContext(r.Context())
 
 	var req struct {
 		AssignmentID string            `json:"assignment_id" validate:"required,uuid"`
-		Rubric       string            `json:"rubric" validate:"required,min=10"`
+		Rubric       string            `json:"rubric" validate:"omitempty,min=10"`
+		RubricID     string            `json:"rubric_id"` // short_id of a stored rubric, instead of rubric text
 		Submissions  map[string]string `json:"submissions" validate:"required,min=1"` // student_id → submission text
 	}
 
@@ -44,6 +49,11 @@ func (h *AIHandler) GradingAssistant(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
+	assignmentID, err := uuid.Parse(req.AssignmentID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "assignment_id is not a valid UUID")
+		return
+	}
 
 	ctx := r.Context()
 
@@ -56,24 +66,37 @@ func (h *AIHandler) GradingAssistant(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get max_points for validation.
+	var courseID uuid.UUID
 	var maxPoints float64
-	h.db.QueryRow(ctx, `SELECT max_points FROM assignments WHERE id = $1 AND school_id = $2`,
-		req.AssignmentID, claims.SchoolID).Scan(&maxPoints)
-
-	// Fetch student names for anonymization.
-	studentNames := make(map[string]string)
-	rows, _ := h.db.Query(ctx, `
-		SELECT s.id, u.first_name || ' ' || u.last_name
-		FROM students s JOIN users u ON u.id = s.user_id
-		WHERE s.id = ANY($1) AND s.school_id = $2
-	`, studentIDSlice(req.Submissions), claims.SchoolID)
-	if rows != nil {
-		defer rows.Close()
-		for rows.Next() {
-			var sid, name string
-			rows.Scan(&sid, &name)
-			studentNames[sid] = name
+	if err := h.db.QueryRow(ctx, `SELECT course_id, max_points FROM assignments WHERE id = $1 AND school_id = $2`,
+		assignmentID, claims.SchoolID).Scan(&courseID, &maxPoints); err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "assignment not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	// A stored rubric replaces the rubric text; with neither, fall back to
+	// the rubric attached to the assignment.
+	rubricText := req.Rubric
+	if req.RubricID != "" || rubricText == "" {
+		var rb *models.Rubric
+		var err error
+		if req.RubricID != "" {
+			rb, err = resolveRubric(ctx, h.db, req.RubricID, claims.SchoolID)
+			if err == nil && !canUseRubric(ctx, h.db, claims, rb) {
+				rb = nil
+			}
+		} else {
+			rb, err = assignmentRubric(ctx, h.db, assignmentID, claims.SchoolID)
 		}
+		if err != nil || rb == nil {
+			writeError(w, http.StatusBadRequest, "validation_error", "rubric or a usable rubric_id is required")
+			return
+		}
+		rubricText = services.RubricPromptText(rb)
 	}
 
 	// Build anonymized submission text.
@@ -82,50 +105,117 @@ func (h *AIHandler) GradingAssistant(w http.ResponseWriter, r *http.Request) {
 	anonSubmissions := ""
 	for studentID, text := range req.Submissions {
 		i++
-		placeholder := fmt.Sprintf("Student %c", rune('A'+i-1))
+		placeholder := fmt.Sprintf("Student %d", i)
 		anonMap[placeholder] = studentID
 		anonSubmissions += placeholder + ":\n" + text + "\n\n"
 	}
 
-	systemPrompt := services.GradingAssistantPrompt(req.Rubric, maxPoints)
-	response, tokens, err := h.ai.Complete(ctx, systemPrompt, anonSubmissions, 2048)
+	systemPrompt := services.GradingAssistantPrompt(rubricText, maxPoints)
+	redactor, err := loadRedactor(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	completion, err := h.ai.Complete(ctx, redactor, services.AIRequest{
+		System:    systemPrompt,
+		Prompt:    anonSubmissions,
+		MaxTokens: 2048,
+		JSON:      true,
+	})
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "ai_error", "AI service is unavailable")
 		return
 	}
 
+	// Only students enrolled in the course get a suggestion stored.
+	enrolled := make(map[string]bool)
+	erows, err := h.db.Query(ctx, `
+		SELECT student_id::text FROM enrollments
+		WHERE course_id = $1 AND status = 'active' AND student_id::text = ANY($2)
+	`, courseID, studentIDSlice(req.Submissions))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	for erows.Next() {
+		var sid string
+		if erows.Scan(&sid) == nil {
+			enrolled[sid] = true
+		}
+	}
+	erows.Close()
+
+	response, tokens := completion.Text, completion.Usage.Total()
+	parsed, parseErr := services.ParseGradingSuggestions(response)
+	suggestions, suggestionErrors := checkGradingSuggestions(parsed, parseErr, anonMap, enrolled, maxPoints)
+
+	// Store the interaction and its suggestions together.
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var interactionID uuid.UUID
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO ai_interactions (school_id, user_id, feature, input_summary, output_summary, tokens_used, redactions)
+		VALUES ($1, $2, 'grading_assistant', $3, $4, $5, $6)
+		RETURNING id
+	`, claims.SchoolID, claims.UserID,
+		"grading_assistant request for "+req.AssignmentID,
+		response[:min(500, len(response))],
+		tokens,
+		completion.Redactions,
+	).Scan(&interactionID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	suggestions, skipped, err := storeAISuggestions(ctx, tx, claims.SchoolID, assignmentID, interactionID, suggestions)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	suggestionErrors = append(suggestionErrors, skipped...)
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
 	// Log AI interaction.
-	interactionID, _ := uuid.Parse("")
 	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
 		SchoolID:   claims.SchoolID,
 		UserID:     &claims.UserID,
 		Action:     "ai.request",
 		EntityType: "ai_interaction",
+		EntityID:   &interactionID,
 		NewValue: map[string]interface{}{
 			"feature":       "grading_assistant",
 			"assignment_id": req.AssignmentID,
 			"tokens":        tokens,
+			"suggested":     len(suggestions),
+			"rejected":      len(suggestionErrors),
+			"redactions":    len(completion.Redactions),
 		},
 		IPAddress: r.RemoteAddr,
 		UserAgent: r.UserAgent(),
 	})
-	_ = interactionID
-
-	// Store in ai_interactions table.
-	h.db.Exec(ctx, `
-		INSERT INTO ai_interactions (school_id, user_id, feature, input_summary, output_summary, tokens_used)
-		VALUES ($1, $2, 'grading_assistant', $3, $4, $5)
-	`, claims.SchoolID, claims.UserID,
-		"grading_assistant request for "+req.AssignmentID,
-		response[:min(500, len(response))],
-		tokens,
-	)
 
+	if suggestions == nil {
+		suggestions = []aiSuggestion{}
+	}
+	if suggestionErrors == nil {
+		suggestionErrors = []aiSuggestionError{}
+	}
 	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"raw_response":  response,
-		"anonymized":    true,
-		"student_map":   anonMap, // tells the teacher which placeholder = which student
-		"tokens_used":   tokens,
+		"interaction_id": interactionID,
+		"suggestions":    suggestions,
+		"errors":         suggestionErrors, // per student: malformed, out of range, missing, or final
+		"raw_response":   response,
+		"anonymized":     true,
+		"redactions":     completion.Redactions,
+		"student_map":    anonMap, // tells the teacher which placeholder = which student
+		"tokens_used":    tokens,
 	})
 }
 
@@ -136,7 +226,7 @@ func (h *AIHandler) ReportComment(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		StudentID     string  `json:"student_id" validate:"required,uuid"`
 		CourseID      string  `json:"course_id" validate:"required,uuid"`
-		GradeSummary  string  `json:"grade_summary" validate:"required"`
+		GradeSummary  string  `json:"grade_summary" validate:"required,max=5000"`
 		TrendDir      string  `json:"trend_direction" validate:"required,oneof=improving declining stable"`
 	}
 
@@ -146,31 +236,111 @@ func (h *AIHandler) ReportComment(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
 		return
 	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
 
 	ctx := r.Context()
 
+	studentUUID, err := uuid.Parse(req.StudentID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid student_id")
+		return
+	}
+	courseUUID, err := uuid.Parse(req.CourseID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid course_id")
+		return
+	}
+
+	// The student must be on the course roster, and a teacher may only ask
+	// about their own course.
+	var enrolled int
+	if err := h.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM enrollments e
+		JOIN courses c ON c.id = e.course_id
+		WHERE e.student_id = $1 AND e.course_id = $2 AND c.school_id = $3
+	`, studentUUID, courseUUID, claims.SchoolID).Scan(&enrolled); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if enrolled == 0 {
+		writeError(w, http.StatusNotFound, "not_found", "student is not enrolled in this course")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you do not teach this course")
+		return
+	}
+
+	// Attendance totals are plain counts, so they carry no PII.
+	attendance, err := loadAttendanceSummary(ctx, h.db, studentUUID, claims.SchoolID, nil, nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
 	systemPrompt := services.ReportCommentPrompt()
-	prompt := "Grade summary (anonymized):\n" + req.GradeSummary + "\n\nTrend: " + req.TrendDir
+	prompt := "Grade summary (anonymized):\n" + req.GradeSummary + "\n\nTrend: " + req.TrendDir +
+		fmt.Sprintf("\n\nAttendance: %d days recorded, %d present, %d tardy, %d absent, %d excused, %.1f%% attendance rate",
+			attendance.DaysRecorded, attendance.Present, attendance.Tardy, attendance.Absent, attendance.Excused, attendance.Rate)
 
-	response, tokens, err := h.ai.Complete(ctx, systemPrompt, prompt, 512)
+	// grade_summary comes from the client, so the gateway scrubs it like
+	// everything else.
+	redactor, err := loadRedactor(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	completion, err := h.ai.Complete(ctx, redactor, services.AIRequest{System: systemPrompt, Prompt: prompt, MaxTokens: 512})
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "ai_error", "AI service is unavailable")
 		return
 	}
+	response, tokens := completion.Text, completion.Usage.Total()
 
 	h.db.Exec(ctx, `
-		INSERT INTO ai_interactions (school_id, user_id, feature, input_summary, output_summary, tokens_used)
-		VALUES ($1, $2, 'report_comments', $3, $4, $5)
-	`, claims.SchoolID, claims.UserID, req.GradeSummary[:min(200, len(req.GradeSummary))],
-		response[:min(200, len(response))], tokens)
+		INSERT INTO ai_interactions (school_id, user_id, feature, input_summary, output_summary, tokens_used, redactions)
+		VALUES ($1, $2, 'report_comments', $3, $4, $5, $6)
+	`, claims.SchoolID, claims.UserID, completion.Sent.Prompt[:min(200, len(completion.Sent.Prompt))],
+		response[:min(200, len(response))], tokens, completion.Redactions)
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"comment":        response,
 		"ai_assisted":    true,
+		"redactions":     completion.Redactions,
 		"tokens_used":    tokens,
 	})
 }
 
+// loadRedactor builds the PII redactor for a school from everyone on its
+// roster, including former students and staff.
+func loadRedactor(ctx context.Context, db dbtx, schoolID uuid.UUID) (*services.Redactor, error) {
+	rows, err := db.Query(ctx, `
+		SELECT u.first_name, u.last_name, COALESCE(u.phone, ''), COALESCE(s.student_number, '')
+		FROM users u
+		LEFT JOIN students s ON s.user_id = u.id
+		WHERE u.school_id = $1
+	`, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var roster []services.RosterPerson
+	for rows.Next() {
+		var p services.RosterPerson
+		if err := rows.Scan(&p.FirstName, &p.LastName, &p.Phone, &p.StudentNumber); err != nil {
+			return nil, err
+		}
+		roster = append(roster, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return services.NewRedactor(roster), nil
+}
+
 func studentIDSlice(m map[string]string) []string {
 	ids := make([]string, 0, len(m))
 	for k := range m {
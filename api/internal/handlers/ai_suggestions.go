@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
+)
+
+// Grading assistant suggestions are saved to grades.ai_suggested and never
+// to points_earned. A teacher then accepts a suggestion, which makes it the
+// grade, or rejects it; either way the decision is kept in
+// grades.ai_accepted and rolled up into the ai_interactions row that made
+// the suggestion.
+
+// aiSuggestion is a validated suggestion for one student.
+type aiSuggestion struct {
+	StudentID       uuid.UUID `json:"student_id"`
+	SuggestedPoints float64   `json:"suggested_points"`
+	Reasoning       string    `json:"reasoning"`
+}
+
+// aiSuggestionError explains why a student got no suggestion. StudentID is
+// empty when the AI named a placeholder that was never sent.
+type aiSuggestionError struct {
+	StudentID string `json:"student_id,omitempty"`
+	Student   string `json:"student,omitempty"` // the anonymized placeholder
+	Error     string `json:"error"`
+}
+
+// checkGradingSuggestions maps parsed suggestions back to students through
+// anonMap (placeholder → student_id) and keeps those within 0..maxPoints for
+// students enrolled in the course. Every student sent to the AI ends up in
+// exactly one of the two results.
+func checkGradingSuggestions(
+	parsed []services.GradingSuggestion,
+	parseErr error,
+	anonMap map[string]string,
+	enrolled map[string]bool,
+	maxPoints float64,
+) ([]aiSuggestion, []aiSuggestionError) {
+	placeholders := make(map[string]string, len(anonMap)) // student_id → placeholder
+	for p, id := range anonMap {
+		placeholders[id] = p
+	}
+	var valid []aiSuggestion
+	var invalid []aiSuggestionError
+	done := make(map[string]bool)
+
+	if parseErr == nil {
+		for i, sg := range parsed {
+			id, known := anonMap[sg.Student]
+			switch {
+			case !known && sg.Err != nil:
+				invalid = append(invalid, aiSuggestionError{Student: sg.Student, Error: fmt.Sprintf("element %d: %v", i, sg.Err)})
+				continue
+			case !known:
+				invalid = append(invalid, aiSuggestionError{Student: sg.Student, Error: "unknown student placeholder"})
+				continue
+			case done[id]:
+				invalid = append(invalid, aiSuggestionError{StudentID: id, Student: sg.Student, Error: "more than one suggestion; only the first was used"})
+				continue
+			}
+			done[id] = true
+			e := aiSuggestionError{StudentID: id, Student: sg.Student}
+			studentID, idErr := uuid.Parse(id)
+			switch {
+			case idErr != nil:
+				e.Error = "student_id is not a valid UUID"
+			case sg.Err != nil:
+				e.Error = sg.Err.Error()
+			case math.IsNaN(*sg.SuggestedPoints) || *sg.SuggestedPoints < 0 || *sg.SuggestedPoints > maxPoints:
+				e.Error = fmt.Sprintf("suggested_points %g is outside 0 to %g", *sg.SuggestedPoints, maxPoints)
+			case !enrolled[id]:
+				e.Error = "student is not enrolled in this course"
+			default:
+				valid = append(valid, aiSuggestion{
+					StudentID:       studentID,
+					SuggestedPoints: math.Round(*sg.SuggestedPoints*100) / 100,
+					Reasoning:       sg.Reasoning,
+				})
+				continue
+			}
+			invalid = append(invalid, e)
+		}
+	}
+
+	reason := "no suggestion in the AI response"
+	if parseErr != nil {
+		reason = "the AI response could not be read"
+	}
+	for id, p := range placeholders {
+		if !done[id] {
+			invalid = append(invalid, aiSuggestionError{StudentID: id, Student: p, Error: reason})
+		}
+	}
+	sort.Slice(invalid, func(i, j int) bool { return invalid[i].Student < invalid[j].Student })
+	return valid, invalid
+}
+
+// storeAISuggestions saves suggestions on their grade rows, creating a row
+// without points where none exists yet. A new suggestion is undecided, so
+// ai_accepted is cleared. Final grades get no suggestion, since accepting one
+// would only file a change request; they are returned as errors instead,
+// with the suggestions that were stored.
+func storeAISuggestions(
+	ctx context.Context,
+	q dbtx,
+	schoolID, assignmentID, interactionID uuid.UUID,
+	suggestions []aiSuggestion,
+) ([]aiSuggestion, []aiSuggestionError, error) {
+	keys := make([]gradeKey, len(suggestions))
+	for i, s := range suggestions {
+		keys[i] = gradeKey{AssignmentID: assignmentID, StudentID: s.StudentID}
+	}
+	locked, err := gradeLockReasons(ctx, q, keys, schoolID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var stored []aiSuggestion
+	var skipped []aiSuggestionError
+	for i, s := range suggestions {
+		if lockedBy, final := locked[keys[i]]; final {
+			skipped = append(skipped, aiSuggestionError{StudentID: s.StudentID.String(), Error: "grade is final: " + lockedBy})
+			continue
+		}
+		if _, err := q.Exec(ctx, `
+			INSERT INTO grades (assignment_id, student_id, school_id, ai_suggested, ai_reasoning, ai_interaction_id)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (assignment_id, student_id)
+			DO UPDATE SET ai_suggested      = EXCLUDED.ai_suggested,
+			              ai_reasoning      = EXCLUDED.ai_reasoning,
+			              ai_interaction_id = EXCLUDED.ai_interaction_id,
+			              ai_accepted       = NULL
+		`, assignmentID, s.StudentID, schoolID, s.SuggestedPoints, nullStr(s.Reasoning), interactionID); err != nil {
+			return nil, nil, err
+		}
+		stored = append(stored, s)
+	}
+	return stored, skipped, nil
+}
+
+// syncAIInteraction rolls a grade's accept or reject decision up into the
+// ai_interactions row that produced its suggestion.
+func syncAIInteraction(ctx context.Context, q dbtx, assignmentID, studentID uuid.UUID) error {
+	_, err := q.Exec(ctx, `
+		UPDATE ai_interactions i
+		SET accepted = (SELECT bool_or(g.ai_accepted) FROM grades g WHERE g.ai_interaction_id = i.id)
+		WHERE i.id = (SELECT ai_interaction_id FROM grades WHERE assignment_id = $1 AND student_id = $2)
+	`, assignmentID, studentID)
+	return err
+}
+
+// ReviewAISuggestion records a teacher's decision on a grading assistant
+// suggestion. Accepting makes the suggestion the grade, through a grade
+// change request when the grade is final (the decision itself is still
+// recorded). Rejecting leaves points_earned alone.
+// courseId URL param is a short_id; body assignment_id and student_id are UUIDs.
+func (h *GradesHandler) ReviewAISuggestion(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	courseUUID, err := resolveCourseUUID(ctx, h.db, chi.URLParam(r, "courseId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	var req struct {
+		AssignmentID string `json:"assignment_id" validate:"required,uuid"`
+		StudentID    string `json:"student_id" validate:"required,uuid"`
+		Accepted     *bool  `json:"accepted" validate:"required"`
+		Reason       string `json:"reason" validate:"max=500"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	assignmentID, studentID := uuid.MustParse(req.AssignmentID), uuid.MustParse(req.StudentID)
+
+	var suggested *float64
+	var interactionID *uuid.UUID
+	err = h.db.QueryRow(ctx, `
+		SELECT g.ai_suggested, g.ai_interaction_id
+		FROM grades g JOIN assignments a ON a.id = g.assignment_id
+		WHERE g.assignment_id = $1 AND g.student_id = $2 AND a.course_id = $3 AND g.school_id = $4
+	`, assignmentID, studentID, courseUUID, claims.SchoolID).Scan(&suggested, &interactionID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && suggested == nil) {
+		writeError(w, http.StatusNotFound, "not_found", "no AI suggestion for this grade")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	// The lock check, change request and grade write share one transaction.
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	in, err := currentGradeWrite(ctx, tx, assignmentID, studentID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	var change *models.GradeChangeRequest
+	var lockedBy string
+	if *req.Accepted {
+		in.PointsEarned, in.IsMissing, in.AIAccepted = suggested, false, req.Accepted
+		in.Reason = req.Reason
+		if in.Reason == "" {
+			in.Reason = "accepted AI suggestion"
+		}
+		if lockedBy, err = gradeLockReason(ctx, tx, assignmentID, studentID, claims.SchoolID); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		if lockedBy != "" {
+			if change, err = createGradeChangeRequest(ctx, tx, claims.SchoolID, courseUUID, claims.UserID, in, lockedBy); err != nil {
+				writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+				return
+			}
+		}
+	}
+
+	var gradeID uuid.UUID
+	if *req.Accepted && change == nil {
+		gradeID, _, err = writeGrade(ctx, tx, claims.SchoolID, claims.UserID, in)
+	} else {
+		err = tx.QueryRow(ctx, `
+			UPDATE grades SET ai_accepted = $1
+			WHERE assignment_id = $2 AND student_id = $3 AND school_id = $4
+			RETURNING id
+		`, *req.Accepted, assignmentID, studentID, claims.SchoolID).Scan(&gradeID)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if err := syncAIInteraction(ctx, tx, assignmentID, studentID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	action := "grade.ai_reject"
+	if *req.Accepted {
+		action = "grade.ai_accept"
+	}
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     action,
+		EntityType: "grade",
+		EntityID:   &gradeID,
+		NewValue: map[string]interface{}{
+			"ai_suggested": suggested, "ai_interaction_id": interactionID, "reason": req.Reason,
+		},
+		IPAddress: r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+	})
+
+	if change != nil {
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{
+			"status":         "pending_approval",
+			"locked_by":      lockedBy,
+			"change_request": change,
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"grade_id":    gradeID,
+		"ai_accepted": *req.Accepted,
+	})
+}
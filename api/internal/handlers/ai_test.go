@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/database"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
+)
+
+// These tests drive the AI handlers against a real database with a
+// FakeAIProvider in place of the model, and check what would have been sent.
+// They need TEST_DATABASE_URL to point at a scratch database: migrations,
+// including the 016 seed data, are applied to it and the tests write to it.
+
+// Seed data from 016_seed_mock_data.sql.
+var (
+	seedSchoolID      = uuid.MustParse("a1b2c3d4-e5f6-7890-abcd-ef1234567890")
+	seedTeacherUserID = uuid.MustParse("22222222-2222-2222-2222-222222222222")
+	seedAlgebraID     = uuid.MustParse("c1c1c1c1-c1c1-c1c1-c1c1-c1c1c1c1c1c1")
+	seedAssignmentID  = uuid.MustParse("a1a1a1a1-a1a1-a1a1-a1a1-a1a1a1a1a1a1")
+	seedSofiaID       = uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa")
+
+	// Roster details that must never appear in a request to the AI provider.
+	seedRosterPII = []string{
+		"Sofia", "Martinez", "Diego", "Alex Kim", "Taylor Chen", "Jordan Patel", "Jane Smith",
+		"LHS-2026-001", "LHS-2026-002", "LHS-2026-003", "LHS-2026-004", "LHS-2026-005",
+	}
+)
+
+// testDB connects to TEST_DATABASE_URL and applies the migrations, or skips
+// the test when it is unset.
+func testDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	db, err := database.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(db.Close)
+	if err := db.RunMigrations(ctx); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	return db.Pool
+}
+
+// serveAs runs handler behind auth.Middleware with a token for the given
+// user and returns the recorded response.
+func serveAs(t *testing.T, handler http.HandlerFunc, userID uuid.UUID, role string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtSvc, err := auth.NewJWTService(base64.StdEncoding.EncodeToString(priv), base64.StdEncoding.EncodeToString(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwtSvc.Issue(userID, seedSchoolID, role, "test@example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	auth.Middleware(jwtSvc)(handler).ServeHTTP(rec, req)
+	return rec
+}
+
+// assertRedacted fails if any roster detail reached the provider.
+func assertRedacted(t *testing.T, calls []services.AIRequest) {
+	t.Helper()
+	if len(calls) == 0 {
+		t.Fatal("no request reached the AI provider")
+	}
+	for i, c := range calls {
+		for _, pii := range seedRosterPII {
+			if strings.Contains(c.System, pii) || strings.Contains(c.Prompt, pii) {
+				t.Errorf("request %d sent %q to the AI provider", i, pii)
+			}
+		}
+	}
+}
+
+func TestGradingAssistantRedactsSubmissions(t *testing.T) {
+	pool := testDB(t)
+	fake := services.NewFakeAIProvider().
+		On("Student 1", `[{"student": "Student 1", "suggested_points": 45, "reasoning": "Clear and complete work."}]`)
+	h := NewAIHandler(pool, services.NewAIGateway(fake))
+
+	rec := serveAs(t, h.GradingAssistant, seedTeacherUserID, models.RoleTeacher, map[string]interface{}{
+		"assignment_id": seedAssignmentID.String(),
+		"rubric":        "Full marks for correct working and a clear final answer.",
+		"submissions": map[string]string{
+			seedSofiaID.String(): "Sofia Martinez (LHS-2026-001): I solved every problem; my partner was sofia martinez's brother Diego.",
+		},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	calls := fake.Calls()
+	assertRedacted(t, calls)
+	if !strings.Contains(calls[0].Prompt, "[NAME_") || !strings.Contains(calls[0].Prompt, "[STUDENT_NUMBER_") {
+		t.Errorf("prompt has no placeholders: %q", calls[0].Prompt)
+	}
+
+	var resp struct {
+		Suggestions []aiSuggestion       `json:"suggestions"`
+		Errors      []aiSuggestionError  `json:"errors"`
+		Redactions  []services.Redaction `json:"redactions"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Redactions) == 0 {
+		t.Error("response lists no redactions")
+	}
+	if len(resp.Suggestions) != 1 || resp.Suggestions[0].StudentID != seedSofiaID || resp.Suggestions[0].SuggestedPoints != 45 {
+		t.Errorf("suggestions = %+v, errors = %+v", resp.Suggestions, resp.Errors)
+	}
+
+	var suggested *float64
+	if err := pool.QueryRow(context.Background(),
+		`SELECT ai_suggested FROM grades WHERE assignment_id = $1 AND student_id = $2`,
+		seedAssignmentID, seedSofiaID).Scan(&suggested); err != nil {
+		t.Fatal(err)
+	}
+	if suggested == nil || *suggested != 45 {
+		t.Errorf("ai_suggested = %v, want 45", suggested)
+	}
+}
+
+func TestReportCommentRedactsGradeSummary(t *testing.T) {
+	pool := testDB(t)
+	fake := services.NewFakeAIProvider("[NAME_1] has worked steadily this term.")
+	h := NewAIHandler(pool, services.NewAIGateway(fake))
+
+	rec := serveAs(t, h.ReportComment, seedTeacherUserID, models.RoleTeacher, map[string]interface{}{
+		"student_id":      seedSofiaID.String(),
+		"course_id":       seedAlgebraID.String(),
+		"grade_summary":   "SOFIA MARTINEZ, student LHS-2026-001, averages 91% in Algebra II.",
+		"trend_direction": "improving",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	calls := fake.Calls()
+	assertRedacted(t, calls)
+	if strings.Contains(strings.ToLower(calls[0].Prompt), "sofia") {
+		t.Errorf("prompt kept the name in another case: %q", calls[0].Prompt)
+	}
+	if !strings.Contains(calls[0].Prompt, "[NAME_") {
+		t.Errorf("prompt has no name placeholder: %q", calls[0].Prompt)
+	}
+
+	var resp struct {
+		Comment    string               `json:"comment"`
+		Redactions []services.Redaction `json:"redactions"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Comment == "" || len(resp.Redactions) == 0 {
+		t.Errorf("comment = %q, redactions = %+v", resp.Comment, resp.Redactions)
+	}
+}
+
+func TestInsightsSendOnlyAnonymizedTrends(t *testing.T) {
+	pool := testDB(t)
+	fake := services.NewFakeAIProvider().
+		On("Student 1", `[{"student": "Student 1", "alert": "Scores have dropped on the last three assignments.", "severity": "high"}]`)
+	h := NewAlertsHandler(pool, services.NewAIGateway(fake))
+
+	run, err := h.runInsights(context.Background(), &seedSchoolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Schools != 1 || run.Trends == 0 {
+		t.Fatalf("run = %+v", run)
+	}
+	assertRedacted(t, fake.Calls())
+	for i, c := range fake.Calls() {
+		if !c.JSON || !strings.Contains(c.Prompt, "Student 1:") {
+			t.Errorf("request %d is not an anonymized trend batch: %q", i, c.Prompt)
+		}
+	}
+
+	var aiAlerts int
+	if err := pool.QueryRow(context.Background(), `
+		SELECT COUNT(*) FROM student_alerts WHERE school_id = $1 AND status = 'open' AND source = $2
+	`, seedSchoolID, models.AlertSourceAI).Scan(&aiAlerts); err != nil {
+		t.Fatal(err)
+	}
+	if aiAlerts == 0 {
+		t.Error("no AI alert was saved")
+	}
+}
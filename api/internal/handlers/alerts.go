@@ -0,0 +1,533 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
+)
+
+// AlertsHandler runs the Student Insights job and serves the at-risk
+// alerts it raises.
+type AlertsHandler struct {
+	db *pgxpool.Pool
+	ai *services.AIGateway
+}
+
+// NewAlertsHandler creates an AlertsHandler.
+func NewAlertsHandler(db *pgxpool.Pool, ai *services.AIGateway) *AlertsHandler {
+	return &AlertsHandler{db: db, ai: ai}
+}
+
+// ---------- Student Insights job ----------
+
+const (
+	insightsHour      = 2                   // nightly run, server local time
+	insightsMinGap    = 12 * time.Hour      // replicas waking for the same night run it once
+	insightsBatchSize = 100                 // trajectories per AI request
+	alertQuietPeriod  = 14 * 24 * time.Hour // a dismissed alert stays quiet unless it gets worse
+)
+
+// trendKey identifies one student's trajectory in one course.
+type trendKey struct {
+	StudentID uuid.UUID
+	CourseID  uuid.UUID
+}
+
+// insight is a concern raised about one trajectory.
+type insight struct {
+	Severity string
+	Message  string
+	Source   string
+}
+
+// insightsRun counts what one run did.
+type insightsRun struct {
+	Schools  int `json:"schools"`
+	Trends   int `json:"trends"`
+	Raised   int `json:"raised"`
+	Updated  int `json:"updated"`
+	Resolved int `json:"resolved"`
+}
+
+// RunNightlyInsights runs the insights job for every school each night
+// until ctx ends.
+func (h *AlertsHandler) RunNightlyInsights(ctx context.Context) {
+	for {
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month(), now.Day(), insightsHour, 0, 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		h.nightlyInsights(ctx)
+	}
+}
+
+// nightlyInsights runs the job for every school unless another replica is
+// running it or already ran it within insightsMinGap.
+func (h *AlertsHandler) nightlyInsights(ctx context.Context) {
+	lease, runCtx, err := startBackgroundRun(ctx, h.db, "nightly_insights", insightsMinGap)
+	if err != nil {
+		log.Printf("insights: nightly run: %v", err)
+		return
+	}
+	if lease == nil {
+		return
+	}
+	run, err := h.runInsights(runCtx, nil)
+	lease.finish(err == nil)
+	if err != nil {
+		log.Printf("insights: nightly run: %v", err)
+		return
+	}
+	log.Printf("insights: %d school(s), %d trend(s): %d alert(s) raised, %d updated, %d resolved",
+		run.Schools, run.Trends, run.Raised, run.Updated, run.Resolved)
+}
+
+// runInsights runs the job for one school, or all schools when schoolID is
+// nil. A failure in one school is logged and the rest still run.
+func (h *AlertsHandler) runInsights(ctx context.Context, schoolID *uuid.UUID) (insightsRun, error) {
+	var run insightsRun
+	rows, err := h.db.Query(ctx, `
+		SELECT id, COALESCE((settings->>'ai_enabled')::boolean, FALSE)
+		FROM schools WHERE $1::uuid IS NULL OR id = $1
+	`, schoolID)
+	if err != nil {
+		return run, err
+	}
+	type school struct {
+		id        uuid.UUID
+		aiEnabled bool
+	}
+	var schools []school
+	for rows.Next() {
+		var s school
+		if err := rows.Scan(&s.id, &s.aiEnabled); err != nil {
+			rows.Close()
+			return run, err
+		}
+		schools = append(schools, s)
+	}
+	rows.Close()
+
+	for _, s := range schools {
+		if err := h.schoolInsights(ctx, s.id, s.aiEnabled, &run); err != nil {
+			if schoolID != nil {
+				return run, err
+			}
+			log.Printf("insights: school %s: %v", s.id, err)
+			continue
+		}
+		run.Schools++
+	}
+	return run, nil
+}
+
+// loadGradeTrends returns each active enrollment's graded and missing work
+// per course as a trend, leaving out excused and extra-credit work and
+// trajectories too short to judge.
+func loadGradeTrends(ctx context.Context, db dbtx, schoolID uuid.UUID) (map[trendKey]models.GradeTrend, error) {
+	rows, err := db.Query(ctx, `
+		SELECT e.student_id, e.course_id, g.points_earned / a.max_points * 100, COALESCE(g.is_missing, FALSE)
+		FROM enrollments e
+		JOIN courses c ON c.id = e.course_id
+		JOIN assignments a ON a.course_id = e.course_id
+		JOIN grades g ON g.assignment_id = a.id AND g.student_id = e.student_id
+		WHERE c.school_id = $1 AND e.status = 'active' AND COALESCE(c.is_active, TRUE)
+		  AND COALESCE(a.is_published, FALSE) AND NOT a.is_extra_credit AND a.max_points > 0
+		  AND NOT COALESCE(g.is_excused, FALSE)
+		  AND (g.points_earned IS NOT NULL OR COALESCE(g.is_missing, FALSE))
+		ORDER BY e.student_id, e.course_id, COALESCE(a.due_date, g.graded_at, a.created_at)
+	`, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scores := make(map[trendKey][]float64)
+	missing := make(map[trendKey]int)
+	for rows.Next() {
+		var k trendKey
+		var pct *float64
+		var isMissing bool
+		if err := rows.Scan(&k.StudentID, &k.CourseID, &pct, &isMissing); err != nil {
+			return nil, err
+		}
+		switch {
+		case pct != nil:
+			scores[k] = append(scores[k], math.Round(*pct*10)/10)
+		case isMissing:
+			missing[k]++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	trends := make(map[trendKey]models.GradeTrend)
+	for k, s := range scores {
+		if len(s) >= services.MinTrendScores || missing[k] >= 2 {
+			trends[k] = services.NewGradeTrend(s, missing[k])
+		}
+	}
+	for k, n := range missing {
+		if _, ok := scores[k]; !ok && n >= 2 {
+			trends[k] = services.NewGradeTrend([]float64{}, n)
+		}
+	}
+	return trends, nil
+}
+
+// schoolInsights judges one school's trends, through the AI service when the
+// school has AI enabled and the local rules otherwise (or if the AI call
+// fails), then saves the alerts.
+func (h *AlertsHandler) schoolInsights(ctx context.Context, schoolID uuid.UUID, aiEnabled bool, run *insightsRun) error {
+	trends, err := loadGradeTrends(ctx, h.db, schoolID)
+	if err != nil {
+		return err
+	}
+	run.Trends += len(trends)
+
+	var found map[trendKey]insight
+	if aiEnabled && len(trends) > 0 {
+		if found, err = h.aiInsights(ctx, schoolID, trends); err != nil {
+			log.Printf("insights: school %s: AI unavailable, using rules: %v", schoolID, err)
+			found = nil
+		}
+	}
+	if found == nil {
+		found = make(map[trendKey]insight)
+		for k, t := range trends {
+			if severity, msg := services.RuleInsight(t); severity != "" {
+				found[k] = insight{Severity: severity, Message: msg, Source: models.AlertSourceRules}
+			}
+		}
+	}
+	return saveAlerts(ctx, h.db, schoolID, trends, found, run)
+}
+
+// aiInsights sends the trends to the AI service in batches, each student
+// and course anonymized as "Student N".
+func (h *AlertsHandler) aiInsights(ctx context.Context, schoolID uuid.UUID, trends map[trendKey]models.GradeTrend) (map[trendKey]insight, error) {
+	redactor, err := loadRedactor(ctx, h.db, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]trendKey, 0, len(trends))
+	for k := range trends {
+		keys = append(keys, k)
+	}
+
+	found := make(map[trendKey]insight)
+	for start := 0; start < len(keys); start += insightsBatchSize {
+		batch := keys[start:min(start+insightsBatchSize, len(keys))]
+		labels := make(map[string]trendKey, len(batch))
+		var prompt strings.Builder
+		for i, k := range batch {
+			label := fmt.Sprintf("Student %d", i+1)
+			labels[label] = k
+			prompt.WriteString(services.InsightLine(label, trends[k]) + "\n")
+		}
+
+		completion, err := h.ai.Complete(ctx, redactor, services.AIRequest{
+			System:    services.StudentInsightsPrompt(),
+			Prompt:    prompt.String(),
+			MaxTokens: 4096,
+			JSON:      true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		insights, err := services.ParseStudentInsights(completion.JSON)
+		if err != nil {
+			return nil, err
+		}
+		// The alerts are still worth saving when the usage record is not.
+		if _, err := h.db.Exec(ctx, `
+			INSERT INTO ai_interactions (school_id, user_id, feature, input_summary, output_summary, tokens_used, redactions)
+			VALUES ($1, NULL, 'student_insights', $2, $3, $4, $5)
+		`, schoolID, fmt.Sprintf("nightly insights: %d trajectories", len(batch)),
+			completion.Text[:min(500, len(completion.Text))], completion.Usage.Total(), completion.Redactions); err != nil {
+			log.Printf("insights: school %s: record AI interaction: %v", schoolID, err)
+		}
+
+		for _, in := range insights {
+			if k, ok := labels[strings.TrimSpace(in.Student)]; ok {
+				found[k] = insight{Severity: in.Severity, Message: strings.TrimSpace(in.Alert), Source: models.AlertSourceAI}
+			}
+		}
+	}
+	return found, nil
+}
+
+// saveAlerts opens or refreshes an alert for each concern and resolves open
+// alerts that no longer have one. A concern is skipped while a recent
+// dismissal or action covers it at the same or a higher severity.
+func saveAlerts(ctx context.Context, db *pgxpool.Pool, schoolID uuid.UUID,
+	trends map[trendKey]models.GradeTrend, found map[trendKey]insight, run *insightsRun) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var students, courses []uuid.UUID
+	for k, in := range found {
+		students = append(students, k.StudentID)
+		courses = append(courses, k.CourseID)
+
+		var lastSeverity string
+		err := tx.QueryRow(ctx, `
+			SELECT severity FROM student_alerts
+			WHERE student_id = $1 AND course_id = $2 AND status IN ('dismissed', 'actioned') AND resolved_at > $3
+			ORDER BY resolved_at DESC LIMIT 1
+		`, k.StudentID, k.CourseID, time.Now().Add(-alertQuietPeriod)).Scan(&lastSeverity)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			// Nothing recent covers it.
+		case err != nil:
+			return err
+		case models.AlertSeverityRank(in.Severity) <= models.AlertSeverityRank(lastSeverity):
+			continue
+		}
+
+		var inserted bool
+		if err := tx.QueryRow(ctx, `
+			INSERT INTO student_alerts (school_id, student_id, course_id, severity, source, message, trend)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (student_id, course_id) WHERE status = 'open'
+			DO UPDATE SET severity = EXCLUDED.severity, source = EXCLUDED.source,
+			              message = EXCLUDED.message, trend = EXCLUDED.trend
+			RETURNING xmax = 0
+		`, schoolID, k.StudentID, k.CourseID, in.Severity, in.Source, in.Message, trends[k]).Scan(&inserted); err != nil {
+			return err
+		}
+		if inserted {
+			run.Raised++
+		} else {
+			run.Updated++
+		}
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE student_alerts a SET status = 'resolved', resolved_at = NOW()
+		WHERE a.school_id = $1 AND a.status = 'open'
+		  AND NOT EXISTS (
+			SELECT 1 FROM unnest($2::uuid[], $3::uuid[]) AS f(student_id, course_id)
+			WHERE f.student_id = a.student_id AND f.course_id = a.course_id
+		  )
+	`, schoolID, students, courses)
+	if err != nil {
+		return err
+	}
+	run.Resolved += int(tag.RowsAffected())
+	return tx.Commit(ctx)
+}
+
+// ---------- Alerts ----------
+
+// listStudentAlerts returns alerts, most severe and newest first. A non-nil
+// teacherUserID limits them to that teacher's courses; status "" means all.
+func listStudentAlerts(ctx context.Context, db dbtx, schoolID uuid.UUID, teacherUserID, courseID *uuid.UUID,
+	status string, limit, offset int) ([]models.StudentAlert, error) {
+	rows, err := db.Query(ctx, `
+		SELECT al.id, al.short_id, al.school_id, al.student_id, al.course_id, al.severity, al.source,
+		       al.message, al.trend, al.status, al.action_note, al.resolved_by, al.resolved_at,
+		       al.created_at, al.updated_at, u.first_name || ' ' || u.last_name, c.short_id, c.name
+		FROM student_alerts al
+		JOIN students s ON s.id = al.student_id
+		JOIN users u ON u.id = s.user_id
+		JOIN courses c ON c.id = al.course_id
+		LEFT JOIN teachers t ON t.id = c.teacher_id
+		WHERE al.school_id = $1
+		  AND ($2::uuid IS NULL OR t.user_id = $2)
+		  AND ($3::uuid IS NULL OR al.course_id = $3)
+		  AND ($4 = '' OR al.status = $4)
+		ORDER BY CASE al.severity WHEN 'high' THEN 3 WHEN 'medium' THEN 2 ELSE 1 END DESC, al.created_at DESC
+		LIMIT $5 OFFSET $6
+	`, schoolID, teacherUserID, courseID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []models.StudentAlert{}
+	for rows.Next() {
+		var a models.StudentAlert
+		if err := rows.Scan(&a.ID, &a.ShortID, &a.SchoolID, &a.StudentID, &a.CourseID, &a.Severity, &a.Source,
+			&a.Message, &a.Trend, &a.Status, &a.ActionNote, &a.ResolvedBy, &a.ResolvedAt,
+			&a.CreatedAt, &a.UpdatedAt, &a.StudentName, &a.CourseShortID, &a.CourseName); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+// ListAlerts returns at-risk alerts: a teacher's own courses, or the whole
+// school for admins.
+// Query params: status (open by default, or dismissed, actioned, resolved,
+// all), course_id (short_id).
+func (h *AlertsHandler) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+	limit, offset := paginate(r)
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = models.AlertOpen
+	case "all":
+		status = ""
+	case models.AlertOpen, models.AlertDismissed, models.AlertActioned, models.AlertResolved:
+	default:
+		writeError(w, http.StatusBadRequest, "invalid_param", "status must be open, dismissed, actioned, resolved, or all")
+		return
+	}
+	var courseID *uuid.UUID
+	if c := r.URL.Query().Get("course_id"); c != "" {
+		id, err := resolveCourseUUID(ctx, h.db, c, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "not_found", "course not found")
+			return
+		}
+		courseID = &id
+	}
+	var teacherUserID *uuid.UUID
+	if claims.Role == models.RoleTeacher {
+		teacherUserID = &claims.UserID
+	}
+
+	alerts, err := listStudentAlerts(ctx, h.db, claims.SchoolID, teacherUserID, courseID, status, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"alerts": alerts})
+}
+
+// DismissAlert closes an open alert without action, e.g. a known situation.
+// alertId URL param is a short_id.
+func (h *AlertsHandler) DismissAlert(w http.ResponseWriter, r *http.Request) {
+	h.resolveAlert(w, r, models.AlertDismissed)
+}
+
+// ActOnAlert closes an open alert with a note on what was done, such as
+// contacting a parent or scheduling support.
+// alertId URL param is a short_id.
+func (h *AlertsHandler) ActOnAlert(w http.ResponseWriter, r *http.Request) {
+	h.resolveAlert(w, r, models.AlertActioned)
+}
+
+// resolveAlert closes an open alert as dismissed or actioned. An action
+// needs a note; a dismissal may have one.
+func (h *AlertsHandler) resolveAlert(w http.ResponseWriter, r *http.Request, status string) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		Note string `json:"note" validate:"max=2000"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if status == models.AlertActioned && strings.TrimSpace(req.Note) == "" {
+		writeError(w, http.StatusBadRequest, "validation_error", "note is required: describe the action taken")
+		return
+	}
+
+	var alertID, courseID uuid.UUID
+	var current string
+	if err := h.db.QueryRow(ctx, `
+		SELECT id, course_id, status FROM student_alerts WHERE short_id = $1 AND school_id = $2
+	`, chi.URLParam(r, "alertId"), claims.SchoolID).Scan(&alertID, &courseID, &current); err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "alert not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+	if current != models.AlertOpen {
+		writeError(w, http.StatusConflict, "alert_closed", "alert is already "+current)
+		return
+	}
+
+	if _, err := h.db.Exec(ctx, `
+		UPDATE student_alerts SET status = $1, action_note = $2, resolved_by = $3, resolved_at = NOW()
+		WHERE id = $4
+	`, status, nullStr(req.Note), claims.UserID, alertID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	action := "alert.dismiss"
+	if status == models.AlertActioned {
+		action = "alert.action"
+	}
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     action,
+		EntityType: "student_alert",
+		EntityID:   &alertID,
+		OldValue:   map[string]interface{}{"status": current},
+		NewValue:   map[string]interface{}{"status": status, "note": req.Note},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": status})
+}
+
+// RefreshAlerts runs the insights job for the caller's school now rather
+// than waiting for the nightly run.
+func (h *AlertsHandler) RefreshAlerts(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	run, err := h.runInsights(ctx, &claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "insights_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "alert.refresh",
+		EntityType: "student_alert",
+		NewValue:   run,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, run)
+}
@@ -3,11 +3,16 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pragma-proto/api/internal/auth"
 	"github.com/pragma-proto/api/internal/middleware"
@@ -122,10 +127,16 @@ func (h *AssignmentsHandler) CreateAssignment(w http.ResponseWriter, r *http.Req
 		Title       string  `json:"title" validate:"required,min=1,max=300"`
 		Description string  `json:"description"`
 		DueDate     *string `json:"due_date"`
-		MaxPoints   float64 `json:"max_points" validate:"required,min=0"`
+		MaxPoints   float64 `json:"max_points" validate:"required,gt=0"`
 		Category    string  `json:"category" validate:"required,oneof=homework quiz test exam project classwork participation other"`
 		Weight      float64 `json:"weight" validate:"min=0,max=1"`
 		IsPublished bool    `json:"is_published"`
+
+		// Extra credit adds earned points without adding to the possible total.
+		IsExtraCredit bool `json:"is_extra_credit"`
+
+		// Students submit work for this assignment through the API.
+		AcceptsSubmissions bool `json:"accepts_submissions"`
 	}
 
 	dec := json.NewDecoder(r.Body)
@@ -178,11 +189,12 @@ func (h *AssignmentsHandler) CreateAssignment(w http.ResponseWriter, r *http.Req
 
 		err = h.db.QueryRow(ctx, `
 			INSERT INTO assignments
-				(course_id, school_id, title, description, due_date, max_points, category, weight, is_published, short_id)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+				(course_id, school_id, title, description, due_date, max_points, category, weight, is_published,
+				 is_extra_credit, accepts_submissions, short_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 			RETURNING id
 		`, req.CourseID, claims.SchoolID, req.Title, nullStr(req.Description),
-			dueDate, req.MaxPoints, req.Category, req.Weight, req.IsPublished, sid,
+			dueDate, req.MaxPoints, req.Category, req.Weight, req.IsPublished, req.IsExtraCredit, req.AcceptsSubmissions, sid,
 		).Scan(&assignmentID)
 		if err == nil {
 			break
@@ -193,6 +205,13 @@ func (h *AssignmentsHandler) CreateAssignment(w http.ResponseWriter, r *http.Req
 		}
 	}
 
+	// Work already past due is older than the missing work sweep's window.
+	if req.IsPublished && req.AcceptsSubmissions && dueDate != nil && dueDate.Before(time.Now()) {
+		if _, err := markMissingWork(ctx, h.db, &assignmentID, nil); err != nil {
+			log.Printf("assignments: mark missing for %s: %v", assignmentID, err)
+		}
+	}
+
 	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
 		SchoolID:   claims.SchoolID,
 		UserID:     &claims.UserID,
@@ -244,8 +263,8 @@ func (h *AssignmentsHandler) ListCourseAssignments(w http.ResponseWriter, r *htt
 
 	rows, err := h.db.Query(ctx, `
 		SELECT a.id, a.short_id, a.course_id, a.title, a.description, a.due_date,
-		       a.max_points, a.category, a.weight, a.is_published,
-		       a.created_at, a.updated_at
+		       a.max_points, a.category, a.weight, a.is_published, a.is_extra_credit,
+		       a.accepts_submissions, a.created_at, a.updated_at
 		FROM assignments a
 		WHERE a.course_id = $1 AND a.school_id = $2
 		ORDER BY a.due_date DESC NULLS LAST, a.created_at DESC
@@ -269,6 +288,9 @@ func (h *AssignmentsHandler) ListCourseAssignments(w http.ResponseWriter, r *htt
 		IsPublished bool       `json:"is_published"`
 		CreatedAt   time.Time  `json:"created_at"`
 		UpdatedAt   time.Time  `json:"updated_at"`
+
+		IsExtraCredit      bool `json:"is_extra_credit"`
+		AcceptsSubmissions bool `json:"accepts_submissions"`
 	}
 
 	var assignments []assignmentRow
@@ -276,8 +298,8 @@ func (h *AssignmentsHandler) ListCourseAssignments(w http.ResponseWriter, r *htt
 		var a assignmentRow
 		if err := rows.Scan(
 			&a.ID, &a.ShortID, &a.CourseID, &a.Title, &a.Description, &a.DueDate,
-			&a.MaxPoints, &a.Category, &a.Weight, &a.IsPublished,
-			&a.CreatedAt, &a.UpdatedAt,
+			&a.MaxPoints, &a.Category, &a.Weight, &a.IsPublished, &a.IsExtraCredit,
+			&a.AcceptsSubmissions, &a.CreatedAt, &a.UpdatedAt,
 		); err != nil {
 			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
 			return
@@ -403,3 +425,681 @@ func (h *AssignmentsHandler) ListAttachments(w http.ResponseWriter, r *http.Requ
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{"attachments": attachments})
 }
+
+// ---------- Update, delete, and copy ----------
+
+// loadAssignment fetches an assignment by short_id, scoped to a school.
+func loadAssignment(ctx context.Context, db dbtx, shortID string, schoolID uuid.UUID) (*models.Assignment, error) {
+	var a models.Assignment
+	err := db.QueryRow(ctx, `
+		SELECT id, short_id, course_id, school_id, title, description, due_date, max_points,
+		       category, COALESCE(weight, 1.0), COALESCE(is_published, FALSE), is_extra_credit,
+		       accepts_submissions, created_at, updated_at
+		FROM assignments
+		WHERE short_id = $1 AND school_id = $2
+	`, shortID, schoolID).Scan(
+		&a.ID, &a.ShortID, &a.CourseID, &a.SchoolID, &a.Title, &a.Description, &a.DueDate, &a.MaxPoints,
+		&a.Category, &a.Weight, &a.IsPublished, &a.IsExtraCredit,
+		&a.AcceptsSubmissions, &a.CreatedAt, &a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// assignmentLocks returns the final grades on an assignment, keyed as
+// gradeLockReasons keys them.
+func assignmentLocks(ctx context.Context, db dbtx, assignmentID, schoolID uuid.UUID) (map[gradeKey]string, error) {
+	rows, err := db.Query(ctx, `
+		SELECT student_id FROM grades WHERE assignment_id = $1 AND school_id = $2
+	`, assignmentID, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	var keys []gradeKey
+	for rows.Next() {
+		k := gradeKey{AssignmentID: assignmentID}
+		if err := rows.Scan(&k.StudentID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return map[gradeKey]string{}, nil
+	}
+	return gradeLockReasons(ctx, db, keys, schoolID)
+}
+
+// assignmentUpdate is the body of PUT and PATCH /assignments/{assignmentId}.
+// PATCH changes only the fields given; PUT replaces the assignment, so
+// omitted optional fields are cleared or reset to their defaults.
+type assignmentUpdate struct {
+	Title         *string  `json:"title" validate:"omitempty,min=1,max=300"`
+	Description   *string  `json:"description"`
+	DueDate       *string  `json:"due_date"` // RFC3339; "" clears it
+	MaxPoints     *float64 `json:"max_points" validate:"omitempty,gt=0"`
+	Category      *string  `json:"category" validate:"omitempty,oneof=homework quiz test exam project classwork participation other"`
+	Weight        *float64 `json:"weight" validate:"omitempty,min=0,max=1"`
+	IsPublished   *bool    `json:"is_published"`
+	IsExtraCredit *bool    `json:"is_extra_credit"`
+
+	AcceptsSubmissions *bool `json:"accepts_submissions"`
+
+	// OverMax says what to do with scores above a lowered max_points:
+	// reject (default) refuses the change, cap lowers them to the new
+	// maximum, and keep leaves them as earned (the excess counts as bonus).
+	OverMax string `json:"over_max" validate:"omitempty,oneof=reject cap keep"`
+	Reason  string `json:"reason" validate:"max=500"`
+}
+
+// overMaxGrade is a score that exceeds a lowered max_points.
+type overMaxGrade struct {
+	StudentID    uuid.UUID `json:"student_id"`
+	PointsEarned float64   `json:"points_earned"`
+}
+
+// UpdateAssignment replaces an assignment (PUT). title, max_points, and
+// category are required.
+// assignmentId URL param is a short_id.
+func (h *AssignmentsHandler) UpdateAssignment(w http.ResponseWriter, r *http.Request) {
+	h.updateAssignment(w, r, true)
+}
+
+// PatchAssignment changes only the given fields of an assignment (PATCH).
+// assignmentId URL param is a short_id.
+func (h *AssignmentsHandler) PatchAssignment(w http.ResponseWriter, r *http.Request) {
+	h.updateAssignment(w, r, false)
+}
+
+// updateAssignment applies an assignmentUpdate. Changes that affect scoring
+// (max points, category, weight, extra credit, due date, publishing) are
+// refused while any grade on the assignment is final, and a due date may not
+// move graded work into a closed term. Lowering max_points below existing scores
+// follows over_max.
+func (h *AssignmentsHandler) updateAssignment(w http.ResponseWriter, r *http.Request, replace bool) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req assignmentUpdate
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if replace && (req.Title == nil || req.MaxPoints == nil || req.Category == nil) {
+		writeError(w, http.StatusBadRequest, "validation_error", "title, max_points, and category are required")
+		return
+	}
+	if req.OverMax == "" {
+		req.OverMax = "reject"
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	cur, err := loadAssignment(ctx, tx, chi.URLParam(r, "assignmentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "assignment not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, cur.CourseID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	next := *cur
+	if replace {
+		next.Description, next.DueDate = nil, nil
+		next.Weight, next.IsPublished, next.IsExtraCredit, next.AcceptsSubmissions = 1.0, false, false, false
+	}
+	if req.Title != nil {
+		next.Title = *req.Title
+	}
+	if req.Description != nil {
+		next.Description = nil
+		if *req.Description != "" {
+			next.Description = req.Description
+		}
+	}
+	if req.DueDate != nil {
+		next.DueDate = nil
+		if *req.DueDate != "" {
+			t, err := time.Parse(time.RFC3339, *req.DueDate)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_due_date", "due_date must be RFC3339 format")
+				return
+			}
+			next.DueDate = &t
+		}
+	}
+	if req.MaxPoints != nil {
+		next.MaxPoints = *req.MaxPoints
+	}
+	if req.Category != nil {
+		next.Category = *req.Category
+	}
+	if req.Weight != nil {
+		next.Weight = *req.Weight
+		if next.Weight == 0 {
+			next.Weight = 1.0
+		}
+	}
+	if req.IsPublished != nil {
+		next.IsPublished = *req.IsPublished
+	}
+	if req.IsExtraCredit != nil {
+		next.IsExtraCredit = *req.IsExtraCredit
+	}
+	if req.AcceptsSubmissions != nil {
+		next.AcceptsSubmissions = *req.AcceptsSubmissions
+	}
+
+	dueChanged := (cur.DueDate == nil) != (next.DueDate == nil) ||
+		(cur.DueDate != nil && next.DueDate != nil && !cur.DueDate.Equal(*next.DueDate))
+	scoringChanged := dueChanged || next.MaxPoints != cur.MaxPoints || next.Category != cur.Category ||
+		next.Weight != cur.Weight || next.IsExtraCredit != cur.IsExtraCredit || next.IsPublished != cur.IsPublished
+	if scoringChanged {
+		locked, err := assignmentLocks(ctx, tx, cur.ID, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		if len(locked) > 0 {
+			writeError(w, http.StatusConflict, "grades_final",
+				fmt.Sprintf("%d grade(s) on this assignment are final; only title and description can change", len(locked)))
+			return
+		}
+	}
+
+	// Scores above a lowered maximum.
+	var over []overMaxGrade
+	var capWrites []gradeWrite
+	if next.MaxPoints < cur.MaxPoints && !next.IsExtraCredit {
+		rows, err := tx.Query(ctx, `
+			SELECT student_id, points_earned, COALESCE(comment, ''),
+			       COALESCE(is_excused, FALSE), COALESCE(is_missing, FALSE), COALESCE(is_late, FALSE), days_late
+			FROM grades
+			WHERE assignment_id = $1 AND school_id = $2 AND points_earned > $3
+		`, cur.ID, claims.SchoolID, next.MaxPoints)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		for rows.Next() {
+			in := gradeWrite{AssignmentID: cur.ID}
+			var points float64
+			if err := rows.Scan(&in.StudentID, &points, &in.Comment,
+				&in.IsExcused, &in.IsMissing, &in.IsLate, &in.DaysLate); err != nil {
+				rows.Close()
+				writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+				return
+			}
+			over = append(over, overMaxGrade{StudentID: in.StudentID, PointsEarned: points})
+			capped := next.MaxPoints
+			in.PointsEarned = &capped
+			in.Reason = fmt.Sprintf("max points lowered from %g to %g", cur.MaxPoints, next.MaxPoints)
+			capWrites = append(capWrites, in)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		if len(over) > 0 && req.OverMax == "reject" {
+			writeJSON(w, http.StatusConflict, map[string]interface{}{
+				"error":   "scores_exceed_max",
+				"message": "some scores exceed the new max_points; set over_max to cap or keep",
+				"grades":  over,
+			})
+			return
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE assignments
+		SET title = $1, description = $2, due_date = $3, max_points = $4, category = $5,
+		    weight = $6, is_published = $7, is_extra_credit = $8, accepts_submissions = $9
+		WHERE id = $10 AND school_id = $11
+	`, next.Title, next.Description, next.DueDate, next.MaxPoints, next.Category,
+		next.Weight, next.IsPublished, next.IsExtraCredit, next.AcceptsSubmissions, cur.ID, claims.SchoolID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	// Grade locks follow the due date's term, so check again under the new date.
+	if dueChanged {
+		locked, err := assignmentLocks(ctx, tx, cur.ID, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		if len(locked) > 0 {
+			writeError(w, http.StatusConflict, "grades_final", "the new due date falls in a closed term and the assignment has grades")
+			return
+		}
+	}
+
+	capped := 0
+	if req.OverMax == "cap" {
+		for _, in := range capWrites {
+			if _, _, err := writeGrade(ctx, tx, claims.SchoolID, claims.UserID, in); err != nil {
+				writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+				return
+			}
+			capped++
+		}
+	}
+
+	// Submissions are timed and work flagged missing against the due date.
+	reflagged := 0
+	if dueChanged || next.IsPublished != cur.IsPublished || next.AcceptsSubmissions != cur.AcceptsSubmissions {
+		if reflagged, err = reflagAssignmentWork(ctx, tx, claims.SchoolID, &claims.UserID, cur.ID); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "assignment.update",
+		EntityType: "assignment",
+		EntityID:   &cur.ID,
+		OldValue:   cur,
+		NewValue: map[string]interface{}{
+			"assignment": next, "over_max": req.OverMax, "scores_over_max": len(over),
+			"grades_capped": capped, "grades_reflagged": reflagged, "reason": req.Reason,
+		},
+		IPAddress: r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+	})
+
+	next.UpdatedAt = time.Now()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"assignment":       next,
+		"scores_over_max":  len(over),
+		"grades_capped":    capped,
+		"grades_reflagged": reflagged,
+	})
+}
+
+// DeleteAssignment deletes an assignment with its grades and attachments.
+// An assignment with grades is only deleted with ?force=true, and never
+// while any of its grades is final. Grade history is kept.
+// assignmentId URL param is a short_id.
+func (h *AssignmentsHandler) DeleteAssignment(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	a, err := loadAssignment(ctx, tx, chi.URLParam(r, "assignmentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "assignment not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, a.CourseID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	locked, err := assignmentLocks(ctx, tx, a.ID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if len(locked) > 0 {
+		writeError(w, http.StatusConflict, "grades_final",
+			fmt.Sprintf("%d grade(s) on this assignment are final", len(locked)))
+		return
+	}
+	var gradeCount int
+	if err := tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM grades WHERE assignment_id = $1 AND school_id = $2
+	`, a.ID, claims.SchoolID).Scan(&gradeCount); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if gradeCount > 0 && r.URL.Query().Get("force") != "true" {
+		writeError(w, http.StatusConflict, "has_grades",
+			fmt.Sprintf("assignment has %d grade(s); pass force=true to delete them too", gradeCount))
+		return
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT file_key FROM assignment_attachments WHERE assignment_id = $1 AND school_id = $2
+	`, a.ID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	var fileKeys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err == nil {
+			fileKeys = append(fileKeys, key)
+		}
+	}
+	rows.Close()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM assignments WHERE id = $1 AND school_id = $2`, a.ID, claims.SchoolID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	// Objects left behind by a failed delete are harmless; nothing refers to them.
+	for _, key := range fileKeys {
+		_ = h.storage.Delete(ctx, key)
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "assignment.delete",
+		EntityType: "assignment",
+		EntityID:   &a.ID,
+		OldValue:   map[string]interface{}{"assignment": a, "grades_deleted": gradeCount, "attachments_deleted": len(fileKeys)},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// copiedAssignment is one copy made by DuplicateAssignment or CopyAssignment.
+type copiedAssignment struct {
+	CourseID     string    `json:"course_id"`
+	AssignmentID uuid.UUID `json:"assignment_id"`
+	ShortID      string    `json:"short_id"`
+}
+
+// copyAssignment inserts an unpublished copy of src into a course, with its
+// standards alignments and current attachments. Attachment objects are
+// copied in storage; their new keys are appended to *copiedKeys so the
+// caller can remove them if the transaction fails.
+func (h *AssignmentsHandler) copyAssignment(ctx context.Context, tx pgx.Tx, src *models.Assignment, courseID uuid.UUID,
+	title string, dueDate *time.Time, userID uuid.UUID, copiedKeys *[]string) (uuid.UUID, string, error) {
+	var id uuid.UUID
+	var sid string
+	for attempt := 0; ; attempt++ {
+		var err error
+		sid, err = shortid.Generate()
+		if err != nil {
+			return uuid.Nil, "", err
+		}
+		err = tx.QueryRow(ctx, `
+			INSERT INTO assignments
+				(course_id, school_id, title, description, due_date, max_points, category, weight, is_published,
+				 is_extra_credit, accepts_submissions, short_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, FALSE, $9, $10, $11)
+			ON CONFLICT (short_id) DO NOTHING
+			RETURNING id
+		`, courseID, src.SchoolID, title, src.Description, dueDate, src.MaxPoints, src.Category, src.Weight,
+			src.IsExtraCredit, src.AcceptsSubmissions, sid,
+		).Scan(&id)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, pgx.ErrNoRows) || attempt == 4 {
+			return uuid.Nil, "", err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO assignment_standards (assignment_id, standard_id, school_id)
+		SELECT $1, standard_id, school_id FROM assignment_standards WHERE assignment_id = $2
+	`, id, src.ID); err != nil {
+		return uuid.Nil, "", err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT file_name, file_key, file_size, mime_type
+		FROM assignment_attachments
+		WHERE assignment_id = $1 AND school_id = $2 AND is_current = TRUE
+	`, src.ID, src.SchoolID)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	var attachments []models.Attachment
+	for rows.Next() {
+		var a models.Attachment
+		if err := rows.Scan(&a.FileName, &a.FileKey, &a.FileSize, &a.MIMEType); err != nil {
+			rows.Close()
+			return uuid.Nil, "", err
+		}
+		attachments = append(attachments, a)
+	}
+	rows.Close()
+
+	for _, a := range attachments {
+		key := services.ObjectKey(src.SchoolID.String(), "attachments",
+			id.String()+"/"+uuid.New().String()+"-"+a.FileName)
+		if err := h.storage.Copy(ctx, a.FileKey, key); err != nil {
+			return uuid.Nil, "", err
+		}
+		*copiedKeys = append(*copiedKeys, key)
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO assignment_attachments
+				(assignment_id, school_id, file_name, file_key, file_size, mime_type, uploaded_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, id, src.SchoolID, a.FileName, key, a.FileSize, a.MIMEType, userID); err != nil {
+			return uuid.Nil, "", err
+		}
+	}
+	return id, sid, nil
+}
+
+// DuplicateAssignment makes an unpublished copy of an assignment in the same
+// course, with its attachments and standards. The body is optional: title
+// defaults to "<title> (copy)" and due_date to the original's.
+// assignmentId URL param is a short_id.
+func (h *AssignmentsHandler) DuplicateAssignment(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		Title   string  `json:"title" validate:"max=300"`
+		DueDate *string `json:"due_date"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	src, err := loadAssignment(ctx, h.db, chi.URLParam(r, "assignmentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "assignment not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, src.CourseID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+	if req.Title == "" {
+		req.Title = src.Title + " (copy)"
+	}
+	dueDate, err := copyDueDate(src, req.DueDate)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_due_date", "due_date must be RFC3339 format")
+		return
+	}
+
+	copies, ok := h.saveCopies(w, r, src, []uuid.UUID{src.CourseID}, []string{""}, req.Title, dueDate)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"assignment_id": copies[0].AssignmentID,
+		"short_id":      copies[0].ShortID,
+	})
+}
+
+// CopyAssignment copies an assignment, unpublished, into other courses —
+// typically the teacher's other sections of the same class. Teachers may
+// copy only into courses they teach.
+// assignmentId URL param is a short_id; body course_ids are short_ids.
+func (h *AssignmentsHandler) CopyAssignment(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		CourseIDs []string `json:"course_ids" validate:"required,min=1,max=20,dive,required"`
+		DueDate   *string  `json:"due_date"` // RFC3339; defaults to the original's
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	src, err := loadAssignment(ctx, h.db, chi.URLParam(r, "assignmentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "assignment not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, src.CourseID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+	dueDate, err := copyDueDate(src, req.DueDate)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_due_date", "due_date must be RFC3339 format")
+		return
+	}
+
+	var courseIDs []uuid.UUID
+	var shortIDs []string
+	seen := make(map[uuid.UUID]bool)
+	for _, sid := range req.CourseIDs {
+		courseUUID, err := resolveCourseUUID(ctx, h.db, sid, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "not_found", "course "+sid+" not found")
+			return
+		}
+		if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+			writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for course "+sid)
+			return
+		}
+		if !seen[courseUUID] {
+			seen[courseUUID] = true
+			courseIDs = append(courseIDs, courseUUID)
+			shortIDs = append(shortIDs, sid)
+		}
+	}
+
+	copies, ok := h.saveCopies(w, r, src, courseIDs, shortIDs, src.Title, dueDate)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"copies": copies})
+}
+
+// copyDueDate parses an optional RFC3339 due date for a copy, defaulting to
+// the source assignment's.
+func copyDueDate(src *models.Assignment, s *string) (*time.Time, error) {
+	if s == nil {
+		return src.DueDate, nil
+	}
+	if *s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, *s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// saveCopies copies src into each course in one transaction and audits each
+// copy as an assignment.create. It writes the error response itself and
+// reports whether the copies were saved.
+func (h *AssignmentsHandler) saveCopies(w http.ResponseWriter, r *http.Request, src *models.Assignment,
+	courseIDs []uuid.UUID, courseShortIDs []string, title string, dueDate *time.Time) ([]copiedAssignment, bool) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var copiedKeys []string
+	fail := func(code string, err error) {
+		for _, key := range copiedKeys {
+			_ = h.storage.Delete(ctx, key)
+		}
+		writeError(w, http.StatusInternalServerError, code, err.Error())
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		fail("db_error", err)
+		return nil, false
+	}
+	defer tx.Rollback(ctx)
+
+	copies := make([]copiedAssignment, 0, len(courseIDs))
+	for i, courseID := range courseIDs {
+		id, sid, err := h.copyAssignment(ctx, tx, src, courseID, title, dueDate, claims.UserID, &copiedKeys)
+		if err != nil {
+			fail("copy_error", err)
+			return nil, false
+		}
+		copies = append(copies, copiedAssignment{CourseID: courseShortIDs[i], AssignmentID: id, ShortID: sid})
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail("db_error", err)
+		return nil, false
+	}
+
+	for i, c := range copies {
+		_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+			SchoolID:   claims.SchoolID,
+			UserID:     &claims.UserID,
+			Action:     "assignment.create",
+			EntityType: "assignment",
+			EntityID:   &copies[i].AssignmentID,
+			NewValue: map[string]interface{}{
+				"copied_from": src.ID, "course_id": courseIDs[i], "title": title, "due_date": dueDate, "short_id": c.ShortID,
+			},
+			IPAddress: r.RemoteAddr,
+			UserAgent: r.UserAgent(),
+		})
+	}
+	return copies, true
+}
@@ -0,0 +1,493 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
+)
+
+// AttendanceHandler manages daily and per-period attendance.
+type AttendanceHandler struct {
+	db *pgxpool.Pool
+}
+
+// NewAttendanceHandler creates an AttendanceHandler.
+func NewAttendanceHandler(db *pgxpool.Pool) *AttendanceHandler {
+	return &AttendanceHandler{db: db}
+}
+
+// attendanceMark is one student's mark in a bulk attendance submission.
+type attendanceMark struct {
+	StudentID string `json:"student_id" validate:"required,uuid"`
+	Status    string `json:"status" validate:"required,oneof=present absent tardy excused"`
+	Note      string `json:"note" validate:"max=500"`
+}
+
+// TakeCourseAttendance records per-period attendance for a course meeting.
+// Re-submitting the same day overwrites the teacher's earlier marks, except
+// for records an admin has already corrected.
+// courseId URL param is a short_id; schedule_block_id is a schedule block short_id.
+func (h *AttendanceHandler) TakeCourseAttendance(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	courseParam := chi.URLParam(r, "courseId")
+	ctx := r.Context()
+
+	courseUUID, err := resolveCourseUUID(ctx, h.db, courseParam, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	var req struct {
+		Date            string           `json:"date" validate:"required"`
+		ScheduleBlockID string           `json:"schedule_block_id"`
+		Records         []attendanceMark `json:"records" validate:"required,min=1,max=500,dive"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	date, err := parseDateParam(req.Date)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "date must be YYYY-MM-DD")
+		return
+	}
+
+	var blockUUID *uuid.UUID
+	if req.ScheduleBlockID != "" {
+		var id uuid.UUID
+		err := h.db.QueryRow(ctx, `
+			SELECT id FROM schedule_blocks WHERE short_id = $1 AND course_id = $2 AND school_id = $3
+		`, req.ScheduleBlockID, courseUUID, claims.SchoolID).Scan(&id)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_schedule_block", "schedule block not found for this course")
+			return
+		}
+		blockUUID = &id
+	}
+
+	// Every student must be actively enrolled in the course.
+	enrolled := make(map[string]bool)
+	rows, err := h.db.Query(ctx, `
+		SELECT student_id::text FROM enrollments
+		WHERE course_id = $1 AND school_id = $2 AND status = 'active'
+	`, courseUUID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	for rows.Next() {
+		var sid string
+		if err := rows.Scan(&sid); err != nil {
+			rows.Close()
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		enrolled[sid] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	var notEnrolled []string
+	for _, m := range req.Records {
+		if !enrolled[m.StudentID] {
+			notEnrolled = append(notEnrolled, m.StudentID)
+		}
+	}
+	if len(notEnrolled) > 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":       "not_enrolled",
+			"message":     "some students are not enrolled in this course",
+			"student_ids": notEnrolled,
+		})
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	recorded := 0
+	for _, m := range req.Records {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO attendance_records
+				(school_id, student_id, course_id, schedule_block_id, attendance_date, status, note, recorded_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (student_id, course_id, attendance_date) WHERE course_id IS NOT NULL
+			DO UPDATE SET
+				status            = EXCLUDED.status,
+				note              = EXCLUDED.note,
+				schedule_block_id = EXCLUDED.schedule_block_id,
+				recorded_by       = EXCLUDED.recorded_by,
+				updated_at        = NOW()
+			WHERE attendance_records.corrected_at IS NULL
+		`, claims.SchoolID, m.StudentID, courseUUID, blockUUID, *date, m.Status, nullStr(m.Note), claims.UserID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		recorded += int(tag.RowsAffected())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "attendance.record",
+		EntityType: "course",
+		EntityID:   &courseUUID,
+		NewValue:   req,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"recorded": recorded,
+		"skipped":  len(req.Records) - recorded, // admin-corrected records are left untouched
+	})
+}
+
+// ListCourseAttendance returns the course roster with each student's mark for one day.
+// Students without a mark have a null status.
+// courseId URL param is a short_id. ?date=YYYY-MM-DD defaults to today.
+func (h *AttendanceHandler) ListCourseAttendance(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	courseParam := chi.URLParam(r, "courseId")
+	ctx := r.Context()
+
+	courseUUID, err := resolveCourseUUID(ctx, h.db, courseParam, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	date := time.Now()
+	if d, err := parseDateParam(r.URL.Query().Get("date")); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "date must be YYYY-MM-DD")
+		return
+	} else if d != nil {
+		date = *d
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT s.id, u.first_name, u.last_name, s.student_number,
+		       ar.short_id, ar.status, ar.note, ar.corrected_at
+		FROM enrollments e
+		JOIN students s ON s.id = e.student_id
+		JOIN users u ON u.id = s.user_id
+		LEFT JOIN attendance_records ar
+		       ON ar.student_id = e.student_id AND ar.course_id = e.course_id AND ar.attendance_date = $3
+		WHERE e.course_id = $1 AND e.school_id = $2 AND e.status = 'active'
+		ORDER BY u.last_name, u.first_name
+	`, courseUUID, claims.SchoolID, date.Format("2006-01-02"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	type rosterRow struct {
+		StudentID     uuid.UUID  `json:"student_id"`
+		FirstName     string     `json:"first_name"`
+		LastName      string     `json:"last_name"`
+		StudentNumber string     `json:"student_number"`
+		RecordID      *string    `json:"record_id"`
+		Status        *string    `json:"status"`
+		Note          *string    `json:"note,omitempty"`
+		CorrectedAt   *time.Time `json:"corrected_at,omitempty"`
+	}
+
+	var roster []rosterRow
+	for rows.Next() {
+		var row rosterRow
+		if err := rows.Scan(&row.StudentID, &row.FirstName, &row.LastName, &row.StudentNumber,
+			&row.RecordID, &row.Status, &row.Note, &row.CorrectedAt); err != nil {
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		roster = append(roster, row)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"date":     date.Format("2006-01-02"),
+		"students": roster,
+	})
+}
+
+// RecordDailyAttendance records whole-day attendance for any students in the school (admin only).
+// Re-recording a day overwrites earlier marks, except for corrected records.
+func (h *AttendanceHandler) RecordDailyAttendance(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		Date    string           `json:"date" validate:"required"`
+		Records []attendanceMark `json:"records" validate:"required,min=1,max=2000,dive"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	date, err := parseDateParam(req.Date)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "date must be YYYY-MM-DD")
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	recorded := 0
+	for _, m := range req.Records {
+		// The SELECT guards against writing records for students of another school.
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO attendance_records
+				(school_id, student_id, attendance_date, status, note, recorded_by)
+			SELECT $1, s.id, $3, $4, $5, $6
+			FROM students s WHERE s.id = $2 AND s.school_id = $1
+			ON CONFLICT (student_id, attendance_date) WHERE course_id IS NULL
+			DO UPDATE SET
+				status      = EXCLUDED.status,
+				note        = EXCLUDED.note,
+				recorded_by = EXCLUDED.recorded_by,
+				updated_at  = NOW()
+			WHERE attendance_records.corrected_at IS NULL
+		`, claims.SchoolID, m.StudentID, *date, m.Status, nullStr(m.Note), claims.UserID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		recorded += int(tag.RowsAffected())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "attendance.record",
+		EntityType: "attendance",
+		NewValue:   req,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"recorded": recorded,
+		"skipped":  len(req.Records) - recorded, // corrected records and other schools' students
+	})
+}
+
+// CorrectAttendance changes the status of an existing record (admin only).
+// Corrected records are no longer overwritten by teacher re-submissions.
+// recordId URL param is a short_id.
+func (h *AttendanceHandler) CorrectAttendance(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	recordParam := chi.URLParam(r, "recordId")
+	ctx := r.Context()
+
+	var req struct {
+		Status string `json:"status" validate:"required,oneof=present absent tardy excused"`
+		Note   string `json:"note" validate:"max=500"`
+		Reason string `json:"reason" validate:"required,min=1,max=500"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	var recordID uuid.UUID
+	var oldStatus string
+	var oldNote *string
+	err := h.db.QueryRow(ctx, `
+		SELECT id, status, note FROM attendance_records WHERE short_id = $1 AND school_id = $2
+	`, recordParam, claims.SchoolID).Scan(&recordID, &oldStatus, &oldNote)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "attendance record not found")
+		return
+	}
+
+	_, err = h.db.Exec(ctx, `
+		UPDATE attendance_records
+		SET status = $1, note = $2, corrected_by = $3, corrected_at = NOW(), correction_reason = $4
+		WHERE id = $5 AND school_id = $6
+	`, req.Status, nullStr(req.Note), claims.UserID, req.Reason, recordID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "attendance.correct",
+		EntityType: "attendance_record",
+		EntityID:   &recordID,
+		OldValue:   map[string]interface{}{"status": oldStatus, "note": oldNote},
+		NewValue:   req,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// GetStudentAttendance returns a student's attendance records and day totals.
+// Optional ?from= and ?to= (YYYY-MM-DD) bound the range.
+// studentId URL param is a short_id.
+func (h *AttendanceHandler) GetStudentAttendance(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	studentParam := chi.URLParam(r, "studentId")
+	ctx := r.Context()
+
+	studentUUID, err := resolveStudentUUID(ctx, h.db, studentParam, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "student not found")
+		return
+	}
+	if !canViewStudent(ctx, h.db, claims, studentUUID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not authorized to view this student's attendance")
+		return
+	}
+
+	from, err := parseDateParam(r.URL.Query().Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "from must be YYYY-MM-DD")
+		return
+	}
+	to, err := parseDateParam(r.URL.Query().Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "to must be YYYY-MM-DD")
+		return
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT ar.short_id, ar.student_id, ar.course_id, COALESCE(c.name, ''),
+		       ar.attendance_date, ar.status, ar.note, ar.corrected_at, ar.created_at, ar.updated_at
+		FROM attendance_records ar
+		LEFT JOIN courses c ON c.id = ar.course_id
+		WHERE ar.student_id = $1 AND ar.school_id = $2
+		  AND ($3::date IS NULL OR ar.attendance_date >= $3)
+		  AND ($4::date IS NULL OR ar.attendance_date <= $4)
+		ORDER BY ar.attendance_date DESC, c.name NULLS FIRST
+	`, studentUUID, claims.SchoolID, from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	var records []models.AttendanceRecord
+	for rows.Next() {
+		var rec models.AttendanceRecord
+		if err := rows.Scan(&rec.ShortID, &rec.StudentID, &rec.CourseID, &rec.CourseName,
+			&rec.AttendanceDate, &rec.Status, &rec.Note, &rec.CorrectedAt, &rec.CreatedAt, &rec.UpdatedAt); err != nil {
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		records = append(records, rec)
+	}
+
+	summary, err := loadAttendanceSummary(ctx, h.db, studentUUID, claims.SchoolID, from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"records": records,
+		"summary": summary,
+	})
+}
+
+// loadAttendanceSummary totals a student's attendance by day between from and to
+// (either may be nil). A day with a daily record uses that record. Otherwise the
+// day is excused if every period was excused, absent if every period was missed,
+// tardy if any period was tardy or missed, and present otherwise.
+func loadAttendanceSummary(ctx context.Context, db *pgxpool.Pool, studentID, schoolID uuid.UUID, from, to *time.Time) (models.AttendanceSummary, error) {
+	var s models.AttendanceSummary
+	err := db.QueryRow(ctx, `
+		WITH days AS (
+			SELECT attendance_date,
+			       COALESCE(
+			           MAX(status) FILTER (WHERE course_id IS NULL),
+			           CASE
+			               WHEN bool_and(status = 'excused') THEN 'excused'
+			               WHEN bool_and(status IN ('absent', 'excused')) THEN 'absent'
+			               WHEN bool_or(status IN ('tardy', 'absent')) THEN 'tardy'
+			               ELSE 'present'
+			           END) AS day_status
+			FROM attendance_records
+			WHERE student_id = $1 AND school_id = $2
+			  AND ($3::date IS NULL OR attendance_date >= $3)
+			  AND ($4::date IS NULL OR attendance_date <= $4)
+			GROUP BY attendance_date
+		)
+		SELECT COUNT(*)::int,
+		       COUNT(*) FILTER (WHERE day_status = 'present')::int,
+		       COUNT(*) FILTER (WHERE day_status = 'absent')::int,
+		       COUNT(*) FILTER (WHERE day_status = 'tardy')::int,
+		       COUNT(*) FILTER (WHERE day_status = 'excused')::int
+		FROM days
+	`, studentID, schoolID, from, to).Scan(&s.DaysRecorded, &s.Present, &s.Absent, &s.Tardy, &s.Excused)
+	if err != nil {
+		return s, err
+	}
+	if counted := s.DaysRecorded - s.Excused; counted > 0 {
+		s.Rate = float64(s.Present+s.Tardy) / float64(counted) * 100
+	}
+	return s, nil
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// backgroundJobLease is how long a replica holds a periodic job between
+// renewals. A run whose replica dies is free to retry once it lapses.
+const backgroundJobLease = 2 * time.Minute
+
+// backgroundRun is this replica's run of a periodic job, held under the
+// job's lease in background_jobs.
+type backgroundRun struct {
+	db      *pgxpool.Pool
+	name    string
+	started time.Time  // database time the run took the lease
+	lastRun *time.Time // start of the last completed run; nil before the first
+	cancel  context.CancelFunc
+}
+
+// startBackgroundRun takes the lease on a periodic job. It returns nil when
+// another replica holds the lease or completed a run less than minGap ago.
+// The returned context is cancelled if the lease is lost; finish the run to
+// release it.
+func startBackgroundRun(ctx context.Context, db *pgxpool.Pool, name string, minGap time.Duration) (*backgroundRun, context.Context, error) {
+	run := &backgroundRun{db: db, name: name}
+	err := db.QueryRow(ctx, `
+		INSERT INTO background_jobs (name, lease_owner, lease_expires_at)
+		VALUES ($1, $2, NOW() + make_interval(secs => $3))
+		ON CONFLICT (name) DO UPDATE
+		SET lease_owner = EXCLUDED.lease_owner, lease_expires_at = EXCLUDED.lease_expires_at
+		WHERE (background_jobs.lease_expires_at IS NULL OR background_jobs.lease_expires_at < NOW())
+		  AND (background_jobs.last_run_at IS NULL
+		       OR background_jobs.last_run_at < NOW() - make_interval(secs => $4))
+		RETURNING NOW(), last_run_at
+	`, name, instanceID, backgroundJobLease.Seconds(), minGap.Seconds()).Scan(&run.started, &run.lastRun)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	run.cancel = cancel
+	go run.hold(runCtx)
+	return run, runCtx, nil
+}
+
+// hold renews the lease until ctx ends, cancelling the run if the lease has
+// been lost to another replica.
+func (run *backgroundRun) hold(ctx context.Context) {
+	ticker := time.NewTicker(backgroundJobLease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		tag, err := run.db.Exec(ctx, `
+			UPDATE background_jobs SET lease_expires_at = NOW() + make_interval(secs => $3)
+			WHERE name = $1 AND lease_owner = $2
+		`, run.name, instanceID, backgroundJobLease.Seconds())
+		if err != nil {
+			log.Printf("%s: renew lease: %v", run.name, err)
+			continue
+		}
+		if tag.RowsAffected() == 0 {
+			log.Printf("%s: lost lease; stopping", run.name)
+			run.cancel()
+			return
+		}
+	}
+}
+
+// finish releases the lease. A completed run becomes the job's last run; a
+// failed one leaves last_run_at alone so the next run covers its window.
+func (run *backgroundRun) finish(completed bool) {
+	run.cancel()
+	if _, err := run.db.Exec(context.Background(), `
+		UPDATE background_jobs
+		SET lease_owner = NULL, lease_expires_at = NULL,
+		    last_run_at = CASE WHEN $3 THEN $4 ELSE last_run_at END
+		WHERE name = $1 AND lease_owner = $2
+	`, run.name, instanceID, completed, run.started); err != nil {
+		log.Printf("%s: release lease: %v", run.name, err)
+	}
+}
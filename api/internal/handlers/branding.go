@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/services"
+)
+
+// Generated PDFs only embed branding images stored under the school's own
+// storage prefix. An admin uploads each image through a presigned URL and
+// then sets it, which records its key in the school settings: the logo in
+// logo_image_key (taking precedence over logo_url) and the signature in
+// signature_image_url.
+
+// brandingImageSettings maps each branding image to its settings key.
+var brandingImageSettings = map[string]string{
+	"logo":      "logo_image_key",
+	"signature": "signature_image_url",
+}
+
+// brandingImageTypes are the image formats the PDF renderer can embed, with
+// the file extension each is stored under.
+var brandingImageTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+}
+
+// BrandingHandler manages the logo and signature images used in generated PDFs.
+type BrandingHandler struct {
+	db      *pgxpool.Pool
+	storage *services.StorageService
+}
+
+// NewBrandingHandler creates a BrandingHandler.
+func NewBrandingHandler(db *pgxpool.Pool, storage *services.StorageService) *BrandingHandler {
+	return &BrandingHandler{db: db, storage: storage}
+}
+
+// brandingImageStatus describes one configured branding image.
+type brandingImageStatus struct {
+	Ref     string  `json:"ref,omitempty"` // as configured: a key or logo_url
+	Key     *string `json:"key"`           // the object used in PDFs, if any
+	Usable  bool    `json:"usable"`
+	Problem string  `json:"problem,omitempty"`
+}
+
+// GetBranding reports whether the school's logo and signature can be used in
+// generated PDFs, and why not when they cannot (admin only).
+func (h *BrandingHandler) GetBranding(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	school, err := loadSchool(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	status := func(ref string) brandingImageStatus {
+		st := brandingImageStatus{Ref: ref}
+		if ref == "" {
+			return st
+		}
+		key, err := h.storage.AssetKey(school.ID.String(), ref)
+		if err != nil {
+			st.Problem = err.Error()
+			return st
+		}
+		st.Key = &key
+		if _, err := h.storage.HeadObject(ctx, key); err != nil {
+			st.Problem = err.Error()
+			return st
+		}
+		st.Usable = true
+		return st
+	}
+	logo, signature := brandingRefs(school)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"logo":      status(logo),
+		"signature": status(signature),
+	})
+}
+
+// RequestBrandingUploadURL returns a presigned URL to upload a new logo or
+// signature image; set it with SetBrandingImage once uploaded (admin only).
+// image URL param is "logo" or "signature".
+func (h *BrandingHandler) RequestBrandingUploadURL(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	image := chi.URLParam(r, "image")
+	if _, ok := brandingImageSettings[image]; !ok {
+		writeError(w, http.StatusNotFound, "not_found", "unknown branding image")
+		return
+	}
+
+	var req struct {
+		MIMEType      string `json:"mime_type" validate:"required"`
+		FileSizeBytes int64  `json:"file_size_bytes" validate:"required,min=1,max=5242880"` // services.MaxAssetBytes
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	ext, ok := brandingImageTypes[req.MIMEType]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_mime_type", "branding images must be PNG or JPEG")
+		return
+	}
+
+	key := services.ObjectKey(claims.SchoolID.String(), "branding", image+"-"+uuid.NewString()+ext)
+	url, err := h.storage.PresignUpload(ctx, key, req.FileSizeBytes)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"upload_url": url,
+		"key":        key,
+	})
+}
+
+// SetBrandingImage makes an uploaded image the school's logo or signature
+// for generated PDFs (admin only).
+// image URL param is "logo" or "signature"; body key is from RequestBrandingUploadURL.
+func (h *BrandingHandler) SetBrandingImage(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	image := chi.URLParam(r, "image")
+	setting, ok := brandingImageSettings[image]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "unknown branding image")
+		return
+	}
+
+	var req struct {
+		Key string `json:"key" validate:"required,max=512"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	prefix := services.ObjectKey(claims.SchoolID.String(), "branding", image+"-")
+	key, err := h.storage.AssetKey(claims.SchoolID.String(), req.Key)
+	if err != nil || key != req.Key || !strings.HasPrefix(key, prefix) {
+		writeError(w, http.StatusBadRequest, "invalid_key", "key is not an uploaded "+image+" image")
+		return
+	}
+	head, err := h.storage.HeadObject(ctx, key)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "not_uploaded", "the image has not finished uploading")
+		return
+	}
+	if head.ContentLength != nil && *head.ContentLength > services.MaxAssetBytes {
+		writeError(w, http.StatusBadRequest, "too_large", "branding images must be at most 5 MB")
+		return
+	}
+
+	var old *string
+	if err := h.db.QueryRow(ctx, `
+		SELECT settings->>$1 FROM schools WHERE id = $2
+	`, setting, claims.SchoolID).Scan(&old); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if _, err := h.db.Exec(ctx, `
+		UPDATE schools
+		SET settings = jsonb_set(COALESCE(settings, '{}'::jsonb), ARRAY[$1::text], to_jsonb($2::text)),
+		    updated_at = NOW()
+		WHERE id = $3
+	`, setting, key, claims.SchoolID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "school.branding_image",
+		EntityType: "school",
+		EntityID:   &claims.SchoolID,
+		OldValue:   map[string]interface{}{setting: old},
+		NewValue:   map[string]interface{}{setting: key},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{image: brandingImageStatus{Ref: key, Key: &key, Usable: true}})
+}
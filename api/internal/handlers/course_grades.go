@@ -0,0 +1,503 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
+)
+
+// Course grades shown anywhere (gradebook, report cards, dashboards) go
+// through computeStudentCourseGrades so every surface uses the same
+// assignments, weights, and scale as GradingService.
+
+// studentCourseGrade is one course's computed grade for a student. Courses
+// in standards mode report Standards instead of Calc.
+type studentCourseGrade struct {
+	CourseID      uuid.UUID
+	CourseShortID string
+	CourseName    string
+	TeacherName   string
+	Credits       float64
+	Level         string
+	Mode          string                   // models.GradingModePoints or models.GradingModeStandards
+	Calc          *models.GradeCalculation // nil until something in the course is graded
+	Standards     []models.StandardMastery
+}
+
+// courseGradingMode returns the grading mode and mastery rule in effect for a
+// course: its own settings when set, otherwise the school's.
+func courseGradingMode(settings models.SchoolSettings, mode, rule *string) (string, string) {
+	m, r := settings.GradingMode, settings.MasteryRule
+	if mode != nil {
+		m = *mode
+	}
+	if rule != nil {
+		r = *rule
+	}
+	if m != models.GradingModeStandards {
+		m = models.GradingModePoints
+	}
+	if r == "" {
+		r = models.MasteryMostRecent
+	}
+	return m, r
+}
+
+// loadCourseStandards returns the standards aligned to each course's
+// published assignments, keyed by course ID and ordered by code.
+func loadCourseStandards(ctx context.Context, db *pgxpool.Pool, courseIDs []uuid.UUID, schoolID uuid.UUID) (map[uuid.UUID][]models.Standard, error) {
+	rows, err := db.Query(ctx, `
+		SELECT DISTINCT a.course_id, s.id, s.short_id, s.code, s.description
+		FROM assignment_standards ast
+		JOIN assignments a ON a.id = ast.assignment_id
+		JOIN standards s ON s.id = ast.standard_id
+		WHERE a.course_id = ANY($1) AND a.school_id = $2 AND a.is_published = TRUE
+		ORDER BY a.course_id, s.code
+	`, courseIDs, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[uuid.UUID][]models.Standard)
+	for rows.Next() {
+		var courseID uuid.UUID
+		var st models.Standard
+		if err := rows.Scan(&courseID, &st.ID, &st.ShortID, &st.Code, &st.Description); err != nil {
+			return nil, err
+		}
+		out[courseID] = append(out[courseID], st)
+	}
+	return out, rows.Err()
+}
+
+// loadStudentStandardScores returns a student's standard scores on published
+// assignments, keyed by course ID.
+func loadStudentStandardScores(ctx context.Context, db *pgxpool.Pool, studentID uuid.UUID, courseIDs []uuid.UUID, schoolID uuid.UUID) (map[uuid.UUID][]models.StandardScore, error) {
+	rows, err := db.Query(ctx, `
+		SELECT a.course_id, ss.id, ss.assignment_id, ss.standard_id, s.short_id, ss.student_id,
+		       ss.score, ss.comment, ss.graded_by, ss.graded_at, COALESCE(a.due_date, ss.graded_at)
+		FROM standard_scores ss
+		JOIN assignments a ON a.id = ss.assignment_id
+		JOIN standards s ON s.id = ss.standard_id
+		WHERE ss.student_id = $1 AND ss.school_id = $2 AND a.course_id = ANY($3) AND a.is_published = TRUE
+	`, studentID, schoolID, courseIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[uuid.UUID][]models.StandardScore)
+	for rows.Next() {
+		var courseID uuid.UUID
+		var sc models.StandardScore
+		if err := rows.Scan(&courseID, &sc.ID, &sc.AssignmentID, &sc.StandardID, &sc.StandardShortID,
+			&sc.StudentID, &sc.Score, &sc.Comment, &sc.GradedBy, &sc.GradedAt, &sc.AssessedAt); err != nil {
+			return nil, err
+		}
+		sc.SchoolID = schoolID
+		out[courseID] = append(out[courseID], sc)
+	}
+	return out, rows.Err()
+}
+
+// decodeGradePolicy decodes a course's grade_policy column; NULL means no policy.
+func decodeGradePolicy(raw []byte) (*models.GradePolicy, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var p models.GradePolicy
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// loadSchool fetches a school with its settings JSONB decoded.
+func loadSchool(ctx context.Context, db *pgxpool.Pool, schoolID uuid.UUID) (*models.School, error) {
+	var s models.School
+	err := db.QueryRow(ctx, `
+		SELECT id, name, address, logo_url, settings, created_at, updated_at
+		FROM schools WHERE id = $1
+	`, schoolID).Scan(&s.ID, &s.Name, &s.Address, &s.LogoURL, &s.SettingsRaw, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.SettingsRaw) > 0 {
+		if err := json.Unmarshal(s.SettingsRaw, &s.Settings); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// loadCourseAssignments returns the published assignments of each course, keyed by course ID.
+func loadCourseAssignments(ctx context.Context, db *pgxpool.Pool, courseIDs []uuid.UUID, schoolID uuid.UUID) (map[uuid.UUID][]models.Assignment, error) {
+	rows, err := db.Query(ctx, `
+		SELECT id, course_id, title, category, max_points, COALESCE(weight, 1), due_date, is_published,
+		       is_extra_credit, created_at
+		FROM assignments
+		WHERE course_id = ANY($1) AND school_id = $2 AND is_published = TRUE
+		ORDER BY due_date NULLS LAST, created_at
+	`, courseIDs, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[uuid.UUID][]models.Assignment)
+	for rows.Next() {
+		var a models.Assignment
+		if err := rows.Scan(&a.ID, &a.CourseID, &a.Title, &a.Category, &a.MaxPoints, &a.Weight,
+			&a.DueDate, &a.IsPublished, &a.IsExtraCredit, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		out[a.CourseID] = append(out[a.CourseID], a)
+	}
+	return out, rows.Err()
+}
+
+// loadStudentGrades returns a student's grades in the given courses.
+func loadStudentGrades(ctx context.Context, db *pgxpool.Pool, studentID uuid.UUID, courseIDs []uuid.UUID, schoolID uuid.UUID) ([]models.Grade, error) {
+	rows, err := db.Query(ctx, `
+		SELECT g.id, g.assignment_id, g.student_id, g.points_earned,
+		       g.is_excused, g.is_missing, g.is_late, g.days_late
+		FROM grades g
+		JOIN assignments a ON a.id = g.assignment_id
+		WHERE g.student_id = $1 AND g.school_id = $2 AND a.course_id = ANY($3)
+	`, studentID, schoolID, courseIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grades []models.Grade
+	for rows.Next() {
+		var g models.Grade
+		if err := rows.Scan(&g.ID, &g.AssignmentID, &g.StudentID, &g.PointsEarned,
+			&g.IsExcused, &g.IsMissing, &g.IsLate, &g.DaysLate); err != nil {
+			return nil, err
+		}
+		grades = append(grades, g)
+	}
+	return grades, rows.Err()
+}
+
+// computeStudentCourseGrades calculates a student's grade in every course they
+// are actively enrolled in, using the school's scale and category weights, or
+// per-standard mastery for courses in standards mode.
+// A non-nil term instead selects the courses that ran during the term, the
+// way buildTranscript does: active or completed enrollments in courses whose
+// term overlaps it (plus active courses with no term), each limited to
+// assignments due within the term. Dropped and transferred-out enrollments
+// are never counted.
+func computeStudentCourseGrades(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	grading *services.GradingService,
+	settings models.SchoolSettings,
+	studentID, schoolID uuid.UUID,
+	term *models.Term,
+) ([]studentCourseGrade, error) {
+	_, termStart, termEnd := termBounds(term)
+	rows, err := db.Query(ctx, `
+		SELECT c.id, c.short_id, c.name, u.first_name || ' ' || u.last_name, c.credits, c.course_level,
+		       c.grading_mode, c.mastery_rule, c.grade_policy
+		FROM enrollments e
+		JOIN courses c ON c.id = e.course_id
+		JOIN teachers t ON t.id = c.teacher_id
+		JOIN users u ON u.id = t.user_id
+		LEFT JOIN terms ct ON ct.id = c.term_id
+		WHERE e.student_id = $1 AND c.school_id = $2
+		  AND CASE WHEN $3::date IS NULL THEN e.status = 'active'
+		           WHEN ct.id IS NULL THEN e.status = 'active'
+		           ELSE e.status IN ('active', 'completed') AND ct.start_date <= $4 AND ct.end_date >= $3
+		      END
+		ORDER BY c.name
+	`, studentID, schoolID, termStart, termEnd)
+	if err != nil {
+		return nil, err
+	}
+	var courses []studentCourseGrade
+	var courseIDs []uuid.UUID
+	var rules []string
+	var policies []*models.GradePolicy
+	standardsMode := false
+	for rows.Next() {
+		var c studentCourseGrade
+		var mode, rule *string
+		var policyRaw []byte
+		if err := rows.Scan(&c.CourseID, &c.CourseShortID, &c.CourseName, &c.TeacherName, &c.Credits, &c.Level,
+			&mode, &rule, &policyRaw); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		policy, err := decodeGradePolicy(policyRaw)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		var r string
+		c.Mode, r = courseGradingMode(settings, mode, rule)
+		if c.Mode == models.GradingModeStandards {
+			standardsMode = true
+		}
+		courses = append(courses, c)
+		courseIDs = append(courseIDs, c.CourseID)
+		rules = append(rules, r)
+		policies = append(policies, policy)
+	}
+	rows.Close()
+	if len(courses) == 0 {
+		return nil, nil
+	}
+
+	assignments, err := loadCourseAssignments(ctx, db, courseIDs, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	grades, err := loadStudentGrades(ctx, db, studentID, courseIDs, schoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	var standards map[uuid.UUID][]models.Standard
+	var scores map[uuid.UUID][]models.StandardScore
+	if standardsMode {
+		if standards, err = loadCourseStandards(ctx, db, courseIDs, schoolID); err != nil {
+			return nil, err
+		}
+		if scores, err = loadStudentStandardScores(ctx, db, studentID, courseIDs, schoolID); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range courses {
+		if courses[i].Mode == models.GradingModeStandards {
+			courses[i].Standards = grading.CalculateMastery(
+				standards[courses[i].CourseID], scores[courses[i].CourseID],
+				rules[i], settings.MasteryDecay, term,
+			)
+			continue
+		}
+		calc := grading.CalculateCourseGrade(
+			assignments[courses[i].CourseID], grades,
+			settings.GradingScale, settings.CategoryWeights, term, policies[i],
+		)
+		if calc != nil {
+			calc.StudentID = studentID
+			calc.CourseID = courses[i].CourseID
+		}
+		courses[i].Calc = calc
+	}
+	return courses, nil
+}
+
+// courseGradeRow turns a computed course grade into a report card or
+// transcript line. Standards-based courses always carry a non-nil Standards.
+func courseGradeRow(c studentCourseGrade) services.CourseGradeRow {
+	row := services.CourseGradeRow{CourseName: c.CourseName, TeacherName: c.TeacherName,
+		Credits: c.Credits, Level: c.Level, LetterGrade: "N/A"}
+	if c.Mode == models.GradingModeStandards {
+		row.Standards = c.Standards
+		if row.Standards == nil {
+			row.Standards = []models.StandardMastery{}
+		}
+	} else if c.Calc != nil {
+		row.Percentage = c.Calc.Percentage
+		row.LetterGrade = c.Calc.LetterGrade
+	}
+	return row
+}
+
+// gpaFromCourseGrades computes the school's credit-weighted GPA over the
+// courses that have a grade. Standards-based courses carry no letter grade
+// and are not counted.
+func gpaFromCourseGrades(grading *services.GradingService, courses []studentCourseGrade, settings models.SchoolSettings) models.GPAResult {
+	var gpaCourses []models.GPACourse
+	for _, c := range courses {
+		if c.Calc != nil {
+			gpaCourses = append(gpaCourses, models.GPACourse{Calc: c.Calc, Credits: c.Credits, Level: c.Level})
+		}
+	}
+	return grading.CalculateGPA(gpaCourses, settings.GradingScale, settings.GPAMethod, settings.GPABonuses)
+}
+
+// courseGradeSetup is what grading any student in one course needs: the
+// course's mode and policy, its published assignments, and aligned standards.
+type courseGradeSetup struct {
+	ID          uuid.UUID
+	ShortID     string
+	Name        string
+	TeacherName string
+	Credits     float64
+	Level       string
+	Mode        string
+	Rule        string
+	Policy      *models.GradePolicy
+	Assignments []models.Assignment
+	Standards   []models.Standard
+}
+
+// loadCourseGradeSetup loads the grading inputs shared by every student in a
+// course. courseShortID is the URL short_id.
+func loadCourseGradeSetup(ctx context.Context, db *pgxpool.Pool, settings models.SchoolSettings, courseShortID string, schoolID uuid.UUID) (*courseGradeSetup, error) {
+	setups, err := loadCourseGradeSetups(ctx, db, settings, []string{courseShortID}, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := setups[courseShortID]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return c, nil
+}
+
+// loadCourseGradeSetups loads the setup for several courses at once, keyed
+// by short_id. Courses not found in the school are left out.
+func loadCourseGradeSetups(ctx context.Context, db *pgxpool.Pool, settings models.SchoolSettings, courseShortIDs []string, schoolID uuid.UUID) (map[string]*courseGradeSetup, error) {
+	rows, err := db.Query(ctx, `
+		SELECT c.id, c.short_id, c.name, u.first_name || ' ' || u.last_name, c.credits, c.course_level,
+		       c.grading_mode, c.mastery_rule, c.grade_policy
+		FROM courses c
+		JOIN teachers t ON t.id = c.teacher_id
+		JOIN users u ON u.id = t.user_id
+		WHERE c.short_id = ANY($1) AND c.school_id = $2
+	`, courseShortIDs, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	setups := make(map[string]*courseGradeSetup)
+	var standardsIDs, pointsIDs []uuid.UUID
+	for rows.Next() {
+		var c courseGradeSetup
+		var mode, rule *string
+		var policyRaw []byte
+		if err := rows.Scan(&c.ID, &c.ShortID, &c.Name, &c.TeacherName, &c.Credits, &c.Level,
+			&mode, &rule, &policyRaw); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		c.Mode, c.Rule = courseGradingMode(settings, mode, rule)
+		if c.Policy, err = decodeGradePolicy(policyRaw); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if c.Mode == models.GradingModeStandards {
+			standardsIDs = append(standardsIDs, c.ID)
+		} else {
+			pointsIDs = append(pointsIDs, c.ID)
+		}
+		setups[c.ShortID] = &c
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var standards map[uuid.UUID][]models.Standard
+	if len(standardsIDs) > 0 {
+		if standards, err = loadCourseStandards(ctx, db, standardsIDs, schoolID); err != nil {
+			return nil, err
+		}
+	}
+	var assignments map[uuid.UUID][]models.Assignment
+	if len(pointsIDs) > 0 {
+		if assignments, err = loadCourseAssignments(ctx, db, pointsIDs, schoolID); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range setups {
+		if c.Mode == models.GradingModeStandards {
+			c.Standards = standards[c.ID]
+		} else {
+			c.Assignments = assignments[c.ID]
+		}
+	}
+	return setups, nil
+}
+
+// grade computes one student's grade in the course from their grades (points
+// mode) or standard scores (standards mode).
+func (c *courseGradeSetup) grade(
+	grading *services.GradingService,
+	settings models.SchoolSettings,
+	studentID uuid.UUID,
+	grades []models.Grade,
+	scores []models.StandardScore,
+	term *models.Term,
+) studentCourseGrade {
+	g := studentCourseGrade{CourseID: c.ID, CourseShortID: c.ShortID, CourseName: c.Name,
+		TeacherName: c.TeacherName, Credits: c.Credits, Level: c.Level, Mode: c.Mode}
+	if c.Mode == models.GradingModeStandards {
+		g.Standards = grading.CalculateMastery(c.Standards, scores, c.Rule, settings.MasteryDecay, term)
+		return g
+	}
+	g.Calc = grading.CalculateCourseGrade(c.Assignments, grades,
+		settings.GradingScale, settings.CategoryWeights, term, c.Policy)
+	if g.Calc != nil {
+		g.Calc.StudentID = studentID
+		g.Calc.CourseID = c.ID
+	}
+	return g
+}
+
+// loadCourseGrades returns every grade in a course, keyed by student ID.
+func loadCourseGrades(ctx context.Context, db *pgxpool.Pool, courseID, schoolID uuid.UUID) (map[uuid.UUID][]models.Grade, error) {
+	rows, err := db.Query(ctx, `
+		SELECT g.id, g.assignment_id, g.student_id, g.points_earned,
+		       g.is_excused, g.is_missing, g.is_late, g.days_late
+		FROM grades g
+		JOIN assignments a ON a.id = g.assignment_id
+		WHERE a.course_id = $1 AND g.school_id = $2
+	`, courseID, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[uuid.UUID][]models.Grade)
+	for rows.Next() {
+		var g models.Grade
+		if err := rows.Scan(&g.ID, &g.AssignmentID, &g.StudentID, &g.PointsEarned,
+			&g.IsExcused, &g.IsMissing, &g.IsLate, &g.DaysLate); err != nil {
+			return nil, err
+		}
+		out[g.StudentID] = append(out[g.StudentID], g)
+	}
+	return out, rows.Err()
+}
+
+// loadCourseStandardScores returns every standard score on a course's
+// published assignments, keyed by student ID.
+func loadCourseStandardScores(ctx context.Context, db *pgxpool.Pool, courseID, schoolID uuid.UUID) (map[uuid.UUID][]models.StandardScore, error) {
+	rows, err := db.Query(ctx, `
+		SELECT ss.id, ss.assignment_id, ss.standard_id, s.short_id, ss.student_id,
+		       ss.score, ss.comment, ss.graded_by, ss.graded_at, COALESCE(a.due_date, ss.graded_at)
+		FROM standard_scores ss
+		JOIN assignments a ON a.id = ss.assignment_id
+		JOIN standards s ON s.id = ss.standard_id
+		WHERE a.course_id = $1 AND ss.school_id = $2 AND a.is_published = TRUE
+	`, courseID, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[uuid.UUID][]models.StandardScore)
+	for rows.Next() {
+		var sc models.StandardScore
+		if err := rows.Scan(&sc.ID, &sc.AssignmentID, &sc.StandardID, &sc.StandardShortID,
+			&sc.StudentID, &sc.Score, &sc.Comment, &sc.GradedBy, &sc.GradedAt, &sc.AssessedAt); err != nil {
+			return nil, err
+		}
+		sc.SchoolID = schoolID
+		out[sc.StudentID] = append(out[sc.StudentID], sc)
+	}
+	return out, rows.Err()
+}
@@ -3,12 +3,14 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
 	"github.com/pragma-proto/api/internal/models"
 	"github.com/pragma-proto/api/internal/shortid"
 )
@@ -118,12 +120,15 @@ func (h *CoursesHandler) GetCourse(w http.ResponseWriter, r *http.Request) {
 
 	var c models.Course
 	err := h.db.QueryRow(ctx, `
-		SELECT c.id, c.short_id, c.name, c.subject, c.period, c.room, c.academic_year, c.semester, c.is_active
+		SELECT c.id, c.short_id, c.name, c.subject, c.period, c.room, c.academic_year, c.semester, c.is_active,
+		       c.term_id, t.short_id, c.grading_mode, c.mastery_rule, c.credits, c.course_level
 		FROM courses c
+		LEFT JOIN terms t ON t.id = c.term_id
 		WHERE c.short_id = $1 AND c.school_id = $2
 	`, courseParam, claims.SchoolID).Scan(
 		&c.ID, &c.ShortID, &c.Name, &c.Subject, &c.Period, &c.Room,
-		&c.AcademicYear, &c.Semester, &c.IsActive,
+		&c.AcademicYear, &c.Semester, &c.IsActive, &c.TermID, &c.TermShortID,
+		&c.GradingMode, &c.MasteryRule, &c.Credits, &c.CourseLevel,
 	)
 	if err != nil {
 		writeError(w, http.StatusNotFound, "not_found", "course not found")
@@ -144,8 +149,15 @@ func (h *CoursesHandler) CreateCourse(w http.ResponseWriter, r *http.Request) {
 		Subject      string `json:"subject" validate:"required,min=1,max=100"`
 		Period       string `json:"period"`
 		Room         string `json:"room"`
-		AcademicYear string `json:"academic_year" validate:"required"`
+		AcademicYear string `json:"academic_year"`
 		Semester     string `json:"semester"`
+		TermID       string `json:"term_id"` // short_id of a year, semester, or quarter
+		GradingMode  string `json:"grading_mode" validate:"omitempty,oneof=points standards"`
+		MasteryRule  string `json:"mastery_rule" validate:"omitempty,oneof=most_recent highest decaying_average mode"`
+
+		// Credits defaults to 1 when omitted; CourseLevel to regular.
+		Credits     *float64 `json:"credits" validate:"omitempty,min=0,max=10"`
+		CourseLevel string   `json:"course_level" validate:"omitempty,oneof=regular honors ap ib"`
 	}
 
 	dec := json.NewDecoder(r.Body)
@@ -159,8 +171,45 @@ func (h *CoursesHandler) CreateCourse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Credits == nil {
+		one := 1.0
+		req.Credits = &one
+	}
+	if req.CourseLevel == "" {
+		req.CourseLevel = models.CourseLevelRegular
+	}
+
 	ctx := r.Context()
 
+	// A term, when given, is authoritative: the year and semester labels are
+	// taken from it so they can't disagree with the term's dates.
+	var termID *uuid.UUID
+	if req.TermID != "" {
+		term, err := resolveTerm(ctx, h.db, req.TermID, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "not_found", "term not found")
+			return
+		}
+		terms, err := loadTerms(ctx, h.db, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		for _, t := range termPath(terms, term) {
+			switch t.TermType {
+			case models.TermYear:
+				req.AcademicYear = t.Name
+			case models.TermSemester:
+				req.Semester = t.Name
+			}
+		}
+		termID = &term.ID
+	} else if !validAcademicYear(req.AcademicYear) {
+		writeError(w, http.StatusBadRequest, "validation_error",
+			"academic_year must be formatted YYYY-YYYY with consecutive years, or term_id must be given")
+		return
+	}
+
 	// Generate a unique short_id with retry on the astronomically unlikely collision.
 	var courseID uuid.UUID
 	var sid string
@@ -173,11 +222,13 @@ func (h *CoursesHandler) CreateCourse(w http.ResponseWriter, r *http.Request) {
 		}
 
 		err = h.db.QueryRow(ctx, `
-			INSERT INTO courses (school_id, teacher_id, name, subject, period, room, academic_year, semester, short_id)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			INSERT INTO courses (school_id, teacher_id, name, subject, period, room, academic_year, semester, term_id,
+			                     grading_mode, mastery_rule, credits, course_level, short_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 			RETURNING id
 		`, claims.SchoolID, req.TeacherID, req.Name, req.Subject,
-			nullStr(req.Period), nullStr(req.Room), req.AcademicYear, nullStr(req.Semester), sid,
+			nullStr(req.Period), nullStr(req.Room), req.AcademicYear, nullStr(req.Semester), termID,
+			nullStr(req.GradingMode), nullStr(req.MasteryRule), *req.Credits, req.CourseLevel, sid,
 		).Scan(&courseID)
 		if err == nil {
 			break
@@ -194,3 +245,70 @@ func (h *CoursesHandler) CreateCourse(w http.ResponseWriter, r *http.Request) {
 		"short_id":  sid,
 	})
 }
+
+// validAcademicYear reports whether s looks like "2025-2026".
+func validAcademicYear(s string) bool {
+	var from, to int
+	if len(s) != 9 {
+		return false
+	}
+	if _, err := fmt.Sscanf(s, "%4d-%4d", &from, &to); err != nil {
+		return false
+	}
+	return to == from+1
+}
+
+// UpdateCourseCredits sets a course's credit value and level used for GPA
+// (admin only).
+// courseId URL param is a short_id.
+func (h *CoursesHandler) UpdateCourseCredits(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		Credits     *float64 `json:"credits" validate:"required,min=0,max=10"`
+		CourseLevel string   `json:"course_level" validate:"required,oneof=regular honors ap ib"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	var courseID uuid.UUID
+	var oldCredits float64
+	var oldLevel string
+	err := h.db.QueryRow(ctx, `
+		SELECT id, credits, course_level FROM courses WHERE short_id = $1 AND school_id = $2
+	`, chi.URLParam(r, "courseId"), claims.SchoolID).Scan(&courseID, &oldCredits, &oldLevel)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+
+	if _, err := h.db.Exec(ctx, `
+		UPDATE courses SET credits = $1, course_level = $2 WHERE id = $3 AND school_id = $4
+	`, *req.Credits, req.CourseLevel, courseID, claims.SchoolID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "course.update_credits",
+		EntityType: "course",
+		EntityID:   &courseID,
+		OldValue:   map[string]interface{}{"credits": oldCredits, "course_level": oldLevel},
+		NewValue:   req,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
@@ -8,16 +8,56 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pragma-proto/api/internal/auth"
 	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
 )
 
 // DashboardHandler aggregates data for role-specific dashboards.
 type DashboardHandler struct {
-	db *pgxpool.Pool
+	db      *pgxpool.Pool
+	grading *services.GradingService
 }
 
 // NewDashboardHandler creates a DashboardHandler.
-func NewDashboardHandler(db *pgxpool.Pool) *DashboardHandler {
-	return &DashboardHandler{db: db}
+func NewDashboardHandler(db *pgxpool.Pool, grading *services.GradingService) *DashboardHandler {
+	return &DashboardHandler{db: db, grading: grading}
+}
+
+// dashboardCourseGrade is a course grade as shown on student and parent dashboards.
+type dashboardCourseGrade struct {
+	CourseID    string   `json:"course_id"`
+	CourseName  string   `json:"course_name"`
+	TeacherName string   `json:"teacher_name"`
+	Percentage  *float64 `json:"percentage"`
+	LetterGrade *string  `json:"letter_grade"`
+	GradingMode string   `json:"grading_mode"`
+
+	// Standards-based courses report mastery per standard instead of a grade.
+	Standards []models.StandardMastery `json:"standards,omitempty"`
+}
+
+// courseGradeSummary computes a student's current course grades and GPA
+// through the shared grading pipeline.
+func (h *DashboardHandler) courseGradeSummary(r *http.Request, settings models.SchoolSettings, studentID, schoolID uuid.UUID) ([]dashboardCourseGrade, float64, error) {
+	courses, err := computeStudentCourseGrades(r.Context(), h.db, h.grading, settings, studentID, schoolID, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]dashboardCourseGrade, 0, len(courses))
+	for _, c := range courses {
+		cg := dashboardCourseGrade{
+			CourseID:    c.CourseShortID,
+			CourseName:  c.CourseName,
+			TeacherName: c.TeacherName,
+			GradingMode: c.Mode,
+			Standards:   c.Standards,
+		}
+		if c.Calc != nil {
+			cg.Percentage = &c.Calc.Percentage
+			cg.LetterGrade = &c.Calc.LetterGrade
+		}
+		out = append(out, cg)
+	}
+	return out, gpaFromCourseGrades(h.grading, courses, settings).GPA, nil
 }
 
 // GetDashboard returns the appropriate dashboard data based on the user's role.
@@ -76,7 +116,9 @@ func (h *DashboardHandler) teacherDashboard(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	// Alerts: ungraded assignments.
+	// Alerts: ungraded assignments. Work counts as due once the student's
+	// effective due date (after extensions) has passed; a grade row holding
+	// only late or missing flags is still ungraded.
 	var ungradedCount int
 	h.db.QueryRow(ctx, `
 		SELECT COUNT(*)
@@ -84,11 +126,13 @@ func (h *DashboardHandler) teacherDashboard(w http.ResponseWriter, r *http.Reque
 		JOIN courses c ON c.id = a.course_id
 		JOIN teachers t ON t.id = c.teacher_id
 		WHERE t.user_id = $1 AND a.school_id = $2
-		  AND a.due_date < NOW()
+		  AND a.due_date IS NOT NULL
 		  AND EXISTS (
 		    SELECT 1 FROM enrollments e WHERE e.course_id = a.course_id AND e.status = 'active'
+		    AND effective_due_date(a.id, e.student_id) < NOW()
 		    AND NOT EXISTS (
 		      SELECT 1 FROM grades g WHERE g.assignment_id = a.id AND g.student_id = e.student_id
+		      AND (g.points_earned IS NOT NULL OR COALESCE(g.is_excused, FALSE))
 		    )
 		  )
 	`, claims.UserID, claims.SchoolID).Scan(&ungradedCount)
@@ -127,11 +171,22 @@ func (h *DashboardHandler) teacherDashboard(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
+	// Needs Attention: open at-risk alerts from the nightly insights job.
+	needsAttention, err := listStudentAlerts(ctx, h.db, claims.SchoolID, &claims.UserID, nil, models.AlertOpen, 10, 0)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if needsAttention == nil {
+		needsAttention = []models.StudentAlert{}
+	}
+
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"role":                  "teacher",
 		"today_schedule":        schedule,
 		"ungraded_assignments":  ungradedCount,
 		"recent_grade_activity": activity,
+		"needs_attention":       needsAttention,
 	})
 }
 
@@ -140,7 +195,7 @@ func (h *DashboardHandler) parentDashboard(w http.ResponseWriter, r *http.Reques
 
 	// Get linked children — return short_id for URL-friendly references.
 	rows, _ := h.db.Query(ctx, `
-		SELECT s.short_id, u.first_name, u.last_name, s.grade_level, s.is_grade_locked,
+		SELECT s.id, s.short_id, u.first_name, u.last_name, s.grade_level, s.is_grade_locked,
 		       ps.can_view_grades
 		FROM parent_students ps
 		JOIN students s ON s.id = ps.student_id
@@ -149,12 +204,15 @@ func (h *DashboardHandler) parentDashboard(w http.ResponseWriter, r *http.Reques
 	`, claims.UserID, claims.SchoolID)
 
 	type childSummary struct {
-		StudentID     string `json:"student_id"`
-		FirstName     string `json:"first_name"`
-		LastName      string `json:"last_name"`
-		GradeLevel    string `json:"grade_level"`
-		IsGradeLocked bool   `json:"is_grade_locked"`
-		CanViewGrades bool   `json:"can_view_grades"`
+		id            uuid.UUID
+		StudentID     string                 `json:"student_id"`
+		FirstName     string                 `json:"first_name"`
+		LastName      string                 `json:"last_name"`
+		GradeLevel    string                 `json:"grade_level"`
+		IsGradeLocked bool                   `json:"is_grade_locked"`
+		CanViewGrades bool                   `json:"can_view_grades"`
+		Courses       []dashboardCourseGrade `json:"courses,omitempty"`
+		GPA           *float64               `json:"gpa,omitempty"`
 	}
 
 	var children []childSummary
@@ -162,9 +220,29 @@ func (h *DashboardHandler) parentDashboard(w http.ResponseWriter, r *http.Reques
 		defer rows.Close()
 		for rows.Next() {
 			var c childSummary
-			rows.Scan(&c.StudentID, &c.FirstName, &c.LastName, &c.GradeLevel, &c.IsGradeLocked, &c.CanViewGrades)
+			rows.Scan(&c.id, &c.StudentID, &c.FirstName, &c.LastName, &c.GradeLevel, &c.IsGradeLocked, &c.CanViewGrades)
 			children = append(children, c)
 		}
+		rows.Close()
+	}
+
+	// Grades only for children the parent may see and who aren't locked.
+	school, err := loadSchool(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	for i := range children {
+		if !children[i].CanViewGrades || children[i].IsGradeLocked {
+			continue
+		}
+		courses, gpa, err := h.courseGradeSummary(r, school.Settings, children[i].id, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		children[i].Courses = courses
+		children[i].GPA = &gpa
 	}
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
@@ -177,10 +255,11 @@ func (h *DashboardHandler) studentDashboard(w http.ResponseWriter, r *http.Reque
 	ctx := r.Context()
 
 	// Get the student record — return short_id for URL-friendly references.
+	var studentID uuid.UUID
 	var studentShortID string
 	var isLocked bool
-	h.db.QueryRow(ctx, `SELECT short_id, is_grade_locked FROM students WHERE user_id = $1 AND school_id = $2`,
-		claims.UserID, claims.SchoolID).Scan(&studentShortID, &isLocked)
+	h.db.QueryRow(ctx, `SELECT id, short_id, is_grade_locked FROM students WHERE user_id = $1 AND school_id = $2`,
+		claims.UserID, claims.SchoolID).Scan(&studentID, &studentShortID, &isLocked)
 
 	response := map[string]interface{}{
 		"role":           "student",
@@ -190,6 +269,19 @@ func (h *DashboardHandler) studentDashboard(w http.ResponseWriter, r *http.Reque
 
 	if isLocked {
 		response["grade_message"] = "Your grade access has been temporarily restricted. Please contact your school administration."
+	} else if studentID != uuid.Nil {
+		school, err := loadSchool(ctx, h.db, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		courses, gpa, err := h.courseGradeSummary(r, school.Settings, studentID, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		response["courses"] = courses
+		response["gpa"] = gpa
 	}
 
 	writeJSON(w, http.StatusOK, response)
@@ -20,13 +20,14 @@ type DocumentsHandler struct {
 	pdf          *services.PDFService
 	storage      *services.StorageService
 	verification *services.VerificationService
+	grading      *services.GradingService
 	baseURL      string
 }
 
 // NewDocumentsHandler creates a DocumentsHandler.
 func NewDocumentsHandler(db *pgxpool.Pool, pdf *services.PDFService, storage *services.StorageService,
-	verification *services.VerificationService, baseURL string) *DocumentsHandler {
-	return &DocumentsHandler{db: db, pdf: pdf, storage: storage, verification: verification, baseURL: baseURL}
+	verification *services.VerificationService, grading *services.GradingService, baseURL string) *DocumentsHandler {
+	return &DocumentsHandler{db: db, pdf: pdf, storage: storage, verification: verification, grading: grading, baseURL: baseURL}
 }
 
 // GenerateDocument creates an official school document and stores it in R2.
@@ -35,7 +36,11 @@ func (h *DocumentsHandler) GenerateDocument(w http.ResponseWriter, r *http.Reque
 
 	var req struct {
 		StudentID string `json:"student_id" validate:"required,uuid"`
-		Type      string `json:"type" validate:"required,oneof=enrollment_certificate attendance_letter academic_standing tuition_confirmation custom"`
+		Type      string `json:"type" validate:"required,oneof=enrollment_certificate attendance_letter academic_standing tuition_confirmation transcript custom"`
+		// Template names one of the school's custom templates; custom type only.
+		Template string `json:"template" validate:"max=100"`
+		// CustomContent is free text offered to the template (admins only).
+		CustomContent string `json:"custom_content" validate:"max=5000"`
 	}
 
 	dec := json.NewDecoder(r.Body)
@@ -49,6 +54,15 @@ func (h *DocumentsHandler) GenerateDocument(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if req.Template != "" && req.Type != services.TemplateKindCustom {
+		writeError(w, http.StatusBadRequest, "validation_error", "template may only be chosen for custom documents")
+		return
+	}
+	if req.CustomContent != "" && claims.Role != models.RoleAdmin && claims.Role != models.RoleSuperAdmin {
+		writeError(w, http.StatusForbidden, "forbidden", "only administrators may add custom content")
+		return
+	}
+
 	ctx := r.Context()
 
 	// Authorization: students for themselves, parents for linked children, admins for anyone.
@@ -70,12 +84,25 @@ func (h *DocumentsHandler) GenerateDocument(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
+	// Documents stating grades respect the grade lock.
+	if (req.Type == "academic_standing" || req.Type == services.TemplateKindTranscript) &&
+		(claims.Role == models.RoleStudent || claims.Role == models.RoleParent) {
+		var isLocked bool
+		h.db.QueryRow(ctx, `
+			SELECT is_grade_locked FROM students WHERE id = $1 AND school_id = $2
+		`, req.StudentID, claims.SchoolID).Scan(&isLocked)
+		if isLocked {
+			writeError(w, http.StatusForbidden, "grade_locked",
+				"Your grade access has been temporarily restricted. Please contact your school administration.")
+			return
+		}
+	}
+
 	// Fetch student and school data.
 	var student models.Student
 	var user models.User
-	var school models.School
 
-	h.db.QueryRow(ctx, `
+	err := h.db.QueryRow(ctx, `
 		SELECT s.id, s.student_number, s.grade_level, s.enrollment_status,
 		       u.first_name, u.last_name, u.email
 		FROM students s JOIN users u ON u.id = s.user_id
@@ -84,10 +111,27 @@ func (h *DocumentsHandler) GenerateDocument(w http.ResponseWriter, r *http.Reque
 		&student.ID, &student.StudentNumber, &student.GradeLevel, &student.EnrollmentStatus,
 		&user.FirstName, &user.LastName, &user.Email,
 	)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "student not found")
+		return
+	}
 
-	h.db.QueryRow(ctx, `SELECT id, name, logo_url FROM schools WHERE id = $1`, claims.SchoolID).Scan(
-		&school.ID, &school.Name, &school.LogoURL,
-	)
+	school, err := loadSchool(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	assets := loadSchoolAssets(ctx, h.storage, school)
+
+	tmpl, err := resolveTemplate(ctx, h.db, school, req.Type, req.Template)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if req.Template != "" && tmpl.ID == nil {
+		writeError(w, http.StatusNotFound, "not_found", "template not found")
+		return
+	}
 
 	// Generate a document ID and verification code.
 	docID := uuid.New()
@@ -101,8 +145,44 @@ func (h *DocumentsHandler) GenerateDocument(w http.ResponseWriter, r *http.Reque
 		expiresAt = &exp
 	}
 
+	var attendance *models.AttendanceSummary
+	if req.Type == "attendance_letter" {
+		summary, err := loadAttendanceSummary(ctx, h.db, student.ID, claims.SchoolID, nil, nil)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		attendance = &summary
+	}
+
+	// Academic standing letters state the current and cumulative GPA.
+	var termGPA, cumulativeGPA *models.GPAResult
+	if req.Type == "academic_standing" {
+		courses, err := computeStudentCourseGrades(ctx, h.db, h.grading, school.Settings, student.ID, claims.SchoolID, nil)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		gpa := gpaFromCourseGrades(h.grading, courses, school.Settings)
+		termGPA = &gpa
+		history, err := loadTermGPAs(ctx, h.db, student.ID, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		cumulativeGPA = latestCumulativeGPA(history)
+	}
+
+	var transcript *services.Transcript
+	if req.Type == services.TemplateKindTranscript {
+		if transcript, err = buildTranscript(ctx, h.db, h.grading, school.Settings, &student, claims.SchoolID); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+	}
+
 	data := services.DocumentData{
-		School:           &school,
+		School:           school,
 		Student:          &student,
 		StudentUser:      &user,
 		DocumentType:     req.Type,
@@ -110,28 +190,43 @@ func (h *DocumentsHandler) GenerateDocument(w http.ResponseWriter, r *http.Reque
 		VerificationURL:  verURL,
 		GeneratedAt:      now,
 		ExpiresAt:        expiresAt,
+		CustomContent:    req.CustomContent,
+		Attendance:       attendance,
+		TermGPA:          termGPA,
+		CumulativeGPA:    cumulativeGPA,
+		Transcript:       transcript,
 		SignatoryName:    school.Settings.SignatoryName,
 		SignatoryTitle:   school.Settings.SignatoryTitle,
+		Template:         tmpl.Body,
+		Logo:             assets.Logo,
+		Signature:        assets.Signature,
+	}
+
+	// Snapshot the template input so the document can be re-rendered exactly.
+	renderData, err := tmpl.snapshot(req.Type, services.DocumentTemplateData(data))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "pdf_error", err.Error())
+		return
 	}
 
-	htmlBytes, err := h.pdf.RenderDocumentHTML(data)
+	pdfBytes, err := h.pdf.RenderDocumentPDF(data)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "pdf_error", err.Error())
 		return
 	}
 
-	// Store the HTML as PDF placeholder (in production this would be fed to chromedp).
-	key := services.ObjectKey(claims.SchoolID.String(), "documents", docID.String()+".html")
-	if err := h.storage.PutObject(ctx, key, htmlBytes, "text/html"); err != nil {
+	key := services.ObjectKey(claims.SchoolID.String(), "documents", docID.String()+".pdf")
+	if err := h.storage.PutObject(ctx, key, pdfBytes, "application/pdf"); err != nil {
 		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
 		return
 	}
 
 	// Record the document.
 	_, err = h.db.Exec(ctx, `
-		INSERT INTO documents (id, school_id, student_id, type, verification_code, pdf_url, generated_by, expires_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-	`, docID, claims.SchoolID, req.StudentID, req.Type, verCode, key, claims.UserID, expiresAt)
+		INSERT INTO documents
+			(id, school_id, student_id, type, verification_code, pdf_url, generated_by, expires_at, template_id, render_data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, docID, claims.SchoolID, req.StudentID, req.Type, verCode, key, claims.UserID, expiresAt, tmpl.ID, renderData)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
 		return
@@ -146,17 +241,21 @@ func (h *DocumentsHandler) GenerateDocument(w http.ResponseWriter, r *http.Reque
 		Action:     "document.generate",
 		EntityType: "document",
 		EntityID:   &docID,
-		NewValue:   map[string]string{"type": req.Type, "student_id": req.StudentID},
+		NewValue:   map[string]interface{}{"type": req.Type, "student_id": req.StudentID, "template_id": tmpl.ID},
 		IPAddress:  r.RemoteAddr,
 		UserAgent:  r.UserAgent(),
 	})
 
-	writeJSON(w, http.StatusCreated, map[string]interface{}{
+	resp := map[string]interface{}{
 		"document_id":       docID,
 		"verification_code": verCode,
 		"download_url":      downloadURL,
 		"expires_at":        expiresAt,
-	})
+	}
+	if len(assets.Warnings) > 0 {
+		resp["branding_warnings"] = assets.Warnings
+	}
+	writeJSON(w, http.StatusCreated, resp)
 }
 
 // VerifyDocument is the public document verification endpoint — no auth required.
@@ -169,14 +268,15 @@ func (h *DocumentsHandler) VerifyDocument(w http.ResponseWriter, r *http.Request
 	var createdAt time.Time
 	var expiresAt *time.Time
 	var isExpired bool
+	var renderData []byte
 
 	err := h.db.QueryRow(ctx, `
-		SELECT d.type, u.first_name, u.last_name, d.created_at, d.expires_at
+		SELECT d.type, u.first_name, u.last_name, d.created_at, d.expires_at, d.render_data
 		FROM documents d
 		JOIN students s ON s.id = d.student_id
 		JOIN users u ON u.id = s.user_id
 		WHERE d.verification_code = $1
-	`, code).Scan(&docType, &studentFirstName, &studentLastName, &createdAt, &expiresAt)
+	`, code).Scan(&docType, &studentFirstName, &studentLastName, &createdAt, &expiresAt, &renderData)
 
 	if err != nil {
 		writeJSON(w, http.StatusOK, map[string]interface{}{"valid": false})
@@ -187,11 +287,32 @@ func (h *DocumentsHandler) VerifyDocument(w http.ResponseWriter, r *http.Request
 		isExpired = true
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
+	resp := map[string]interface{}{
 		"valid":         !isExpired,
 		"document_type": docType,
 		"student_name":  studentFirstName + " " + studentLastName,
 		"issued_at":     createdAt.Format("2006-01-02"),
 		"expires_at":    expiresAt,
-	})
+	}
+
+	// Transcripts also state the figures they were issued with, so a
+	// recipient can check them against the copy they were given.
+	if docType == services.TemplateKindTranscript && renderData != nil {
+		var snapshot services.TemplateData
+		if err := json.Unmarshal(renderData, &snapshot); err == nil && snapshot.Transcript != nil {
+			summary := map[string]interface{}{
+				"terms":             len(snapshot.Transcript.Terms),
+				"credits_attempted": snapshot.Transcript.CreditsAttempted,
+				"credits_earned":    snapshot.Transcript.CreditsEarned,
+				"graduation_status": snapshot.Transcript.GraduationStatus,
+			}
+			if snapshot.CumulativeGPA != nil {
+				summary["cumulative_gpa"] = snapshot.CumulativeGPA.GPA
+				summary["gpa_method"] = snapshot.CumulativeGPA.Method
+			}
+			resp["transcript"] = summary
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
 }
@@ -0,0 +1,718 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
+)
+
+// EnrollmentsHandler manages course rosters: enrolling, dropping, moving
+// students between sections, and bulk enrollment.
+type EnrollmentsHandler struct {
+	db *pgxpool.Pool
+}
+
+// NewEnrollmentsHandler creates an EnrollmentsHandler.
+func NewEnrollmentsHandler(db *pgxpool.Pool) *EnrollmentsHandler {
+	return &EnrollmentsHandler{db: db}
+}
+
+// Results of enrollStudent.
+const (
+	enrollCreated     = "enrolled"
+	enrollReactivated = "reactivated"
+	enrollDuplicate   = "already_enrolled"
+)
+
+// enrollStudent makes a student's enrollment in a course active. A dropped,
+// completed, or transferred enrollment is reactivated rather than
+// duplicated; an active one is left alone and reported as already_enrolled.
+func enrollStudent(ctx context.Context, q dbtx, schoolID, courseID, studentID, userID uuid.UUID, at time.Time) (uuid.UUID, string, error) {
+	var id uuid.UUID
+	var inserted bool
+	err := q.QueryRow(ctx, `
+		INSERT INTO enrollments (student_id, course_id, school_id, enrolled_at, enrolled_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (student_id, course_id) DO UPDATE
+		SET status = 'active', enrolled_at = EXCLUDED.enrolled_at, enrolled_by = EXCLUDED.enrolled_by,
+		    dropped_at = NULL, dropped_by = NULL, drop_reason = NULL, transferred_to = NULL
+		WHERE enrollments.status <> 'active'
+		RETURNING id, xmax = 0
+	`, studentID, courseID, schoolID, at, userID).Scan(&id, &inserted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = q.QueryRow(ctx, `SELECT id FROM enrollments WHERE student_id = $1 AND course_id = $2`,
+			studentID, courseID).Scan(&id)
+		return id, enrollDuplicate, err
+	}
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	if inserted {
+		return id, enrollCreated, nil
+	}
+	return id, enrollReactivated, nil
+}
+
+// resolveActiveCourse resolves a course short_id and reports whether the
+// course is active; inactive courses can't take new enrollments.
+func resolveActiveCourse(ctx context.Context, db dbtx, shortID string, schoolID uuid.UUID) (uuid.UUID, bool, error) {
+	var id uuid.UUID
+	var active bool
+	err := db.QueryRow(ctx, `
+		SELECT id, COALESCE(is_active, TRUE) FROM courses WHERE short_id = $1 AND school_id = $2
+	`, shortID, schoolID).Scan(&id, &active)
+	return id, active, err
+}
+
+// enrollmentDate parses an optional YYYY-MM-DD field, defaulting to now.
+func enrollmentDate(s string) (time.Time, error) {
+	t, err := parseDateParam(s)
+	if err != nil || t == nil {
+		return time.Now(), err
+	}
+	return *t, nil
+}
+
+// ListEnrollments returns a course's enrollments. Teachers see only their
+// own courses.
+// Query param: status (active, dropped, completed, transferred, or all;
+// default active).
+// courseId URL param is a short_id.
+func (h *EnrollmentsHandler) ListEnrollments(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	courseUUID, err := resolveCourseUUID(ctx, h.db, chi.URLParam(r, "courseId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = models.EnrollmentActive
+	case models.EnrollmentActive, models.EnrollmentDropped, models.EnrollmentCompleted, models.EnrollmentTransferred, "all":
+	default:
+		writeError(w, http.StatusBadRequest, "invalid_param", "status must be active, dropped, completed, transferred, or all")
+		return
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT e.id, e.student_id, e.course_id, e.school_id, e.enrolled_at, e.dropped_at, e.status,
+		       e.drop_reason, e.transferred_to,
+		       s.short_id, u.first_name || ' ' || u.last_name, s.student_number, s.grade_level, tc.short_id
+		FROM enrollments e
+		JOIN students s ON s.id = e.student_id
+		JOIN users u ON u.id = s.user_id
+		LEFT JOIN courses tc ON tc.id = e.transferred_to
+		WHERE e.course_id = $1 AND e.school_id = $2 AND ($3 = 'all' OR e.status = $3)
+		ORDER BY u.last_name, u.first_name
+	`, courseUUID, claims.SchoolID, status)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	enrollments := []models.Enrollment{}
+	for rows.Next() {
+		var e models.Enrollment
+		if err := rows.Scan(&e.ID, &e.StudentID, &e.CourseID, &e.SchoolID, &e.EnrolledAt, &e.DroppedAt, &e.Status,
+			&e.DropReason, &e.TransferredTo,
+			&e.StudentShortID, &e.StudentName, &e.StudentNumber, &e.GradeLevel, &e.TransferredToShortID); err != nil {
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		enrollments = append(enrollments, e)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"enrollments": enrollments})
+}
+
+// EnrollStudent enrolls one student in a course (admin only). Re-enrolling a
+// dropped student reactivates their enrollment and keeps their grades.
+// courseId URL param is a short_id; body student_id is a short_id.
+func (h *EnrollmentsHandler) EnrollStudent(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		StudentID  string `json:"student_id" validate:"required"`
+		EnrolledAt string `json:"enrolled_at"` // YYYY-MM-DD; defaults to now
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	at, err := enrollmentDate(req.EnrolledAt)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "enrolled_at must be YYYY-MM-DD")
+		return
+	}
+
+	courseUUID, active, err := resolveActiveCourse(ctx, h.db, chi.URLParam(r, "courseId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if !active {
+		writeError(w, http.StatusBadRequest, "course_inactive", "course is not active")
+		return
+	}
+
+	var studentUUID uuid.UUID
+	var studentStatus string
+	if err := h.db.QueryRow(ctx, `
+		SELECT id, COALESCE(enrollment_status, 'active') FROM students WHERE short_id = $1 AND school_id = $2
+	`, req.StudentID, claims.SchoolID).Scan(&studentUUID, &studentStatus); err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "student not found")
+		return
+	}
+	if studentStatus != "active" {
+		writeError(w, http.StatusBadRequest, "student_inactive", "student is "+studentStatus)
+		return
+	}
+
+	enrollmentID, result, err := enrollStudent(ctx, h.db, claims.SchoolID, courseUUID, studentUUID, claims.UserID, at)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if result == enrollDuplicate {
+		writeError(w, http.StatusConflict, "already_enrolled", "student is already enrolled in this course")
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "enrollment.create",
+		EntityType: "enrollment",
+		EntityID:   &enrollmentID,
+		NewValue:   map[string]interface{}{"course_id": courseUUID, "student_id": studentUUID, "enrolled_at": at, "result": result},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	status := http.StatusCreated
+	if result == enrollReactivated {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, map[string]interface{}{"enrollment_id": enrollmentID, "result": result})
+}
+
+// DropStudent drops a student from a course with a date and reason (admin
+// only). Grades are kept.
+// courseId and studentId URL params are short_ids.
+func (h *EnrollmentsHandler) DropStudent(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		DroppedAt string `json:"dropped_at"` // YYYY-MM-DD; defaults to now
+		Reason    string `json:"reason" validate:"required,min=1,max=500"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	at, err := enrollmentDate(req.DroppedAt)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "dropped_at must be YYYY-MM-DD")
+		return
+	}
+
+	courseUUID, err := resolveCourseUUID(ctx, h.db, chi.URLParam(r, "courseId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	studentUUID, err := resolveStudentUUID(ctx, h.db, chi.URLParam(r, "studentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "student not found")
+		return
+	}
+
+	var old models.Enrollment
+	if err := h.db.QueryRow(ctx, `
+		SELECT id, enrolled_at, status FROM enrollments
+		WHERE course_id = $1 AND student_id = $2 AND school_id = $3
+	`, courseUUID, studentUUID, claims.SchoolID).Scan(&old.ID, &old.EnrolledAt, &old.Status); err != nil || old.Status != models.EnrollmentActive {
+		writeError(w, http.StatusNotFound, "not_enrolled", "student is not enrolled in this course")
+		return
+	}
+	if at.Before(old.EnrolledAt.Truncate(24 * time.Hour)) {
+		writeError(w, http.StatusBadRequest, "invalid_date", "dropped_at is before the enrollment date")
+		return
+	}
+
+	if _, err := h.db.Exec(ctx, `
+		UPDATE enrollments
+		SET status = 'dropped', dropped_at = $1, dropped_by = $2, drop_reason = $3
+		WHERE id = $4 AND status = 'active'
+	`, at, claims.UserID, req.Reason, old.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "enrollment.drop",
+		EntityType: "enrollment",
+		EntityID:   &old.ID,
+		OldValue:   map[string]interface{}{"status": old.Status},
+		NewValue:   map[string]interface{}{"status": models.EnrollmentDropped, "dropped_at": at, "reason": req.Reason},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"enrollment_id": old.ID, "status": models.EnrollmentDropped})
+}
+
+// transferGradeSkip explains why a grade wasn't carried to the new section.
+type transferGradeSkip struct {
+	AssignmentID uuid.UUID `json:"assignment_id"`
+	Title        string    `json:"title"`
+	Reason       string    `json:"reason"`
+}
+
+// TransferStudent moves a student to another section (admin only). The old
+// enrollment becomes 'transferred' and keeps its grades. Grades are also
+// carried to the new section's assignments with the same title, category,
+// due date, and max points, unless the match is ambiguous, the student
+// already has a grade there, or it is final; grades that couldn't be carried
+// are listed in the response.
+// courseId and studentId URL params are short_ids; body to_course_id is a short_id.
+func (h *EnrollmentsHandler) TransferStudent(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		ToCourseID    string `json:"to_course_id" validate:"required"`
+		EffectiveDate string `json:"effective_date"` // YYYY-MM-DD; defaults to now
+		Reason        string `json:"reason" validate:"omitempty,max=500"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	at, err := enrollmentDate(req.EffectiveDate)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "effective_date must be YYYY-MM-DD")
+		return
+	}
+
+	fromParam := chi.URLParam(r, "courseId")
+	fromUUID, err := resolveCourseUUID(ctx, h.db, fromParam, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	toUUID, active, err := resolveActiveCourse(ctx, h.db, req.ToCourseID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "target course not found")
+		return
+	}
+	if !active {
+		writeError(w, http.StatusBadRequest, "course_inactive", "target course is not active")
+		return
+	}
+	if toUUID == fromUUID {
+		writeError(w, http.StatusBadRequest, "same_course", "target course is the current course")
+		return
+	}
+	studentUUID, err := resolveStudentUUID(ctx, h.db, chi.URLParam(r, "studentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "student not found")
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var fromEnrollment uuid.UUID
+	if err := tx.QueryRow(ctx, `
+		UPDATE enrollments
+		SET status = 'transferred', dropped_at = $1, dropped_by = $2, drop_reason = $3, transferred_to = $4
+		WHERE course_id = $5 AND student_id = $6 AND school_id = $7 AND status = 'active'
+		RETURNING id
+	`, at, claims.UserID, nullStr(req.Reason), toUUID, fromUUID, studentUUID, claims.SchoolID).Scan(&fromEnrollment); err != nil {
+		writeError(w, http.StatusNotFound, "not_enrolled", "student is not enrolled in this course")
+		return
+	}
+	toEnrollment, result, err := enrollStudent(ctx, tx, claims.SchoolID, toUUID, studentUUID, claims.UserID, at)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if result == enrollDuplicate {
+		writeError(w, http.StatusConflict, "already_enrolled", "student is already enrolled in the target course")
+		return
+	}
+
+	// Pair each graded assignment with its counterpart in the new section.
+	rows, err := tx.Query(ctx, `
+		SELECT g.assignment_id, a.title, g.points_earned, COALESCE(g.comment, ''),
+		       COALESCE(g.is_excused, FALSE), COALESCE(g.is_missing, FALSE), COALESCE(g.is_late, FALSE), g.days_late,
+		       ta.id, ta.max_points = a.max_points, COALESCE(ta.matches, 0),
+		       EXISTS (SELECT 1 FROM grades tg WHERE tg.assignment_id = ta.id AND tg.student_id = g.student_id)
+		FROM grades g
+		JOIN assignments a ON a.id = g.assignment_id
+		LEFT JOIN LATERAL (
+			SELECT x.id, x.max_points, count(*) OVER () AS matches FROM assignments x
+			WHERE x.course_id = $3 AND lower(x.title) = lower(a.title) AND x.category = a.category
+			  AND (x.due_date AT TIME ZONE 'UTC')::date IS NOT DISTINCT FROM (a.due_date AT TIME ZONE 'UTC')::date
+			ORDER BY x.created_at LIMIT 1
+		) ta ON TRUE
+		WHERE g.student_id = $1 AND a.course_id = $2 AND g.school_id = $4
+		ORDER BY a.due_date
+	`, studentUUID, fromUUID, toUUID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	var writes []gradeWrite
+	var keys []gradeKey
+	var titles []string
+	skipped := []transferGradeSkip{}
+	carried := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var in gradeWrite
+		var sourceID uuid.UUID
+		var title string
+		var target *uuid.UUID
+		var samePoints *bool
+		var matches int64
+		var graded bool
+		if err := rows.Scan(&sourceID, &title, &in.PointsEarned, &in.Comment,
+			&in.IsExcused, &in.IsMissing, &in.IsLate, &in.DaysLate, &target, &samePoints, &matches, &graded); err != nil {
+			rows.Close()
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		switch {
+		case target == nil:
+			skipped = append(skipped, transferGradeSkip{sourceID, title, "no matching assignment in the new section"})
+		case matches > 1:
+			skipped = append(skipped, transferGradeSkip{sourceID, title, "more than one matching assignment in the new section"})
+		case carried[*target]:
+			skipped = append(skipped, transferGradeSkip{sourceID, title, "another grade was already carried to the matching assignment"})
+		case samePoints == nil || !*samePoints:
+			skipped = append(skipped, transferGradeSkip{sourceID, title, "matching assignment has different max points"})
+		case graded:
+			skipped = append(skipped, transferGradeSkip{sourceID, title, "student already has a grade in the new section"})
+		default:
+			carried[*target] = true
+			in.AssignmentID, in.StudentID = *target, studentUUID
+			in.Reason = "transferred from course " + fromParam
+			writes = append(writes, in)
+			keys = append(keys, gradeKey{AssignmentID: *target, StudentID: studentUUID})
+			titles = append(titles, title)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	locked, err := gradeLockReasons(ctx, tx, keys, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	copied := 0
+	for i, in := range writes {
+		if lockedBy, ok := locked[keys[i]]; ok {
+			skipped = append(skipped, transferGradeSkip{in.AssignmentID, titles[i], "grade is final: " + lockedBy})
+			continue
+		}
+		if _, _, err := writeGrade(ctx, tx, claims.SchoolID, claims.UserID, in); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		copied++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "enrollment.transfer",
+		EntityType: "enrollment",
+		EntityID:   &fromEnrollment,
+		OldValue:   map[string]interface{}{"course_id": fromUUID, "status": models.EnrollmentActive},
+		NewValue: map[string]interface{}{
+			"course_id": toUUID, "enrollment_id": toEnrollment, "effective_date": at,
+			"reason": req.Reason, "grades_copied": copied,
+		},
+		IPAddress: r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"from_enrollment_id": fromEnrollment,
+		"to_enrollment_id":   toEnrollment,
+		"grades_copied":      copied,
+		"grades_not_copied":  skipped,
+	})
+}
+
+// bulkEnrollResult reports what a bulk enrollment did with each student.
+type bulkEnrollResult struct {
+	Enrolled        []string `json:"enrolled"`
+	Reactivated     []string `json:"reactivated"`
+	AlreadyEnrolled []string `json:"already_enrolled"`
+	Inactive        []string `json:"inactive"`
+	NotFound        []string `json:"not_found"`
+}
+
+// BulkEnroll enrolls many students in a course in one transaction (admin
+// only). Give exactly one of: grade_level (every active student in that
+// grade), student_ids (short_ids), or file (a CSV or XLSX list with a header
+// row; student_column names the key column and student_match what it holds).
+// Students already enrolled are reported, not duplicated.
+// courseId URL param is a short_id.
+func (h *EnrollmentsHandler) BulkEnroll(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		GradeLevel    string   `json:"grade_level" validate:"omitempty,max=50"`
+		StudentIDs    []string `json:"student_ids" validate:"omitempty,max=2000"`
+		File          []byte   `json:"file"` // base64 in JSON
+		Format        string   `json:"format" validate:"omitempty,oneof=csv xlsx"`
+		StudentColumn string   `json:"student_column" validate:"omitempty,max=200"`
+		StudentMatch  string   `json:"student_match" validate:"omitempty,oneof=id student_number email"`
+		EnrolledAt    string   `json:"enrolled_at"` // YYYY-MM-DD; defaults to now
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	sources := 0
+	for _, given := range []bool{req.GradeLevel != "", len(req.StudentIDs) > 0, len(req.File) > 0} {
+		if given {
+			sources++
+		}
+	}
+	if sources != 1 {
+		writeError(w, http.StatusBadRequest, "validation_error", "give exactly one of grade_level, student_ids, or file")
+		return
+	}
+	at, err := enrollmentDate(req.EnrolledAt)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "enrolled_at must be YYYY-MM-DD")
+		return
+	}
+
+	courseUUID, active, err := resolveActiveCourse(ctx, h.db, chi.URLParam(r, "courseId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if !active {
+		writeError(w, http.StatusBadRequest, "course_inactive", "course is not active")
+		return
+	}
+
+	// Collect the lookup keys and the column they match.
+	match := "id"
+	var wanted []string
+	switch {
+	case req.GradeLevel != "":
+		match = "grade_level"
+		wanted = []string{req.GradeLevel}
+	case len(req.StudentIDs) > 0:
+		wanted = req.StudentIDs
+	default:
+		if req.Format == "" {
+			req.Format = services.SpreadsheetCSV
+		}
+		if req.StudentColumn == "" {
+			req.StudentColumn = gradebookStudentColumn
+		}
+		if req.StudentMatch != "" {
+			match = req.StudentMatch
+		}
+		sheet, err := services.DecodeSpreadsheet(req.Format, req.File)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_file", err.Error())
+			return
+		}
+		col := -1
+		if len(sheet) > 0 {
+			for i, name := range sheet[0] {
+				if strings.EqualFold(strings.TrimSpace(name), req.StudentColumn) {
+					col = i
+				}
+			}
+		}
+		if col < 0 {
+			writeError(w, http.StatusBadRequest, "invalid_file", fmt.Sprintf("column %q not found", req.StudentColumn))
+			return
+		}
+		for _, row := range sheet[1:] {
+			if col < len(row) && strings.TrimSpace(row[col]) != "" {
+				wanted = append(wanted, strings.TrimSpace(row[col]))
+			}
+		}
+		if len(wanted) > 2000 {
+			writeError(w, http.StatusBadRequest, "validation_error", "file lists more than 2000 students")
+			return
+		}
+	}
+	if match == "email" {
+		for i := range wanted {
+			wanted[i] = strings.ToLower(wanted[i])
+		}
+	}
+
+	column := map[string]string{
+		"id":             "s.short_id",
+		"student_number": "s.student_number",
+		"email":          "lower(u.email)",
+		"grade_level":    "s.grade_level",
+	}[match]
+	rows, err := h.db.Query(ctx, `
+		SELECT s.id, s.short_id, `+column+`, COALESCE(s.enrollment_status, 'active')
+		FROM students s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.school_id = $1 AND `+column+` = ANY($2)
+		ORDER BY u.last_name, u.first_name
+	`, claims.SchoolID, wanted)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	type candidate struct {
+		id      uuid.UUID
+		shortID string
+	}
+	var candidates []candidate
+	found := make(map[string]bool)
+	seen := make(map[uuid.UUID]bool)
+	res := bulkEnrollResult{
+		Enrolled: []string{}, Reactivated: []string{}, AlreadyEnrolled: []string{}, Inactive: []string{}, NotFound: []string{},
+	}
+	for rows.Next() {
+		var c candidate
+		var key, status string
+		if err := rows.Scan(&c.id, &c.shortID, &key, &status); err != nil {
+			rows.Close()
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		found[key] = true
+		switch {
+		case seen[c.id]:
+		case status != "active" && match != "grade_level":
+			res.Inactive = append(res.Inactive, c.shortID)
+		case status != "active":
+			// Withdrawn and graduated students are left out of grade-level enrollment.
+		default:
+			candidates = append(candidates, c)
+		}
+		seen[c.id] = true
+	}
+	rows.Close()
+	if match != "grade_level" {
+		reported := make(map[string]bool)
+		for _, key := range wanted {
+			if !found[key] && !reported[key] {
+				reported[key] = true
+				res.NotFound = append(res.NotFound, key)
+			}
+		}
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+	for _, c := range candidates {
+		_, result, err := enrollStudent(ctx, tx, claims.SchoolID, courseUUID, c.id, claims.UserID, at)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		switch result {
+		case enrollCreated:
+			res.Enrolled = append(res.Enrolled, c.shortID)
+		case enrollReactivated:
+			res.Reactivated = append(res.Reactivated, c.shortID)
+		default:
+			res.AlreadyEnrolled = append(res.AlreadyEnrolled, c.shortID)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "enrollment.bulk_create",
+		EntityType: "course",
+		EntityID:   &courseUUID,
+		NewValue:   map[string]interface{}{"match": match, "enrolled_at": at, "result": res},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, res)
+}
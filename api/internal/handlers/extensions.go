@@ -0,0 +1,529 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
+)
+
+// ExtensionsHandler manages per-student due date extensions and standing
+// accommodations. Both feed effective_due_date, so every change re-times the
+// student's submissions and missing flags.
+type ExtensionsHandler struct {
+	db *pgxpool.Pool
+}
+
+// NewExtensionsHandler creates an ExtensionsHandler.
+func NewExtensionsHandler(db *pgxpool.Pool) *ExtensionsHandler {
+	return &ExtensionsHandler{db: db}
+}
+
+// ListStudentExtensions returns a student's due date extensions and
+// accommodations. Teachers see only extensions in their own courses, and
+// accommodations without the plan notes.
+// studentId URL param is a short_id.
+func (h *ExtensionsHandler) ListStudentExtensions(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	studentUUID, err := resolveStudentUUID(ctx, h.db, chi.URLParam(r, "studentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "student not found")
+		return
+	}
+	if !canViewStudent(ctx, h.db, claims, studentUUID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you cannot view this student")
+		return
+	}
+
+	var teacherUser *uuid.UUID
+	if claims.Role == models.RoleTeacher {
+		teacherUser = &claims.UserID
+	}
+	rows, err := h.db.Query(ctx, `
+		SELECT x.id, x.short_id, x.school_id, x.student_id, x.assignment_id, x.course_id, x.due_date,
+		       x.extra_days, x.reason, x.granted_by, x.created_at, x.updated_at, a.title, c.name
+		FROM due_date_extensions x
+		LEFT JOIN assignments a ON a.id = x.assignment_id
+		JOIN courses c ON c.id = COALESCE(x.course_id, a.course_id)
+		LEFT JOIN teachers t ON t.id = c.teacher_id
+		WHERE x.student_id = $1 AND x.school_id = $2 AND ($3::uuid IS NULL OR t.user_id = $3)
+		ORDER BY x.created_at DESC
+	`, studentUUID, claims.SchoolID, teacherUser)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+	extensions := []models.DueDateExtension{}
+	for rows.Next() {
+		var x models.DueDateExtension
+		if err := rows.Scan(&x.ID, &x.ShortID, &x.SchoolID, &x.StudentID, &x.AssignmentID, &x.CourseID, &x.DueDate,
+			&x.ExtraDays, &x.Reason, &x.GrantedBy, &x.CreatedAt, &x.UpdatedAt, &x.AssignmentTitle, &x.CourseName); err != nil {
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		extensions = append(extensions, x)
+	}
+	rows.Close()
+
+	accommodations, err := listAccommodations(ctx, h.db, studentUUID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if claims.Role != models.RoleAdmin && claims.Role != models.RoleSuperAdmin {
+		for i := range accommodations {
+			accommodations[i].Notes = nil
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"extensions":     extensions,
+		"accommodations": accommodations,
+	})
+}
+
+func listAccommodations(ctx context.Context, db dbtx, studentID, schoolID uuid.UUID) ([]models.Accommodation, error) {
+	rows, err := db.Query(ctx, `
+		SELECT id, short_id, school_id, student_id, plan, extra_days, starts_on, ends_on, notes,
+		       created_by, created_at, updated_at
+		FROM student_accommodations
+		WHERE student_id = $1 AND school_id = $2
+		ORDER BY starts_on NULLS FIRST, created_at
+	`, studentID, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	accommodations := []models.Accommodation{}
+	for rows.Next() {
+		var a models.Accommodation
+		if err := rows.Scan(&a.ID, &a.ShortID, &a.SchoolID, &a.StudentID, &a.Plan, &a.ExtraDays, &a.StartsOn,
+			&a.EndsOn, &a.Notes, &a.CreatedBy, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		accommodations = append(accommodations, a)
+	}
+	return accommodations, rows.Err()
+}
+
+// GrantExtension gives a student a later due date on one assignment
+// (due_date or extra_days) or extra days on every assignment in a course,
+// replacing any earlier extension with the same scope. Teachers may extend
+// only in their own courses.
+// studentId URL param is a short_id; body assignment_id and course_id are short_ids.
+func (h *ExtensionsHandler) GrantExtension(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		AssignmentID string  `json:"assignment_id"`
+		CourseID     string  `json:"course_id"`
+		DueDate      *string `json:"due_date"` // RFC3339; assignment extensions only
+		ExtraDays    *int    `json:"extra_days" validate:"omitempty,min=1,max=365"`
+		Reason       string  `json:"reason" validate:"max=500"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if (req.AssignmentID == "") == (req.CourseID == "") {
+		writeError(w, http.StatusBadRequest, "validation_error", "give exactly one of assignment_id or course_id")
+		return
+	}
+	if (req.DueDate == nil) == (req.ExtraDays == nil) {
+		writeError(w, http.StatusBadRequest, "validation_error", "give exactly one of due_date or extra_days")
+		return
+	}
+	if req.DueDate != nil && req.CourseID != "" {
+		writeError(w, http.StatusBadRequest, "validation_error", "course extensions take extra_days, not due_date")
+		return
+	}
+	var dueDate *time.Time
+	if req.DueDate != nil {
+		t, err := time.Parse(time.RFC3339, *req.DueDate)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_due_date", "due_date must be RFC3339 format")
+			return
+		}
+		dueDate = &t
+	}
+
+	studentUUID, err := resolveStudentUUID(ctx, h.db, chi.URLParam(r, "studentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "student not found")
+		return
+	}
+
+	var assignmentUUID, courseUUID *uuid.UUID
+	var ownerCourse uuid.UUID
+	if req.AssignmentID != "" {
+		a, err := loadAssignment(ctx, h.db, req.AssignmentID, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "not_found", "assignment not found")
+			return
+		}
+		assignmentUUID, ownerCourse = &a.ID, a.CourseID
+	} else {
+		id, err := resolveCourseUUID(ctx, h.db, req.CourseID, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "not_found", "course not found")
+			return
+		}
+		courseUUID, ownerCourse = &id, id
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, ownerCourse, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+	var enrolled bool
+	h.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM enrollments WHERE course_id = $1 AND student_id = $2 AND status = 'active')
+	`, ownerCourse, studentUUID).Scan(&enrolled)
+	if !enrolled {
+		writeError(w, http.StatusBadRequest, "not_enrolled", "student is not enrolled in this course")
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Replace an extension with the same scope.
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM due_date_extensions
+		WHERE student_id = $1 AND school_id = $2
+		  AND (assignment_id = $3 OR course_id = $4)
+	`, studentUUID, claims.SchoolID, assignmentUUID, courseUUID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	var x models.DueDateExtension
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO due_date_extensions
+			(school_id, student_id, assignment_id, course_id, due_date, extra_days, reason, granted_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, short_id, created_at, updated_at
+	`, claims.SchoolID, studentUUID, assignmentUUID, courseUUID, dueDate, req.ExtraDays,
+		nullStr(req.Reason), claims.UserID).Scan(&x.ID, &x.ShortID, &x.CreatedAt, &x.UpdatedAt); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	x.SchoolID, x.StudentID, x.AssignmentID, x.CourseID = claims.SchoolID, studentUUID, assignmentUUID, courseUUID
+	x.DueDate, x.ExtraDays, x.GrantedBy = dueDate, req.ExtraDays, &claims.UserID
+	if req.Reason != "" {
+		x.Reason = &req.Reason
+	}
+
+	reflagged, err := reflagStudentWork(ctx, tx, claims.SchoolID, &claims.UserID, studentUUID, courseUUID, assignmentUUID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "extension.create",
+		EntityType: "due_date_extension",
+		EntityID:   &x.ID,
+		NewValue:   x,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"extension":        x,
+		"grades_reflagged": reflagged,
+	})
+}
+
+// RevokeExtension removes a due date extension. Teachers may revoke only
+// extensions in their own courses.
+// extensionId URL param is a short_id.
+func (h *ExtensionsHandler) RevokeExtension(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var x models.DueDateExtension
+	var ownerCourse uuid.UUID
+	if err := tx.QueryRow(ctx, `
+		DELETE FROM due_date_extensions x
+		USING courses c
+		WHERE x.short_id = $1 AND x.school_id = $2
+		  AND c.id = COALESCE(x.course_id, (SELECT course_id FROM assignments WHERE id = x.assignment_id))
+		RETURNING x.id, x.student_id, x.assignment_id, x.course_id, x.due_date, x.extra_days, x.reason, c.id
+	`, chi.URLParam(r, "extensionId"), claims.SchoolID).Scan(
+		&x.ID, &x.StudentID, &x.AssignmentID, &x.CourseID, &x.DueDate, &x.ExtraDays, &x.Reason, &ownerCourse,
+	); err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "extension not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, ownerCourse, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	reflagged, err := reflagStudentWork(ctx, tx, claims.SchoolID, &claims.UserID, x.StudentID, x.CourseID, x.AssignmentID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "extension.delete",
+		EntityType: "due_date_extension",
+		EntityID:   &x.ID,
+		OldValue:   x,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"grades_reflagged": reflagged})
+}
+
+// accommodationRequest is the body of accommodation create and update.
+type accommodationRequest struct {
+	Plan      string `json:"plan" validate:"required,oneof=iep 504 other"`
+	ExtraDays int    `json:"extra_days" validate:"required,min=1,max=365"`
+	StartsOn  string `json:"starts_on"` // YYYY-MM-DD; open-ended if empty
+	EndsOn    string `json:"ends_on"`   // YYYY-MM-DD; open-ended if empty
+	Notes     string `json:"notes" validate:"max=2000"`
+}
+
+// decodeAccommodation reads and validates an accommodationRequest, writing
+// the error response itself.
+func decodeAccommodation(w http.ResponseWriter, r *http.Request) (accommodationRequest, *time.Time, *time.Time, bool) {
+	var req accommodationRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return req, nil, nil, false
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return req, nil, nil, false
+	}
+	startsOn, err := parseDateParam(req.StartsOn)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "starts_on must be YYYY-MM-DD")
+		return req, nil, nil, false
+	}
+	endsOn, err := parseDateParam(req.EndsOn)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "ends_on must be YYYY-MM-DD")
+		return req, nil, nil, false
+	}
+	if startsOn != nil && endsOn != nil && endsOn.Before(*startsOn) {
+		writeError(w, http.StatusBadRequest, "invalid_date", "ends_on is before starts_on")
+		return req, nil, nil, false
+	}
+	return req, startsOn, endsOn, true
+}
+
+// CreateAccommodation records a standing accommodation for a student (admin only).
+// studentId URL param is a short_id.
+func (h *ExtensionsHandler) CreateAccommodation(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	req, startsOn, endsOn, ok := decodeAccommodation(w, r)
+	if !ok {
+		return
+	}
+	studentUUID, err := resolveStudentUUID(ctx, h.db, chi.URLParam(r, "studentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "student not found")
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var id uuid.UUID
+	var shortID string
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO student_accommodations
+			(school_id, student_id, plan, extra_days, starts_on, ends_on, notes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, short_id
+	`, claims.SchoolID, studentUUID, req.Plan, req.ExtraDays, startsOn, endsOn,
+		nullStr(req.Notes), claims.UserID).Scan(&id, &shortID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	reflagged, err := reflagStudentWork(ctx, tx, claims.SchoolID, &claims.UserID, studentUUID, nil, nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "accommodation.create",
+		EntityType: "student_accommodation",
+		EntityID:   &id,
+		NewValue:   map[string]interface{}{"student_id": studentUUID, "plan": req.Plan, "extra_days": req.ExtraDays, "starts_on": startsOn, "ends_on": endsOn},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"accommodation_id": shortID,
+		"grades_reflagged": reflagged,
+	})
+}
+
+// UpdateAccommodation replaces an accommodation's rule (admin only).
+// accommodationId URL param is a short_id.
+func (h *ExtensionsHandler) UpdateAccommodation(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	req, startsOn, endsOn, ok := decodeAccommodation(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var old models.Accommodation
+	if err := tx.QueryRow(ctx, `
+		SELECT id, student_id, plan, extra_days, starts_on, ends_on
+		FROM student_accommodations WHERE short_id = $1 AND school_id = $2
+		FOR UPDATE
+	`, chi.URLParam(r, "accommodationId"), claims.SchoolID).Scan(
+		&old.ID, &old.StudentID, &old.Plan, &old.ExtraDays, &old.StartsOn, &old.EndsOn,
+	); err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "accommodation not found")
+		return
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE student_accommodations
+		SET plan = $1, extra_days = $2, starts_on = $3, ends_on = $4, notes = $5
+		WHERE id = $6
+	`, req.Plan, req.ExtraDays, startsOn, endsOn, nullStr(req.Notes), old.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	reflagged, err := reflagStudentWork(ctx, tx, claims.SchoolID, &claims.UserID, old.StudentID, nil, nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "accommodation.update",
+		EntityType: "student_accommodation",
+		EntityID:   &old.ID,
+		OldValue:   map[string]interface{}{"plan": old.Plan, "extra_days": old.ExtraDays, "starts_on": old.StartsOn, "ends_on": old.EndsOn},
+		NewValue:   map[string]interface{}{"plan": req.Plan, "extra_days": req.ExtraDays, "starts_on": startsOn, "ends_on": endsOn},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"grades_reflagged": reflagged})
+}
+
+// DeleteAccommodation removes an accommodation (admin only).
+// accommodationId URL param is a short_id.
+func (h *ExtensionsHandler) DeleteAccommodation(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var old models.Accommodation
+	if err := tx.QueryRow(ctx, `
+		DELETE FROM student_accommodations WHERE short_id = $1 AND school_id = $2
+		RETURNING id, student_id, plan, extra_days, starts_on, ends_on
+	`, chi.URLParam(r, "accommodationId"), claims.SchoolID).Scan(
+		&old.ID, &old.StudentID, &old.Plan, &old.ExtraDays, &old.StartsOn, &old.EndsOn,
+	); err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "accommodation not found")
+		return
+	}
+	reflagged, err := reflagStudentWork(ctx, tx, claims.SchoolID, &claims.UserID, old.StudentID, nil, nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "accommodation.delete",
+		EntityType: "student_accommodation",
+		EntityID:   &old.ID,
+		OldValue:   map[string]interface{}{"student_id": old.StudentID, "plan": old.Plan, "extra_days": old.ExtraDays, "starts_on": old.StartsOn, "ends_on": old.EndsOn},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"grades_reflagged": reflagged})
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
+)
+
+// Term GPAs are stored whenever a term's GPA is computed (report cards, or
+// an explicit record request). Cumulative GPA combines the stored terms of
+// the same type, so quarters and the semesters containing them are never
+// counted together.
+
+// loadTermGPAs returns a student's stored term GPAs, oldest term first.
+func loadTermGPAs(ctx context.Context, db *pgxpool.Pool, studentID, schoolID uuid.UUID) ([]models.StudentTermGPA, error) {
+	rows, err := db.Query(ctx, `
+		SELECT g.id, g.student_id, g.school_id, g.term_id, t.short_id, t.name, t.term_type, t.end_date,
+		       g.method, g.gpa, g.unweighted_gpa, g.weighted_gpa, g.credits_attempted, g.credits_earned,
+		       g.quality_points, g.weighted_quality_points,
+		       g.cumulative_gpa, g.cumulative_unweighted_gpa, g.cumulative_weighted_gpa,
+		       g.cumulative_credits_attempted, g.cumulative_credits_earned,
+		       g.computed_at
+		FROM student_term_gpas g
+		JOIN terms t ON t.id = g.term_id
+		WHERE g.student_id = $1 AND g.school_id = $2
+		ORDER BY t.end_date, t.start_date DESC
+	`, studentID, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.StudentTermGPA
+	for rows.Next() {
+		var g models.StudentTermGPA
+		if err := rows.Scan(&g.ID, &g.StudentID, &g.SchoolID, &g.TermID, &g.TermShortID, &g.TermName, &g.TermType, &g.TermEndDate,
+			&g.Method, &g.GPA, &g.Unweighted, &g.Weighted, &g.CreditsAttempted, &g.CreditsEarned,
+			&g.QualityPoints, &g.WeightedQualityPoints,
+			&g.Cumulative.GPA, &g.Cumulative.Unweighted, &g.Cumulative.Weighted,
+			&g.Cumulative.CreditsAttempted, &g.Cumulative.CreditsEarned,
+			&g.ComputedAt); err != nil {
+			return nil, err
+		}
+		g.Cumulative.Method = g.Method
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+// latestCumulativeGPA returns the cumulative GPA through the most recent
+// stored term, or nil when none is stored.
+func latestCumulativeGPA(history []models.StudentTermGPA) *models.GPAResult {
+	if len(history) == 0 {
+		return nil
+	}
+	c := history[len(history)-1].Cumulative
+	return &c
+}
+
+// recordTermGPA stores a student's GPA for a term, then refreshes the
+// cumulative GPA of that term and every later term of the same type.
+func recordTermGPA(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	grading *services.GradingService,
+	studentID, schoolID uuid.UUID,
+	term *models.Term,
+	gpa models.GPAResult,
+) (*models.StudentTermGPA, error) {
+	_, err := db.Exec(ctx, `
+		INSERT INTO student_term_gpas
+			(student_id, school_id, term_id, method, gpa, unweighted_gpa, weighted_gpa,
+			 credits_attempted, credits_earned, quality_points, weighted_quality_points,
+			 cumulative_gpa, cumulative_unweighted_gpa, cumulative_weighted_gpa,
+			 cumulative_credits_attempted, cumulative_credits_earned)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $5, $6, $7, $8, $9)
+		ON CONFLICT (student_id, term_id)
+		DO UPDATE SET
+			method                  = EXCLUDED.method,
+			gpa                     = EXCLUDED.gpa,
+			unweighted_gpa          = EXCLUDED.unweighted_gpa,
+			weighted_gpa            = EXCLUDED.weighted_gpa,
+			credits_attempted       = EXCLUDED.credits_attempted,
+			credits_earned          = EXCLUDED.credits_earned,
+			quality_points          = EXCLUDED.quality_points,
+			weighted_quality_points = EXCLUDED.weighted_quality_points,
+			computed_at             = NOW()
+	`, studentID, schoolID, term.ID, gpa.Method, gpa.GPA, gpa.Unweighted, gpa.Weighted,
+		gpa.CreditsAttempted, gpa.CreditsEarned, gpa.QualityPoints, gpa.WeightedQualityPoints)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := loadTermGPAs(ctx, db, studentID, schoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	var record *models.StudentTermGPA
+	var through []models.GPAResult
+	for i := range history {
+		g := &history[i]
+		if g.TermType != term.TermType {
+			continue
+		}
+		through = append(through, g.GPAResult)
+		if g.TermEndDate.Before(term.EndDate) {
+			continue
+		}
+		g.Cumulative = grading.CumulativeGPA(through, gpa.Method)
+		if _, err := db.Exec(ctx, `
+			UPDATE student_term_gpas
+			SET cumulative_gpa = $1, cumulative_unweighted_gpa = $2, cumulative_weighted_gpa = $3,
+			    cumulative_credits_attempted = $4, cumulative_credits_earned = $5
+			WHERE id = $6
+		`, g.Cumulative.GPA, g.Cumulative.Unweighted, g.Cumulative.Weighted,
+			g.Cumulative.CreditsAttempted, g.Cumulative.CreditsEarned, g.ID); err != nil {
+			return nil, err
+		}
+		if g.TermID == term.ID {
+			record = g
+		}
+	}
+	return record, nil
+}
+
+// GetStudentGPA returns a student's current GPA, their stored term GPAs, and
+// the cumulative GPA through the latest stored term. Grade lock applies to
+// students and parents.
+// studentId URL param is a short_id.
+func (h *GradesHandler) GetStudentGPA(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	studentID, err := resolveStudentUUID(ctx, h.db, chi.URLParam(r, "studentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "student not found")
+		return
+	}
+	if !canViewStudent(ctx, h.db, claims, studentID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you cannot view this student's grades")
+		return
+	}
+	if claims.Role == models.RoleStudent || claims.Role == models.RoleParent {
+		var isLocked bool
+		h.db.QueryRow(ctx, `
+			SELECT is_grade_locked FROM students WHERE id = $1 AND school_id = $2
+		`, studentID, claims.SchoolID).Scan(&isLocked)
+		if isLocked {
+			writeError(w, http.StatusForbidden, "grade_locked",
+				"Your grade access has been temporarily restricted. Please contact your school administration.")
+			return
+		}
+	}
+
+	school, err := loadSchool(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	courses, err := computeStudentCourseGrades(ctx, h.db, h.grading, school.Settings, studentID, claims.SchoolID, nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	history, err := loadTermGPAs(ctx, h.db, studentID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if history == nil {
+		history = []models.StudentTermGPA{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"current":    gpaFromCourseGrades(h.grading, courses, school.Settings),
+		"terms":      history,
+		"cumulative": latestCumulativeGPA(history),
+	})
+}
+
+// RecordStudentGPA computes and stores a student's GPA for a term, updating
+// cumulative GPAs from that term on (admin only). Report card generation
+// does the same automatically.
+// studentId URL param is a short_id; term_id in the body is a short_id.
+func (h *GradesHandler) RecordStudentGPA(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		TermID string `json:"term_id" validate:"required"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	studentID, err := resolveStudentUUID(ctx, h.db, chi.URLParam(r, "studentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "student not found")
+		return
+	}
+	term, err := resolveTerm(ctx, h.db, req.TermID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "term not found")
+		return
+	}
+
+	school, err := loadSchool(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	courses, err := computeStudentCourseGrades(ctx, h.db, h.grading, school.Settings, studentID, claims.SchoolID, term)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	record, err := recordTermGPA(ctx, h.db, h.grading, studentID, claims.SchoolID, term,
+		gpaFromCourseGrades(h.grading, courses, school.Settings))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "gpa.record",
+		EntityType: "student",
+		EntityID:   &studentID,
+		NewValue:   record,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"term_gpa": record})
+}
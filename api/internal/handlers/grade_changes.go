@@ -0,0 +1,555 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
+)
+
+// A grade is final once the term its assignment falls in is closed, or once a
+// report card covering it is finalized. Assignments are placed in terms by
+// due date (creation date when undated), as in report card calculations;
+// a report card without a term covers assignments dated on or before the day
+// it was generated. Changes to final grades are filed as grade change
+// requests, and approving one regenerates every report card that was final
+// for the grade.
+
+// finalGradeCards selects the current report cards a grade is final for,
+// given the assignment's date as a.at. $2 is the student and $3 the school.
+const finalGradeCards = `
+	SELECT rc.id
+	FROM report_cards rc
+	LEFT JOIN terms t ON t.id = rc.term_id, a
+	WHERE rc.student_id = $2 AND rc.school_id = $3 AND rc.superseded_by IS NULL
+	  AND ((t.id IS NOT NULL AND a.at BETWEEN t.start_date AND t.end_date)
+	       OR (rc.term_id IS NULL AND a.at <= (rc.generated_at AT TIME ZONE 'UTC')::date))
+	  AND (rc.is_finalized OR t.is_closed)`
+
+const gradeAssignmentDate = `
+	WITH a AS (
+		SELECT (COALESCE(due_date, created_at) AT TIME ZONE 'UTC')::date AS at
+		FROM assignments WHERE id = $1 AND school_id = $3
+	)`
+
+// gradeKey identifies one student's grade on one assignment.
+type gradeKey struct {
+	AssignmentID uuid.UUID
+	StudentID    uuid.UUID
+}
+
+// gradeLockReasons explains why each of the given grades is final. Grades
+// that can still be changed directly are absent from the result.
+//
+// It share-locks the school's terms and the students' current report cards
+// first, so when called in a write transaction no term can close and no card
+// be finalized or superseded until that transaction ends.
+func gradeLockReasons(ctx context.Context, db dbtx, keys []gradeKey, schoolID uuid.UUID) (map[gradeKey]string, error) {
+	assignmentIDs := make([]uuid.UUID, len(keys))
+	studentIDs := make([]uuid.UUID, len(keys))
+	for i, k := range keys {
+		assignmentIDs[i], studentIDs[i] = k.AssignmentID, k.StudentID
+	}
+
+	if _, err := db.Exec(ctx, `SELECT 1 FROM terms WHERE school_id = $1 FOR SHARE`, schoolID); err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(ctx, `
+		SELECT 1 FROM report_cards
+		WHERE school_id = $1 AND student_id = ANY($2) AND superseded_by IS NULL
+		FOR SHARE
+	`, schoolID, studentIDs); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(ctx, `
+		WITH a AS (
+			SELECT k.assignment_id, k.student_id,
+			       (COALESCE(x.due_date, x.created_at) AT TIME ZONE 'UTC')::date AS at
+			FROM unnest($1::UUID[], $2::UUID[]) AS k(assignment_id, student_id)
+			JOIN assignments x ON x.id = k.assignment_id AND x.school_id = $3
+		)
+		SELECT DISTINCT ON (assignment_id, student_id) assignment_id, student_id, reason
+		FROM (
+			SELECT a.assignment_id, a.student_id, t.name || ' is closed' AS reason, 1 AS priority
+			FROM a
+			JOIN terms t ON t.school_id = $3 AND t.is_closed AND a.at BETWEEN t.start_date AND t.end_date
+			UNION ALL
+			SELECT a.assignment_id, a.student_id, 'the ' || rc.academic_period || ' report card is finalized', 2
+			FROM a
+			JOIN report_cards rc ON rc.student_id = a.student_id AND rc.school_id = $3
+			                    AND rc.is_finalized AND rc.superseded_by IS NULL
+			LEFT JOIN terms t ON t.id = rc.term_id
+			WHERE (t.id IS NOT NULL AND a.at BETWEEN t.start_date AND t.end_date)
+			   OR (rc.term_id IS NULL AND a.at <= (rc.generated_at AT TIME ZONE 'UTC')::date)
+		) locks
+		ORDER BY assignment_id, student_id, priority, reason
+	`, assignmentIDs, studentIDs, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[gradeKey]string)
+	for rows.Next() {
+		var k gradeKey
+		var reason string
+		if err := rows.Scan(&k.AssignmentID, &k.StudentID, &reason); err != nil {
+			return nil, err
+		}
+		out[k] = reason
+	}
+	return out, rows.Err()
+}
+
+// gradeLockReason explains why a student's grade on an assignment is final,
+// or returns "" when it can still be changed directly.
+func gradeLockReason(ctx context.Context, db dbtx, assignmentID, studentID, schoolID uuid.UUID) (string, error) {
+	key := gradeKey{AssignmentID: assignmentID, StudentID: studentID}
+	reasons, err := gradeLockReasons(ctx, db, []gradeKey{key}, schoolID)
+	if err != nil {
+		return "", err
+	}
+	return reasons[key], nil
+}
+
+// finalReportCardIDs returns the report cards to regenerate when a final
+// grade changes.
+func finalReportCardIDs(ctx context.Context, db dbtx, assignmentID, studentID, schoolID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := db.Query(ctx, gradeAssignmentDate+finalGradeCards, assignmentID, studentID, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// createGradeChangeRequest files a pending request to make the write in to a
+// final grade.
+func createGradeChangeRequest(
+	ctx context.Context,
+	db dbtx,
+	schoolID, courseID, userID uuid.UUID,
+	in gradeWrite,
+	lockedBy string,
+) (*models.GradeChangeRequest, error) {
+	req := &models.GradeChangeRequest{
+		SchoolID: schoolID, AssignmentID: in.AssignmentID, StudentID: in.StudentID,
+		CourseID: courseID, RequestedBy: userID, PointsEarned: in.PointsEarned,
+		IsExcused: in.IsExcused, IsMissing: in.IsMissing, IsLate: in.IsLate, DaysLate: in.DaysLate,
+		LockedBy: lockedBy,
+	}
+	if in.Comment != "" {
+		req.Comment = &in.Comment
+	}
+	if in.Reason != "" {
+		req.Reason = &in.Reason
+	}
+	err := db.QueryRow(ctx, `
+		INSERT INTO grade_change_requests
+			(school_id, assignment_id, student_id, course_id, requested_by,
+			 points_earned, comment, is_excused, is_missing, is_late, days_late, reason, locked_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, short_id, status, created_at
+	`, schoolID, in.AssignmentID, in.StudentID, courseID, userID,
+		in.PointsEarned, req.Comment, in.IsExcused, in.IsMissing, in.IsLate, in.DaysLate, req.Reason, lockedBy,
+	).Scan(&req.ID, &req.ShortID, &req.Status, &req.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+const gradeChangeRequestSelect = `
+	SELECT r.id, r.short_id, r.school_id, r.assignment_id, r.student_id, r.course_id, r.requested_by,
+	       r.points_earned, r.comment, r.is_excused, r.is_missing, r.is_late, r.days_late,
+	       r.reason, r.locked_by, r.status, r.reviewed_by, r.reviewed_at, r.review_note, r.created_at,
+	       r.rubric_scores, r.standard_scores, r.pending_report_cards, r.regeneration_error,
+	       c.short_id, a.title, su.first_name || ' ' || su.last_name, ru.first_name || ' ' || ru.last_name
+	FROM grade_change_requests r
+	JOIN courses c ON c.id = r.course_id
+	JOIN assignments a ON a.id = r.assignment_id
+	JOIN students s ON s.id = r.student_id
+	JOIN users su ON su.id = s.user_id
+	JOIN users ru ON ru.id = r.requested_by`
+
+func scanGradeChangeRequest(row pgx.Row) (*models.GradeChangeRequest, error) {
+	var g models.GradeChangeRequest
+	err := row.Scan(&g.ID, &g.ShortID, &g.SchoolID, &g.AssignmentID, &g.StudentID, &g.CourseID, &g.RequestedBy,
+		&g.PointsEarned, &g.Comment, &g.IsExcused, &g.IsMissing, &g.IsLate, &g.DaysLate,
+		&g.Reason, &g.LockedBy, &g.Status, &g.ReviewedBy, &g.ReviewedAt, &g.ReviewNote, &g.CreatedAt,
+		&g.RubricScores, &g.StandardScores, &g.PendingReportCards, &g.RegenerationError,
+		&g.CourseShortID, &g.AssignmentTitle, &g.StudentName, &g.RequestedByName)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// GradeChangesHandler lists and reviews grade change requests.
+type GradeChangesHandler struct {
+	db      *pgxpool.Pool
+	reports *ReportsHandler // regenerates report cards on approval
+}
+
+// NewGradeChangesHandler creates a GradeChangesHandler.
+func NewGradeChangesHandler(db *pgxpool.Pool, reports *ReportsHandler) *GradeChangesHandler {
+	return &GradeChangesHandler{db: db, reports: reports}
+}
+
+// ListGradeChangeRequests returns grade change requests, newest first,
+// filtered by ?status= (default pending). Teachers see their own requests;
+// admins see the whole school's.
+func (h *GradeChangesHandler) ListGradeChangeRequests(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.GradeChangePending
+	}
+	if status != models.GradeChangePending && status != models.GradeChangeApproved && status != models.GradeChangeRejected {
+		writeError(w, http.StatusBadRequest, "invalid_param", "status must be pending, approved, or rejected")
+		return
+	}
+	limit, offset := paginate(r)
+
+	var requestedBy *uuid.UUID
+	if claims.Role == models.RoleTeacher {
+		requestedBy = &claims.UserID
+	}
+
+	rows, err := h.db.Query(ctx, gradeChangeRequestSelect+`
+		WHERE r.school_id = $1 AND r.status = $2 AND ($3::UUID IS NULL OR r.requested_by = $3)
+		ORDER BY r.created_at DESC
+		LIMIT $4 OFFSET $5
+	`, claims.SchoolID, status, requestedBy, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	requests := []*models.GradeChangeRequest{}
+	for rows.Next() {
+		g, err := scanGradeChangeRequest(rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		requests = append(requests, g)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"requests": requests})
+}
+
+type reviewGradeChangeRequest struct {
+	Note string `json:"note" validate:"max=1000"`
+}
+
+func decodeReview(w http.ResponseWriter, r *http.Request) (*reviewGradeChangeRequest, bool) {
+	var req reviewGradeChangeRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return nil, false
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return nil, false
+	}
+	return &req, true
+}
+
+// ApproveGradeChangeRequest writes the requested grade and any rubric scores
+// filed with it, or the standard scores for a standards request, records the
+// change in history and the audit log, and regenerates the report cards that
+// were final for the grade (admin only).
+// requestId URL param is a short_id.
+func (h *GradeChangesHandler) ApproveGradeChangeRequest(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	review, ok := decodeReview(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := scanGradeChangeRequest(h.db.QueryRow(ctx, gradeChangeRequestSelect+`
+		WHERE r.short_id = $1 AND r.school_id = $2
+	`, chi.URLParam(r, "requestId"), claims.SchoolID))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "grade change request not found")
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE grade_change_requests
+		SET status = 'approved', reviewed_by = $1, reviewed_at = NOW(), review_note = $2
+		WHERE id = $3 AND status = 'pending'
+	`, claims.UserID, nullStr(review.Note), req.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		writeError(w, http.StatusConflict, "not_pending", "this request has already been reviewed")
+		return
+	}
+
+	in := gradeWrite{
+		AssignmentID: req.AssignmentID,
+		StudentID:    req.StudentID,
+		PointsEarned: req.PointsEarned,
+		IsExcused:    req.IsExcused,
+		IsMissing:    req.IsMissing,
+		IsLate:       req.IsLate,
+		DaysLate:     req.DaysLate,
+		Reason:       fmt.Sprintf("Approved change request %s from %s", req.ShortID, req.RequestedByName),
+	}
+	if req.Comment != nil {
+		in.Comment = *req.Comment
+	}
+	if req.Reason != nil {
+		in.Reason += ": " + *req.Reason
+	}
+
+	// A request holding standard scores changes only those scores.
+	var gradeID uuid.UUID
+	var oldGrade *models.Grade
+	if len(req.StandardScores) > 0 {
+		err = restoreStandardScores(ctx, tx, claims.SchoolID, req, in.Reason)
+	} else {
+		gradeID, oldGrade, err = writeGrade(ctx, tx, claims.SchoolID, claims.UserID, in)
+		if err == nil && len(req.RubricScores) > 0 {
+			err = restoreRubricScores(ctx, tx, claims.SchoolID, req)
+		}
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	// Record the cards the grade was final for with the approval, so they are
+	// regenerated even if the attempt below fails.
+	cardIDs, err := finalReportCardIDs(ctx, tx, req.AssignmentID, req.StudentID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if len(cardIDs) > 0 {
+		if _, err := tx.Exec(ctx, `
+			UPDATE grade_change_requests SET pending_report_cards = $1, updated_at = NOW() WHERE id = $2
+		`, cardIDs, req.ID); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	regenerated, pending, regenErr := h.regenerateCards(ctx, claims.SchoolID, req.ID, claims.UserID)
+
+	entityType, entityID := "grade", gradeID
+	if len(req.StandardScores) > 0 {
+		entityType, entityID = "assignment", req.AssignmentID
+	}
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "grade_change.approve",
+		EntityType: entityType,
+		EntityID:   &entityID,
+		OldValue:   oldGrade,
+		NewValue: map[string]interface{}{
+			"request":      req,
+			"report_cards": regenerated,
+		},
+		IPAddress: r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+	})
+
+	// The approval stands even when regeneration fails; the remaining cards
+	// stay on the request for RegenerateGradeChangeReportCards to retry.
+	resp := map[string]interface{}{"report_cards": regenerated}
+	if len(req.StandardScores) > 0 {
+		resp["standard_scores"] = req.StandardScores
+	} else {
+		resp["grade_id"] = gradeID
+	}
+	if regenErr != nil {
+		resp["pending_report_cards"] = pending
+		resp["regeneration_error"] = regenErr.Error()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// RegenerateGradeChangeReportCards retries regenerating the report cards an
+// approved grade change request left pending (admin only).
+// requestId URL param is a short_id.
+func (h *GradeChangesHandler) RegenerateGradeChangeReportCards(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var id uuid.UUID
+	var status string
+	err := h.db.QueryRow(ctx, `
+		SELECT id, status FROM grade_change_requests WHERE short_id = $1 AND school_id = $2
+	`, chi.URLParam(r, "requestId"), claims.SchoolID).Scan(&id, &status)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "grade change request not found")
+		return
+	}
+	if status != models.GradeChangeApproved {
+		writeError(w, http.StatusConflict, "not_approved", "only approved requests regenerate report cards")
+		return
+	}
+
+	regenerated, pending, err := h.regenerateCards(ctx, claims.SchoolID, id, claims.UserID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"error":                "report_error",
+			"message":              "regenerating report cards failed: " + err.Error(),
+			"report_cards":         regenerated,
+			"pending_report_cards": pending,
+		})
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "grade_change.regenerate",
+		EntityType: "grade_change_request",
+		EntityID:   &id,
+		NewValue:   map[string]interface{}{"report_cards": regenerated},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"report_cards": regenerated})
+}
+
+// regenerateCards regenerates the report cards still pending on an approved
+// grade change request, removing each from the request once its replacement
+// is saved. A card another attempt already replaced counts as done. It stops
+// at the first failure, recording the error on the request, and returns the
+// new cards and the ones still pending.
+func (h *GradeChangesHandler) regenerateCards(ctx context.Context, schoolID, requestID, userID uuid.UUID) ([]uuid.UUID, []uuid.UUID, error) {
+	var pending []uuid.UUID
+	if err := h.db.QueryRow(ctx, `
+		SELECT pending_report_cards FROM grade_change_requests WHERE id = $1 AND school_id = $2
+	`, requestID, schoolID).Scan(&pending); err != nil {
+		return nil, pending, err
+	}
+	if len(pending) == 0 {
+		return nil, nil, nil
+	}
+	school, err := loadSchool(ctx, h.db, schoolID)
+	if err != nil {
+		return nil, pending, err
+	}
+
+	var regenerated []uuid.UUID
+	for len(pending) > 0 {
+		cardID := pending[0]
+		rc, err := h.reports.regenerateReportCard(ctx, school, cardID, userID)
+		if err != nil && !errors.Is(err, errReportCardSuperseded) {
+			_, _ = h.db.Exec(ctx, `
+				UPDATE grade_change_requests SET regeneration_error = $1, updated_at = NOW() WHERE id = $2
+			`, err.Error(), requestID)
+			return regenerated, pending, err
+		}
+		if _, err := h.db.Exec(ctx, `
+			UPDATE grade_change_requests
+			SET pending_report_cards = array_remove(pending_report_cards, $1), regeneration_error = NULL, updated_at = NOW()
+			WHERE id = $2
+		`, cardID, requestID); err != nil {
+			return regenerated, pending, err
+		}
+		if rc != nil {
+			regenerated = append(regenerated, rc.ID)
+		}
+		pending = pending[1:]
+	}
+	return regenerated, nil, nil
+}
+
+// RejectGradeChangeRequest declines a pending grade change request, leaving
+// the grade unchanged (admin only).
+// requestId URL param is a short_id.
+func (h *GradeChangesHandler) RejectGradeChangeRequest(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	review, ok := decodeReview(w, r)
+	if !ok {
+		return
+	}
+
+	var id uuid.UUID
+	var status string
+	err := h.db.QueryRow(ctx, `
+		SELECT id, status FROM grade_change_requests WHERE short_id = $1 AND school_id = $2
+	`, chi.URLParam(r, "requestId"), claims.SchoolID).Scan(&id, &status)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "grade change request not found")
+		return
+	}
+
+	tag, err := h.db.Exec(ctx, `
+		UPDATE grade_change_requests
+		SET status = 'rejected', reviewed_by = $1, reviewed_at = NOW(), review_note = $2
+		WHERE id = $3 AND status = 'pending'
+	`, claims.UserID, nullStr(review.Note), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		writeError(w, http.StatusConflict, "not_pending", "this request has already been reviewed")
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "grade_change.reject",
+		EntityType: "grade_change_request",
+		EntityID:   &id,
+		OldValue:   map[string]string{"status": status},
+		NewValue:   map[string]string{"status": models.GradeChangeRejected, "note": review.Note},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
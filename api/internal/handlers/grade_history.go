@@ -0,0 +1,427 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/models"
+)
+
+// Every write to grades goes through writeGrade, which appends the new
+// version to grade_history in the same transaction. History rows are never
+// updated or deleted, so a course gradebook can be rebuilt as of any moment
+// by taking each grade's latest version at that time.
+
+// gradeWrite is one grade to create or update.
+type gradeWrite struct {
+	AssignmentID uuid.UUID
+	StudentID    uuid.UUID
+	PointsEarned *float64
+	Comment      string
+	IsExcused    bool
+	IsMissing    bool
+	IsLate       bool
+	DaysLate     *int
+	AIAccepted   *bool  // nil keeps the stored value
+	Reason       string // optional; recorded in grade_history only
+}
+
+// writeGrade upserts a grade and records the change in grade_history. It
+// returns the grade ID and the previous values (nil for a new grade). Saving
+// identical values adds no history entry. q should be a transaction so the
+// grade and its history are written together.
+func writeGrade(ctx context.Context, q dbtx, schoolID, userID uuid.UUID, in gradeWrite) (uuid.UUID, *models.Grade, error) {
+	var old *models.Grade
+	var existing models.Grade
+	err := q.QueryRow(ctx, `
+		SELECT id, points_earned, letter_grade, comment,
+		       COALESCE(is_excused, FALSE), COALESCE(is_missing, FALSE), COALESCE(is_late, FALSE), days_late
+		FROM grades WHERE assignment_id = $1 AND student_id = $2 AND school_id = $3
+		FOR UPDATE
+	`, in.AssignmentID, in.StudentID, schoolID).Scan(
+		&existing.ID, &existing.PointsEarned, &existing.LetterGrade,
+		&existing.Comment, &existing.IsExcused, &existing.IsMissing, &existing.IsLate, &existing.DaysLate,
+	)
+	if err == nil {
+		old = &existing
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, nil, err
+	}
+
+	var gradeID uuid.UUID
+	err = q.QueryRow(ctx, `
+		INSERT INTO grades
+			(assignment_id, student_id, school_id, points_earned, comment,
+			 is_excused, is_missing, is_late, days_late, ai_accepted, graded_by, graded_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+		ON CONFLICT (assignment_id, student_id)
+		DO UPDATE SET
+			points_earned = EXCLUDED.points_earned,
+			comment       = EXCLUDED.comment,
+			is_excused    = EXCLUDED.is_excused,
+			is_missing    = EXCLUDED.is_missing,
+			is_late       = EXCLUDED.is_late,
+			days_late     = EXCLUDED.days_late,
+			ai_accepted   = COALESCE(EXCLUDED.ai_accepted, grades.ai_accepted),
+			graded_by     = EXCLUDED.graded_by,
+			graded_at     = NOW(),
+			updated_at    = NOW()
+		RETURNING id
+	`, in.AssignmentID, in.StudentID, schoolID,
+		in.PointsEarned, nullStr(in.Comment),
+		in.IsExcused, in.IsMissing, in.IsLate, in.DaysLate, in.AIAccepted, userID,
+	).Scan(&gradeID)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	if old != nil && gradeUnchanged(old, in) {
+		return gradeID, old, nil
+	}
+
+	var prev models.Grade
+	if old != nil {
+		prev = *old
+	}
+	if err := appendGradeHistory(ctx, q, gradeHistoryEntry{
+		GradeID: gradeID, AssignmentID: in.AssignmentID, StudentID: in.StudentID, SchoolID: schoolID,
+		OldPoints: prev.PointsEarned, NewPoints: in.PointsEarned,
+		OldExcused: prev.IsExcused, NewExcused: in.IsExcused,
+		OldMissing: prev.IsMissing, NewMissing: in.IsMissing,
+		OldLate: prev.IsLate, NewLate: in.IsLate,
+		OldDaysLate: prev.DaysLate, NewDaysLate: in.DaysLate,
+		Comment: nullStr(in.Comment), Reason: nullStr(in.Reason), ChangedBy: &userID,
+	}); err != nil {
+		return uuid.Nil, nil, err
+	}
+	return gradeID, old, nil
+}
+
+// gradeHistoryEntry is one version appended to grade_history.
+type gradeHistoryEntry struct {
+	GradeID, AssignmentID, StudentID, SchoolID uuid.UUID
+	OldPoints, NewPoints                       *float64
+	OldExcused, NewExcused                     bool
+	OldMissing, NewMissing                     bool
+	OldLate, NewLate                           bool
+	OldDaysLate, NewDaysLate                   *int
+	Comment, Reason                            interface{} // string or nil
+	ChangedBy                                  *uuid.UUID
+}
+
+// appendGradeHistory adds the next version of a grade, copying the
+// assignment's course and title so the entry outlives the assignment. If a
+// concurrent write takes the same version number first, it retries with the
+// next one.
+func appendGradeHistory(ctx context.Context, q dbtx, e gradeHistoryEntry) error {
+	for attempt := 0; attempt < 3; attempt++ {
+		tag, err := q.Exec(ctx, `
+			INSERT INTO grade_history
+				(grade_id, assignment_id, course_id, assignment_title, student_id, school_id, version,
+				 old_points_earned, new_points_earned, old_is_excused, new_is_excused,
+				 old_is_missing, new_is_missing, old_is_late, new_is_late,
+				 old_days_late, new_days_late, comment, reason, changed_by)
+			SELECT $1, $2, a.course_id, a.title, $3, $4,
+			       COALESCE((SELECT MAX(version) FROM grade_history WHERE grade_id = $1), 0) + 1,
+			       $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+			FROM assignments a WHERE a.id = $2
+			ON CONFLICT (grade_id, version) DO NOTHING
+		`, e.GradeID, e.AssignmentID, e.StudentID, e.SchoolID,
+			e.OldPoints, e.NewPoints, e.OldExcused, e.NewExcused,
+			e.OldMissing, e.NewMissing, e.OldLate, e.NewLate,
+			e.OldDaysLate, e.NewDaysLate, e.Comment, e.Reason, e.ChangedBy)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 1 {
+			return nil
+		}
+	}
+	return fmt.Errorf("grade history: could not record a new version of grade %s", e.GradeID)
+}
+
+// gradeUnchanged reports whether a write leaves a grade's recorded values as they were.
+func gradeUnchanged(old *models.Grade, in gradeWrite) bool {
+	oldComment := ""
+	if old.Comment != nil {
+		oldComment = *old.Comment
+	}
+	return equalPointsPtr(old.PointsEarned, in.PointsEarned) &&
+		equalIntPtr(old.DaysLate, in.DaysLate) &&
+		old.IsExcused == in.IsExcused && old.IsMissing == in.IsMissing && old.IsLate == in.IsLate &&
+		oldComment == in.Comment
+}
+
+// equalPointsPtr compares points as stored (DECIMAL(8,2)), so 9.999 equals
+// the 10.00 it was saved as.
+func equalPointsPtr(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return math.Round(*a*100) == math.Round(*b*100)
+}
+
+func equalIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// writeGradeFlags sets a grade's late and missing flags from submission
+// tracking, leaving the score and comment alone, and records the change in
+// grade_history like writeGrade. userID is nil for automatic sweeps. A
+// missing grade row is created only when a flag needs setting; excused
+// grades are never touched. It reports whether anything changed.
+func writeGradeFlags(ctx context.Context, q dbtx, schoolID uuid.UUID, userID *uuid.UUID,
+	assignmentID, studentID uuid.UUID, isLate bool, daysLate *int, isMissing bool, reason string) (bool, error) {
+	var old models.Grade
+	exists := true
+	err := q.QueryRow(ctx, `
+		SELECT id, points_earned, comment,
+		       COALESCE(is_excused, FALSE), COALESCE(is_missing, FALSE), COALESCE(is_late, FALSE), days_late
+		FROM grades WHERE assignment_id = $1 AND student_id = $2 AND school_id = $3
+		FOR UPDATE
+	`, assignmentID, studentID, schoolID).Scan(
+		&old.ID, &old.PointsEarned, &old.Comment, &old.IsExcused, &old.IsMissing, &old.IsLate, &old.DaysLate,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		exists = false
+	} else if err != nil {
+		return false, err
+	}
+	if !isLate {
+		daysLate = nil
+	}
+	switch {
+	case old.IsExcused:
+		return false, nil
+	case !exists && !isLate && !isMissing:
+		return false, nil
+	case exists && old.IsLate == isLate && old.IsMissing == isMissing && equalIntPtr(old.DaysLate, daysLate):
+		return false, nil
+	}
+
+	var gradeID uuid.UUID
+	err = q.QueryRow(ctx, `
+		INSERT INTO grades (assignment_id, student_id, school_id, is_missing, is_late, days_late)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (assignment_id, student_id)
+		DO UPDATE SET
+			is_missing = EXCLUDED.is_missing,
+			is_late    = EXCLUDED.is_late,
+			days_late  = EXCLUDED.days_late,
+			updated_at = NOW()
+		RETURNING id
+	`, assignmentID, studentID, schoolID, isMissing, isLate, daysLate).Scan(&gradeID)
+	if err != nil {
+		return false, err
+	}
+
+	err = appendGradeHistory(ctx, q, gradeHistoryEntry{
+		GradeID: gradeID, AssignmentID: assignmentID, StudentID: studentID, SchoolID: schoolID,
+		OldPoints: old.PointsEarned, NewPoints: old.PointsEarned,
+		OldMissing: old.IsMissing, NewMissing: isMissing,
+		OldLate: old.IsLate, NewLate: isLate,
+		OldDaysLate: old.DaysLate, NewDaysLate: daysLate,
+		Comment: old.Comment, Reason: nullStr(reason), ChangedBy: userID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// scanGradeVersions reads grade_history rows selected with gradeVersionColumns.
+func scanGradeVersions(rows pgx.Rows) ([]models.GradeVersion, error) {
+	defer rows.Close()
+	versions := []models.GradeVersion{}
+	for rows.Next() {
+		var v models.GradeVersion
+		if err := rows.Scan(&v.ID, &v.GradeID, &v.AssignmentID, &v.AssignmentTitle, &v.CourseID,
+			&v.StudentID, &v.SchoolID, &v.Version,
+			&v.OldPointsEarned, &v.NewPointsEarned, &v.OldIsExcused, &v.NewIsExcused,
+			&v.OldIsMissing, &v.NewIsMissing, &v.OldIsLate, &v.NewIsLate,
+			&v.OldDaysLate, &v.NewDaysLate, &v.Comment, &v.Reason,
+			&v.ChangedBy, &v.ChangedByName, &v.ChangedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+const gradeVersionColumns = `
+	h.id, h.grade_id, h.assignment_id, h.assignment_title, h.course_id,
+	h.student_id, h.school_id, h.version,
+	h.old_points_earned, h.new_points_earned, h.old_is_excused, h.new_is_excused,
+	h.old_is_missing, h.new_is_missing, h.old_is_late, h.new_is_late,
+	h.old_days_late, h.new_days_late, h.comment, h.reason,
+	h.changed_by, COALESCE(u.first_name || ' ' || u.last_name, ''), h.changed_at`
+
+// GetGradeHistory returns every version of one student's grade on one
+// assignment, oldest first.
+// courseId URL param is a short_id; assignment_id and student_id query
+// params are UUIDs.
+func (h *GradesHandler) GetGradeHistory(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	courseUUID, err := resolveCourseUUID(ctx, h.db, chi.URLParam(r, "courseId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	assignmentID, err := uuid.Parse(r.URL.Query().Get("assignment_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_param", "assignment_id must be a UUID")
+		return
+	}
+	studentID, err := uuid.Parse(r.URL.Query().Get("student_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_param", "student_id must be a UUID")
+		return
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT `+gradeVersionColumns+`
+		FROM grade_history h
+		LEFT JOIN users u ON u.id = h.changed_by
+		WHERE h.assignment_id = $1 AND h.student_id = $2 AND h.course_id = $3 AND h.school_id = $4
+		ORDER BY h.version
+	`, assignmentID, studentID, courseUUID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	history, err := scanGradeVersions(rows)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"history": history})
+}
+
+// GetGradebookAsOf rebuilds a course gradebook as it stood at a past moment:
+// each grade's latest version at that time, and the course grades those
+// versions produce. Course grades use the course's current assignments
+// (excluding any created later) and grade policy; standards-based courses
+// return grades only.
+// courseId URL param is a short_id; the at query param is an RFC 3339
+// timestamp, or a YYYY-MM-DD date meaning the end of that day.
+func (h *GradesHandler) GetGradebookAsOf(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	courseParam := chi.URLParam(r, "courseId")
+	courseUUID, err := resolveCourseUUID(ctx, h.db, courseParam, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	at, err := parseAsOfParam(r.URL.Query().Get("at"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_param", "at must be an RFC 3339 timestamp or YYYY-MM-DD date")
+		return
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT DISTINCT ON (h.assignment_id, h.student_id) `+gradeVersionColumns+`
+		FROM grade_history h
+		LEFT JOIN users u ON u.id = h.changed_by
+		WHERE h.course_id = $1 AND h.school_id = $2 AND h.changed_at <= $3
+		ORDER BY h.assignment_id, h.student_id, h.changed_at DESC, h.version DESC
+	`, courseUUID, claims.SchoolID, at)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	versions, err := scanGradeVersions(rows)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+		return
+	}
+
+	school, err := loadSchool(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	setup, err := loadCourseGradeSetup(ctx, h.db, school.Settings, courseParam, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	courseGrades := []*models.GradeCalculation{}
+	if setup.Mode != models.GradingModeStandards {
+		var assignments []models.Assignment
+		for _, a := range setup.Assignments {
+			if !a.CreatedAt.After(at) {
+				assignments = append(assignments, a)
+			}
+		}
+		setup.Assignments = assignments
+
+		byStudent := make(map[uuid.UUID][]models.Grade)
+		var students []uuid.UUID
+		for _, v := range versions {
+			if _, ok := byStudent[v.StudentID]; !ok {
+				students = append(students, v.StudentID)
+			}
+			changedAt := v.ChangedAt
+			byStudent[v.StudentID] = append(byStudent[v.StudentID], models.Grade{
+				ID: v.GradeID, AssignmentID: v.AssignmentID, StudentID: v.StudentID, SchoolID: v.SchoolID,
+				PointsEarned: v.NewPointsEarned, Comment: v.Comment, GradedBy: v.ChangedBy, GradedAt: &changedAt,
+				IsExcused: v.NewIsExcused, IsMissing: v.NewIsMissing, IsLate: v.NewIsLate, DaysLate: v.NewDaysLate,
+			})
+		}
+		for _, studentID := range students {
+			g := setup.grade(h.grading, school.Settings, studentID, byStudent[studentID], nil, nil)
+			if g.Calc != nil {
+				courseGrades = append(courseGrades, g.Calc)
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"as_of":         at,
+		"grades":        versions,
+		"course_grades": courseGrades,
+	})
+}
+
+// parseAsOfParam parses an RFC 3339 timestamp, or a YYYY-MM-DD date taken as
+// the last moment of that day (UTC).
+func parseAsOfParam(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := parseDateParam(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if d == nil {
+		return time.Time{}, fmt.Errorf("at is required")
+	}
+	return d.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
@@ -0,0 +1,559 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
+)
+
+// Gradebook spreadsheet cell markers. A blank cell means no grade.
+const (
+	gradebookExcused = "EX"
+	gradebookMissing = "M"
+)
+
+// gradebookStudentColumn is the default student key column, holding the
+// student's short_id.
+const gradebookStudentColumn = "Student ID"
+
+// gradebookColumnRef matches the "[short_id]" suffix export puts on each
+// assignment header, so an exported file imports without a column mapping.
+var gradebookColumnRef = regexp.MustCompile(`\[([A-Za-z0-9]+)\]\s*$`)
+
+type gradebookAssignment struct {
+	ID        uuid.UUID
+	ShortID   string
+	Title     string
+	MaxPoints float64
+}
+
+type gradebookStudent struct {
+	ID            uuid.UUID
+	ShortID       string
+	StudentNumber string
+	FirstName     string
+	LastName      string
+	Email         string
+}
+
+// loadGradebookLayout returns a course's assignments in due-date order and its
+// students: everyone actively enrolled plus anyone who still has a grade in
+// the course.
+func loadGradebookLayout(ctx context.Context, db dbtx, courseID, schoolID uuid.UUID) ([]gradebookAssignment, []gradebookStudent, error) {
+	rows, err := db.Query(ctx, `
+		SELECT id, short_id, title, max_points
+		FROM assignments
+		WHERE course_id = $1 AND school_id = $2
+		ORDER BY due_date, created_at
+	`, courseID, schoolID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var assignments []gradebookAssignment
+	for rows.Next() {
+		var a gradebookAssignment
+		if err := rows.Scan(&a.ID, &a.ShortID, &a.Title, &a.MaxPoints); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		assignments = append(assignments, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err = db.Query(ctx, `
+		SELECT s.id, s.short_id, s.student_number, u.first_name, u.last_name, u.email
+		FROM students s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.school_id = $2
+		  AND (EXISTS (SELECT 1 FROM enrollments e
+		               WHERE e.student_id = s.id AND e.course_id = $1 AND e.status = 'active')
+		    OR EXISTS (SELECT 1 FROM grades g JOIN assignments a ON a.id = g.assignment_id
+		               WHERE g.student_id = s.id AND a.course_id = $1))
+		ORDER BY u.last_name, u.first_name
+	`, courseID, schoolID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var students []gradebookStudent
+	for rows.Next() {
+		var s gradebookStudent
+		if err := rows.Scan(&s.ID, &s.ShortID, &s.StudentNumber, &s.FirstName, &s.LastName, &s.Email); err != nil {
+			return nil, nil, err
+		}
+		students = append(students, s)
+	}
+	return assignments, students, rows.Err()
+}
+
+// gradebookCell renders a grade the way import reads it back.
+func gradebookCell(g *models.Grade) string {
+	switch {
+	case g == nil:
+		return ""
+	case g.IsExcused:
+		return gradebookExcused
+	case g.PointsEarned != nil:
+		return strconv.FormatFloat(*g.PointsEarned, 'f', -1, 64)
+	case g.IsMissing:
+		return gradebookMissing
+	}
+	return ""
+}
+
+// ExportGradebook downloads a course's assignments × students matrix.
+// Query param: format (csv or xlsx; default csv).
+// courseId URL param is a short_id.
+func (h *GradesHandler) ExportGradebook(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = services.SpreadsheetCSV
+	}
+	if format != services.SpreadsheetCSV && format != services.SpreadsheetXLSX {
+		writeError(w, http.StatusBadRequest, "invalid_param", "format must be csv or xlsx")
+		return
+	}
+
+	courseParam := chi.URLParam(r, "courseId")
+	courseUUID, err := resolveCourseUUID(ctx, h.db, courseParam, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	var courseName string
+	if err := h.db.QueryRow(ctx, `SELECT name FROM courses WHERE id = $1 AND school_id = $2`,
+		courseUUID, claims.SchoolID).Scan(&courseName); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	assignments, students, err := loadGradebookLayout(ctx, h.db, courseUUID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	grades, err := listCourseGrades(ctx, h.db, courseUUID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", "failed to fetch grades")
+		return
+	}
+	byKey := make(map[gradeKey]*models.Grade, len(grades))
+	for i := range grades {
+		byKey[gradeKey{AssignmentID: grades[i].AssignmentID, StudentID: grades[i].StudentID}] = &grades[i]
+	}
+
+	header := []string{gradebookStudentColumn, "Student Number", "Last Name", "First Name"}
+	for _, a := range assignments {
+		header = append(header, fmt.Sprintf("%s [%s]", a.Title, a.ShortID))
+	}
+	rows := [][]string{header}
+	for _, s := range students {
+		row := []string{s.ShortID, s.StudentNumber, s.LastName, s.FirstName}
+		for _, a := range assignments {
+			row = append(row, gradebookCell(byKey[gradeKey{AssignmentID: a.ID, StudentID: s.ID}]))
+		}
+		rows = append(rows, row)
+	}
+
+	out, err := services.EncodeSpreadsheet(format, courseName, rows)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "export_error", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", services.SpreadsheetContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="gradebook-`+courseParam+`.`+format+`"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// gradebookImportRequest is the body of ImportGradebook. Columns maps a
+// spreadsheet header to an assignment short_id; when omitted, headers ending
+// in "[short_id]" (as written by ExportGradebook) are mapped automatically.
+type gradebookImportRequest struct {
+	Format        string            `json:"format" validate:"required,oneof=csv xlsx"`
+	File          []byte            `json:"file" validate:"required"` // base64 in JSON
+	StudentColumn string            `json:"student_column" validate:"omitempty,max=200"`
+	StudentMatch  string            `json:"student_match" validate:"omitempty,oneof=id student_number email"`
+	Columns       map[string]string `json:"columns"`
+	DryRun        bool              `json:"dry_run"`
+	Reason        string            `json:"reason" validate:"omitempty,max=500"`
+}
+
+// gradebookImportValue is the part of a grade a spreadsheet cell sets.
+type gradebookImportValue struct {
+	PointsEarned *float64 `json:"points_earned"`
+	IsExcused    bool     `json:"is_excused"`
+	IsMissing    bool     `json:"is_missing"`
+}
+
+// gradebookImportChange is one grade an import would create or update.
+// Row is the 1-based spreadsheet row, counting the header.
+type gradebookImportChange struct {
+	Row             int                   `json:"row"`
+	Column          string                `json:"column"`
+	AssignmentID    uuid.UUID             `json:"assignment_id"`
+	AssignmentTitle string                `json:"assignment_title"`
+	StudentID       uuid.UUID             `json:"student_id"`
+	StudentName     string                `json:"student_name"`
+	Action          string                `json:"action"` // create or update
+	Old             *gradebookImportValue `json:"old,omitempty"`
+	New             gradebookImportValue  `json:"new"`
+	LockedBy        string                `json:"locked_by,omitempty"`
+}
+
+// gradebookImportError reports a spreadsheet cell or row that can't be imported.
+type gradebookImportError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// ImportGradebook imports scores from a CSV or XLSX file. The first row is the
+// header; each later row is matched to an enrolled student by student_column.
+// Cells hold points, EX (excused), M (missing), or nothing (left unchanged).
+// Comments and late flags on existing grades are kept.
+//
+// With dry_run the response lists what would change and any invalid cells
+// without saving. Otherwise, if every cell is valid, all changes are written
+// in one transaction through the normal grade write path; grades that are
+// final become grade change requests.
+// courseId URL param is a short_id.
+func (h *GradesHandler) ImportGradebook(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req gradebookImportRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if req.StudentColumn == "" {
+		req.StudentColumn = gradebookStudentColumn
+	}
+	if req.StudentMatch == "" {
+		req.StudentMatch = "id"
+	}
+
+	courseUUID, err := resolveCourseUUID(ctx, h.db, chi.URLParam(r, "courseId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	sheet, err := services.DecodeSpreadsheet(req.Format, req.File)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_file", err.Error())
+		return
+	}
+	if len(sheet) < 2 {
+		writeError(w, http.StatusBadRequest, "invalid_file", "file needs a header row and at least one student row")
+		return
+	}
+	header := sheet[0]
+
+	assignments, students, err := loadGradebookLayout(ctx, h.db, courseUUID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	// Map header columns to the student key and to assignments.
+	assignmentsByShortID := make(map[string]*gradebookAssignment, len(assignments))
+	for i := range assignments {
+		assignmentsByShortID[assignments[i].ShortID] = &assignments[i]
+	}
+	studentCol := -1
+	type mappedColumn struct {
+		index      int
+		assignment *gradebookAssignment
+	}
+	var columns []mappedColumn
+	mapped := make(map[string]bool)
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if strings.EqualFold(name, req.StudentColumn) {
+			studentCol = i
+			continue
+		}
+		shortID, ok := req.Columns[name]
+		if len(req.Columns) == 0 {
+			if m := gradebookColumnRef.FindStringSubmatch(name); m != nil {
+				shortID, ok = m[1], true
+			}
+		}
+		if !ok {
+			continue
+		}
+		a, found := assignmentsByShortID[shortID]
+		if !found {
+			writeError(w, http.StatusBadRequest, "invalid_mapping",
+				fmt.Sprintf("column %q: assignment %q not found in this course", name, shortID))
+			return
+		}
+		columns = append(columns, mappedColumn{index: i, assignment: a})
+		mapped[name] = true
+	}
+	if studentCol < 0 {
+		writeError(w, http.StatusBadRequest, "invalid_mapping", fmt.Sprintf("student column %q not found", req.StudentColumn))
+		return
+	}
+	for name := range req.Columns {
+		if !mapped[name] {
+			writeError(w, http.StatusBadRequest, "invalid_mapping", fmt.Sprintf("column %q not found", name))
+			return
+		}
+	}
+	if len(columns) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid_mapping", "no columns are mapped to assignments")
+		return
+	}
+
+	// Only actively enrolled students can be graded.
+	enrolled := make(map[uuid.UUID]bool)
+	erows, err := h.db.Query(ctx, `
+		SELECT student_id FROM enrollments WHERE course_id = $1 AND status = 'active'
+	`, courseUUID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	for erows.Next() {
+		var id uuid.UUID
+		if err := erows.Scan(&id); err == nil {
+			enrolled[id] = true
+		}
+	}
+	erows.Close()
+
+	studentsByKey := make(map[string]*gradebookStudent, len(students))
+	for i := range students {
+		s := &students[i]
+		if !enrolled[s.ID] {
+			continue
+		}
+		switch req.StudentMatch {
+		case "id":
+			studentsByKey[s.ShortID] = s
+		case "student_number":
+			studentsByKey[s.StudentNumber] = s
+		case "email":
+			studentsByKey[strings.ToLower(s.Email)] = s
+		}
+	}
+
+	existing, err := listCourseGrades(ctx, h.db, courseUUID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", "failed to fetch grades")
+		return
+	}
+	current := make(map[gradeKey]*models.Grade, len(existing))
+	for i := range existing {
+		current[gradeKey{AssignmentID: existing[i].AssignmentID, StudentID: existing[i].StudentID}] = &existing[i]
+	}
+
+	// Diff every cell against the current gradebook.
+	var changes []gradebookImportChange
+	var writes []gradeWrite
+	var keys []gradeKey
+	var importErrors []gradebookImportError
+	unchanged := 0
+	seenRows := make(map[uuid.UUID]int)
+	for i, row := range sheet[1:] {
+		rowNum := i + 2
+		cell := func(col int) string {
+			if col < len(row) {
+				return strings.TrimSpace(row[col])
+			}
+			return ""
+		}
+		key := cell(studentCol)
+		if key == "" {
+			continue
+		}
+		if req.StudentMatch == "email" {
+			key = strings.ToLower(key)
+		}
+		student, ok := studentsByKey[key]
+		if !ok {
+			importErrors = append(importErrors, gradebookImportError{
+				Row: rowNum, Column: header[studentCol], Value: key,
+				Error: "student_not_found", Message: "no student enrolled in this course matches this row",
+			})
+			continue
+		}
+		if first, dup := seenRows[student.ID]; dup {
+			importErrors = append(importErrors, gradebookImportError{
+				Row: rowNum, Column: header[studentCol], Value: key,
+				Error: "duplicate_row", Message: fmt.Sprintf("same student as row %d", first),
+			})
+			continue
+		}
+		seenRows[student.ID] = rowNum
+
+		for _, mc := range columns {
+			col, a := mc.index, mc.assignment
+			raw := cell(col)
+			if raw == "" {
+				continue
+			}
+			var val gradebookImportValue
+			switch strings.ToUpper(raw) {
+			case gradebookExcused:
+				val.IsExcused = true
+			case gradebookMissing:
+				val.IsMissing = true
+			default:
+				points, err := strconv.ParseFloat(raw, 64)
+				if err != nil || math.IsNaN(points) || math.IsInf(points, 0) {
+					importErrors = append(importErrors, gradebookImportError{
+						Row: rowNum, Column: header[col], Value: raw,
+						Error: "invalid_value", Message: "expected points, EX, M, or a blank cell",
+					})
+					continue
+				}
+				if points < 0 || points > a.MaxPoints {
+					importErrors = append(importErrors, gradebookImportError{
+						Row: rowNum, Column: header[col], Value: raw,
+						Error:   "invalid_points",
+						Message: fmt.Sprintf("points must be between 0 and %g", a.MaxPoints),
+					})
+					continue
+				}
+				val.PointsEarned = &points
+			}
+
+			gk := gradeKey{AssignmentID: a.ID, StudentID: student.ID}
+			in := gradeWrite{
+				AssignmentID: a.ID,
+				StudentID:    student.ID,
+				PointsEarned: val.PointsEarned,
+				IsExcused:    val.IsExcused,
+				IsMissing:    val.IsMissing,
+				Reason:       req.Reason,
+			}
+			change := gradebookImportChange{
+				Row:             rowNum,
+				Column:          header[col],
+				AssignmentID:    a.ID,
+				AssignmentTitle: a.Title,
+				StudentID:       student.ID,
+				StudentName:     student.FirstName + " " + student.LastName,
+				Action:          "create",
+				New:             val,
+			}
+			if old, ok := current[gk]; ok {
+				if old.Comment != nil {
+					in.Comment = *old.Comment
+				}
+				in.IsLate, in.DaysLate = old.IsLate, old.DaysLate
+				if gradeUnchanged(old, in) {
+					unchanged++
+					continue
+				}
+				change.Action = "update"
+				change.Old = &gradebookImportValue{
+					PointsEarned: old.PointsEarned, IsExcused: old.IsExcused, IsMissing: old.IsMissing,
+				}
+			}
+			changes = append(changes, change)
+			writes = append(writes, in)
+			keys = append(keys, gk)
+		}
+	}
+
+	locked, err := gradeLockReasons(ctx, h.db, keys, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	for i := range changes {
+		changes[i].LockedBy = locked[keys[i]]
+	}
+
+	if req.DryRun {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"dry_run":   true,
+			"changes":   changes,
+			"unchanged": unchanged,
+			"errors":    importErrors,
+		})
+		return
+	}
+	if len(importErrors) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+			"error":   "invalid_cells",
+			"message": fmt.Sprintf("%d cells or rows are invalid; no grades were saved", len(importErrors)),
+			"errors":  importErrors,
+		})
+		return
+	}
+
+	var results []gradeCellResult
+	var oldGrades []*models.Grade
+	if len(writes) > 0 {
+		results, oldGrades, err = saveGradeWrites(ctx, h.db, claims, courseUUID, writes)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+	}
+	saved, pending := countGradeResults(results)
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "grade.import",
+		EntityType: "course",
+		EntityID:   &courseUUID,
+		OldValue:   oldGrades,
+		NewValue: map[string]interface{}{
+			"format": req.Format, "changes": changes, "reason": req.Reason,
+			"saved": saved, "pending_approval": pending,
+		},
+		IPAddress: r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"saved":            saved,
+		"pending_approval": pending,
+		"unchanged":        unchanged,
+		"changes":          changes,
+		"results":          results,
+	})
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -57,20 +60,31 @@ func (h *GradesHandler) ListGrades(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	rows, err := h.db.Query(ctx, `
+	grades, err := listCourseGrades(ctx, h.db, courseUUID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", "failed to fetch grades")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"grades": grades})
+}
+
+// listCourseGrades returns every grade in a course, ordered by student and
+// assignment due date.
+func listCourseGrades(ctx context.Context, db dbtx, courseID, schoolID uuid.UUID) ([]models.Grade, error) {
+	rows, err := db.Query(ctx, `
 		SELECT g.id, g.assignment_id, g.student_id, g.school_id,
 		       g.points_earned, g.letter_grade, g.comment, g.graded_by,
-		       g.graded_at, g.ai_suggested, g.ai_accepted,
-		       g.is_excused, g.is_missing, g.is_late,
+		       g.graded_at, g.ai_suggested, g.ai_reasoning, g.ai_accepted,
+		       g.is_excused, g.is_missing, g.is_late, g.days_late,
 		       g.created_at, g.updated_at
 		FROM grades g
 		JOIN assignments a ON a.id = g.assignment_id
 		WHERE a.course_id = $1 AND g.school_id = $2
 		ORDER BY g.student_id, a.due_date
-	`, courseUUID, claims.SchoolID)
+	`, courseID, schoolID)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "db_error", "failed to fetch grades")
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -80,20 +94,21 @@ func (h *GradesHandler) ListGrades(w http.ResponseWriter, r *http.Request) {
 		if err := rows.Scan(
 			&g.ID, &g.AssignmentID, &g.StudentID, &g.SchoolID,
 			&g.PointsEarned, &g.LetterGrade, &g.Comment, &g.GradedBy,
-			&g.GradedAt, &g.AISuggested, &g.AIAccepted,
-			&g.IsExcused, &g.IsMissing, &g.IsLate,
+			&g.GradedAt, &g.AISuggested, &g.AIReasoning, &g.AIAccepted,
+			&g.IsExcused, &g.IsMissing, &g.IsLate, &g.DaysLate,
 			&g.CreatedAt, &g.UpdatedAt,
 		); err != nil {
-			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
-			return
+			return nil, err
 		}
 		grades = append(grades, g)
 	}
-
-	writeJSON(w, http.StatusOK, map[string]interface{}{"grades": grades})
+	return grades, rows.Err()
 }
 
-// UpsertGrade creates or updates a single grade entry.
+// UpsertGrade creates or updates a single grade entry, recording the new
+// version in grade_history with an optional reason. A grade that is final
+// (closed term or finalized report card) is not written: a pending grade
+// change request is returned with 202 instead.
 // courseId URL param is a short_id.
 func (h *GradesHandler) UpsertGrade(w http.ResponseWriter, r *http.Request) {
 	claims, _ := auth.ClaimsFromContext(r.Context())
@@ -106,7 +121,10 @@ func (h *GradesHandler) UpsertGrade(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, "not_found", "course not found")
 		return
 	}
-	_ = courseUUID // Used for scoping verification; the grade INSERT uses assignment_id directly.
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
 
 	var req struct {
 		AssignmentID string   `json:"assignment_id" validate:"required,uuid"`
@@ -116,7 +134,9 @@ func (h *GradesHandler) UpsertGrade(w http.ResponseWriter, r *http.Request) {
 		IsExcused    bool     `json:"is_excused"`
 		IsMissing    bool     `json:"is_missing"`
 		IsLate       bool     `json:"is_late"`
+		DaysLate     *int     `json:"days_late" validate:"omitempty,min=0"`
 		AIAccepted   *bool    `json:"ai_accepted"`
+		Reason       string   `json:"reason" validate:"max=500"`
 	}
 
 	dec := json.NewDecoder(r.Body)
@@ -130,57 +150,101 @@ func (h *GradesHandler) UpsertGrade(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Fetch current grade for audit log old_value.
-	var oldGrade *models.Grade
-	var existing models.Grade
-	err = h.db.QueryRow(ctx, `
-		SELECT id, points_earned, letter_grade, comment, is_excused, is_missing, is_late
-		FROM grades WHERE assignment_id = $1 AND student_id = $2 AND school_id = $3
-	`, req.AssignmentID, req.StudentID, claims.SchoolID).Scan(
-		&existing.ID, &existing.PointsEarned, &existing.LetterGrade,
-		&existing.Comment, &existing.IsExcused, &existing.IsMissing, &existing.IsLate,
-	)
-	if err == nil {
-		oldGrade = &existing
+	// The assignment must belong to this course and the student be enrolled in it.
+	var inCourse, enrolled bool
+	var maxPoints float64
+	h.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM assignments WHERE id = $1 AND course_id = $2 AND school_id = $3),
+		       EXISTS (SELECT 1 FROM enrollments WHERE student_id = $4 AND course_id = $2 AND status = 'active'),
+		       COALESCE((SELECT max_points FROM assignments WHERE id = $1 AND school_id = $3), 0)
+	`, req.AssignmentID, courseUUID, claims.SchoolID, req.StudentID).Scan(&inCourse, &enrolled, &maxPoints)
+	if !inCourse {
+		writeError(w, http.StatusNotFound, "not_found", "assignment not found in this course")
+		return
+	}
+	if !enrolled {
+		writeError(w, http.StatusBadRequest, "not_enrolled", "student is not enrolled in this course")
+		return
 	}
 
 	// Validate points_earned against max_points.
-	var maxPoints float64
-	h.db.QueryRow(ctx, `SELECT max_points FROM assignments WHERE id = $1 AND school_id = $2`,
-		req.AssignmentID, claims.SchoolID).Scan(&maxPoints)
-
 	if req.PointsEarned != nil && (*req.PointsEarned < 0 || *req.PointsEarned > maxPoints) {
 		writeError(w, http.StatusBadRequest, "invalid_points",
 			"points_earned must be between 0 and the assignment's max_points")
 		return
 	}
 
-	var gradeID uuid.UUID
-	err = h.db.QueryRow(ctx, `
-		INSERT INTO grades
-			(assignment_id, student_id, school_id, points_earned, comment,
-			 is_excused, is_missing, is_late, ai_accepted, graded_by, graded_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
-		ON CONFLICT (assignment_id, student_id)
-		DO UPDATE SET
-			points_earned = EXCLUDED.points_earned,
-			comment       = EXCLUDED.comment,
-			is_excused    = EXCLUDED.is_excused,
-			is_missing    = EXCLUDED.is_missing,
-			is_late       = EXCLUDED.is_late,
-			ai_accepted   = EXCLUDED.ai_accepted,
-			graded_by     = EXCLUDED.graded_by,
-			graded_at     = NOW(),
-			updated_at    = NOW()
-		RETURNING id
-	`, req.AssignmentID, req.StudentID, claims.SchoolID,
-		req.PointsEarned, nullStr(req.Comment),
-		req.IsExcused, req.IsMissing, req.IsLate, req.AIAccepted, claims.UserID,
-	).Scan(&gradeID)
+	in := gradeWrite{
+		AssignmentID: uuid.MustParse(req.AssignmentID),
+		StudentID:    uuid.MustParse(req.StudentID),
+		PointsEarned: req.PointsEarned,
+		Comment:      req.Comment,
+		IsExcused:    req.IsExcused,
+		IsMissing:    req.IsMissing,
+		IsLate:       req.IsLate,
+		DaysLate:     req.DaysLate,
+		AIAccepted:   req.AIAccepted,
+		Reason:       req.Reason,
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Final grades (closed term or finalized report card) change only
+	// through an approved request. The check runs in the write transaction
+	// so a term can't close or a card be finalized in between.
+	lockedBy, err := gradeLockReason(ctx, tx, in.AssignmentID, in.StudentID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if lockedBy != "" {
+		change, err := createGradeChangeRequest(ctx, tx, claims.SchoolID, courseUUID, claims.UserID, in, lockedBy)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		if err := tx.Commit(ctx); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+			SchoolID:   claims.SchoolID,
+			UserID:     &claims.UserID,
+			Action:     "grade_change.request",
+			EntityType: "grade_change_request",
+			EntityID:   &change.ID,
+			NewValue:   req,
+			IPAddress:  r.RemoteAddr,
+			UserAgent:  r.UserAgent(),
+		})
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{
+			"status":         "pending_approval",
+			"locked_by":      lockedBy,
+			"change_request": change,
+		})
+		return
+	}
+
+	gradeID, oldGrade, err := writeGrade(ctx, tx, claims.SchoolID, claims.UserID, in)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
 		return
 	}
+	if in.AIAccepted != nil {
+		if err := syncAIInteraction(ctx, tx, in.AssignmentID, in.StudentID); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
 
 	// Audit log.
 	action := "grade.create"
@@ -202,6 +266,249 @@ func (h *GradesHandler) UpsertGrade(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]interface{}{"grade_id": gradeID})
 }
 
+// gradeCell is one cell of a batch grade save.
+type gradeCell struct {
+	AssignmentID string   `json:"assignment_id" validate:"required,uuid"`
+	StudentID    string   `json:"student_id" validate:"required,uuid"`
+	PointsEarned *float64 `json:"points_earned"`
+	Comment      string   `json:"comment" validate:"max=2000"`
+	IsExcused    bool     `json:"is_excused"`
+	IsMissing    bool     `json:"is_missing"`
+	IsLate       bool     `json:"is_late"`
+	DaysLate     *int     `json:"days_late" validate:"omitempty,min=0"`
+}
+
+// gradeCellError explains why one cell of a batch was refused.
+type gradeCellError struct {
+	Index        int    `json:"index"`
+	AssignmentID string `json:"assignment_id"`
+	StudentID    string `json:"student_id"`
+	Error        string `json:"error"`
+	Message      string `json:"message"`
+}
+
+// gradeCellResult is the outcome of one saved cell: "saved", or
+// "pending_approval" when the grade is final and a change request was filed.
+type gradeCellResult struct {
+	Index           int        `json:"index"`
+	AssignmentID    string     `json:"assignment_id"`
+	StudentID       string     `json:"student_id"`
+	Status          string     `json:"status"`
+	GradeID         *uuid.UUID `json:"grade_id,omitempty"`
+	ChangeRequestID string     `json:"change_request_id,omitempty"`
+}
+
+// saveGradeWrites writes a batch of grades in one transaction through
+// writeGrade. Grades that are final when the transaction checks them become
+// grade change requests instead. It returns each write's result and the
+// previous values of updated grades.
+func saveGradeWrites(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	claims *auth.Claims,
+	courseID uuid.UUID,
+	writes []gradeWrite,
+) ([]gradeCellResult, []*models.Grade, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	keys := make([]gradeKey, len(writes))
+	for i, in := range writes {
+		keys[i] = gradeKey{AssignmentID: in.AssignmentID, StudentID: in.StudentID}
+	}
+	locked, err := gradeLockReasons(ctx, tx, keys, claims.SchoolID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]gradeCellResult, len(writes))
+	var oldGrades []*models.Grade
+	for i, in := range writes {
+		res := gradeCellResult{Index: i, AssignmentID: in.AssignmentID.String(), StudentID: in.StudentID.String()}
+		if lockedBy, ok := locked[gradeKey{AssignmentID: in.AssignmentID, StudentID: in.StudentID}]; ok {
+			change, err := createGradeChangeRequest(ctx, tx, claims.SchoolID, courseID, claims.UserID, in, lockedBy)
+			if err != nil {
+				return nil, nil, err
+			}
+			res.Status, res.ChangeRequestID = "pending_approval", change.ShortID
+		} else {
+			gradeID, old, err := writeGrade(ctx, tx, claims.SchoolID, claims.UserID, in)
+			if err != nil {
+				return nil, nil, err
+			}
+			if old != nil {
+				oldGrades = append(oldGrades, old)
+			}
+			res.Status, res.GradeID = "saved", &gradeID
+		}
+		results[i] = res
+	}
+	return results, oldGrades, tx.Commit(ctx)
+}
+
+// countGradeResults tallies saved and pending_approval results.
+func countGradeResults(results []gradeCellResult) (saved, pending int) {
+	for _, r := range results {
+		if r.Status == "saved" {
+			saved++
+		} else {
+			pending++
+		}
+	}
+	return saved, pending
+}
+
+// BatchUpsertGrades saves many grade cells at once, e.g. a column pasted
+// from a spreadsheet. Every cell is checked first (assignment in this course,
+// student enrolled, points within range, no cell repeated); if any fails,
+// nothing is saved and the per-cell errors are returned with 422. Otherwise
+// the whole batch is written in one transaction with a single audit event.
+// Final grades become grade change requests, as in UpsertGrade.
+// courseId URL param is a short_id.
+func (h *GradesHandler) BatchUpsertGrades(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	courseUUID, err := resolveCourseUUID(ctx, h.db, chi.URLParam(r, "courseId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	var req struct {
+		Grades []gradeCell `json:"grades" validate:"required,min=1,max=1000"`
+		Reason string      `json:"reason" validate:"max=500"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	// Load the course's assignments and active enrollments once for the batch.
+	maxPoints := make(map[uuid.UUID]float64)
+	rows, err := h.db.Query(ctx, `
+		SELECT id, max_points FROM assignments WHERE course_id = $1 AND school_id = $2
+	`, courseUUID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	for rows.Next() {
+		var id uuid.UUID
+		var points float64
+		if err := rows.Scan(&id, &points); err == nil {
+			maxPoints[id] = points
+		}
+	}
+	rows.Close()
+
+	enrolled := make(map[uuid.UUID]bool)
+	rows, err = h.db.Query(ctx, `
+		SELECT student_id FROM enrollments WHERE course_id = $1 AND status = 'active'
+	`, courseUUID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err == nil {
+			enrolled[id] = true
+		}
+	}
+	rows.Close()
+
+	writes := make([]gradeWrite, len(req.Grades))
+	seen := make(map[gradeKey]int)
+	var cellErrors []gradeCellError
+	for i, c := range req.Grades {
+		fail := func(code, message string) {
+			cellErrors = append(cellErrors, gradeCellError{
+				Index: i, AssignmentID: c.AssignmentID, StudentID: c.StudentID, Error: code, Message: message,
+			})
+		}
+		if err := validate.Struct(c); err != nil {
+			fail("validation_error", err.Error())
+			continue
+		}
+		key := gradeKey{AssignmentID: uuid.MustParse(c.AssignmentID), StudentID: uuid.MustParse(c.StudentID)}
+		limit, ok := maxPoints[key.AssignmentID]
+		switch {
+		case !ok:
+			fail("not_found", "assignment not found in this course")
+			continue
+		case !enrolled[key.StudentID]:
+			fail("not_enrolled", "student is not enrolled in this course")
+			continue
+		case c.PointsEarned != nil && (*c.PointsEarned < 0 || *c.PointsEarned > limit):
+			fail("invalid_points", "points_earned must be between 0 and the assignment's max_points")
+			continue
+		}
+		if first, dup := seen[key]; dup {
+			fail("duplicate_cell", fmt.Sprintf("same assignment and student as cell %d", first))
+			continue
+		}
+		seen[key] = i
+		writes[i] = gradeWrite{
+			AssignmentID: key.AssignmentID,
+			StudentID:    key.StudentID,
+			PointsEarned: c.PointsEarned,
+			Comment:      c.Comment,
+			IsExcused:    c.IsExcused,
+			IsMissing:    c.IsMissing,
+			IsLate:       c.IsLate,
+			DaysLate:     c.DaysLate,
+			Reason:       req.Reason,
+		}
+	}
+	if len(cellErrors) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+			"error":   "invalid_cells",
+			"message": fmt.Sprintf("%d of %d cells are invalid; no grades were saved", len(cellErrors), len(req.Grades)),
+			"errors":  cellErrors,
+		})
+		return
+	}
+
+	results, oldGrades, err := saveGradeWrites(ctx, h.db, claims, courseUUID, writes)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	saved, pending := countGradeResults(results)
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "grade.batch_upsert",
+		EntityType: "course",
+		EntityID:   &courseUUID,
+		OldValue:   oldGrades,
+		NewValue:   map[string]interface{}{"grades": req.Grades, "reason": req.Reason, "saved": saved, "pending_approval": pending},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"saved":            saved,
+		"pending_approval": pending,
+		"results":          results,
+	})
+}
+
 // GetStudentGrades returns a student's grades for their own courses.
 // Checks grade lock for student/parent roles.
 // studentId URL param is a short_id.
@@ -274,6 +581,7 @@ func (h *GradesHandler) GetStudentGrades(w http.ResponseWriter, r *http.Request)
 		IsExcused    bool      `json:"is_excused"`
 		IsMissing    bool      `json:"is_missing"`
 		IsLate       bool      `json:"is_late"`
+		UpdatedAt    time.Time `json:"updated_at"`
 	}
 
 	var grades []gradeRow
@@ -283,13 +591,382 @@ func (h *GradesHandler) GetStudentGrades(w http.ResponseWriter, r *http.Request)
 			&g.ID, &g.AssignmentID, &g.StudentID, &g.Title, &g.MaxPoints,
 			&g.Category, &g.CourseName,
 			&g.PointsEarned, &g.LetterGrade, &g.Comment,
-			&g.IsExcused, &g.IsMissing, &g.IsLate,
+			&g.IsExcused, &g.IsMissing, &g.IsLate, &g.UpdatedAt,
 		); err != nil {
 			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
 			return
 		}
 		grades = append(grades, g)
 	}
+	rows.Close()
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{"grades": grades})
+	// Course totals from the shared pipeline, so they match report cards.
+	school, err := loadSchool(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	courses, err := computeStudentCourseGrades(ctx, h.db, h.grading, school.Settings, studentUUID, claims.SchoolID, nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	var courseGrades []*models.GradeCalculation
+	for _, c := range courses {
+		if c.Calc != nil {
+			courseGrades = append(courseGrades, c.Calc)
+		}
+	}
+
+	gpa := gpaFromCourseGrades(h.grading, courses, school.Settings)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"grades":        grades,
+		"course_grades": courseGrades,
+		"gpa":           gpa.GPA,
+		"gpa_detail":    gpa,
+	})
+}
+
+// GetGradePolicy returns a course's grade policy; an empty policy means plain
+// point totals.
+// courseId URL param is a short_id.
+func (h *GradesHandler) GetGradePolicy(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	courseUUID, err := resolveCourseUUID(ctx, h.db, chi.URLParam(r, "courseId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	var raw []byte
+	if err := h.db.QueryRow(ctx, `SELECT grade_policy FROM courses WHERE id = $1 AND school_id = $2`,
+		courseUUID, claims.SchoolID).Scan(&raw); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	policy, err := decodeGradePolicy(raw)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "decode_error", err.Error())
+		return
+	}
+	if policy == nil {
+		policy = &models.GradePolicy{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"grade_policy": policy})
+}
+
+// UpdateGradePolicy replaces a course's grade policy. Drop rules must name
+// assignment categories.
+// courseId URL param is a short_id.
+func (h *GradesHandler) UpdateGradePolicy(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	courseUUID, err := resolveCourseUUID(ctx, h.db, chi.URLParam(r, "courseId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	var req models.GradePolicy
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	for cat := range req.DropLowest {
+		if err := validate.Var(cat, "oneof=homework quiz test exam project classwork participation other"); err != nil {
+			writeError(w, http.StatusBadRequest, "validation_error", "drop_lowest: unknown category "+cat)
+			return
+		}
+	}
+
+	newRaw, err := json.Marshal(req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "encode_error", err.Error())
+		return
+	}
+	var oldRaw []byte
+	h.db.QueryRow(ctx, `SELECT grade_policy FROM courses WHERE id = $1 AND school_id = $2`,
+		courseUUID, claims.SchoolID).Scan(&oldRaw)
+
+	if _, err := h.db.Exec(ctx, `
+		UPDATE courses SET grade_policy = $1 WHERE id = $2 AND school_id = $3
+	`, newRaw, courseUUID, claims.SchoolID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "course.update_grade_policy",
+		EntityType: "course",
+		EntityID:   &courseUUID,
+		OldValue:   json.RawMessage(oldRaw),
+		NewValue:   req,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"grade_policy": req})
+}
+
+// GetStudentCourseGrade returns a student's computed grade in one course with
+// its category breakdown and policy adjustments, or per-standard mastery for
+// standards-based courses. Grade lock applies to students and parents.
+// Optional ?term_id= limits the grade to one term.
+// courseId and studentId URL params are short_ids.
+func (h *GradesHandler) GetStudentCourseGrade(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	school, err := loadSchool(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	course, err := loadCourseGradeSetup(ctx, h.db, school.Settings, chi.URLParam(r, "courseId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	studentID, err := resolveStudentUUID(ctx, h.db, chi.URLParam(r, "studentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "student not found")
+		return
+	}
+
+	if claims.Role == models.RoleTeacher {
+		if !teacherOwnsCourse(ctx, h.db, claims.UserID, course.ID, claims.SchoolID) {
+			writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+			return
+		}
+	} else if !canViewStudent(ctx, h.db, claims, studentID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you cannot view this student's grades")
+		return
+	}
+	if claims.Role == models.RoleStudent || claims.Role == models.RoleParent {
+		var isLocked bool
+		h.db.QueryRow(ctx, `
+			SELECT is_grade_locked FROM students WHERE id = $1 AND school_id = $2
+		`, studentID, claims.SchoolID).Scan(&isLocked)
+		if isLocked {
+			writeError(w, http.StatusForbidden, "grade_locked",
+				"Your grade access has been temporarily restricted. Please contact your school administration.")
+			return
+		}
+	}
+
+	var enrolled int
+	h.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM enrollments WHERE course_id = $1 AND student_id = $2 AND school_id = $3
+	`, course.ID, studentID, claims.SchoolID).Scan(&enrolled)
+	if enrolled == 0 {
+		writeError(w, http.StatusNotFound, "not_found", "student is not enrolled in this course")
+		return
+	}
+
+	var term *models.Term
+	if tid := r.URL.Query().Get("term_id"); tid != "" {
+		if term, err = resolveTerm(ctx, h.db, tid, claims.SchoolID); err != nil {
+			writeError(w, http.StatusNotFound, "not_found", "term not found")
+			return
+		}
+	}
+
+	courseIDs := []uuid.UUID{course.ID}
+	var grades []models.Grade
+	var scores map[uuid.UUID][]models.StandardScore
+	if course.Mode == models.GradingModeStandards {
+		scores, err = loadStudentStandardScores(ctx, h.db, studentID, courseIDs, claims.SchoolID)
+	} else {
+		grades, err = loadStudentGrades(ctx, h.db, studentID, courseIDs, claims.SchoolID)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	g := course.grade(h.grading, school.Settings, studentID, grades, scores[course.ID], term)
+
+	resp := map[string]interface{}{
+		"course_id":    course.ShortID,
+		"course_name":  course.Name,
+		"grading_mode": course.Mode,
+		"grade":        g.Calc,
+	}
+	if course.Mode == models.GradingModeStandards {
+		resp["standards"] = g.Standards
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// courseGradeSummaryRow is one enrolled student's line in a course summary.
+type courseGradeSummaryRow struct {
+	StudentID uuid.UUID                `json:"student_id"`
+	FirstName string                   `json:"first_name"`
+	LastName  string                   `json:"last_name"`
+	Grade     *models.GradeCalculation `json:"grade"`
+	Standards []models.StandardMastery `json:"standards,omitempty"`
+}
+
+// GetCourseGradeSummary returns every actively enrolled student's computed
+// grade in a course, with class averages overall, per category, and per
+// standard (teacher/admin only). Optional ?term_id= limits it to one term.
+// courseId URL param is a short_id.
+func (h *GradesHandler) GetCourseGradeSummary(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	school, err := loadSchool(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	course, err := loadCourseGradeSetup(ctx, h.db, school.Settings, chi.URLParam(r, "courseId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, course.ID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	var term *models.Term
+	if tid := r.URL.Query().Get("term_id"); tid != "" {
+		if term, err = resolveTerm(ctx, h.db, tid, claims.SchoolID); err != nil {
+			writeError(w, http.StatusNotFound, "not_found", "term not found")
+			return
+		}
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT s.id, u.first_name, u.last_name
+		FROM enrollments e
+		JOIN students s ON s.id = e.student_id
+		JOIN users u ON u.id = s.user_id
+		WHERE e.course_id = $1 AND e.status = 'active' AND s.school_id = $2
+		ORDER BY u.last_name, u.first_name
+	`, course.ID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	students := []courseGradeSummaryRow{}
+	for rows.Next() {
+		var s courseGradeSummaryRow
+		if err := rows.Scan(&s.StudentID, &s.FirstName, &s.LastName); err != nil {
+			rows.Close()
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		students = append(students, s)
+	}
+	rows.Close()
+
+	var grades map[uuid.UUID][]models.Grade
+	var scores map[uuid.UUID][]models.StandardScore
+	if course.Mode == models.GradingModeStandards {
+		scores, err = loadCourseStandardScores(ctx, h.db, course.ID, claims.SchoolID)
+	} else {
+		grades, err = loadCourseGrades(ctx, h.db, course.ID, claims.SchoolID)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	// Class averages over students who have a grade.
+	var pctSum float64
+	var graded int
+	letters := make(map[string]int)
+	type average struct{ sum, n float64 }
+	categories := make(map[string]*average)
+	standards := make(map[uuid.UUID]*average)
+
+	for i := range students {
+		sid := students[i].StudentID
+		g := course.grade(h.grading, school.Settings, sid, grades[sid], scores[sid], term)
+		students[i].Grade = g.Calc
+		students[i].Standards = g.Standards
+
+		for _, m := range g.Standards {
+			if m.Evidence == 0 {
+				continue
+			}
+			if standards[m.StandardID] == nil {
+				standards[m.StandardID] = &average{}
+			}
+			standards[m.StandardID].sum += m.Score
+			standards[m.StandardID].n++
+		}
+		if g.Calc == nil {
+			continue
+		}
+		pctSum += g.Calc.Percentage
+		graded++
+		letters[g.Calc.LetterGrade]++
+		for cat, cg := range g.Calc.CategoryBreakdown {
+			if categories[cat] == nil {
+				categories[cat] = &average{}
+			}
+			categories[cat].sum += cg.Percentage
+			categories[cat].n++
+		}
+	}
+
+	resp := map[string]interface{}{
+		"course_id":    course.ShortID,
+		"course_name":  course.Name,
+		"grading_mode": course.Mode,
+		"students":     students,
+	}
+	if course.Mode == models.GradingModeStandards {
+		type standardAverage struct {
+			StandardID string  `json:"standard_id"`
+			Code       string  `json:"code"`
+			Average    float64 `json:"average"`
+			Assessed   int     `json:"assessed"`
+		}
+		averages := []standardAverage{}
+		for _, st := range course.Standards {
+			if a := standards[st.ID]; a != nil {
+				averages = append(averages, standardAverage{st.ShortID, st.Code, a.sum / a.n, int(a.n)})
+			}
+		}
+		resp["standard_averages"] = averages
+	} else {
+		var classAverage *float64
+		if graded > 0 {
+			avg := pctSum / float64(graded)
+			classAverage = &avg
+		}
+		categoryAverages := make(map[string]float64, len(categories))
+		for cat, a := range categories {
+			categoryAverages[cat] = a.sum / a.n
+		}
+		resp["class_average"] = classAverage
+		resp["letter_distribution"] = letters
+		resp["category_averages"] = categoryAverages
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
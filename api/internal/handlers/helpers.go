@@ -4,12 +4,27 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
 )
 
+// dbtx is the query surface shared by *pgxpool.Pool and pgx.Tx, for helpers
+// that run either standalone or inside a caller's transaction.
+type dbtx interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 // writeJSON encodes v as JSON and writes it with the given status code.
 func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -87,3 +102,92 @@ func resolveDigitalIDUUID(ctx context.Context, db *pgxpool.Pool, shortID string,
 	).Scan(&id)
 	return id, err
 }
+
+// teacherOwnsCourse reports whether the given user is the teacher of record for a course.
+func teacherOwnsCourse(ctx context.Context, db *pgxpool.Pool, userID, courseID, schoolID uuid.UUID) bool {
+	var count int
+	db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM courses c
+		JOIN teachers t ON t.id = c.teacher_id
+		WHERE c.id = $1 AND t.user_id = $2 AND c.school_id = $3
+	`, courseID, userID, schoolID).Scan(&count)
+	return count > 0
+}
+
+// canViewStudent reports whether the caller may read a student's records:
+// students see themselves, parents their linked children, teachers students
+// enrolled in one of their courses, and admins anyone in their school.
+func canViewStudent(ctx context.Context, db *pgxpool.Pool, claims *auth.Claims, studentID uuid.UUID) bool {
+	var count int
+	switch claims.Role {
+	case models.RoleAdmin, models.RoleSuperAdmin:
+		return true
+	case models.RoleStudent:
+		db.QueryRow(ctx, `SELECT COUNT(*) FROM students WHERE id = $1 AND user_id = $2 AND school_id = $3`,
+			studentID, claims.UserID, claims.SchoolID).Scan(&count)
+	case models.RoleParent:
+		db.QueryRow(ctx, `SELECT COUNT(*) FROM parent_students WHERE parent_id = $1 AND student_id = $2 AND school_id = $3`,
+			claims.UserID, studentID, claims.SchoolID).Scan(&count)
+	case models.RoleTeacher:
+		db.QueryRow(ctx, `
+			SELECT COUNT(*) FROM enrollments e
+			JOIN courses c ON c.id = e.course_id
+			JOIN teachers t ON t.id = c.teacher_id
+			WHERE e.student_id = $1 AND t.user_id = $2 AND c.school_id = $3
+		`, studentID, claims.UserID, claims.SchoolID).Scan(&count)
+	}
+	return count > 0
+}
+
+// parseDateParam parses a YYYY-MM-DD date. An empty string yields nil.
+func parseDateParam(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// schoolAssets holds a school's branding images for generated PDFs.
+type schoolAssets struct {
+	Logo      []byte
+	Signature []byte
+	Warnings  []string // configured images that could not be used
+}
+
+// brandingRefs returns the references to a school's logo and signature
+// images: the uploaded logo key, else logo_url, and the signature key.
+func brandingRefs(school *models.School) (logo, signature string) {
+	logo = school.Settings.LogoImageKey
+	if logo == "" && school.LogoURL != nil {
+		logo = *school.LogoURL
+	}
+	return logo, school.Settings.SignatureImageURL
+}
+
+// loadSchoolAssets fetches the school logo and signature image from the
+// school's own storage. An image that is configured but cannot be used, such
+// as a logo_url outside the school's storage, is left nil so the document
+// still renders, and is reported in Warnings for the caller to pass on.
+func loadSchoolAssets(ctx context.Context, storage *services.StorageService, school *models.School) *schoolAssets {
+	assets := &schoolAssets{}
+	logo, signature := brandingRefs(school)
+	fetch := func(name, ref string) []byte {
+		if ref == "" {
+			return nil
+		}
+		b, err := storage.FetchAsset(ctx, school.ID.String(), ref)
+		if err != nil {
+			log.Printf("pdf: school %s %s: %v", school.ID, name, err)
+			assets.Warnings = append(assets.Warnings, fmt.Sprintf("the %s was left out: %v", name, err))
+			return nil
+		}
+		return b
+	}
+	assets.Logo = fetch("logo", logo)
+	assets.Signature = fetch("signature", signature)
+	return assets
+}
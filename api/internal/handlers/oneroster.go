@@ -0,0 +1,1310 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
+	"github.com/pragma-proto/api/internal/shortid"
+)
+
+// OneRosterHandler imports OneRoster CSV rosters from a school's SIS and
+// exports gradebook results back to it.
+type OneRosterHandler struct {
+	db *pgxpool.Pool
+}
+
+// NewOneRosterHandler creates a OneRosterHandler.
+func NewOneRosterHandler(db *pgxpool.Pool) *OneRosterHandler {
+	return &OneRosterHandler{db: db}
+}
+
+// oneRosterNoPassword is the password hash given to imported users. It
+// matches no password, so they can't sign in until they set one from a link
+// an admin sends them (see SendPasswordSetup). Each import report counts the
+// school's active users still in this state.
+const oneRosterNoPassword = "!"
+
+// oneRosterRoles maps OneRoster roles to ours. Roles not listed (aide,
+// proctor, ...) are not imported.
+var oneRosterRoles = map[string]string{
+	"student":               models.RoleStudent,
+	"teacher":               models.RoleTeacher,
+	"administrator":         models.RoleAdmin,
+	"principal":             models.RoleAdmin,
+	"siteadministrator":     models.RoleAdmin,
+	"districtadministrator": models.RoleAdmin,
+	"systemadministrator":   models.RoleAdmin,
+	"parent":                models.RoleParent,
+	"guardian":              models.RoleParent,
+	"relative":              models.RoleParent,
+}
+
+// oneRosterSessionRank orders academic sessions so parents come first.
+var oneRosterSessionRank = map[string]int{
+	"schoolyear":    0,
+	"semester":      1,
+	"term":          1,
+	"gradingperiod": 2,
+}
+
+// oneRosterChildTermType is the term type nested one level under each type.
+var oneRosterChildTermType = map[string]string{
+	models.TermYear:     models.TermSemester,
+	models.TermSemester: models.TermQuarter,
+}
+
+type oneRosterSource struct {
+	EntityID uuid.UUID
+	Hash     string
+	Deleted  bool
+}
+
+// oneRosterImporter applies one bundle inside a transaction. Every row it
+// sees, changed or not, has its oneroster_sources last_seen_at set to the
+// transaction's NOW(), which is how bulk reconciliation finds stale rows.
+type oneRosterImporter struct {
+	ctx      context.Context
+	tx       pgx.Tx
+	schoolID uuid.UUID
+	org      string
+	bundle   *services.OneRosterBundle
+	report   *models.OneRosterReport
+	sources  map[string]map[string]oneRosterSource
+	terms    []models.Term
+	roles    map[string]string // user sourcedId → our role
+}
+
+func (im *oneRosterImporter) count(kind string) *models.OneRosterEntityCount {
+	c, ok := im.report.Entities[kind]
+	if !ok {
+		c = &models.OneRosterEntityCount{}
+		im.report.Entities[kind] = c
+	}
+	return c
+}
+
+// source returns the live (not deleted) source for a sourcedId.
+func (im *oneRosterImporter) source(kind, sourcedID string) (oneRosterSource, bool) {
+	src, ok := im.sources[kind][sourcedID]
+	return src, ok && !src.Deleted
+}
+
+// skip reports a row that wasn't imported. A record an earlier import created
+// is kept as it is rather than reconciled away.
+func (im *oneRosterImporter) skip(kind, file, sourcedID, message string) error {
+	im.count(kind).Skipped++
+	im.report.Warnings = append(im.report.Warnings, models.OneRosterWarning{File: file, SourcedID: sourcedID, Message: message})
+	if _, ok := im.source(kind, sourcedID); ok {
+		_, err := im.tx.Exec(im.ctx, `
+			UPDATE oneroster_sources SET last_seen_at = NOW()
+			WHERE school_id = $1 AND kind = $2 AND sourced_id = $3
+		`, im.schoolID, kind, sourcedID)
+		return err
+	}
+	return nil
+}
+
+// record saves the mapping from a sourcedId to the row it created.
+func (im *oneRosterImporter) record(kind, sourcedID string, entityID uuid.UUID, hash string) error {
+	_, err := im.tx.Exec(im.ctx, `
+		INSERT INTO oneroster_sources (school_id, kind, sourced_id, entity_id, row_hash)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (school_id, kind, sourced_id)
+		DO UPDATE SET entity_id = EXCLUDED.entity_id, row_hash = EXCLUDED.row_hash,
+		              last_seen_at = NOW(), deleted_at = NULL
+	`, im.schoolID, kind, sourcedID, entityID, hash)
+	if err != nil {
+		return err
+	}
+	if im.sources[kind] == nil {
+		im.sources[kind] = make(map[string]oneRosterSource)
+	}
+	im.sources[kind][sourcedID] = oneRosterSource{EntityID: entityID, Hash: hash}
+	return nil
+}
+
+// unchanged records a row whose source hash matches the last import.
+func (im *oneRosterImporter) unchanged(kind, sourcedID, hash string) (bool, error) {
+	src, ok := im.source(kind, sourcedID)
+	if !ok || src.Hash != hash {
+		return false, nil
+	}
+	im.count(kind).Unchanged++
+	return true, im.record(kind, sourcedID, src.EntityID, hash)
+}
+
+// remove deactivates the row behind a source and marks the source deleted.
+func (im *oneRosterImporter) remove(kind, sourcedID string) error {
+	src, ok := im.source(kind, sourcedID)
+	if !ok {
+		im.count(kind).Skipped++
+		return nil
+	}
+	var err error
+	switch kind {
+	case models.OneRosterUser:
+		if _, err = im.tx.Exec(im.ctx, `UPDATE users SET is_active = FALSE WHERE id = $1 AND school_id = $2`,
+			src.EntityID, im.schoolID); err == nil {
+			_, err = im.tx.Exec(im.ctx, `DELETE FROM sessions WHERE user_id = $1`, src.EntityID)
+		}
+	case models.OneRosterClass:
+		_, err = im.tx.Exec(im.ctx, `UPDATE courses SET is_active = FALSE WHERE id = $1 AND school_id = $2`,
+			src.EntityID, im.schoolID)
+	case models.OneRosterEnrollment:
+		_, err = im.tx.Exec(im.ctx, `
+			UPDATE enrollments SET status = 'dropped', dropped_at = NOW()
+			WHERE id = $1 AND school_id = $2 AND status = 'active'
+		`, src.EntityID, im.schoolID)
+	case models.OneRosterParentLink:
+		_, err = im.tx.Exec(im.ctx, `DELETE FROM parent_students WHERE id = $1 AND school_id = $2`,
+			src.EntityID, im.schoolID)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := im.tx.Exec(im.ctx, `
+		UPDATE oneroster_sources SET deleted_at = NOW(), last_seen_at = NOW()
+		WHERE school_id = $1 AND kind = $2 AND sourced_id = $3
+	`, im.schoolID, kind, sourcedID); err != nil {
+		return err
+	}
+	src.Deleted = true
+	im.sources[kind][sourcedID] = src
+	im.count(kind).Deactivated++
+	return nil
+}
+
+func oneRosterHash(v interface{}) string {
+	b, _ := json.Marshal(v)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func oneRosterDeleted(status string) bool {
+	return strings.EqualFold(status, services.OneRosterToBeDeleted)
+}
+
+func (im *oneRosterImporter) run() error {
+	for _, file := range []string{"orgs", "academicSessions", "users", "roles", "courses", "classes", "enrollments"} {
+		im.report.Modes[file] = im.bundle.Mode(file)
+	}
+
+	rows, err := im.tx.Query(im.ctx, `
+		SELECT kind, sourced_id, entity_id, row_hash, deleted_at IS NOT NULL
+		FROM oneroster_sources WHERE school_id = $1
+	`, im.schoolID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var kind, sourcedID string
+		var src oneRosterSource
+		if err := rows.Scan(&kind, &sourcedID, &src.EntityID, &src.Hash, &src.Deleted); err != nil {
+			rows.Close()
+			return err
+		}
+		if im.sources[kind] == nil {
+			im.sources[kind] = make(map[string]oneRosterSource)
+		}
+		im.sources[kind][sourcedID] = src
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if im.terms, err = loadTerms(im.ctx, im.tx, im.schoolID); err != nil {
+		return err
+	}
+
+	for _, step := range []func() error{
+		im.importOrgs,
+		im.importSessions,
+		im.importUsers,
+		im.importParentLinks,
+		im.importClasses,
+		im.importEnrollments,
+		im.reconcile,
+	} {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return im.tx.QueryRow(im.ctx, `
+		SELECT COUNT(*) FROM users WHERE school_id = $1 AND is_active AND password_hash = $2
+	`, im.schoolID, oneRosterNoPassword).Scan(&im.report.PendingCredentials)
+}
+
+// importOrgs maps the chosen school org to this school. Other orgs (districts,
+// other schools) are skipped.
+func (im *oneRosterImporter) importOrgs() error {
+	for _, o := range im.bundle.Orgs {
+		if o.SourcedID != im.org {
+			im.count(models.OneRosterOrg).Skipped++
+			continue
+		}
+		hash := oneRosterHash(o)
+		if ok, err := im.unchanged(models.OneRosterOrg, o.SourcedID, hash); ok || err != nil {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := im.source(models.OneRosterOrg, o.SourcedID); ok {
+			im.count(models.OneRosterOrg).Updated++
+		} else {
+			im.count(models.OneRosterOrg).Created++
+		}
+		if err := im.record(models.OneRosterOrg, o.SourcedID, im.schoolID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importSessions upserts academic sessions as terms. A schoolYear becomes a
+// year; other sessions nest one level under their parent (semester under a
+// year, quarter under a semester). Terms are never deleted, since courses and
+// report cards refer to them.
+func (im *oneRosterImporter) importSessions() error {
+	const file = "academicSessions.csv"
+	kind := models.OneRosterAcademicSession
+	sessions := append([]services.OneRosterSession(nil), im.bundle.AcademicSessions...)
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return oneRosterSessionRank[strings.ToLower(sessions[i].Type)] < oneRosterSessionRank[strings.ToLower(sessions[j].Type)]
+	})
+
+	for _, s := range sessions {
+		if oneRosterDeleted(s.Status) {
+			if err := im.skip(kind, file, s.SourcedID, "terms are not deleted by import; delete it in the app if it is unused"); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, known := oneRosterSessionRank[strings.ToLower(s.Type)]; !known {
+			if err := im.skip(kind, file, s.SourcedID, fmt.Sprintf("session type %q is not supported", s.Type)); err != nil {
+				return err
+			}
+			continue
+		}
+		if s.StartDate.IsZero() || s.EndDate.IsZero() {
+			if err := im.skip(kind, file, s.SourcedID, "startDate and endDate are required"); err != nil {
+				return err
+			}
+			continue
+		}
+		hash := oneRosterHash(s)
+		if ok, err := im.unchanged(kind, s.SourcedID, hash); ok || err != nil {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		var parent *models.Term
+		if src, ok := im.source(kind, s.ParentSourcedID); ok {
+			for i := range im.terms {
+				if im.terms[i].ID == src.EntityID {
+					parent = &im.terms[i]
+				}
+			}
+		}
+		termType := models.TermYear
+		if !strings.EqualFold(s.Type, "schoolYear") {
+			if parent == nil {
+				if err := im.skip(kind, file, s.SourcedID, "parent session not found"); err != nil {
+					return err
+				}
+				continue
+			}
+			child, ok := oneRosterChildTermType[parent.TermType]
+			if !ok {
+				if err := im.skip(kind, file, s.SourcedID, "sessions nest at most three levels (year, semester, quarter)"); err != nil {
+					return err
+				}
+				continue
+			}
+			termType = child
+		} else {
+			parent = nil
+		}
+
+		src, exists := im.source(kind, s.SourcedID)
+		excludeID := uuid.Nil
+		if exists {
+			excludeID = src.EntityID
+		}
+		if err := validateTermPlacement(im.terms, termType, parent, s.StartDate, s.EndDate, excludeID); err != nil {
+			if err := im.skip(kind, file, s.SourcedID, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+		var parentID *uuid.UUID
+		if parent != nil {
+			parentID = &parent.ID
+		}
+
+		var t models.Term
+		err := im.tx.QueryRow(im.ctx, `
+			UPDATE terms SET name = $1, term_type = $2, parent_id = $3, start_date = $4, end_date = $5
+			WHERE id = $6 AND school_id = $7
+			RETURNING id, short_id, school_id, parent_id, name, term_type, start_date, end_date, is_closed, closed_at,
+			          created_at, updated_at
+		`, s.Title, termType, parentID, s.StartDate, s.EndDate, excludeID, im.schoolID).Scan(
+			&t.ID, &t.ShortID, &t.SchoolID, &t.ParentID, &t.Name, &t.TermType,
+			&t.StartDate, &t.EndDate, &t.IsClosed, &t.ClosedAt, &t.CreatedAt, &t.UpdatedAt)
+		if err == nil {
+			im.count(kind).Updated++
+			for i := range im.terms {
+				if im.terms[i].ID == t.ID {
+					im.terms[i] = t
+				}
+			}
+		} else if errors.Is(err, pgx.ErrNoRows) {
+			err = im.tx.QueryRow(im.ctx, `
+				INSERT INTO terms (school_id, parent_id, name, term_type, start_date, end_date)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				RETURNING id, short_id, school_id, parent_id, name, term_type, start_date, end_date, is_closed, closed_at,
+				          created_at, updated_at
+			`, im.schoolID, parentID, s.Title, termType, s.StartDate, s.EndDate).Scan(
+				&t.ID, &t.ShortID, &t.SchoolID, &t.ParentID, &t.Name, &t.TermType,
+				&t.StartDate, &t.EndDate, &t.IsClosed, &t.ClosedAt, &t.CreatedAt, &t.UpdatedAt)
+			if err != nil {
+				return err
+			}
+			im.count(kind).Created++
+			im.terms = append(im.terms, t)
+		} else {
+			return err
+		}
+		if err := im.record(kind, s.SourcedID, t.ID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importUsers upserts users and their student or teacher records. Users are
+// matched by sourcedId, then by email so accounts created before the first
+// import are adopted rather than duplicated. Disabled users are deactivated.
+func (im *oneRosterImporter) importUsers() error {
+	const file = "users.csv"
+	kind := models.OneRosterUser
+
+	// 1.2 bundles list roles separately; use each user's primary role here.
+	listedRoles := make(map[string]string)
+	for _, r := range im.bundle.Roles {
+		if r.OrgSourcedID != "" && im.org != "" && r.OrgSourcedID != im.org {
+			continue
+		}
+		if _, ok := listedRoles[r.UserSourcedID]; !ok || strings.EqualFold(r.RoleType, "primary") {
+			listedRoles[r.UserSourcedID] = r.Role
+		}
+	}
+
+	for _, u := range im.bundle.Users {
+		if im.org != "" && len(u.OrgSourcedIDs) > 0 && !containsString(u.OrgSourcedIDs, im.org) {
+			im.count(kind).Skipped++
+			continue
+		}
+		if oneRosterDeleted(u.Status) {
+			if err := im.remove(kind, u.SourcedID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		role := u.Role
+		if role == "" {
+			role = listedRoles[u.SourcedID]
+		}
+		ourRole, ok := oneRosterRoles[strings.ToLower(role)]
+		if !ok {
+			if err := im.skip(kind, file, u.SourcedID, fmt.Sprintf("role %q is not imported", role)); err != nil {
+				return err
+			}
+			continue
+		}
+		im.roles[u.SourcedID] = ourRole
+
+		email := strings.ToLower(u.Email)
+		if email == "" && strings.Contains(u.Username, "@") {
+			email = strings.ToLower(u.Username)
+		}
+		if email == "" {
+			if err := im.skip(kind, file, u.SourcedID, "user has no email address"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		hash := oneRosterHash(struct {
+			User services.OneRosterUser
+			Role string
+		}{u, ourRole})
+		if ok, err := im.unchanged(kind, u.SourcedID, hash); ok || err != nil {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		var userID uuid.UUID
+		src, exists := im.source(kind, u.SourcedID)
+		if exists {
+			userID = src.EntityID
+		} else {
+			err := im.tx.QueryRow(im.ctx, `SELECT id FROM users WHERE school_id = $1 AND lower(email) = $2`,
+				im.schoolID, email).Scan(&userID)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+		}
+
+		var conflict bool
+		if err := im.tx.QueryRow(im.ctx, `
+			SELECT EXISTS (SELECT 1 FROM users WHERE school_id = $1 AND lower(email) = $2 AND id <> $3)
+		`, im.schoolID, email, userID).Scan(&conflict); err != nil {
+			return err
+		}
+		if conflict {
+			if err := im.skip(kind, file, u.SourcedID, "email "+email+" belongs to another user"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		studentNumber := u.Identifier
+		if studentNumber == "" {
+			studentNumber = u.SourcedID
+		}
+		if ourRole == models.RoleStudent {
+			if err := im.tx.QueryRow(im.ctx, `
+				SELECT EXISTS (SELECT 1 FROM students WHERE school_id = $1 AND student_number = $2 AND user_id <> $3)
+			`, im.schoolID, studentNumber, userID).Scan(&conflict); err != nil {
+				return err
+			}
+			if conflict {
+				if err := im.skip(kind, file, u.SourcedID, "student number "+studentNumber+" belongs to another student"); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if userID != uuid.Nil {
+			var currentRole string
+			if err := im.tx.QueryRow(im.ctx, `SELECT role FROM users WHERE id = $1`, userID).Scan(&currentRole); err != nil {
+				return err
+			}
+			if currentRole != ourRole {
+				if err := im.skip(kind, file, u.SourcedID,
+					fmt.Sprintf("existing user is a %s, not a %s; roles are not changed by import", currentRole, ourRole)); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := im.tx.Exec(im.ctx, `
+				UPDATE users
+				SET email = CASE WHEN lower(email) = $1 THEN email ELSE $1 END,
+				    first_name = $2, last_name = $3, phone = $4, is_active = $5
+				WHERE id = $6
+			`, email, u.GivenName, u.FamilyName, nullStr(u.Phone), u.EnabledUser, userID); err != nil {
+				return err
+			}
+			im.count(kind).Updated++
+		} else {
+			if err := im.tx.QueryRow(im.ctx, `
+				INSERT INTO users (school_id, role, email, password_hash, first_name, last_name, phone, is_active)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+				RETURNING id
+			`, im.schoolID, ourRole, email, oneRosterNoPassword, u.GivenName, u.FamilyName, nullStr(u.Phone),
+				u.EnabledUser).Scan(&userID); err != nil {
+				return err
+			}
+			im.count(kind).Created++
+		}
+		if !u.EnabledUser {
+			if _, err := im.tx.Exec(im.ctx, `DELETE FROM sessions WHERE user_id = $1`, userID); err != nil {
+				return err
+			}
+		}
+
+		switch ourRole {
+		case models.RoleStudent:
+			gradeLevel := "Unassigned"
+			if len(u.Grades) > 0 {
+				gradeLevel = u.Grades[0]
+			}
+			if _, err := im.tx.Exec(im.ctx, `
+				INSERT INTO students (user_id, school_id, student_number, grade_level, enrollment_date, short_id)
+				VALUES ($1, $2, $3, $4, CURRENT_DATE, left(md5(gen_random_uuid()::text), 8))
+				ON CONFLICT (user_id)
+				DO UPDATE SET student_number = EXCLUDED.student_number, grade_level = EXCLUDED.grade_level
+			`, userID, im.schoolID, studentNumber, gradeLevel); err != nil {
+				return err
+			}
+		case models.RoleTeacher:
+			if _, err := im.tx.Exec(im.ctx, `
+				INSERT INTO teachers (user_id, school_id) VALUES ($1, $2)
+				ON CONFLICT (user_id) DO NOTHING
+			`, userID, im.schoolID); err != nil {
+				return err
+			}
+		}
+
+		if err := im.record(kind, u.SourcedID, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importParentLinks links parents to students from users.csv agentSourcedIds,
+// which may be listed on either side. New links are "guardian" links.
+func (im *oneRosterImporter) importParentLinks() error {
+	const file = "users.csv"
+	kind := models.OneRosterParentLink
+
+	type pair struct{ parent, student string }
+	var pairs []pair
+	seen := make(map[pair]bool)
+	for _, u := range im.bundle.Users {
+		if oneRosterDeleted(u.Status) {
+			continue
+		}
+		for _, agent := range u.AgentSourcedIDs {
+			var p pair
+			switch im.roles[u.SourcedID] {
+			case models.RoleStudent:
+				p = pair{parent: agent, student: u.SourcedID}
+			case models.RoleParent:
+				p = pair{parent: u.SourcedID, student: agent}
+			default:
+				continue
+			}
+			if !seen[p] {
+				seen[p] = true
+				pairs = append(pairs, p)
+			}
+		}
+	}
+
+	for _, p := range pairs {
+		key := p.parent + "/" + p.student
+		const hash = "link"
+		if ok, err := im.unchanged(kind, key, hash); ok || err != nil {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		parentSrc, ok := im.source(models.OneRosterUser, p.parent)
+		if !ok {
+			if err := im.skip(kind, file, key, "parent "+p.parent+" was not imported"); err != nil {
+				return err
+			}
+			continue
+		}
+		studentSrc, ok := im.source(models.OneRosterUser, p.student)
+		if !ok {
+			if err := im.skip(kind, file, key, "student "+p.student+" was not imported"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var linkID uuid.UUID
+		err := im.tx.QueryRow(im.ctx, `
+			INSERT INTO parent_students (parent_id, student_id, school_id, relationship)
+			SELECT u.id, s.id, $3, 'guardian'
+			FROM users u, students s
+			WHERE u.id = $1 AND u.role = 'parent' AND s.user_id = $2 AND s.school_id = $3
+			ON CONFLICT (parent_id, student_id) DO UPDATE SET parent_id = EXCLUDED.parent_id
+			RETURNING id
+		`, parentSrc.EntityID, studentSrc.EntityID, im.schoolID).Scan(&linkID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			if err := im.skip(kind, file, key, "agent is not a parent of a student"); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		im.count(kind).Created++
+		if err := im.record(kind, key, linkID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importClasses upserts classes as courses. A class's teacher comes from its
+// primary teacher enrollment, and its year and semester labels from its
+// first term. A new class needs both.
+func (im *oneRosterImporter) importClasses() error {
+	const file = "classes.csv"
+	kind := models.OneRosterClass
+
+	courses := make(map[string]services.OneRosterCourse, len(im.bundle.Courses))
+	for _, c := range im.bundle.Courses {
+		courses[c.SourcedID] = c
+	}
+	teachers := make(map[string]string)
+	for _, e := range im.bundle.Enrollments {
+		if oneRosterDeleted(e.Status) || !strings.EqualFold(e.Role, "teacher") {
+			continue
+		}
+		if _, ok := teachers[e.ClassSourcedID]; !ok || e.Primary {
+			teachers[e.ClassSourcedID] = e.UserSourcedID
+		}
+	}
+
+	for _, c := range im.bundle.Classes {
+		if im.org != "" && c.SchoolSourcedID != "" && c.SchoolSourcedID != im.org {
+			im.count(kind).Skipped++
+			continue
+		}
+		if oneRosterDeleted(c.Status) {
+			if err := im.remove(kind, c.SourcedID); err != nil {
+				return err
+			}
+			continue
+		}
+		course := courses[c.CourseSourcedID]
+		teacher := teachers[c.SourcedID]
+		hash := oneRosterHash(struct {
+			Class   services.OneRosterClass
+			Course  services.OneRosterCourse
+			Teacher string
+		}{c, course, teacher})
+		if ok, err := im.unchanged(kind, c.SourcedID, hash); ok || err != nil {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		var teacherID *uuid.UUID
+		if src, ok := im.source(models.OneRosterUser, teacher); ok {
+			var id uuid.UUID
+			err := im.tx.QueryRow(im.ctx, `SELECT id FROM teachers WHERE user_id = $1 AND school_id = $2`,
+				src.EntityID, im.schoolID).Scan(&id)
+			if err == nil {
+				teacherID = &id
+			} else if !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+		}
+
+		var term *models.Term
+		for _, sid := range c.TermSourcedIDs {
+			if src, ok := im.source(models.OneRosterAcademicSession, sid); ok {
+				for i := range im.terms {
+					if im.terms[i].ID == src.EntityID {
+						term = &im.terms[i]
+					}
+				}
+				break
+			}
+		}
+		var termID *uuid.UUID
+		var academicYear, semester string
+		if term != nil {
+			termID = &term.ID
+			for _, t := range termPath(im.terms, term) {
+				switch t.TermType {
+				case models.TermYear:
+					academicYear = t.Name
+				case models.TermSemester:
+					semester = t.Name
+				}
+			}
+		}
+
+		subject := course.Title
+		if len(c.Subjects) > 0 {
+			subject = c.Subjects[0]
+		} else if len(course.Subjects) > 0 {
+			subject = course.Subjects[0]
+		}
+		if subject == "" {
+			subject = "General"
+		}
+		period := strings.Join(c.Periods, ", ")
+
+		var courseID uuid.UUID
+		if src, ok := im.source(kind, c.SourcedID); ok {
+			err := im.tx.QueryRow(im.ctx, `
+				UPDATE courses
+				SET name = $1, subject = $2, period = $3, room = $4, is_active = TRUE,
+				    teacher_id = COALESCE($5, teacher_id),
+				    term_id = COALESCE($6, term_id),
+				    academic_year = COALESCE(NULLIF($7, ''), academic_year),
+				    semester = COALESCE(NULLIF($8, ''), semester)
+				WHERE id = $9 AND school_id = $10
+				RETURNING id
+			`, c.Title, subject, nullStr(period), nullStr(c.Location), teacherID, termID, academicYear, semester,
+				src.EntityID, im.schoolID).Scan(&courseID)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+			if err == nil {
+				im.count(kind).Updated++
+			}
+		}
+		if courseID == uuid.Nil {
+			if teacherID == nil {
+				if err := im.skip(kind, file, c.SourcedID, "class has no imported teacher"); err != nil {
+					return err
+				}
+				continue
+			}
+			if term == nil {
+				if err := im.skip(kind, file, c.SourcedID, "class has no imported academic session"); err != nil {
+					return err
+				}
+				continue
+			}
+			sid, err := shortid.Generate()
+			if err != nil {
+				return err
+			}
+			if err := im.tx.QueryRow(im.ctx, `
+				INSERT INTO courses (school_id, teacher_id, name, subject, period, room, academic_year, semester, term_id, short_id)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+				RETURNING id
+			`, im.schoolID, *teacherID, c.Title, subject, nullStr(period), nullStr(c.Location),
+				academicYear, nullStr(semester), termID, sid).Scan(&courseID); err != nil {
+				return err
+			}
+			im.count(kind).Created++
+		}
+		if err := im.record(kind, c.SourcedID, courseID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importEnrollments upserts student enrollments. Teacher enrollments only set
+// a class's teacher (see importClasses) and other roles aren't imported; both
+// count as skipped.
+func (im *oneRosterImporter) importEnrollments() error {
+	const file = "enrollments.csv"
+	kind := models.OneRosterEnrollment
+
+	for _, e := range im.bundle.Enrollments {
+		if im.org != "" && e.SchoolSourcedID != "" && e.SchoolSourcedID != im.org {
+			im.count(kind).Skipped++
+			continue
+		}
+		if oneRosterDeleted(e.Status) {
+			if err := im.remove(kind, e.SourcedID); err != nil {
+				return err
+			}
+			continue
+		}
+		if !strings.EqualFold(e.Role, "student") {
+			im.count(kind).Skipped++
+			continue
+		}
+		hash := oneRosterHash(e)
+		if ok, err := im.unchanged(kind, e.SourcedID, hash); ok || err != nil {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		classSrc, ok := im.source(models.OneRosterClass, e.ClassSourcedID)
+		if !ok {
+			if err := im.skip(kind, file, e.SourcedID, "class "+e.ClassSourcedID+" was not imported"); err != nil {
+				return err
+			}
+			continue
+		}
+		userSrc, ok := im.source(models.OneRosterUser, e.UserSourcedID)
+		if !ok {
+			if err := im.skip(kind, file, e.SourcedID, "student "+e.UserSourcedID+" was not imported"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var enrollmentID uuid.UUID
+		err := im.tx.QueryRow(im.ctx, `
+			INSERT INTO enrollments (student_id, course_id, school_id)
+			SELECT s.id, $2, $3 FROM students s WHERE s.user_id = $1 AND s.school_id = $3
+			ON CONFLICT (student_id, course_id) DO UPDATE SET status = 'active', dropped_at = NULL
+			RETURNING id
+		`, userSrc.EntityID, classSrc.EntityID, im.schoolID).Scan(&enrollmentID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			if err := im.skip(kind, file, e.SourcedID, "user "+e.UserSourcedID+" is not a student"); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		if _, existed := im.sources[kind][e.SourcedID]; existed {
+			im.count(kind).Updated++
+		} else {
+			im.count(kind).Created++
+		}
+		if err := im.record(kind, e.SourcedID, enrollmentID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// incompleteBulkFile explains why a bulk file can't be trusted to list every
+// live row, or returns "" when it can: the file must be in the bundle, have
+// rows, and give every row a sourcedId.
+func (im *oneRosterImporter) incompleteBulkFile(file string) string {
+	if !im.bundle.Present(file) {
+		return "the manifest lists this file as bulk but it is missing from the bundle"
+	}
+	var ids []string
+	switch file {
+	case "users":
+		for _, u := range im.bundle.Users {
+			ids = append(ids, u.SourcedID)
+		}
+	case "classes":
+		for _, c := range im.bundle.Classes {
+			ids = append(ids, c.SourcedID)
+		}
+	case "enrollments":
+		for _, e := range im.bundle.Enrollments {
+			ids = append(ids, e.SourcedID)
+		}
+	}
+	if len(ids) == 0 {
+		return "the file has no rows"
+	}
+	blank := 0
+	for _, id := range ids {
+		if strings.TrimSpace(id) == "" {
+			blank++
+		}
+	}
+	if blank > 0 {
+		return fmt.Sprintf("%d rows have no sourcedId", blank)
+	}
+	return ""
+}
+
+// reconcile deactivates what earlier imports created but a bulk file no longer
+// lists: users are deactivated, classes made inactive, enrollments dropped,
+// and parent links removed. Delta files only remove tobedeleted rows. A bulk
+// file that is missing, empty, or has rows without a sourcedId removes
+// nothing, since what it leaves out isn't known to be gone.
+func (im *oneRosterImporter) reconcile() error {
+	warned := make(map[string]bool)
+	for _, r := range []struct{ kind, file string }{
+		{models.OneRosterUser, "users"},
+		{models.OneRosterParentLink, "users"},
+		{models.OneRosterClass, "classes"},
+		{models.OneRosterEnrollment, "enrollments"},
+	} {
+		if im.bundle.Mode(r.file) != services.OneRosterBulk {
+			continue
+		}
+		if reason := im.incompleteBulkFile(r.file); reason != "" {
+			if !warned[r.file] {
+				warned[r.file] = true
+				im.report.Warnings = append(im.report.Warnings, models.OneRosterWarning{
+					File:    r.file + ".csv",
+					Message: reason + "; nothing missing from it was deactivated",
+				})
+			}
+			continue
+		}
+		rows, err := im.tx.Query(im.ctx, `
+			SELECT sourced_id FROM oneroster_sources
+			WHERE school_id = $1 AND kind = $2 AND deleted_at IS NULL AND last_seen_at < NOW()
+		`, im.schoolID, r.kind)
+		if err != nil {
+			return err
+		}
+		var stale []string
+		for rows.Next() {
+			var sid string
+			if err := rows.Scan(&sid); err != nil {
+				rows.Close()
+				return err
+			}
+			stale = append(stale, sid)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, sid := range stale {
+			if err := im.remove(r.kind, sid); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportOneRoster imports a OneRoster 1.2 CSV bundle (base64 zip in "file").
+// When the bundle lists more than one school, org_sourced_id picks this
+// school's org. Everything is applied in one transaction; with dry_run it is
+// rolled back, so the report shows exactly what an import would do. Every
+// import, dry run or not, is recorded with its report.
+func (h *OneRosterHandler) ImportOneRoster(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		File         []byte `json:"file" validate:"required"`
+		OrgSourcedID string `json:"org_sourced_id" validate:"omitempty,max=255"`
+		DryRun       bool   `json:"dry_run"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	bundle, err := services.ParseOneRosterBundle(req.File)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_bundle", err.Error())
+		return
+	}
+
+	// Pick the org that is this school.
+	org := req.OrgSourcedID
+	if org != "" {
+		found := false
+		for _, o := range bundle.Orgs {
+			found = found || o.SourcedID == org
+		}
+		if !found && len(bundle.Orgs) > 0 {
+			writeError(w, http.StatusBadRequest, "invalid_org", "org_sourced_id is not in orgs.csv")
+			return
+		}
+	} else {
+		var schools []string
+		for _, o := range bundle.Orgs {
+			if strings.EqualFold(o.Type, "school") && !oneRosterDeleted(o.Status) {
+				schools = append(schools, o.SourcedID)
+			}
+		}
+		switch {
+		case len(schools) == 1:
+			org = schools[0]
+		case len(schools) > 1:
+			writeError(w, http.StatusBadRequest, "ambiguous_org",
+				fmt.Sprintf("bundle lists %d schools; set org_sourced_id", len(schools)))
+			return
+		default:
+			// Delta bundles often leave out orgs.csv; reuse the org from earlier imports.
+			err := h.db.QueryRow(ctx, `
+				SELECT sourced_id FROM oneroster_sources
+				WHERE school_id = $1 AND kind = 'org' AND deleted_at IS NULL
+				ORDER BY last_seen_at DESC LIMIT 1
+			`, claims.SchoolID).Scan(&org)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+				return
+			}
+		}
+	}
+
+	report := &models.OneRosterReport{
+		Modes:    make(map[string]string),
+		Entities: make(map[string]*models.OneRosterEntityCount),
+	}
+	runErr := func() error {
+		tx, err := h.db.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+		im := &oneRosterImporter{
+			ctx:      ctx,
+			tx:       tx,
+			schoolID: claims.SchoolID,
+			org:      org,
+			bundle:   bundle,
+			report:   report,
+			sources:  make(map[string]map[string]oneRosterSource),
+			roles:    make(map[string]string),
+		}
+		if err := im.run(); err != nil {
+			return err
+		}
+		if req.DryRun {
+			return nil
+		}
+		return tx.Commit(ctx)
+	}()
+
+	imp := models.OneRosterImport{
+		SchoolID:   claims.SchoolID,
+		DryRun:     req.DryRun,
+		Status:     models.OneRosterImportCompleted,
+		Report:     report,
+		ImportedBy: claims.UserID,
+	}
+	if org != "" {
+		imp.OrgSourcedID = &org
+	}
+	if runErr != nil {
+		msg := runErr.Error()
+		imp.Status, imp.Error = models.OneRosterImportFailed, &msg
+	}
+	reportJSON, _ := json.Marshal(report)
+	if err := h.db.QueryRow(ctx, `
+		INSERT INTO oneroster_imports (school_id, org_sourced_id, dry_run, status, report, error, imported_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, short_id, created_at
+	`, imp.SchoolID, imp.OrgSourcedID, imp.DryRun, imp.Status, reportJSON, imp.Error, imp.ImportedBy).Scan(
+		&imp.ID, &imp.ShortID, &imp.CreatedAt); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	if runErr != nil {
+		writeError(w, http.StatusInternalServerError, "import_failed", runErr.Error())
+		return
+	}
+
+	if !req.DryRun {
+		_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+			SchoolID:   claims.SchoolID,
+			UserID:     &claims.UserID,
+			Action:     "oneroster.import",
+			EntityType: "oneroster_import",
+			EntityID:   &imp.ID,
+			NewValue:   map[string]interface{}{"org_sourced_id": org, "modes": report.Modes, "entities": report.Entities},
+			IPAddress:  r.RemoteAddr,
+			UserAgent:  r.UserAgent(),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, imp)
+}
+
+const oneRosterImportColumns = `id, short_id, school_id, org_sourced_id, dry_run, status, report, error, imported_by, created_at`
+
+func scanOneRosterImport(row pgx.Row) (*models.OneRosterImport, error) {
+	var imp models.OneRosterImport
+	if err := row.Scan(&imp.ID, &imp.ShortID, &imp.SchoolID, &imp.OrgSourcedID, &imp.DryRun, &imp.Status,
+		&imp.ReportRaw, &imp.Error, &imp.ImportedBy, &imp.CreatedAt); err != nil {
+		return nil, err
+	}
+	if len(imp.ReportRaw) > 0 {
+		if err := json.Unmarshal(imp.ReportRaw, &imp.Report); err != nil {
+			return nil, err
+		}
+	}
+	return &imp, nil
+}
+
+// ListOneRosterImports returns the school's imports, newest first, without
+// their warnings.
+func (h *OneRosterHandler) ListOneRosterImports(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+	limit, offset := paginate(r)
+
+	rows, err := h.db.Query(ctx, `
+		SELECT `+oneRosterImportColumns+`
+		FROM oneroster_imports
+		WHERE school_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`, claims.SchoolID, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	imports := []models.OneRosterImport{}
+	for rows.Next() {
+		imp, err := scanOneRosterImport(rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		if imp.Report != nil {
+			imp.Report.Warnings = nil
+		}
+		imports = append(imports, *imp)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"imports": imports})
+}
+
+// GetOneRosterImport returns one import with its full report.
+// importId URL param is a short_id.
+func (h *OneRosterHandler) GetOneRosterImport(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	imp, err := scanOneRosterImport(h.db.QueryRow(ctx, `
+		SELECT `+oneRosterImportColumns+`
+		FROM oneroster_imports
+		WHERE short_id = $1 AND school_id = $2
+	`, chi.URLParam(r, "importId"), claims.SchoolID))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "import not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, imp)
+}
+
+// ExportOneRosterGradebook downloads published assignments as OneRoster line
+// items and their grades as results, zipped with categories and a manifest.
+// Classes, students, and grading periods that came from a OneRoster import
+// keep their sourcedIds; everything else uses our UUIDs.
+// Query param: course_id (short_id; optional, defaults to every course).
+func (h *OneRosterHandler) ExportOneRosterGradebook(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var courseID *uuid.UUID
+	if param := r.URL.Query().Get("course_id"); param != "" {
+		id, err := resolveCourseUUID(ctx, h.db, param, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "not_found", "course not found")
+			return
+		}
+		courseID = &id
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT a.id, a.title, COALESCE(a.description, ''), a.created_at, a.due_date, a.category,
+		       a.max_points, a.updated_at,
+		       COALESCE(cs.sourced_id, c.id::text), COALESCE(ts.sourced_id, c.term_id::text, '')
+		FROM assignments a
+		JOIN courses c ON c.id = a.course_id
+		LEFT JOIN oneroster_sources cs
+		       ON cs.school_id = a.school_id AND cs.kind = 'class' AND cs.entity_id = c.id AND cs.deleted_at IS NULL
+		LEFT JOIN oneroster_sources ts
+		       ON ts.school_id = a.school_id AND ts.kind = 'academicSession' AND ts.entity_id = c.term_id
+		       AND ts.deleted_at IS NULL
+		WHERE a.school_id = $1 AND a.is_published = TRUE AND ($2::UUID IS NULL OR a.course_id = $2)
+		ORDER BY c.name, a.due_date
+	`, claims.SchoolID, courseID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	var lineItems []services.OneRosterLineItem
+	categorySeen := make(map[string]bool)
+	var categories []services.OneRosterCategory
+	now := time.Now()
+	for rows.Next() {
+		var li services.OneRosterLineItem
+		var id uuid.UUID
+		if err := rows.Scan(&id, &li.Title, &li.Description, &li.AssignDate, &li.DueDate, &li.CategorySourcedID,
+			&li.ResultValueMax, &li.DateLastModified, &li.ClassSourcedID, &li.GradingPeriodSourcedID); err != nil {
+			rows.Close()
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		li.SourcedID = id.String()
+		lineItems = append(lineItems, li)
+		if !categorySeen[li.CategorySourcedID] {
+			categorySeen[li.CategorySourcedID] = true
+			categories = append(categories, services.OneRosterCategory{
+				SourcedID: li.CategorySourcedID, DateLastModified: now, Title: li.CategorySourcedID,
+			})
+		}
+	}
+	rows.Close()
+
+	rows, err = h.db.Query(ctx, `
+		SELECT g.id, g.assignment_id, COALESCE(us.sourced_id, s.user_id::text),
+		       g.points_earned, COALESCE(g.is_excused, FALSE), COALESCE(g.comment, ''),
+		       COALESCE(g.graded_at, g.updated_at), g.updated_at
+		FROM grades g
+		JOIN assignments a ON a.id = g.assignment_id
+		JOIN students s ON s.id = g.student_id
+		LEFT JOIN oneroster_sources us
+		       ON us.school_id = g.school_id AND us.kind = 'user' AND us.entity_id = s.user_id AND us.deleted_at IS NULL
+		WHERE g.school_id = $1 AND a.is_published = TRUE AND ($2::UUID IS NULL OR a.course_id = $2)
+		ORDER BY a.id, g.student_id
+	`, claims.SchoolID, courseID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+	var results []services.OneRosterResult
+	for rows.Next() {
+		var res services.OneRosterResult
+		var id, assignmentID uuid.UUID
+		var excused bool
+		if err := rows.Scan(&id, &assignmentID, &res.StudentSourcedID, &res.Score, &excused, &res.Comment,
+			&res.ScoreDate, &res.DateLastModified); err != nil {
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		res.SourcedID, res.LineItemSourcedID = id.String(), assignmentID.String()
+		switch {
+		case excused:
+			res.ScoreStatus, res.Score = services.OneRosterScoreExempt, nil
+		case res.Score != nil:
+			res.ScoreStatus = services.OneRosterScoreFullyGraded
+		default:
+			res.ScoreStatus = services.OneRosterScoreNotSubmitted
+		}
+		results = append(results, res)
+	}
+	if err := rows.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	out, err := services.EncodeOneRosterGradebook(categories, lineItems, results)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "export_error", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="oneroster-gradebook.zip"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+)
+
+// Users imported from a SIS have no password (oneRosterNoPassword). An admin
+// lists them with ListPendingCredentials and emails each a single-use link
+// with SendPasswordSetup; the user redeems it with SetUpPassword.
+
+// passwordSetupTTL is how long a password setup link stays valid.
+const passwordSetupTTL = 7 * 24 * time.Hour
+
+// pendingCredentialsUser is an active account that cannot sign in yet.
+type pendingCredentialsUser struct {
+	UserID        uuid.UUID  `json:"user_id"`
+	Email         string     `json:"email"`
+	FirstName     string     `json:"first_name"`
+	LastName      string     `json:"last_name"`
+	Role          string     `json:"role"`
+	LastInvitedAt *time.Time `json:"last_invited_at"`
+}
+
+// ListPendingCredentials returns the school's active users who have no
+// password yet, with when each was last sent a setup link (admin only).
+func (h *AdminHandler) ListPendingCredentials(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+	limit, offset := paginate(r)
+
+	rows, err := h.db.Query(ctx, `
+		SELECT u.id, u.email, u.first_name, u.last_name, u.role,
+		       (SELECT MAX(t.created_at) FROM password_setup_tokens t WHERE t.user_id = u.id)
+		FROM users u
+		WHERE u.school_id = $1 AND u.is_active AND u.password_hash = $2
+		ORDER BY u.last_name, u.first_name
+		LIMIT $3 OFFSET $4
+	`, claims.SchoolID, oneRosterNoPassword, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	users := []pendingCredentialsUser{}
+	for rows.Next() {
+		var u pendingCredentialsUser
+		if err := rows.Scan(&u.UserID, &u.Email, &u.FirstName, &u.LastName, &u.Role, &u.LastInvitedAt); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"users": users})
+}
+
+// SendPasswordSetup emails each listed user a link to set their password,
+// replacing any link sent before (admin only). Only active users of the
+// school who have no password yet are sent one; the rest are reported back.
+func (h *AdminHandler) SendPasswordSetup(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		UserIDs []string `json:"user_ids" validate:"required,min=1,max=500,dive,uuid"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	var schoolName string
+	if err := h.db.QueryRow(ctx, `SELECT name FROM schools WHERE id = $1`, claims.SchoolID).Scan(&schoolName); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	type failure struct {
+		UserID string `json:"user_id"`
+		Reason string `json:"reason"`
+	}
+	sent := []string{}
+	failed := []failure{}
+	for _, id := range req.UserIDs {
+		var email, firstName string
+		err := h.db.QueryRow(ctx, `
+			SELECT email, first_name FROM users
+			WHERE id = $1 AND school_id = $2 AND is_active AND password_hash = $3
+		`, id, claims.SchoolID, oneRosterNoPassword).Scan(&email, &firstName)
+		if errors.Is(err, pgx.ErrNoRows) {
+			failed = append(failed, failure{id, "not an active user of this school without a password"})
+			continue
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+
+		token, err := newPasswordSetupToken()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "token_error", "")
+			return
+		}
+		expiresAt := time.Now().Add(passwordSetupTTL)
+		tx, err := h.db.Begin(ctx)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE password_setup_tokens SET expires_at = NOW()
+			WHERE user_id = $1 AND used_at IS NULL AND expires_at > NOW()
+		`, id)
+		if err == nil {
+			_, err = tx.Exec(ctx, `
+				INSERT INTO password_setup_tokens (user_id, school_id, token_hash, expires_at, created_by)
+				VALUES ($1, $2, $3, $4, $5)
+			`, id, claims.SchoolID, auth.HashToken(token), expiresAt, claims.UserID)
+		}
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+		tx.Rollback(ctx)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+
+		if err := h.email.SendAccountSetup(email, firstName, schoolName, h.frontendOrigin+"/set-password?token="+token); err != nil {
+			log.Printf("password setup: user %s: %v", id, err)
+			failed = append(failed, failure{id, "the email could not be sent"})
+			continue
+		}
+		sent = append(sent, id)
+
+		userID := uuid.MustParse(id)
+		_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+			SchoolID:   claims.SchoolID,
+			UserID:     &claims.UserID,
+			Action:     "user.password_setup_sent",
+			EntityType: "user",
+			EntityID:   &userID,
+			NewValue:   map[string]interface{}{"email": email, "expires_at": expiresAt},
+			IPAddress:  r.RemoteAddr,
+			UserAgent:  r.UserAgent(),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sent": sent, "failed": failed})
+}
+
+// SetUpPassword sets a user's password from a setup link sent by
+// SendPasswordSetup. Each link works once and only until it expires.
+func (h *AuthHandler) SetUpPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token    string `json:"token" validate:"required,max=128"`
+		Password string `json:"password" validate:"required,min=12"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	if err := auth.ValidatePasswordStrength(req.Password); err != nil {
+		writeError(w, http.StatusBadRequest, "weak_password", err.Error())
+		return
+	}
+
+	breached, _ := auth.CheckBreachedPassword(req.Password)
+	if breached {
+		writeError(w, http.StatusBadRequest, "breached_password",
+			"this password has appeared in a known data breach; please choose a different password")
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "hash_error", "")
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var tokenID, userID, schoolID uuid.UUID
+	err = tx.QueryRow(ctx, `
+		UPDATE password_setup_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING id, user_id, school_id
+	`, auth.HashToken(req.Token)).Scan(&tokenID, &userID, &schoolID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeError(w, http.StatusBadRequest, "invalid_token", "this link is invalid or has expired; ask your school for a new one")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE users SET password_hash = $1, failed_login_attempts = 0, locked_until = NULL
+		WHERE id = $2 AND is_active
+	`, hash, userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		writeError(w, http.StatusForbidden, "account_inactive", "account has been deactivated")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   schoolID,
+		UserID:     &userID,
+		Action:     "user.password_setup",
+		EntityType: "user",
+		EntityID:   &userID,
+		NewValue:   map[string]interface{}{"token_id": tokenID},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newPasswordSetupToken returns a random URL-safe token.
+func newPasswordSetupToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
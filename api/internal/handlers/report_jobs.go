@@ -0,0 +1,452 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
+)
+
+// reportJobWorkers bounds how many cards one batch job renders at once.
+// Sized to stay well under the pool's 25 connections.
+const reportJobWorkers = 12
+
+// reportJobLease is how long a replica holds a batch job between renewals.
+// A job whose lease lapses is claimed by another replica.
+const reportJobLease = 2 * time.Minute
+
+// instanceID identifies this process as the holder of a lease.
+var instanceID = uuid.New()
+
+// errReportJobItemTaken means another runner already finished an item.
+var errReportJobItemTaken = errors.New("report card job item already processed")
+
+// ResumeReportJobs picks up batch jobs no replica holds a lease on: jobs left
+// by a process that stopped, or by a replica that died mid-run. It checks at
+// startup and then once per lease period until ctx ends. Only items not yet
+// completed or failed are generated again.
+func (h *ReportsHandler) ResumeReportJobs(ctx context.Context) {
+	for {
+		ids, err := h.claimReportJobs(ctx)
+		if err != nil {
+			log.Printf("reports: resume jobs: %v", err)
+		}
+		for _, id := range ids {
+			log.Printf("reports: resuming batch job %s", id)
+			go h.runReportJob(id)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reportJobLease):
+		}
+	}
+}
+
+// claimReportJobs takes the lease on every unfinished job whose lease is
+// free or lapsed. SKIP LOCKED keeps two replicas from claiming the same job.
+func (h *ReportsHandler) claimReportJobs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := h.db.Query(ctx, `
+		UPDATE report_card_jobs
+		SET lease_owner = $1, lease_expires_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM report_card_jobs
+			WHERE status IN ('pending', 'running')
+			  AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id
+	`, instanceID, reportJobLease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// holdReportJobLease renews this process's lease on a job until ctx ends.
+// If the lease has been lost to another replica it cancels the run.
+func (h *ReportsHandler) holdReportJobLease(ctx context.Context, cancel context.CancelFunc, jobID uuid.UUID) {
+	ticker := time.NewTicker(reportJobLease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		tag, err := h.db.Exec(ctx, `
+			UPDATE report_card_jobs SET lease_expires_at = NOW() + make_interval(secs => $3)
+			WHERE id = $1 AND lease_owner = $2
+		`, jobID, instanceID, reportJobLease.Seconds())
+		if err != nil {
+			log.Printf("reports: renew lease on job %s: %v", jobID, err)
+			continue
+		}
+		if tag.RowsAffected() == 0 {
+			log.Printf("reports: lost lease on job %s; stopping", jobID)
+			cancel()
+			return
+		}
+	}
+}
+
+// runReportJob renders every pending item of a job, then builds the optional
+// ZIP bundle. Each card is recorded in the same transaction that marks its
+// item complete, so a crash never produces a duplicate card for a student.
+// The job runs only while this process holds its lease.
+func (h *ReportsHandler) runReportJob(jobID uuid.UUID) {
+	// runningJobs stops this process from starting a job it already runs;
+	// the lease does the same across replicas.
+	if _, busy := h.runningJobs.LoadOrStore(jobID, struct{}{}); busy {
+		return
+	}
+	defer h.runningJobs.Delete(jobID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var job models.ReportCardJob
+	err := h.db.QueryRow(ctx, `
+		UPDATE report_card_jobs
+		SET status = 'running', started_at = COALESCE(started_at, NOW()),
+		    lease_owner = $2, lease_expires_at = NOW() + make_interval(secs => $3)
+		WHERE id = $1 AND status IN ('pending', 'running')
+		  AND (lease_owner = $2 OR lease_expires_at IS NULL OR lease_expires_at < NOW())
+		RETURNING school_id, term_id, academic_period, bundle, requested_by
+	`, jobID, instanceID, reportJobLease.Seconds()).Scan(&job.SchoolID, &job.TermID, &job.AcademicPeriod, &job.Bundle, &job.RequestedBy)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return // finished, or another replica holds it
+	}
+	if err != nil {
+		log.Printf("reports: start job %s: %v", jobID, err)
+		return
+	}
+	go h.holdReportJobLease(ctx, cancel, jobID)
+
+	school, err := loadSchool(ctx, h.db, job.SchoolID)
+	if err != nil {
+		h.failReportJob(ctx, jobID, err)
+		return
+	}
+
+	// Branding images and the template are fetched once for the whole batch.
+	assets := loadSchoolAssets(ctx, h.storage, school)
+	tmpl, err := resolveTemplate(ctx, h.db, school, services.TemplateKindReportCard, "")
+	if err != nil {
+		h.failReportJob(ctx, jobID, err)
+		return
+	}
+
+	var term *models.Term
+	if job.TermID != nil {
+		terms, err := loadTerms(ctx, h.db, job.SchoolID)
+		if err != nil {
+			h.failReportJob(ctx, jobID, err)
+			return
+		}
+		for i := range terms {
+			if terms[i].ID == *job.TermID {
+				term = &terms[i]
+			}
+		}
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT id, student_id FROM report_card_job_items WHERE job_id = $1 AND status = 'pending'
+	`, jobID)
+	if err != nil {
+		h.failReportJob(ctx, jobID, err)
+		return
+	}
+	var items []models.ReportCardJobItem
+	for rows.Next() {
+		var it models.ReportCardJobItem
+		if err := rows.Scan(&it.ID, &it.StudentID); err != nil {
+			rows.Close()
+			h.failReportJob(ctx, jobID, err)
+			return
+		}
+		items = append(items, it)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		h.failReportJob(ctx, jobID, err)
+		return
+	}
+
+	queue := make(chan models.ReportCardJobItem)
+	var wg sync.WaitGroup
+	for i := 0; i < reportJobWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for it := range queue {
+				h.runReportJobItem(ctx, jobID, it, reportCardInput{
+					School:         school,
+					StudentID:      it.StudentID,
+					Term:           term,
+					AcademicPeriod: job.AcademicPeriod,
+					GeneratedBy:    job.RequestedBy,
+					Assets:         assets,
+					Template:       tmpl,
+				})
+			}
+		}()
+	}
+	for _, it := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- it
+	}
+	close(queue)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return // lease lost; the new holder finishes the job
+	}
+
+	if job.Bundle {
+		key, err := h.buildReportBundle(ctx, jobID, school.ID)
+		if err != nil {
+			h.failReportJob(ctx, jobID, fmt.Errorf("bundle: %w", err))
+			return
+		}
+		if _, err := h.db.Exec(ctx, `UPDATE report_card_jobs SET bundle_url = $1 WHERE id = $2`, key, jobID); err != nil {
+			h.failReportJob(ctx, jobID, fmt.Errorf("bundle: %w", err))
+			return
+		}
+	}
+
+	if _, err := h.db.Exec(ctx, `
+		UPDATE report_card_jobs
+		SET status = 'completed', finished_at = NOW(), lease_owner = NULL, lease_expires_at = NULL
+		WHERE id = $1 AND lease_owner = $2
+	`, jobID, instanceID); err != nil {
+		// The lease expires and ResumeReportJobs runs the job again, which
+		// finds no pending items and completes it.
+		log.Printf("reports: complete job %s: %v", jobID, err)
+	}
+}
+
+// runReportJobItem generates one student's card and records the outcome.
+func (h *ReportsHandler) runReportJobItem(ctx context.Context, jobID uuid.UUID, it models.ReportCardJobItem, in reportCardInput) {
+	rc, err := h.renderReportCard(ctx, in)
+	if err == nil {
+		err = h.completeReportJobItem(ctx, jobID, it.ID, rc)
+	}
+	if errors.Is(err, errReportJobItemTaken) || ctx.Err() != nil {
+		return // not this runner's to record
+	}
+	if err != nil {
+		// Only the runner whose update fails the item counts it.
+		if _, dbErr := h.db.Exec(ctx, `
+			WITH failed AS (
+				UPDATE report_card_job_items SET status = 'failed', error = $1, updated_at = NOW()
+				WHERE id = $2 AND status = 'pending'
+				RETURNING job_id
+			)
+			UPDATE report_card_jobs SET failed_count = failed_count + 1
+			WHERE id IN (SELECT job_id FROM failed)
+		`, err.Error(), it.ID); dbErr != nil {
+			log.Printf("reports: job %s: record failed item %s: %v", jobID, it.ID, dbErr)
+		}
+	}
+}
+
+// completeReportJobItem inserts the card and marks its item done atomically.
+func (h *ReportsHandler) completeReportJobItem(ctx context.Context, jobID, itemID uuid.UUID, rc *generatedReportCard) error {
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertReportCard(ctx, tx, rc); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `
+		UPDATE report_card_job_items SET status = 'completed', report_card_id = $1, updated_at = NOW()
+		WHERE id = $2 AND status = 'pending'
+	`, rc.ID, itemID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errReportJobItemTaken
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE report_card_jobs SET completed_count = completed_count + 1 WHERE id = $1
+	`, jobID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// buildReportBundle zips every completed card in a job and uploads the archive.
+// Entries are named Last_First_StudentNumber so a grade level sorts by name.
+func (h *ReportsHandler) buildReportBundle(ctx context.Context, jobID, schoolID uuid.UUID) (string, error) {
+	rows, err := h.db.Query(ctx, `
+		SELECT u.last_name, u.first_name, s.student_number, rc.pdf_url
+		FROM report_card_job_items i
+		JOIN report_cards rc ON rc.id = i.report_card_id
+		JOIN students s ON s.id = i.student_id
+		JOIN users u ON u.id = s.user_id
+		WHERE i.job_id = $1 AND i.status = 'completed'
+		ORDER BY u.last_name, u.first_name
+	`, jobID)
+	if err != nil {
+		return "", err
+	}
+	type entry struct{ name, key string }
+	var entries []entry
+	for rows.Next() {
+		var last, first, number, key string
+		if err := rows.Scan(&last, &first, &number, &key); err != nil {
+			rows.Close()
+			return "", err
+		}
+		name := strings.Join([]string{last, first, number}, "_")
+		name = strings.Map(func(r rune) rune {
+			if r == '/' || r == '\\' || r == ' ' {
+				return '_'
+			}
+			return r
+		}, name)
+		entries = append(entries, entry{name: name + path.Ext(key), key: key})
+	}
+	rows.Close()
+
+	// The archive is spooled to a temp file so a large batch is never held
+	// in memory; each card is streamed into it from storage.
+	f, err := os.CreateTemp("", "report-bundle-*.zip")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		if err := h.addBundleEntry(ctx, zw, e.name, e.key); err != nil {
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	key := services.ObjectKey(schoolID.String(), "reports", "batch-"+jobID.String()+".zip")
+	if err := h.storage.PutFile(ctx, key, f, "application/zip"); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// addBundleEntry copies one stored card into the archive.
+func (h *ReportsHandler) addBundleEntry(ctx context.Context, zw *zip.Writer, name, key string) error {
+	body, err := h.storage.OpenObject(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, body)
+	return err
+}
+
+// failReportJob marks a job failed with the error that stopped it.
+func (h *ReportsHandler) failReportJob(ctx context.Context, jobID uuid.UUID, cause error) {
+	log.Printf("reports: job %s failed: %v", jobID, cause)
+	if _, err := h.db.Exec(ctx, `
+		UPDATE report_card_jobs
+		SET status = 'failed', error = $1, finished_at = NOW(), lease_owner = NULL, lease_expires_at = NULL
+		WHERE id = $2
+	`, cause.Error(), jobID); err != nil {
+		log.Printf("reports: record failure of job %s: %v", jobID, err)
+	}
+}
+
+// GetBatchJob returns a batch job's progress, any per-student failures, and
+// a download link for the ZIP bundle once it is ready.
+// jobId URL param is a short_id.
+func (h *ReportsHandler) GetBatchJob(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var job models.ReportCardJob
+	err := h.db.QueryRow(ctx, `
+		SELECT id, short_id, school_id, academic_period, grade_level, status,
+		       total_count, completed_count, failed_count, bundle, bundle_url, error,
+		       requested_by, started_at, finished_at, created_at
+		FROM report_card_jobs
+		WHERE short_id = $1 AND school_id = $2
+	`, chi.URLParam(r, "jobId"), claims.SchoolID).Scan(
+		&job.ID, &job.ShortID, &job.SchoolID, &job.AcademicPeriod, &job.GradeLevel, &job.Status,
+		&job.TotalCount, &job.CompletedCount, &job.FailedCount, &job.Bundle, &job.BundleURL, &job.Error,
+		&job.RequestedBy, &job.StartedAt, &job.FinishedAt, &job.CreatedAt,
+	)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "batch job not found")
+		return
+	}
+
+	if job.BundleURL != nil {
+		if url, err := h.storage.PresignDownload(ctx, *job.BundleURL); err == nil {
+			job.BundleURL = &url
+		}
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT student_id, status, report_card_id, error
+		FROM report_card_job_items
+		WHERE job_id = $1 AND status = 'failed'
+	`, job.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	failures := []models.ReportCardJobItem{}
+	for rows.Next() {
+		var it models.ReportCardJobItem
+		if err := rows.Scan(&it.StudentID, &it.Status, &it.ReportCardID, &it.Error); err != nil {
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		failures = append(failures, it)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"job":      job,
+		"failures": failures,
+	})
+}
@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pragma-proto/api/internal/auth"
 	"github.com/pragma-proto/api/internal/middleware"
@@ -21,6 +24,10 @@ type ReportsHandler struct {
 	pdf     *services.PDFService
 	storage *services.StorageService
 	grading *services.GradingService
+
+	// runningJobs guards against processing the same batch job twice in this
+	// process; the job's lease guards across replicas.
+	runningJobs sync.Map
 }
 
 // NewReportsHandler creates a ReportsHandler.
@@ -39,7 +46,8 @@ func (h *ReportsHandler) GenerateReportCard(w http.ResponseWriter, r *http.Reque
 
 	var req struct {
 		StudentID      string `json:"student_id" validate:"required,uuid"`
-		AcademicPeriod string `json:"academic_period" validate:"required,min=3,max=100"`
+		TermID         string `json:"term_id"` // short_id; limits grades and attendance to the term's dates
+		AcademicPeriod string `json:"academic_period" validate:"omitempty,min=3,max=100"`
 		TeacherComment string `json:"teacher_comments"`
 	}
 
@@ -56,137 +64,277 @@ func (h *ReportsHandler) GenerateReportCard(w http.ResponseWriter, r *http.Reque
 
 	ctx := r.Context()
 
-	// Fetch student data.
+	term, ok := h.reportTerm(w, r, req.TermID, &req.AcademicPeriod)
+	if !ok {
+		return
+	}
+
+	school, err := loadSchool(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	studentID, _ := uuid.Parse(req.StudentID)
+	assets := loadSchoolAssets(ctx, h.storage, school)
+	rc, err := h.renderReportCard(ctx, reportCardInput{
+		School:         school,
+		StudentID:      studentID,
+		Term:           term,
+		AcademicPeriod: req.AcademicPeriod,
+		TeacherComment: req.TeacherComment,
+		GeneratedBy:    claims.UserID,
+		Assets:         assets,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "not_found", "student not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "report_error", err.Error())
+		return
+	}
+
+	if err := insertReportCard(ctx, h.db, rc); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	downloadURL, _ := h.storage.PresignDownload(ctx, rc.Key)
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "report_card.generate",
+		EntityType: "report_card",
+		EntityID:   &rc.ID,
+		NewValue:   map[string]string{"student_id": req.StudentID, "period": req.AcademicPeriod},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	resp := map[string]interface{}{
+		"report_card_id": rc.ID,
+		"gpa":            rc.GPA,
+		"download_url":   downloadURL,
+	}
+	if len(assets.Warnings) > 0 {
+		resp["branding_warnings"] = assets.Warnings
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// reportCardInput describes one report card to render.
+type reportCardInput struct {
+	School         *models.School
+	StudentID      uuid.UUID
+	Term           *models.Term
+	AcademicPeriod string
+	TeacherComment string
+	GeneratedBy    uuid.UUID
+	Assets         *schoolAssets     // loaded on demand when nil
+	Template       *resolvedTemplate // resolved on demand when nil
+}
+
+// generatedReportCard is a rendered and stored card awaiting its report_cards row.
+type generatedReportCard struct {
+	reportCardInput
+	ID         uuid.UUID
+	Key        string
+	GPA        float64
+	RenderData []byte // TemplateData snapshot for exact re-rendering
+}
+
+// renderReportCard computes a student's grades and attendance, renders the
+// card, and uploads it to R2. The caller records it with insertReportCard.
+// Returns pgx.ErrNoRows if the student does not belong to the school.
+func (h *ReportsHandler) renderReportCard(ctx context.Context, in reportCardInput) (*generatedReportCard, error) {
 	var student models.Student
 	var user models.User
-	var school models.School
-
-	h.db.QueryRow(ctx, `
+	err := h.db.QueryRow(ctx, `
 		SELECT s.id, s.student_number, s.grade_level, s.enrollment_status,
 		       u.first_name, u.last_name, u.email
 		FROM students s JOIN users u ON u.id = s.user_id
 		WHERE s.id = $1 AND s.school_id = $2
-	`, req.StudentID, claims.SchoolID).Scan(
+	`, in.StudentID, in.School.ID).Scan(
 		&student.ID, &student.StudentNumber, &student.GradeLevel, &student.EnrollmentStatus,
 		&user.FirstName, &user.LastName, &user.Email,
 	)
+	if err != nil {
+		return nil, err
+	}
 
-	h.db.QueryRow(ctx, `SELECT id, name, logo_url FROM schools WHERE id = $1`, claims.SchoolID).Scan(
-		&school.ID, &school.Name, &school.LogoURL,
-	)
-
-	// Fetch course grades.
-	gradeRows, err := h.db.Query(ctx, `
-		SELECT c.name, u.first_name || ' ' || u.last_name,
-		       COALESCE(AVG(g.points_earned / a.max_points * 100), 0)::float,
-		       COALESCE(
-		           CASE WHEN AVG(g.points_earned / a.max_points * 100) >= 93 THEN 'A'
-		                WHEN AVG(g.points_earned / a.max_points * 100) >= 90 THEN 'A-'
-		                WHEN AVG(g.points_earned / a.max_points * 100) >= 87 THEN 'B+'
-		                WHEN AVG(g.points_earned / a.max_points * 100) >= 83 THEN 'B'
-		                WHEN AVG(g.points_earned / a.max_points * 100) >= 80 THEN 'B-'
-		                WHEN AVG(g.points_earned / a.max_points * 100) >= 77 THEN 'C+'
-		                WHEN AVG(g.points_earned / a.max_points * 100) >= 73 THEN 'C'
-		                WHEN AVG(g.points_earned / a.max_points * 100) >= 70 THEN 'C-'
-		                WHEN AVG(g.points_earned / a.max_points * 100) >= 67 THEN 'D+'
-		                WHEN AVG(g.points_earned / a.max_points * 100) >= 63 THEN 'D'
-		                WHEN AVG(g.points_earned / a.max_points * 100) >= 60 THEN 'D-'
-		                ELSE 'F'
-		           END, 'N/A')
-		FROM enrollments e
-		JOIN courses c ON c.id = e.course_id
-		JOIN teachers t ON t.id = c.teacher_id
-		JOIN users u ON u.id = t.user_id
-		JOIN assignments a ON a.course_id = c.id AND a.is_published = TRUE
-		LEFT JOIN grades g ON g.assignment_id = a.id AND g.student_id = e.student_id
-		WHERE e.student_id = $1 AND e.status = 'active' AND e.course_id IN (
-		    SELECT id FROM courses WHERE school_id = $2
-		)
-		GROUP BY c.name, u.first_name, u.last_name
-		ORDER BY c.name
-	`, req.StudentID, claims.SchoolID)
+	// Course grades use the same pipeline as the gradebook.
+	courses, err := computeStudentCourseGrades(ctx, h.db, h.grading, in.School.Settings, student.ID, in.School.ID, in.Term)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
-		return
+		return nil, err
 	}
-	defer gradeRows.Close()
 
 	var courseGrades []services.CourseGradeRow
-	var gpaSum float64
-	var courseCount int
-	for gradeRows.Next() {
-		var row services.CourseGradeRow
-		gradeRows.Scan(&row.CourseName, &row.TeacherName, &row.Percentage, &row.LetterGrade)
-		courseGrades = append(courseGrades, row)
-		gpaSum += gradePointFromLetter(row.LetterGrade)
-		courseCount++
+	for _, c := range courses {
+		courseGrades = append(courseGrades, courseGradeRow(c))
+	}
+	gpa := gpaFromCourseGrades(h.grading, courses, in.School.Settings)
+
+	// A term card records the term GPA and shows the cumulative GPA through
+	// it; otherwise the latest stored cumulative GPA is shown.
+	var cumulative *models.GPAResult
+	if in.Term != nil {
+		record, err := recordTermGPA(ctx, h.db, h.grading, student.ID, in.School.ID, in.Term, gpa)
+		if err != nil {
+			return nil, err
+		}
+		cumulative = &record.Cumulative
+	} else {
+		history, err := loadTermGPAs(ctx, h.db, student.ID, in.School.ID)
+		if err != nil {
+			return nil, err
+		}
+		cumulative = latestCumulativeGPA(history)
 	}
 
-	var gpa float64
-	if courseCount > 0 {
-		gpa = gpaSum / float64(courseCount)
+	_, from, to := termBounds(in.Term)
+	attendance, err := loadAttendanceSummary(ctx, h.db, student.ID, in.School.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.Assets == nil {
+		in.Assets = loadSchoolAssets(ctx, h.storage, in.School)
+	}
+	if in.Template == nil {
+		if in.Template, err = resolveTemplate(ctx, h.db, in.School, services.TemplateKindReportCard, ""); err != nil {
+			return nil, err
+		}
 	}
 
 	data := services.ReportCardData{
-		School:          &school,
+		School:          in.School,
 		Student:         &student,
 		StudentUser:     &user,
-		AcademicPeriod:  req.AcademicPeriod,
-		GPA:             gpa,
+		AcademicPeriod:  in.AcademicPeriod,
+		GPA:             gpa.GPA,
+		TermGPA:         &gpa,
+		CumulativeGPA:   cumulative,
 		CourseGrades:    courseGrades,
-		TeacherComments: req.TeacherComment,
+		TeacherComments: in.TeacherComment,
+		Attendance:      &attendance,
 		GeneratedAt:     time.Now(),
+		Template:        in.Template.Body,
+		Logo:            in.Assets.Logo,
+		Signature:       in.Assets.Signature,
 	}
 
-	htmlBytes, err := h.pdf.RenderReportCardHTML(data)
+	pdfBytes, err := h.pdf.RenderReportCardPDF(data)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "pdf_error", err.Error())
-		return
+		return nil, err
 	}
 
-	reportID := uuid.New()
-	key := services.ObjectKey(claims.SchoolID.String(), "reports", reportID.String()+".html")
+	renderData, err := in.Template.snapshot(services.TemplateKindReportCard, services.ReportCardTemplateData(data))
+	if err != nil {
+		return nil, err
+	}
 
-	if err := h.storage.PutObject(ctx, key, htmlBytes, "text/html"); err != nil {
-		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
-		return
+	rc := &generatedReportCard{reportCardInput: in, ID: uuid.New(), GPA: gpa.GPA, RenderData: renderData}
+	rc.Key = services.ObjectKey(in.School.ID.String(), "reports", rc.ID.String()+".pdf")
+	if err := h.storage.PutObject(ctx, rc.Key, pdfBytes, "application/pdf"); err != nil {
+		return nil, err
 	}
+	return rc, nil
+}
 
-	var rcID uuid.UUID
-	h.db.QueryRow(ctx, `
+// insertReportCard records a rendered card. q may be a transaction.
+func insertReportCard(ctx context.Context, q dbtx, rc *generatedReportCard) error {
+	termID, _, _ := termBounds(rc.Term)
+	_, err := q.Exec(ctx, `
 		INSERT INTO report_cards
-			(student_id, school_id, academic_period, gpa, teacher_comments, pdf_url, generated_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id
-	`, req.StudentID, claims.SchoolID, req.AcademicPeriod, gpa, req.TeacherComment, key, claims.UserID).Scan(&rcID)
+			(id, student_id, school_id, academic_period, term_id, gpa, teacher_comments, pdf_url, generated_by,
+			 template_id, render_data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, rc.ID, rc.StudentID, rc.School.ID, rc.AcademicPeriod, termID, rc.GPA,
+		nullStr(rc.TeacherComment), rc.Key, rc.GeneratedBy, rc.Template.ID, rc.RenderData)
+	return err
+}
 
-	downloadURL, _ := h.storage.PresignDownload(ctx, key)
+// errReportCardSuperseded means the card was already replaced by another
+// regeneration.
+var errReportCardSuperseded = errors.New("report card already superseded")
+
+// regenerateReportCard renders a fresh copy of a report card from current
+// grades, keeping its period, term, comments, and finalization. The new card
+// supersedes the old one, unless another card already has.
+func (h *ReportsHandler) regenerateReportCard(ctx context.Context, school *models.School, cardID, generatedBy uuid.UUID) (*generatedReportCard, error) {
+	var studentID uuid.UUID
+	var period string
+	var comment, termShortID *string
+	var isFinalized bool
+	var finalizedAt *time.Time
+	var finalizedBy *uuid.UUID
+	err := h.db.QueryRow(ctx, `
+		SELECT rc.student_id, rc.academic_period, rc.teacher_comments, t.short_id,
+		       COALESCE(rc.is_finalized, FALSE), rc.finalized_at, rc.finalized_by
+		FROM report_cards rc
+		LEFT JOIN terms t ON t.id = rc.term_id
+		WHERE rc.id = $1 AND rc.school_id = $2
+	`, cardID, school.ID).Scan(&studentID, &period, &comment, &termShortID,
+		&isFinalized, &finalizedAt, &finalizedBy)
+	if err != nil {
+		return nil, err
+	}
 
-	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
-		SchoolID:   claims.SchoolID,
-		UserID:     &claims.UserID,
-		Action:     "report_card.generate",
-		EntityType: "report_card",
-		EntityID:   &rcID,
-		NewValue:   map[string]string{"student_id": req.StudentID, "period": req.AcademicPeriod},
-		IPAddress:  r.RemoteAddr,
-		UserAgent:  r.UserAgent(),
-	})
+	in := reportCardInput{School: school, StudentID: studentID, AcademicPeriod: period, GeneratedBy: generatedBy}
+	if comment != nil {
+		in.TeacherComment = *comment
+	}
+	if termShortID != nil {
+		if in.Term, err = resolveTerm(ctx, h.db, *termShortID, school.ID); err != nil {
+			return nil, err
+		}
+	}
 
-	writeJSON(w, http.StatusCreated, map[string]interface{}{
-		"report_card_id": rcID,
-		"gpa":            gpa,
-		"download_url":   downloadURL,
-	})
+	rc, err := h.renderReportCard(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertReportCard(ctx, tx, rc); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE report_cards SET is_finalized = $1, finalized_at = $2, finalized_by = $3 WHERE id = $4
+	`, isFinalized, finalizedAt, finalizedBy, rc.ID); err != nil {
+		return nil, err
+	}
+	tag, err := tx.Exec(ctx, `
+		UPDATE report_cards SET superseded_by = $1 WHERE id = $2 AND superseded_by IS NULL
+	`, rc.ID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, errReportCardSuperseded
+	}
+	return rc, tx.Commit(ctx)
 }
 
-// BatchGenerateReports generates report cards for multiple students concurrently.
+// BatchGenerateReports queues report cards for a list of students or a whole
+// grade level and returns immediately. Progress is polled with GetBatchJob.
+// Set bundle to also produce a single ZIP of every card when the job finishes.
 func (h *ReportsHandler) BatchGenerateReports(w http.ResponseWriter, r *http.Request) {
 	claims, _ := auth.ClaimsFromContext(r.Context())
 
 	var req struct {
-		StudentIDs     []string `json:"student_ids" validate:"required,min=1"`
-		AcademicPeriod string   `json:"academic_period" validate:"required"`
+		StudentIDs     []string `json:"student_ids" validate:"omitempty,dive,uuid"`
+		GradeLevel     string   `json:"grade_level" validate:"max=20"`
+		TermID         string   `json:"term_id"`
+		AcademicPeriod string   `json:"academic_period"`
+		Bundle         bool     `json:"bundle"`
 	}
 
 	dec := json.NewDecoder(r.Body)
@@ -195,52 +343,159 @@ func (h *ReportsHandler) BatchGenerateReports(w http.ResponseWriter, r *http.Req
 		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
 		return
 	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if (len(req.StudentIDs) == 0) == (req.GradeLevel == "") {
+		writeError(w, http.StatusBadRequest, "validation_error", "provide either student_ids or grade_level")
+		return
+	}
 
-	type result struct {
-		StudentID    string `json:"student_id"`
-		ReportCardID string `json:"report_card_id,omitempty"`
-		Error        string `json:"error,omitempty"`
-	}
-
-	results := make([]result, len(req.StudentIDs))
-	var wg sync.WaitGroup
-
-	// Semaphore to limit concurrent goroutines.
-	sem := make(chan struct{}, 10)
-
-	for i, sid := range req.StudentIDs {
-		wg.Add(1)
-		go func(idx int, studentID string) {
-			defer wg.Done()
-			sem <- struct{}{}
-			defer func() { <-sem }()
-
-			results[idx] = result{StudentID: studentID}
-			// Individual generation logic (simplified for batch).
-			var rcID uuid.UUID
-			err := h.db.QueryRow(r.Context(), `
-				INSERT INTO report_cards
-					(student_id, school_id, academic_period, generated_by)
-				VALUES ($1, $2, $3, $4)
-				RETURNING id
-			`, studentID, claims.SchoolID, req.AcademicPeriod, claims.UserID).Scan(&rcID)
-			if err != nil {
-				results[idx].Error = err.Error()
-				return
-			}
-			results[idx].ReportCardID = rcID.String()
-		}(i, sid)
+	ctx := r.Context()
+
+	term, ok := h.reportTerm(w, r, req.TermID, &req.AcademicPeriod)
+	if !ok {
+		return
+	}
+	termID, _, _ := termBounds(term)
+
+	// Resolve the roster up front so the job total is fixed.
+	var rows pgx.Rows
+	var err error
+	if req.GradeLevel != "" {
+		rows, err = h.db.Query(ctx, `
+			SELECT id FROM students
+			WHERE school_id = $1 AND grade_level = $2 AND enrollment_status = 'active'
+		`, claims.SchoolID, req.GradeLevel)
+	} else {
+		rows, err = h.db.Query(ctx, `
+			SELECT id FROM students WHERE school_id = $1 AND id = ANY($2::uuid[])
+		`, claims.SchoolID, req.StudentIDs)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	var studentIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		studentIDs = append(studentIDs, id)
+	}
+	rows.Close()
+
+	if len(studentIDs) == 0 {
+		writeError(w, http.StatusBadRequest, "no_students", "no matching students found")
+		return
+	}
+	if req.GradeLevel == "" && len(studentIDs) != len(req.StudentIDs) {
+		writeError(w, http.StatusBadRequest, "invalid_student",
+			"one or more student_ids do not belong to this school")
+		return
 	}
 
-	wg.Wait()
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var job models.ReportCardJob
+	err = tx.QueryRow(ctx, `
+		INSERT INTO report_card_jobs
+			(school_id, term_id, academic_period, grade_level, total_count, bundle, requested_by,
+			 lease_owner, lease_expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW() + make_interval(secs => $9))
+		RETURNING id, short_id, status, created_at
+	`, claims.SchoolID, termID, req.AcademicPeriod, nullStr(req.GradeLevel), len(studentIDs),
+		req.Bundle, claims.UserID, instanceID, reportJobLease.Seconds(),
+	).Scan(&job.ID, &job.ShortID, &job.Status, &job.CreatedAt)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"results":  results,
-		"total":    len(req.StudentIDs),
-		"period":   req.AcademicPeriod,
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO report_card_job_items (job_id, school_id, student_id)
+		SELECT $1, $2, unnest($3::uuid[])
+	`, job.ID, claims.SchoolID, studentIDs); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "report_card.generate",
+		EntityType: "report_card_job",
+		EntityID:   &job.ID,
+		NewValue: map[string]interface{}{
+			"period":      req.AcademicPeriod,
+			"grade_level": req.GradeLevel,
+			"total":       len(studentIDs),
+			"bundle":      req.Bundle,
+		},
+		IPAddress: r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+	})
+
+	// The job outlives the request; it runs on its own context.
+	go h.runReportJob(job.ID)
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"job_id": job.ShortID,
+		"status": job.Status,
+		"total":  len(studentIDs),
+		"period": req.AcademicPeriod,
 	})
 }
 
+// reportTerm resolves the optional term_id on a report request. The term's
+// name becomes the academic period when none was given; without a term an
+// academic period is required. Writes the error response and returns false
+// on failure.
+func (h *ReportsHandler) reportTerm(w http.ResponseWriter, r *http.Request, termShortID string, period *string) (*models.Term, bool) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+
+	if termShortID == "" {
+		if len(*period) < 3 {
+			writeError(w, http.StatusBadRequest, "validation_error", "academic_period or term_id is required")
+			return nil, false
+		}
+		return nil, true
+	}
+
+	term, err := resolveTerm(r.Context(), h.db, termShortID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "term not found")
+		return nil, false
+	}
+	if *period == "" {
+		*period = term.Name
+	}
+	return term, true
+}
+
+// termBounds returns the term's ID and inclusive date range, or nils when
+// no term was selected.
+func termBounds(term *models.Term) (*uuid.UUID, *time.Time, *time.Time) {
+	if term == nil {
+		return nil, nil, nil
+	}
+	return &term.ID, &term.StartDate, &term.EndDate
+}
+
 // ListReportCards returns a student's report card history.
 // studentId URL param is a short_id.
 func (h *ReportsHandler) ListReportCards(w http.ResponseWriter, r *http.Request) {
@@ -256,9 +511,9 @@ func (h *ReportsHandler) ListReportCards(w http.ResponseWriter, r *http.Request)
 	}
 
 	rows, err := h.db.Query(ctx, `
-		SELECT id, academic_period, gpa, is_finalized, pdf_url, generated_at
+		SELECT id, academic_period, gpa, is_finalized, finalized_at, pdf_url, generated_at
 		FROM report_cards
-		WHERE student_id = $1 AND school_id = $2
+		WHERE student_id = $1 AND school_id = $2 AND superseded_by IS NULL
 		ORDER BY generated_at DESC
 	`, studentUUID, claims.SchoolID)
 	if err != nil {
@@ -270,7 +525,7 @@ func (h *ReportsHandler) ListReportCards(w http.ResponseWriter, r *http.Request)
 	var reports []models.ReportCard
 	for rows.Next() {
 		var rc models.ReportCard
-		rows.Scan(&rc.ID, &rc.AcademicPeriod, &rc.GPA, &rc.IsFinalized, &rc.PDFURL, &rc.GeneratedAt)
+		rows.Scan(&rc.ID, &rc.AcademicPeriod, &rc.GPA, &rc.IsFinalized, &rc.FinalizedAt, &rc.PDFURL, &rc.GeneratedAt)
 		// Generate fresh download URL.
 		if rc.PDFURL != nil {
 			if url, err := h.storage.PresignDownload(ctx, *rc.PDFURL); err == nil {
@@ -283,16 +538,36 @@ func (h *ReportsHandler) ListReportCards(w http.ResponseWriter, r *http.Request)
 	writeJSON(w, http.StatusOK, map[string]interface{}{"report_cards": reports})
 }
 
-func gradePointFromLetter(letter string) float64 {
-	gp := map[string]float64{
-		"A": 4.0, "A-": 3.7,
-		"B+": 3.3, "B": 3.0, "B-": 2.7,
-		"C+": 2.3, "C": 2.0, "C-": 1.7,
-		"D+": 1.3, "D": 1.0, "D-": 0.7,
-		"F": 0.0,
-	}
-	if gp, ok := gp[letter]; ok {
-		return gp
+// FinalizeReportCard marks a report card final (admin only). Grades the card
+// covers can then only be changed through an approved grade change request.
+// reportCardId URL param is the report card's UUID.
+func (h *ReportsHandler) FinalizeReportCard(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var id uuid.UUID
+	var studentID uuid.UUID
+	err := h.db.QueryRow(ctx, `
+		UPDATE report_cards
+		SET is_finalized = TRUE, finalized_at = COALESCE(finalized_at, NOW()), finalized_by = COALESCE(finalized_by, $1)
+		WHERE id = $2 AND school_id = $3 AND superseded_by IS NULL
+		RETURNING id, student_id
+	`, claims.UserID, chi.URLParam(r, "reportCardId"), claims.SchoolID).Scan(&id, &studentID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "report card not found")
+		return
 	}
-	return 0.0
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "report_card.finalize",
+		EntityType: "report_card",
+		EntityID:   &id,
+		NewValue:   map[string]interface{}{"student_id": studentID, "is_finalized": true},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
 }
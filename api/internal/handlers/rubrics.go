@@ -0,0 +1,931 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
+)
+
+// RubricsHandler manages reusable rubrics and per-criterion rubric grading.
+type RubricsHandler struct {
+	db *pgxpool.Pool
+}
+
+// NewRubricsHandler creates a RubricsHandler.
+func NewRubricsHandler(db *pgxpool.Pool) *RubricsHandler {
+	return &RubricsHandler{db: db}
+}
+
+// ---------- Loading and access ----------
+
+// rubricMaxPoints sums each criterion's best level, as a SQL expression over r.id.
+const rubricMaxPoints = `
+	COALESCE((SELECT SUM(m.max) FROM (
+		SELECT MAX(l.points) AS max FROM rubric_criteria c
+		JOIN rubric_levels l ON l.criterion_id = c.id
+		WHERE c.rubric_id = r.id GROUP BY c.id
+	) m), 0)::float8`
+
+// loadRubric fetches a rubric by UUID with its criteria and levels in order.
+func loadRubric(ctx context.Context, db dbtx, id, schoolID uuid.UUID) (*models.Rubric, error) {
+	var rb models.Rubric
+	err := db.QueryRow(ctx, `
+		SELECT r.id, r.short_id, r.school_id, r.title, r.description, r.visibility, r.department,
+		       r.created_by, r.is_archived, r.created_at, r.updated_at,
+		       COALESCE(u.first_name || ' ' || u.last_name, ''), `+rubricMaxPoints+`
+		FROM rubrics r
+		LEFT JOIN users u ON u.id = r.created_by
+		WHERE r.id = $1 AND r.school_id = $2
+	`, id, schoolID).Scan(&rb.ID, &rb.ShortID, &rb.SchoolID, &rb.Title, &rb.Description, &rb.Visibility,
+		&rb.Department, &rb.CreatedBy, &rb.IsArchived, &rb.CreatedAt, &rb.UpdatedAt, &rb.AuthorName, &rb.MaxPoints)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT c.id, c.position, c.title, c.description, l.id, l.position, l.title, l.description, l.points
+		FROM rubric_criteria c
+		JOIN rubric_levels l ON l.criterion_id = c.id
+		WHERE c.rubric_id = $1
+		ORDER BY c.position, l.position
+	`, rb.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	rb.Criteria = []models.RubricCriterion{}
+	for rows.Next() {
+		var c models.RubricCriterion
+		var l models.RubricLevel
+		if err := rows.Scan(&c.ID, &c.Position, &c.Title, &c.Description,
+			&l.ID, &l.Position, &l.Title, &l.Description, &l.Points); err != nil {
+			return nil, err
+		}
+		if n := len(rb.Criteria); n == 0 || rb.Criteria[n-1].ID != c.ID {
+			rb.Criteria = append(rb.Criteria, c)
+		}
+		last := &rb.Criteria[len(rb.Criteria)-1]
+		last.Levels = append(last.Levels, l)
+	}
+	return &rb, rows.Err()
+}
+
+// resolveRubric loads a rubric by short_id.
+func resolveRubric(ctx context.Context, db dbtx, shortID string, schoolID uuid.UUID) (*models.Rubric, error) {
+	var id uuid.UUID
+	if err := db.QueryRow(ctx, `SELECT id FROM rubrics WHERE short_id = $1 AND school_id = $2`,
+		shortID, schoolID).Scan(&id); err != nil {
+		return nil, err
+	}
+	return loadRubric(ctx, db, id, schoolID)
+}
+
+// userDepartment returns a teacher's department, or nil for other users.
+func userDepartment(ctx context.Context, db dbtx, userID, schoolID uuid.UUID) *string {
+	var dept *string
+	db.QueryRow(ctx, `SELECT department FROM teachers WHERE user_id = $1 AND school_id = $2`,
+		userID, schoolID).Scan(&dept)
+	return dept
+}
+
+// canUseRubric reports whether the caller may see and attach a rubric:
+// admins and the author always, others when it is shared with the school or
+// with their department.
+func canUseRubric(ctx context.Context, db dbtx, claims *auth.Claims, rb *models.Rubric) bool {
+	switch {
+	case claims.Role == models.RoleAdmin || claims.Role == models.RoleSuperAdmin:
+		return true
+	case rb.CreatedBy == claims.UserID, rb.Visibility == models.RubricSchool:
+		return true
+	case rb.Visibility == models.RubricDepartment && rb.Department != nil:
+		dept := userDepartment(ctx, db, claims.UserID, claims.SchoolID)
+		return dept != nil && *dept == *rb.Department
+	}
+	return false
+}
+
+// canEditRubric reports whether the caller may change a rubric: its author or an admin.
+func canEditRubric(claims *auth.Claims, rb *models.Rubric) bool {
+	return rb.CreatedBy == claims.UserID || claims.Role == models.RoleAdmin || claims.Role == models.RoleSuperAdmin
+}
+
+// ---------- Rubric CRUD ----------
+
+type rubricLevelInput struct {
+	Title       string  `json:"title" validate:"required,min=1,max=200"`
+	Description string  `json:"description" validate:"max=2000"`
+	Points      float64 `json:"points" validate:"min=0,max=10000"`
+}
+
+type rubricCriterionInput struct {
+	Title       string             `json:"title" validate:"required,min=1,max=300"`
+	Description string             `json:"description" validate:"max=2000"`
+	Levels      []rubricLevelInput `json:"levels" validate:"required,min=1,max=10,dive"`
+}
+
+// rubricInput is the body of rubric create and update. On update, omitting
+// criteria keeps the existing ones.
+type rubricInput struct {
+	Title       string                 `json:"title" validate:"required,min=1,max=300"`
+	Description string                 `json:"description" validate:"max=2000"`
+	Visibility  string                 `json:"visibility" validate:"omitempty,oneof=private department school"`
+	Criteria    []rubricCriterionInput `json:"criteria" validate:"omitempty,max=30,dive"`
+}
+
+// insertRubricCriteria writes a rubric's criteria and levels in order.
+func insertRubricCriteria(ctx context.Context, tx pgx.Tx, rubricID, schoolID uuid.UUID, criteria []rubricCriterionInput) error {
+	for i, c := range criteria {
+		var criterionID uuid.UUID
+		if err := tx.QueryRow(ctx, `
+			INSERT INTO rubric_criteria (rubric_id, school_id, position, title, description)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, rubricID, schoolID, i+1, c.Title, nullStr(c.Description)).Scan(&criterionID); err != nil {
+			return err
+		}
+		for j, l := range c.Levels {
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO rubric_levels (criterion_id, school_id, position, title, description, points)
+				VALUES ($1, $2, $3, $4, $5, $6)
+			`, criterionID, schoolID, j+1, l.Title, nullStr(l.Description), l.Points); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ListRubrics returns the rubrics the caller can use, newest first, without
+// their criteria.
+// Query params: mine=true (only the caller's own), include_archived=true.
+func (h *RubricsHandler) ListRubrics(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+	limit, offset := paginate(r)
+
+	isAdmin := claims.Role == models.RoleAdmin || claims.Role == models.RoleSuperAdmin
+	dept := userDepartment(ctx, h.db, claims.UserID, claims.SchoolID)
+	rows, err := h.db.Query(ctx, `
+		SELECT r.id, r.short_id, r.school_id, r.title, r.description, r.visibility, r.department,
+		       r.created_by, r.is_archived, r.created_at, r.updated_at,
+		       COALESCE(u.first_name || ' ' || u.last_name, ''), `+rubricMaxPoints+`
+		FROM rubrics r
+		LEFT JOIN users u ON u.id = r.created_by
+		WHERE r.school_id = $1
+		  AND ($2 OR NOT r.is_archived)
+		  AND (r.created_by = $3 OR (NOT $4 AND (
+		        $5
+		        OR r.visibility = 'school'
+		        OR (r.visibility = 'department' AND r.department = $6))))
+		ORDER BY r.created_at DESC
+		LIMIT $7 OFFSET $8
+	`, claims.SchoolID, r.URL.Query().Get("include_archived") == "true", claims.UserID,
+		r.URL.Query().Get("mine") == "true", isAdmin, dept, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	rubrics := []models.Rubric{}
+	for rows.Next() {
+		var rb models.Rubric
+		if err := rows.Scan(&rb.ID, &rb.ShortID, &rb.SchoolID, &rb.Title, &rb.Description, &rb.Visibility,
+			&rb.Department, &rb.CreatedBy, &rb.IsArchived, &rb.CreatedAt, &rb.UpdatedAt,
+			&rb.AuthorName, &rb.MaxPoints); err != nil {
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		rubrics = append(rubrics, rb)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"rubrics": rubrics})
+}
+
+// GetRubric returns a rubric with its criteria and levels.
+// rubricId URL param is a short_id.
+func (h *RubricsHandler) GetRubric(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	rb, err := resolveRubric(ctx, h.db, chi.URLParam(r, "rubricId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "rubric not found")
+		return
+	}
+	if !canUseRubric(ctx, h.db, claims, rb) {
+		writeError(w, http.StatusNotFound, "not_found", "rubric not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rb)
+}
+
+// CreateRubric saves a new rubric. Department sharing uses the author's
+// department, so it needs a teacher with one.
+func (h *RubricsHandler) CreateRubric(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req rubricInput
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if len(req.Criteria) == 0 {
+		writeError(w, http.StatusBadRequest, "validation_error", "a rubric needs at least one criterion")
+		return
+	}
+	if req.Visibility == "" {
+		req.Visibility = models.RubricPrivate
+	}
+	dept := userDepartment(ctx, h.db, claims.UserID, claims.SchoolID)
+	if req.Visibility == models.RubricDepartment && dept == nil {
+		writeError(w, http.StatusBadRequest, "no_department", "you have no department to share with")
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var id uuid.UUID
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO rubrics (school_id, title, description, visibility, department, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, claims.SchoolID, req.Title, nullStr(req.Description), req.Visibility, dept, claims.UserID).Scan(&id); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if err := insertRubricCriteria(ctx, tx, id, claims.SchoolID, req.Criteria); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	rb, err := loadRubric(ctx, tx, id, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "rubric.create",
+		EntityType: "rubric",
+		EntityID:   &id,
+		NewValue:   req,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusCreated, rb)
+}
+
+// UpdateRubric changes a rubric (author or admin only). Criteria can be
+// replaced only while no student has been scored with the rubric; after
+// that, copy it into a new rubric instead.
+// rubricId URL param is a short_id.
+func (h *RubricsHandler) UpdateRubric(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req rubricInput
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	old, err := resolveRubric(ctx, tx, chi.URLParam(r, "rubricId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "rubric not found")
+		return
+	}
+	if !canEditRubric(claims, old) {
+		writeError(w, http.StatusForbidden, "forbidden", "only the rubric's author or an admin can change it")
+		return
+	}
+	if req.Visibility == "" {
+		req.Visibility = old.Visibility
+	}
+	dept := old.Department
+	if req.Visibility == models.RubricDepartment && dept == nil {
+		if dept = userDepartment(ctx, tx, old.CreatedBy, claims.SchoolID); dept == nil {
+			writeError(w, http.StatusBadRequest, "no_department", "the rubric's author has no department to share with")
+			return
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE rubrics SET title = $1, description = $2, visibility = $3, department = $4
+		WHERE id = $5
+	`, req.Title, nullStr(req.Description), req.Visibility, dept, old.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if req.Criteria != nil {
+		var scored bool
+		tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM rubric_scores s JOIN rubric_criteria c ON c.id = s.criterion_id
+			               WHERE c.rubric_id = $1)
+		`, old.ID).Scan(&scored)
+		if scored {
+			writeError(w, http.StatusConflict, "rubric_in_use",
+				"students have been scored with this rubric; its criteria can no longer change")
+			return
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM rubric_criteria WHERE rubric_id = $1`, old.ID); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		if err := insertRubricCriteria(ctx, tx, old.ID, claims.SchoolID, req.Criteria); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+	}
+	rb, err := loadRubric(ctx, tx, old.ID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "rubric.update",
+		EntityType: "rubric",
+		EntityID:   &old.ID,
+		OldValue:   old,
+		NewValue:   req,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, rb)
+}
+
+// DeleteRubric deletes an unused rubric, or archives one that assignments
+// still use so existing scores keep their criteria (author or admin only).
+// rubricId URL param is a short_id.
+func (h *RubricsHandler) DeleteRubric(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	rb, err := resolveRubric(ctx, h.db, chi.URLParam(r, "rubricId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "rubric not found")
+		return
+	}
+	if !canEditRubric(claims, rb) {
+		writeError(w, http.StatusForbidden, "forbidden", "only the rubric's author or an admin can delete it")
+		return
+	}
+
+	var inUse bool
+	h.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM assignments WHERE rubric_id = $1)
+		    OR EXISTS (SELECT 1 FROM rubric_scores s JOIN rubric_criteria c ON c.id = s.criterion_id
+		               WHERE c.rubric_id = $1)
+	`, rb.ID).Scan(&inUse)
+	action := "rubric.delete"
+	if inUse {
+		action = "rubric.archive"
+		_, err = h.db.Exec(ctx, `UPDATE rubrics SET is_archived = TRUE WHERE id = $1`, rb.ID)
+	} else {
+		_, err = h.db.Exec(ctx, `DELETE FROM rubrics WHERE id = $1`, rb.ID)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     action,
+		EntityType: "rubric",
+		EntityID:   &rb.ID,
+		OldValue:   rb,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"archived": inUse})
+}
+
+// ---------- Assignment rubrics and scoring ----------
+
+// SetAssignmentRubric attaches a rubric to an assignment, or detaches it
+// when rubric_id is empty. The rubric can't change once students have
+// been scored with it.
+// assignmentId URL param is a short_id; body rubric_id is a short_id.
+func (h *RubricsHandler) SetAssignmentRubric(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		RubricID string `json:"rubric_id"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	a, err := loadAssignment(ctx, h.db, chi.URLParam(r, "assignmentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "assignment not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, a.CourseID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	var rubricID *uuid.UUID
+	if req.RubricID != "" {
+		rb, err := resolveRubric(ctx, h.db, req.RubricID, claims.SchoolID)
+		if err != nil || !canUseRubric(ctx, h.db, claims, rb) {
+			writeError(w, http.StatusNotFound, "not_found", "rubric not found")
+			return
+		}
+		if rb.IsArchived {
+			writeError(w, http.StatusBadRequest, "rubric_archived", "rubric is archived")
+			return
+		}
+		rubricID = &rb.ID
+	}
+
+	var old *uuid.UUID
+	var scored bool
+	h.db.QueryRow(ctx, `
+		SELECT rubric_id, EXISTS (SELECT 1 FROM rubric_scores WHERE assignment_id = $1)
+		FROM assignments WHERE id = $1
+	`, a.ID).Scan(&old, &scored)
+	if scored {
+		writeError(w, http.StatusConflict, "rubric_in_use",
+			"students have already been scored with this assignment's rubric")
+		return
+	}
+	if _, err := h.db.Exec(ctx, `UPDATE assignments SET rubric_id = $1 WHERE id = $2`, rubricID, a.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "assignment.update",
+		EntityType: "assignment",
+		EntityID:   &a.ID,
+		OldValue:   map[string]interface{}{"rubric_id": old},
+		NewValue:   map[string]interface{}{"rubric_id": rubricID},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"rubric_id": req.RubricID})
+}
+
+// assignmentRubric loads the rubric attached to an assignment, or nil.
+func assignmentRubric(ctx context.Context, db dbtx, assignmentID, schoolID uuid.UUID) (*models.Rubric, error) {
+	var rubricID *uuid.UUID
+	if err := db.QueryRow(ctx, `SELECT rubric_id FROM assignments WHERE id = $1 AND school_id = $2`,
+		assignmentID, schoolID).Scan(&rubricID); err != nil {
+		return nil, err
+	}
+	if rubricID == nil {
+		return nil, nil
+	}
+	return loadRubric(ctx, db, *rubricID, schoolID)
+}
+
+// currentGradeWrite returns a gradeWrite holding a grade's current values,
+// so a caller can change one field without resetting the others.
+func currentGradeWrite(ctx context.Context, q dbtx, assignmentID, studentID, schoolID uuid.UUID) (gradeWrite, error) {
+	in := gradeWrite{AssignmentID: assignmentID, StudentID: studentID}
+	var comment *string
+	err := q.QueryRow(ctx, `
+		SELECT points_earned, comment, COALESCE(is_excused, FALSE), COALESCE(is_missing, FALSE),
+		       COALESCE(is_late, FALSE), days_late, ai_accepted
+		FROM grades WHERE assignment_id = $1 AND student_id = $2 AND school_id = $3
+	`, assignmentID, studentID, schoolID).Scan(&in.PointsEarned, &comment, &in.IsExcused, &in.IsMissing,
+		&in.IsLate, &in.DaysLate, &in.AIAccepted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return in, nil
+	}
+	if comment != nil {
+		in.Comment = *comment
+	}
+	return in, err
+}
+
+// rubricScoreInput scores one criterion: by level (earning its points
+// unless points is given) or by points alone.
+type rubricScoreInput struct {
+	CriterionID string   `json:"criterion_id" validate:"required,uuid"`
+	LevelID     string   `json:"level_id" validate:"omitempty,uuid"`
+	Points      *float64 `json:"points" validate:"omitempty,min=0"`
+	Comment     string   `json:"comment" validate:"max=2000"`
+}
+
+// saveRubricScore saves one criterion score, replacing any earlier score for
+// the criterion, and fills in its ID, grader, and graded time.
+func saveRubricScore(ctx context.Context, q dbtx, schoolID, userID uuid.UUID, s *models.RubricScore) error {
+	s.GradedBy = &userID
+	return q.QueryRow(ctx, `
+		INSERT INTO rubric_scores
+			(school_id, assignment_id, student_id, criterion_id, level_id, points, comment, graded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (assignment_id, student_id, criterion_id)
+		DO UPDATE SET level_id = EXCLUDED.level_id, points = EXCLUDED.points, comment = EXCLUDED.comment,
+		              graded_by = EXCLUDED.graded_by, graded_at = NOW()
+		RETURNING id, graded_at
+	`, schoolID, s.AssignmentID, s.StudentID, s.CriterionID, s.LevelID, s.Points, s.Comment,
+		userID).Scan(&s.ID, &s.GradedAt)
+}
+
+// restoreRubricScores saves the criterion scores filed with an approved
+// grade change request, credited to the teacher who scored them. If the
+// assignment's rubric has changed since, so that a score's criterion or
+// level is no longer on it, none are saved; the approved total still is.
+func restoreRubricScores(ctx context.Context, q dbtx, schoolID uuid.UUID, req *models.GradeChangeRequest) error {
+	var current int
+	criterionIDs := make([]uuid.UUID, len(req.RubricScores))
+	var levelIDs []uuid.UUID
+	for i, s := range req.RubricScores {
+		criterionIDs[i] = s.CriterionID
+		if s.LevelID != nil {
+			levelIDs = append(levelIDs, *s.LevelID)
+		}
+	}
+	err := q.QueryRow(ctx, `
+		SELECT (SELECT COUNT(*) FROM rubric_criteria c JOIN assignments a ON a.rubric_id = c.rubric_id
+		        WHERE a.id = $1 AND a.school_id = $2 AND c.id = ANY($3))
+		     + (SELECT COUNT(*) FROM rubric_levels l JOIN rubric_criteria c ON c.id = l.criterion_id
+		        JOIN assignments a ON a.rubric_id = c.rubric_id
+		        WHERE a.id = $1 AND a.school_id = $2 AND l.id = ANY($4))
+	`, req.AssignmentID, schoolID, criterionIDs, levelIDs).Scan(&current)
+	if err != nil {
+		return err
+	}
+	if current != len(criterionIDs)+len(levelIDs) {
+		return nil
+	}
+	for i := range req.RubricScores {
+		s := &req.RubricScores[i]
+		s.AssignmentID, s.StudentID = req.AssignmentID, req.StudentID
+		if err := saveRubricScore(ctx, q, schoolID, req.RequestedBy, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScoreRubric records a student's scores on some or all of an assignment's
+// rubric criteria. Once every criterion is scored, the total is written to
+// the grade's points_earned, scaled to the assignment's max_points when the
+// rubric is worth a different amount. A final grade instead gets a grade
+// change request for the new total, holding the criterion scores until it
+// is approved.
+// assignmentId URL param is a short_id; body student_id is a UUID.
+func (h *RubricsHandler) ScoreRubric(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		StudentID string             `json:"student_id" validate:"required,uuid"`
+		Scores    []rubricScoreInput `json:"scores" validate:"required,min=1,max=30,dive"`
+		Comment   *string            `json:"comment" validate:"omitempty,max=2000"` // overall grade comment
+		Reason    string             `json:"reason" validate:"max=500"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	studentID := uuid.MustParse(req.StudentID)
+
+	a, err := loadAssignment(ctx, h.db, chi.URLParam(r, "assignmentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "assignment not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, a.CourseID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+	rb, err := assignmentRubric(ctx, h.db, a.ID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if rb == nil {
+		writeError(w, http.StatusBadRequest, "no_rubric", "assignment has no rubric")
+		return
+	}
+	var enrolled bool
+	h.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM enrollments WHERE course_id = $1 AND student_id = $2 AND status = 'active')
+	`, a.CourseID, studentID).Scan(&enrolled)
+	if !enrolled {
+		writeError(w, http.StatusBadRequest, "not_enrolled", "student is not enrolled in this course")
+		return
+	}
+
+	// Resolve each score against the rubric.
+	criteria := make(map[uuid.UUID]models.RubricCriterion, len(rb.Criteria))
+	for _, c := range rb.Criteria {
+		criteria[c.ID] = c
+	}
+	scores := make([]models.RubricScore, 0, len(req.Scores))
+	seen := make(map[uuid.UUID]bool)
+	for i, in := range req.Scores {
+		c, ok := criteria[uuid.MustParse(in.CriterionID)]
+		if !ok || seen[c.ID] {
+			writeError(w, http.StatusBadRequest, "invalid_score", fmt.Sprintf("scores[%d]: unknown or repeated criterion", i))
+			return
+		}
+		seen[c.ID] = true
+		s := models.RubricScore{AssignmentID: a.ID, StudentID: studentID, CriterionID: c.ID}
+		if in.LevelID != "" {
+			levelID := uuid.MustParse(in.LevelID)
+			for _, l := range c.Levels {
+				if l.ID == levelID {
+					s.LevelID, s.Points = &l.ID, l.Points
+				}
+			}
+			if s.LevelID == nil {
+				writeError(w, http.StatusBadRequest, "invalid_score", fmt.Sprintf("scores[%d]: level is not part of this criterion", i))
+				return
+			}
+		}
+		switch {
+		case in.Points != nil:
+			s.Points = *in.Points
+		case s.LevelID == nil:
+			writeError(w, http.StatusBadRequest, "invalid_score", fmt.Sprintf("scores[%d]: give level_id or points", i))
+			return
+		}
+		if s.Points > c.MaxPoints() {
+			writeError(w, http.StatusBadRequest, "invalid_score",
+				fmt.Sprintf("scores[%d]: %s is worth at most %g points", i, c.Title, c.MaxPoints()))
+			return
+		}
+		if in.Comment != "" {
+			s.Comment = &in.Comment
+		}
+		scores = append(scores, s)
+	}
+
+	// Combine with earlier scores to see whether the rubric is complete.
+	total := make(map[uuid.UUID]float64)
+	rows, err := h.db.Query(ctx, `
+		SELECT criterion_id, points FROM rubric_scores WHERE assignment_id = $1 AND student_id = $2
+	`, a.ID, studentID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	for rows.Next() {
+		var id uuid.UUID
+		var pts float64
+		if err := rows.Scan(&id, &pts); err == nil {
+			total[id] = pts
+		}
+	}
+	rows.Close()
+	for _, s := range scores {
+		total[s.CriterionID] = s.Points
+	}
+	complete := len(total) == len(rb.Criteria)
+	var rubricTotal float64
+	for _, pts := range total {
+		rubricTotal += pts
+	}
+
+	var grade *gradeWrite
+	if complete {
+		points := rubricTotal
+		if rb.MaxPoints > 0 && rb.MaxPoints != a.MaxPoints {
+			points = math.Round(rubricTotal/rb.MaxPoints*a.MaxPoints*100) / 100
+		}
+		in, err := currentGradeWrite(ctx, h.db, a.ID, studentID, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		in.PointsEarned, in.IsMissing = &points, false
+		if req.Comment != nil {
+			in.Comment = *req.Comment
+		}
+		in.Reason = req.Reason
+		if in.Reason == "" {
+			in.Reason = "scored with rubric " + rb.ShortID
+		}
+		grade = &in
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// A final grade changes only through an approved request, which keeps
+	// the criterion scores until then.
+	if grade != nil {
+		lockedBy, err := gradeLockReason(ctx, tx, a.ID, studentID, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		if lockedBy != "" {
+			change, err := createGradeChangeRequest(ctx, tx, claims.SchoolID, a.CourseID, claims.UserID, *grade, lockedBy)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+				return
+			}
+			if _, err := tx.Exec(ctx, `
+				UPDATE grade_change_requests SET rubric_scores = $1 WHERE id = $2
+			`, scores, change.ID); err != nil {
+				writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+				return
+			}
+			if err := tx.Commit(ctx); err != nil {
+				writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+				return
+			}
+			change.RubricScores = scores
+			_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+				SchoolID:   claims.SchoolID,
+				UserID:     &claims.UserID,
+				Action:     "grade_change.request",
+				EntityType: "grade_change_request",
+				EntityID:   &change.ID,
+				NewValue:   req,
+				IPAddress:  r.RemoteAddr,
+				UserAgent:  r.UserAgent(),
+			})
+			writeJSON(w, http.StatusAccepted, map[string]interface{}{
+				"status":         "pending_approval",
+				"locked_by":      lockedBy,
+				"change_request": change,
+			})
+			return
+		}
+	}
+
+	for i := range scores {
+		if err := saveRubricScore(ctx, tx, claims.SchoolID, claims.UserID, &scores[i]); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+	}
+	var gradeID *uuid.UUID
+	if grade != nil {
+		id, _, err := writeGrade(ctx, tx, claims.SchoolID, claims.UserID, *grade)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		gradeID = &id
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "grade.rubric_score",
+		EntityType: "assignment",
+		EntityID:   &a.ID,
+		NewValue: map[string]interface{}{
+			"student_id": studentID, "rubric_id": rb.ID, "scores": scores,
+			"complete": complete, "rubric_total": rubricTotal, "grade_id": gradeID,
+		},
+		IPAddress: r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+	})
+
+	resp := map[string]interface{}{
+		"scores":       scores,
+		"complete":     complete,
+		"rubric_total": rubricTotal,
+		"rubric_max":   rb.MaxPoints,
+	}
+	if grade != nil {
+		resp["grade_id"] = gradeID
+		resp["points_earned"] = grade.PointsEarned
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ListRubricScores returns an assignment's rubric with the criterion scores
+// given so far, optionally for one student.
+// assignmentId URL param is a short_id; student_id query param is a UUID.
+func (h *RubricsHandler) ListRubricScores(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	a, err := loadAssignment(ctx, h.db, chi.URLParam(r, "assignmentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "assignment not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, a.CourseID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+	var studentID *uuid.UUID
+	if s := r.URL.Query().Get("student_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_param", "student_id must be a UUID")
+			return
+		}
+		studentID = &id
+	}
+	rb, err := assignmentRubric(ctx, h.db, a.ID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT id, assignment_id, student_id, criterion_id, level_id, points, comment, graded_by, graded_at
+		FROM rubric_scores
+		WHERE assignment_id = $1 AND school_id = $2 AND ($3::uuid IS NULL OR student_id = $3)
+		ORDER BY student_id, graded_at
+	`, a.ID, claims.SchoolID, studentID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+	scores := []models.RubricScore{}
+	for rows.Next() {
+		var s models.RubricScore
+		if err := rows.Scan(&s.ID, &s.AssignmentID, &s.StudentID, &s.CriterionID, &s.LevelID, &s.Points,
+			&s.Comment, &s.GradedBy, &s.GradedAt); err != nil {
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		scores = append(scores, s)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"rubric": rb,
+		"scores": scores,
+	})
+}
@@ -0,0 +1,732 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
+)
+
+// StandardsHandler manages learning standards, their alignment to
+// assignments, and per-standard scores for standards-based courses.
+type StandardsHandler struct {
+	db      *pgxpool.Pool
+	grading *services.GradingService
+}
+
+// NewStandardsHandler creates a StandardsHandler.
+func NewStandardsHandler(db *pgxpool.Pool, grading *services.GradingService) *StandardsHandler {
+	return &StandardsHandler{db: db, grading: grading}
+}
+
+// resolveStandard looks up a standard by its short_id, scoped to a school.
+func resolveStandard(ctx context.Context, db *pgxpool.Pool, shortID string, schoolID uuid.UUID) (*models.Standard, error) {
+	var s models.Standard
+	err := db.QueryRow(ctx, `
+		SELECT id, short_id, school_id, code, description, subject, grade_level, is_active, created_at, updated_at
+		FROM standards
+		WHERE short_id = $1 AND school_id = $2
+	`, shortID, schoolID).Scan(&s.ID, &s.ShortID, &s.SchoolID, &s.Code, &s.Description,
+		&s.Subject, &s.GradeLevel, &s.IsActive, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListStandards returns the school's standards ordered by code.
+// Optional filters: ?subject=, ?grade_level=, ?include_inactive=true.
+func (h *StandardsHandler) ListStandards(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	q := r.URL.Query()
+
+	rows, err := h.db.Query(r.Context(), `
+		SELECT id, short_id, school_id, code, description, subject, grade_level, is_active, created_at, updated_at
+		FROM standards
+		WHERE school_id = $1
+		  AND ($2 = '' OR subject = $2)
+		  AND ($3 = '' OR grade_level = $3)
+		  AND (is_active OR $4)
+		ORDER BY code
+	`, claims.SchoolID, q.Get("subject"), q.Get("grade_level"), q.Get("include_inactive") == "true")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	standards := []models.Standard{}
+	for rows.Next() {
+		var s models.Standard
+		if err := rows.Scan(&s.ID, &s.ShortID, &s.SchoolID, &s.Code, &s.Description,
+			&s.Subject, &s.GradeLevel, &s.IsActive, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		standards = append(standards, s)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"standards": standards})
+}
+
+// CreateStandard adds a learning standard (admin only). Codes are unique per school.
+func (h *StandardsHandler) CreateStandard(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		Code        string `json:"code" validate:"required,min=1,max=50"`
+		Description string `json:"description" validate:"required,min=1,max=1000"`
+		Subject     string `json:"subject" validate:"max=100"`
+		GradeLevel  string `json:"grade_level" validate:"max=20"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	var exists bool
+	h.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM standards WHERE school_id = $1 AND code = $2)`,
+		claims.SchoolID, req.Code).Scan(&exists)
+	if exists {
+		writeError(w, http.StatusConflict, "duplicate_code", "a standard with this code already exists")
+		return
+	}
+
+	var s models.Standard
+	err := h.db.QueryRow(ctx, `
+		INSERT INTO standards (school_id, code, description, subject, grade_level)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, short_id, school_id, code, description, subject, grade_level, is_active, created_at, updated_at
+	`, claims.SchoolID, req.Code, req.Description, nullStr(req.Subject), nullStr(req.GradeLevel)).Scan(
+		&s.ID, &s.ShortID, &s.SchoolID, &s.Code, &s.Description,
+		&s.Subject, &s.GradeLevel, &s.IsActive, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "standard.create",
+		EntityType: "standard",
+		EntityID:   &s.ID,
+		NewValue:   req,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusCreated, s)
+}
+
+// UpdateStandard edits a standard's description, subject, or grade level, or
+// retires it with is_active=false (admin only). Codes cannot change once
+// scores reference them.
+// standardId URL param is a short_id.
+func (h *StandardsHandler) UpdateStandard(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		Description string `json:"description" validate:"required,min=1,max=1000"`
+		Subject     string `json:"subject" validate:"max=100"`
+		GradeLevel  string `json:"grade_level" validate:"max=20"`
+		IsActive    *bool  `json:"is_active"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	existing, err := resolveStandard(ctx, h.db, chi.URLParam(r, "standardId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "standard not found")
+		return
+	}
+
+	active := existing.IsActive
+	if req.IsActive != nil {
+		active = *req.IsActive
+	}
+
+	_, err = h.db.Exec(ctx, `
+		UPDATE standards SET description = $1, subject = $2, grade_level = $3, is_active = $4
+		WHERE id = $5 AND school_id = $6
+	`, req.Description, nullStr(req.Subject), nullStr(req.GradeLevel), active, existing.ID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "standard.update",
+		EntityType: "standard",
+		EntityID:   &existing.ID,
+		OldValue:   existing,
+		NewValue:   req,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// assignmentCourse returns the course of an assignment, checking that a
+// teacher caller owns it.
+func (h *StandardsHandler) assignmentCourse(w http.ResponseWriter, r *http.Request, claims *auth.Claims) (assignmentID, courseID uuid.UUID, ok bool) {
+	ctx := r.Context()
+	assignmentID, err := resolveAssignmentUUID(ctx, h.db, chi.URLParam(r, "assignmentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "assignment not found")
+		return uuid.Nil, uuid.Nil, false
+	}
+	h.db.QueryRow(ctx, `SELECT course_id FROM assignments WHERE id = $1`, assignmentID).Scan(&courseID)
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return assignmentID, courseID, true
+}
+
+// ListAssignmentStandards returns the standards an assignment is aligned to.
+// assignmentId URL param is a short_id.
+func (h *StandardsHandler) ListAssignmentStandards(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+
+	assignmentID, _, ok := h.assignmentCourse(w, r, claims)
+	if !ok {
+		return
+	}
+
+	rows, err := h.db.Query(r.Context(), `
+		SELECT s.id, s.short_id, s.school_id, s.code, s.description, s.subject, s.grade_level,
+		       s.is_active, s.created_at, s.updated_at
+		FROM assignment_standards ast
+		JOIN standards s ON s.id = ast.standard_id
+		WHERE ast.assignment_id = $1 AND ast.school_id = $2
+		ORDER BY s.code
+	`, assignmentID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	standards := []models.Standard{}
+	for rows.Next() {
+		var s models.Standard
+		if err := rows.Scan(&s.ID, &s.ShortID, &s.SchoolID, &s.Code, &s.Description,
+			&s.Subject, &s.GradeLevel, &s.IsActive, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		standards = append(standards, s)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"standards": standards})
+}
+
+// SetAssignmentStandards replaces the standards an assignment is aligned to.
+// Scores already recorded against a removed standard are kept but no longer
+// count toward mastery for this assignment's course.
+// assignmentId URL param is a short_id; standard_ids are short_ids.
+func (h *StandardsHandler) SetAssignmentStandards(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		StandardIDs []string `json:"standard_ids" validate:"max=50,dive,min=1,max=8"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	assignmentID, _, ok := h.assignmentCourse(w, r, claims)
+	if !ok {
+		return
+	}
+
+	var standardIDs []uuid.UUID
+	for _, sid := range req.StandardIDs {
+		s, err := resolveStandard(ctx, h.db, sid, claims.SchoolID)
+		if err != nil || !s.IsActive {
+			writeError(w, http.StatusNotFound, "not_found", "standard "+sid+" not found")
+			return
+		}
+		standardIDs = append(standardIDs, s.ID)
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM assignment_standards WHERE assignment_id = $1`, assignmentID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if len(standardIDs) > 0 {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO assignment_standards (assignment_id, standard_id, school_id)
+			SELECT $1, unnest($2::uuid[]), $3
+			ON CONFLICT DO NOTHING
+		`, assignmentID, standardIDs, claims.SchoolID); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "assignment.align_standards",
+		EntityType: "assignment",
+		EntityID:   &assignmentID,
+		NewValue:   req,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// writeStandardScore saves one standard score, replacing any earlier score
+// for the assignment and standard, and appends the change to
+// standard_score_history. Saving identical values adds no history entry. q
+// should be a transaction so the score and its history are written together.
+func writeStandardScore(ctx context.Context, q dbtx, schoolID, userID, assignmentID, studentID uuid.UUID,
+	sc models.StandardScoreChange, reason string) error {
+	var oldScore *float64
+	var oldComment *string
+	err := q.QueryRow(ctx, `
+		SELECT score, comment FROM standard_scores
+		WHERE assignment_id = $1 AND standard_id = $2 AND student_id = $3 AND school_id = $4
+		FOR UPDATE
+	`, assignmentID, sc.StandardID, studentID, schoolID).Scan(&oldScore, &oldComment)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	if _, err := q.Exec(ctx, `
+		INSERT INTO standard_scores
+			(assignment_id, standard_id, student_id, school_id, score, comment, graded_by, graded_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (assignment_id, standard_id, student_id)
+		DO UPDATE SET
+			score     = EXCLUDED.score,
+			comment   = EXCLUDED.comment,
+			graded_by = EXCLUDED.graded_by,
+			graded_at = NOW()
+	`, assignmentID, sc.StandardID, studentID, schoolID, sc.Score, sc.Comment, userID); err != nil {
+		return err
+	}
+
+	newScore := math.Round(sc.Score*100) / 100
+	sameComment := (oldComment == nil && sc.Comment == nil) ||
+		(oldComment != nil && sc.Comment != nil && *oldComment == *sc.Comment)
+	if oldScore != nil && *oldScore == newScore && sameComment {
+		return nil
+	}
+	for attempt := 0; attempt < 3; attempt++ {
+		tag, err := q.Exec(ctx, `
+			INSERT INTO standard_score_history
+				(assignment_id, standard_id, student_id, school_id, version,
+				 old_score, new_score, comment, reason, changed_by)
+			SELECT $1, $2, $3, $4,
+			       COALESCE((SELECT MAX(version) FROM standard_score_history
+			                 WHERE assignment_id = $1 AND standard_id = $2 AND student_id = $3), 0) + 1,
+			       $5, $6, $7, $8, $9
+			ON CONFLICT (assignment_id, standard_id, student_id, version) DO NOTHING
+		`, assignmentID, sc.StandardID, studentID, schoolID, oldScore, newScore, sc.Comment, nullStr(reason), userID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 1 {
+			return nil
+		}
+	}
+	return fmt.Errorf("standard score history: could not record a new version for student %s", studentID)
+}
+
+// restoreStandardScores saves the standard scores filed with an approved
+// grade change request, credited to the teacher who scored them. If a
+// standard is no longer aligned to the assignment, none are saved.
+func restoreStandardScores(ctx context.Context, q dbtx, schoolID uuid.UUID, req *models.GradeChangeRequest, reason string) error {
+	standardIDs := make([]uuid.UUID, len(req.StandardScores))
+	for i, sc := range req.StandardScores {
+		standardIDs[i] = sc.StandardID
+	}
+	var aligned int
+	if err := q.QueryRow(ctx, `
+		SELECT COUNT(*) FROM assignment_standards
+		WHERE assignment_id = $1 AND school_id = $2 AND standard_id = ANY($3)
+	`, req.AssignmentID, schoolID, standardIDs).Scan(&aligned); err != nil {
+		return err
+	}
+	if aligned != len(standardIDs) {
+		return nil
+	}
+	for _, sc := range req.StandardScores {
+		if err := writeStandardScore(ctx, q, schoolID, req.RequestedBy, req.AssignmentID, req.StudentID, sc, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordStandardScores records a student's 1–4 scores on the standards an
+// assignment is aligned to. Scores replace earlier ones for the same
+// assignment and standard; all are written or none are. When the student's
+// grade on the assignment is final, the scores are instead filed as a grade
+// change request and saved once it is approved.
+// courseId URL param is a short_id; assignment_id and student_id are UUIDs.
+func (h *StandardsHandler) RecordStandardScores(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	courseUUID, err := resolveCourseUUID(ctx, h.db, chi.URLParam(r, "courseId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, courseUUID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+
+	var req struct {
+		AssignmentID string `json:"assignment_id" validate:"required,uuid"`
+		StudentID    string `json:"student_id" validate:"required,uuid"`
+		Scores       []struct {
+			StandardID string  `json:"standard_id" validate:"required,max=8"`
+			Score      float64 `json:"score" validate:"min=1,max=4"`
+			Comment    string  `json:"comment" validate:"max=2000"`
+		} `json:"scores" validate:"required,min=1,max=50,dive"`
+		Reason string `json:"reason" validate:"max=500"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	assignmentID, studentID := uuid.MustParse(req.AssignmentID), uuid.MustParse(req.StudentID)
+
+	var inCourse, enrolled bool
+	if err := h.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM assignments WHERE id = $1 AND course_id = $2 AND school_id = $3),
+		       EXISTS (SELECT 1 FROM enrollments
+		               WHERE student_id = $4 AND course_id = $2 AND school_id = $3 AND status = 'active')
+	`, assignmentID, courseUUID, claims.SchoolID, studentID).Scan(&inCourse, &enrolled); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if !inCourse {
+		writeError(w, http.StatusNotFound, "not_found", "assignment not found in this course")
+		return
+	}
+	if !enrolled {
+		writeError(w, http.StatusBadRequest, "not_enrolled", "student is not enrolled in this course")
+		return
+	}
+
+	// Only standards aligned to the assignment can be scored.
+	aligned := make(map[string]uuid.UUID)
+	rows, err := h.db.Query(ctx, `
+		SELECT s.short_id, s.id
+		FROM assignment_standards ast JOIN standards s ON s.id = ast.standard_id
+		WHERE ast.assignment_id = $1
+	`, assignmentID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	for rows.Next() {
+		var sid string
+		var id uuid.UUID
+		if err := rows.Scan(&sid, &id); err != nil {
+			rows.Close()
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		aligned[sid] = id
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	scores := make([]models.StandardScoreChange, len(req.Scores))
+	for i, sc := range req.Scores {
+		standardID, ok := aligned[sc.StandardID]
+		if !ok {
+			writeError(w, http.StatusBadRequest, "not_aligned",
+				"standard "+sc.StandardID+" is not aligned to this assignment")
+			return
+		}
+		scores[i] = models.StandardScoreChange{StandardID: standardID, Score: sc.Score}
+		if sc.Comment != "" {
+			scores[i].Comment = &req.Scores[i].Comment
+		}
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Standard scores are the grade in a standards-based course, so a final
+	// grade's scores change only through an approved request.
+	lockedBy, err := gradeLockReason(ctx, tx, assignmentID, studentID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if lockedBy != "" {
+		in, err := currentGradeWrite(ctx, tx, assignmentID, studentID, claims.SchoolID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		in.Reason = req.Reason
+		if in.Reason == "" {
+			in.Reason = "standard scores"
+		}
+		change, err := createGradeChangeRequest(ctx, tx, claims.SchoolID, courseUUID, claims.UserID, in, lockedBy)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE grade_change_requests SET standard_scores = $1 WHERE id = $2
+		`, scores, change.ID); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		if err := tx.Commit(ctx); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+		change.StandardScores = scores
+		_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+			SchoolID:   claims.SchoolID,
+			UserID:     &claims.UserID,
+			Action:     "grade_change.request",
+			EntityType: "grade_change_request",
+			EntityID:   &change.ID,
+			NewValue:   req,
+			IPAddress:  r.RemoteAddr,
+			UserAgent:  r.UserAgent(),
+		})
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{
+			"status":         "pending_approval",
+			"locked_by":      lockedBy,
+			"change_request": change,
+		})
+		return
+	}
+
+	for _, sc := range scores {
+		if err := writeStandardScore(ctx, tx, claims.SchoolID, claims.UserID, assignmentID, studentID, sc, req.Reason); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "standard_score.upsert",
+		EntityType: "assignment",
+		EntityID:   &assignmentID,
+		NewValue:   req,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"recorded": len(req.Scores)})
+}
+
+// GetStudentMastery returns a student's mastery of each standard in a course
+// under the course's mastery rule, with the scores behind it. Students and
+// parents are refused while the student's grades are locked.
+// courseId and studentId URL params are short_ids. Optional ?term_id= (short_id).
+func (h *StandardsHandler) GetStudentMastery(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var courseID uuid.UUID
+	var mode, rule *string
+	err := h.db.QueryRow(ctx, `
+		SELECT id, grading_mode, mastery_rule FROM courses WHERE short_id = $1 AND school_id = $2
+	`, chi.URLParam(r, "courseId"), claims.SchoolID).Scan(&courseID, &mode, &rule)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+	studentID, err := resolveStudentUUID(ctx, h.db, chi.URLParam(r, "studentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "student not found")
+		return
+	}
+	if !canViewStudent(ctx, h.db, claims, studentID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you cannot view this student's grades")
+		return
+	}
+	if claims.Role == models.RoleStudent || claims.Role == models.RoleParent {
+		var isLocked bool
+		h.db.QueryRow(ctx, `SELECT is_grade_locked FROM students WHERE id = $1`, studentID).Scan(&isLocked)
+		if isLocked {
+			writeError(w, http.StatusForbidden, "grade_locked",
+				"Your grade access has been temporarily restricted. Please contact your school administration.")
+			return
+		}
+	}
+
+	var term *models.Term
+	if tid := r.URL.Query().Get("term_id"); tid != "" {
+		if term, err = resolveTerm(ctx, h.db, tid, claims.SchoolID); err != nil {
+			writeError(w, http.StatusNotFound, "not_found", "term not found")
+			return
+		}
+	}
+
+	school, err := loadSchool(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	gradingMode, masteryRule := courseGradingMode(school.Settings, mode, rule)
+
+	courseIDs := []uuid.UUID{courseID}
+	standards, err := loadCourseStandards(ctx, h.db, courseIDs, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	scores, err := loadStudentStandardScores(ctx, h.db, studentID, courseIDs, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	mastery := h.grading.CalculateMastery(standards[courseID], scores[courseID],
+		masteryRule, school.Settings.MasteryDecay, term)
+
+	evidence := scores[courseID]
+	if evidence == nil {
+		evidence = []models.StandardScore{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"grading_mode": gradingMode,
+		"mastery_rule": masteryRule,
+		"standards":    mastery,
+		"scores":       evidence,
+	})
+}
+
+// UpdateCourseGrading sets a course's grading mode and mastery rule (admin
+// only). Empty values revert to the school defaults.
+// courseId URL param is a short_id.
+func (h *StandardsHandler) UpdateCourseGrading(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		GradingMode string `json:"grading_mode" validate:"omitempty,oneof=points standards"`
+		MasteryRule string `json:"mastery_rule" validate:"omitempty,oneof=most_recent highest decaying_average mode"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	var courseID uuid.UUID
+	var oldMode, oldRule *string
+	err := h.db.QueryRow(ctx, `
+		SELECT id, grading_mode, mastery_rule FROM courses WHERE short_id = $1 AND school_id = $2
+	`, chi.URLParam(r, "courseId"), claims.SchoolID).Scan(&courseID, &oldMode, &oldRule)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "course not found")
+		return
+	}
+
+	if _, err := h.db.Exec(ctx, `
+		UPDATE courses SET grading_mode = $1, mastery_rule = $2 WHERE id = $3 AND school_id = $4
+	`, nullStr(req.GradingMode), nullStr(req.MasteryRule), courseID, claims.SchoolID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "course.update_grading",
+		EntityType: "course",
+		EntityID:   &courseID,
+		OldValue:   map[string]*string{"grading_mode": oldMode, "mastery_rule": oldRule},
+		NewValue:   req,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
@@ -0,0 +1,859 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
+)
+
+// maxSubmissionFiles caps the uploads on one submission attempt.
+const maxSubmissionFiles = 10
+
+// SubmissionsHandler manages student turn-ins and the teacher's submission
+// queue. Submitting sets the grade's late flag against the student's
+// effective due date; work never turned in is flagged missing by
+// markMissingWork.
+type SubmissionsHandler struct {
+	db      *pgxpool.Pool
+	storage *services.StorageService
+}
+
+// NewSubmissionsHandler creates a SubmissionsHandler.
+func NewSubmissionsHandler(db *pgxpool.Pool, storage *services.StorageService) *SubmissionsHandler {
+	return &SubmissionsHandler{db: db, storage: storage}
+}
+
+// effectiveDueDate returns when a student's work on an assignment is due,
+// or nil if it has no due date.
+func effectiveDueDate(ctx context.Context, q dbtx, assignmentID, studentID uuid.UUID) (*time.Time, error) {
+	var due *time.Time
+	err := q.QueryRow(ctx, `SELECT effective_due_date($1, $2)`, assignmentID, studentID).Scan(&due)
+	return due, err
+}
+
+// lateness reports whether work turned in at t is late for due, and by how
+// many days, counting any part of a day as a whole day.
+func lateness(t time.Time, due *time.Time) (bool, *int) {
+	if due == nil || !t.After(*due) {
+		return false, nil
+	}
+	days := int(math.Ceil(t.Sub(*due).Hours() / 24))
+	return true, &days
+}
+
+// submissionTarget resolves the calling student and the assignment they are
+// submitting to. The assignment must be published, accept submissions, and
+// belong to a course the student is actively enrolled in. It writes the
+// error response itself and reports whether to continue.
+func (h *SubmissionsHandler) submissionTarget(w http.ResponseWriter, r *http.Request) (*models.Assignment, uuid.UUID, bool) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var studentID uuid.UUID
+	if err := h.db.QueryRow(ctx, `SELECT id FROM students WHERE user_id = $1 AND school_id = $2`,
+		claims.UserID, claims.SchoolID).Scan(&studentID); err != nil {
+		writeError(w, http.StatusForbidden, "forbidden", "no student record for this user")
+		return nil, uuid.Nil, false
+	}
+
+	a, err := loadAssignment(ctx, h.db, chi.URLParam(r, "assignmentId"), claims.SchoolID)
+	if err != nil || !a.IsPublished {
+		writeError(w, http.StatusNotFound, "not_found", "assignment not found")
+		return nil, uuid.Nil, false
+	}
+	var enrolled bool
+	h.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM enrollments WHERE course_id = $1 AND student_id = $2 AND status = 'active')
+	`, a.CourseID, studentID).Scan(&enrolled)
+	if !enrolled {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not enrolled in this course")
+		return nil, uuid.Nil, false
+	}
+	if !a.AcceptsSubmissions {
+		writeError(w, http.StatusBadRequest, "submissions_closed", "this assignment does not take submissions")
+		return nil, uuid.Nil, false
+	}
+	return a, studentID, true
+}
+
+// listSubmissions returns a student's attempts on an assignment, oldest
+// first, with their files. Drafts are included only when withDrafts is set.
+func (h *SubmissionsHandler) listSubmissions(ctx context.Context, assignmentID, studentID, schoolID uuid.UUID, withDrafts bool) ([]models.Submission, error) {
+	rows, err := h.db.Query(ctx, `
+		SELECT id, short_id, assignment_id, student_id, school_id, attempt, status, text_entry,
+		       submitted_at, due_at, is_late, days_late, created_at, updated_at
+		FROM submissions
+		WHERE assignment_id = $1 AND student_id = $2 AND school_id = $3 AND ($4 OR status = 'submitted')
+		ORDER BY attempt
+	`, assignmentID, studentID, schoolID, withDrafts)
+	if err != nil {
+		return nil, err
+	}
+	subs := []models.Submission{}
+	index := make(map[uuid.UUID]int)
+	var ids []uuid.UUID
+	for rows.Next() {
+		var s models.Submission
+		if err := rows.Scan(&s.ID, &s.ShortID, &s.AssignmentID, &s.StudentID, &s.SchoolID, &s.Attempt, &s.Status,
+			&s.TextEntry, &s.SubmittedAt, &s.DueAt, &s.IsLate, &s.DaysLate, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		s.Files = []models.SubmissionFile{}
+		index[s.ID] = len(subs)
+		ids = append(ids, s.ID)
+		subs = append(subs, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return subs, nil
+	}
+
+	rows, err = h.db.Query(ctx, `
+		SELECT submission_id, id, file_name, file_key, file_size, mime_type, created_at
+		FROM submission_files
+		WHERE submission_id = ANY($1)
+		ORDER BY created_at
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var subID uuid.UUID
+		var f models.SubmissionFile
+		if err := rows.Scan(&subID, &f.ID, &f.FileName, &f.FileKey, &f.FileSize, &f.MIMEType, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		if url, err := h.storage.PresignDownload(ctx, f.FileKey); err == nil {
+			f.DownloadURL = url
+		}
+		subs[index[subID]].Files = append(subs[index[subID]].Files, f)
+	}
+	return subs, rows.Err()
+}
+
+// openDraft returns the student's open draft for an assignment, creating it
+// as the next attempt if there is none.
+func openDraft(ctx context.Context, q dbtx, assignmentID, studentID, schoolID uuid.UUID) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := q.QueryRow(ctx, `
+		SELECT id FROM submissions
+		WHERE assignment_id = $1 AND student_id = $2 AND status = 'draft'
+		FOR UPDATE
+	`, assignmentID, studentID).Scan(&id)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return id, err
+	}
+	err = q.QueryRow(ctx, `
+		INSERT INTO submissions (assignment_id, student_id, school_id, attempt)
+		SELECT $1, $2, $3, COALESCE(MAX(attempt), 0) + 1
+		FROM submissions WHERE assignment_id = $1 AND student_id = $2
+		RETURNING id
+	`, assignmentID, studentID, schoolID).Scan(&id)
+	return id, err
+}
+
+// RequestSubmissionUploadURL adds a file to the student's draft submission
+// and returns a presigned upload URL for it. The file is checked when the
+// draft is submitted.
+// assignmentId URL param is a short_id.
+func (h *SubmissionsHandler) RequestSubmissionUploadURL(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		FileName      string `json:"file_name" validate:"required,min=1,max=255"`
+		MIMEType      string `json:"mime_type" validate:"required"`
+		FileSizeBytes int64  `json:"file_size_bytes" validate:"required,min=1,max=26214400"` // 25MB
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if err := services.ValidateMIMEType(req.MIMEType); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_mime_type", err.Error())
+		return
+	}
+
+	a, studentID, ok := h.submissionTarget(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	draftID, err := openDraft(ctx, tx, a.ID, studentID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	var fileCount int
+	tx.QueryRow(ctx, `SELECT COUNT(*) FROM submission_files WHERE submission_id = $1`, draftID).Scan(&fileCount)
+	if fileCount >= maxSubmissionFiles {
+		writeError(w, http.StatusBadRequest, "too_many_files", "a submission can have at most 10 files")
+		return
+	}
+
+	fileID := uuid.New()
+	key := services.ObjectKey(claims.SchoolID.String(), "submissions",
+		draftID.String()+"/"+fileID.String()+"-"+req.FileName)
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO submission_files (id, submission_id, school_id, file_name, file_key, file_size, mime_type)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, fileID, draftID, claims.SchoolID, req.FileName, key, req.FileSizeBytes, req.MIMEType); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	url, err := h.storage.PresignUpload(ctx, key, req.FileSizeBytes)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"upload_url": url,
+		"file_id":    fileID,
+	})
+}
+
+// DeleteSubmissionFile removes a file from the student's draft submission.
+// Files on submitted attempts can't be removed.
+// assignmentId URL param is a short_id; fileId is a UUID.
+func (h *SubmissionsHandler) DeleteSubmissionFile(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	fileID, err := uuid.Parse(chi.URLParam(r, "fileId"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_param", "fileId must be a UUID")
+		return
+	}
+	a, studentID, ok := h.submissionTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var key string
+	err = h.db.QueryRow(ctx, `
+		DELETE FROM submission_files f
+		USING submissions s
+		WHERE f.id = $1 AND f.submission_id = s.id AND s.assignment_id = $2 AND s.student_id = $3
+		  AND s.school_id = $4 AND s.status = 'draft'
+		RETURNING f.file_key
+	`, fileID, a.ID, studentID, claims.SchoolID).Scan(&key)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "file not found in your draft")
+		return
+	}
+	_ = h.storage.Delete(ctx, key)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Submit turns in the student's draft, with an optional text entry, as a new
+// attempt. The attempt is timestamped against the student's effective due
+// date, and the grade's late flag follows the latest attempt; a missing flag
+// is cleared. Flags on a final grade are left alone.
+// assignmentId URL param is a short_id.
+func (h *SubmissionsHandler) Submit(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		TextEntry string `json:"text_entry" validate:"max=50000"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	a, studentID, ok := h.submissionTarget(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	draftID, err := openDraft(ctx, tx, a.ID, studentID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	// Every file must have finished uploading.
+	rows, err := tx.Query(ctx, `SELECT file_name, file_key FROM submission_files WHERE submission_id = $1`, draftID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	var names, keys []string
+	for rows.Next() {
+		var name, key string
+		if err := rows.Scan(&name, &key); err != nil {
+			rows.Close()
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		names, keys = append(names, name), append(keys, key)
+	}
+	rows.Close()
+	for i, key := range keys {
+		if _, err := h.storage.HeadObject(ctx, key); err != nil {
+			writeError(w, http.StatusBadRequest, "upload_incomplete", names[i]+" has not finished uploading")
+			return
+		}
+	}
+	if len(keys) == 0 && req.TextEntry == "" {
+		writeError(w, http.StatusBadRequest, "empty_submission", "add a file or a text entry before submitting")
+		return
+	}
+
+	due, err := effectiveDueDate(ctx, tx, a.ID, studentID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	now := time.Now()
+	late, daysLate := lateness(now, due)
+
+	var attempt int
+	if err := tx.QueryRow(ctx, `
+		UPDATE submissions
+		SET status = 'submitted', text_entry = $1, submitted_at = $2, due_at = $3, is_late = $4, days_late = $5
+		WHERE id = $6
+		RETURNING attempt
+	`, nullStr(req.TextEntry), now, due, late, daysLate, draftID).Scan(&attempt); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	key := gradeKey{AssignmentID: a.ID, StudentID: studentID}
+	locked, err := gradeLockReasons(ctx, tx, []gradeKey{key}, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if _, final := locked[key]; !final {
+		reason := "submitted on time"
+		if late {
+			reason = "submitted late"
+		}
+		if _, err := writeGradeFlags(ctx, tx, claims.SchoolID, &claims.UserID, a.ID, studentID,
+			late, daysLate, false, reason); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "submission.create",
+		EntityType: "submission",
+		EntityID:   &draftID,
+		NewValue: map[string]interface{}{
+			"assignment_id": a.ID, "attempt": attempt, "files": len(keys),
+			"due_at": due, "is_late": late, "days_late": daysLate,
+		},
+		IPAddress: r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"attempt":   attempt,
+		"is_late":   late,
+		"days_late": daysLate,
+		"due_at":    due,
+	})
+}
+
+// GetMySubmissions returns the calling student's attempts on an assignment,
+// including an open draft, and their effective due date.
+// assignmentId URL param is a short_id.
+func (h *SubmissionsHandler) GetMySubmissions(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	a, studentID, ok := h.submissionTarget(w, r)
+	if !ok {
+		return
+	}
+	due, err := effectiveDueDate(ctx, h.db, a.ID, studentID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	subs, err := h.listSubmissions(ctx, a.ID, studentID, claims.SchoolID, true)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"due_at":      due,
+		"submissions": subs,
+	})
+}
+
+// Submission queue statuses, one per enrolled student.
+const (
+	queueSubmitted    = "submitted"
+	queueLate         = "late"
+	queueMissing      = "missing"
+	queueNotSubmitted = "not_submitted"
+	queueExcused      = "excused"
+)
+
+// submissionQueueRow is one student's line in an assignment's submission queue.
+type submissionQueueRow struct {
+	StudentID      uuid.UUID  `json:"student_id"`
+	StudentShortID string     `json:"student_short_id"`
+	StudentName    string     `json:"student_name"`
+	DueAt          *time.Time `json:"due_at,omitempty"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LatestAttempt  *int       `json:"latest_attempt,omitempty"`
+	SubmittedAt    *time.Time `json:"submitted_at,omitempty"`
+	DaysLate       *int       `json:"days_late,omitempty"`
+	PointsEarned   *float64   `json:"points_earned"`
+	Graded         bool       `json:"graded"`
+}
+
+// ListSubmissionQueue returns an assignment's submission queue: one row per
+// actively enrolled student with their latest attempt and grade, newest
+// turn-ins first. Overdue work is flagged missing before the queue is read.
+// Query param: status (submitted, late, missing, not_submitted, excused).
+// assignmentId URL param is a short_id.
+func (h *SubmissionsHandler) ListSubmissionQueue(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	a, err := loadAssignment(ctx, h.db, chi.URLParam(r, "assignmentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "assignment not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, a.CourseID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", queueSubmitted, queueLate, queueMissing, queueNotSubmitted, queueExcused:
+	default:
+		writeError(w, http.StatusBadRequest, "invalid_param", "status must be submitted, late, missing, not_submitted, or excused")
+		return
+	}
+
+	if a.AcceptsSubmissions {
+		if _, err := markMissingWork(ctx, h.db, &a.ID, nil); err != nil {
+			log.Printf("submissions: mark missing for %s: %v", a.ID, err)
+		}
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT s.id, s.short_id, u.first_name || ' ' || u.last_name, effective_due_date($1, s.id),
+		       ls.attempt, ls.submitted_at, COALESCE(ls.is_late, FALSE), ls.days_late,
+		       (SELECT COUNT(*) FROM submissions x
+		        WHERE x.assignment_id = $1 AND x.student_id = s.id AND x.status = 'submitted'),
+		       g.points_earned, COALESCE(g.is_excused, FALSE), COALESCE(g.is_missing, FALSE)
+		FROM enrollments e
+		JOIN students s ON s.id = e.student_id
+		JOIN users u ON u.id = s.user_id
+		LEFT JOIN LATERAL (
+			SELECT attempt, submitted_at, is_late, days_late FROM submissions x
+			WHERE x.assignment_id = $1 AND x.student_id = s.id AND x.status = 'submitted'
+			ORDER BY attempt DESC LIMIT 1
+		) ls ON TRUE
+		LEFT JOIN grades g ON g.assignment_id = $1 AND g.student_id = s.id
+		WHERE e.course_id = $2 AND e.status = 'active' AND e.school_id = $3
+		ORDER BY ls.submitted_at DESC NULLS LAST, u.last_name, u.first_name
+	`, a.ID, a.CourseID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	queue := []submissionQueueRow{}
+	counts := make(map[string]int)
+	for rows.Next() {
+		var q submissionQueueRow
+		var late, excused, missing bool
+		if err := rows.Scan(&q.StudentID, &q.StudentShortID, &q.StudentName, &q.DueAt,
+			&q.LatestAttempt, &q.SubmittedAt, &late, &q.DaysLate, &q.Attempts,
+			&q.PointsEarned, &excused, &missing); err != nil {
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		q.Graded = q.PointsEarned != nil
+		switch {
+		case excused:
+			q.Status = queueExcused
+		case q.SubmittedAt != nil && late:
+			q.Status = queueLate
+		case q.SubmittedAt != nil:
+			q.Status = queueSubmitted
+		case missing || (q.DueAt != nil && q.DueAt.Before(time.Now()) && !q.Graded):
+			q.Status = queueMissing
+		default:
+			q.Status = queueNotSubmitted
+		}
+		counts[q.Status]++
+		if status == "" || q.Status == status {
+			queue = append(queue, q)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"assignment_id": a.ID,
+		"due_date":      a.DueDate,
+		"counts":        counts,
+		"queue":         queue,
+	})
+}
+
+// GetStudentSubmissions returns every submitted attempt one student made on
+// an assignment, oldest first, with download links for their files.
+// assignmentId and studentId URL params are short_ids.
+func (h *SubmissionsHandler) GetStudentSubmissions(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	a, err := loadAssignment(ctx, h.db, chi.URLParam(r, "assignmentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "assignment not found")
+		return
+	}
+	if claims.Role == models.RoleTeacher && !teacherOwnsCourse(ctx, h.db, claims.UserID, a.CourseID, claims.SchoolID) {
+		writeError(w, http.StatusForbidden, "forbidden", "you are not the teacher for this course")
+		return
+	}
+	studentID, err := resolveStudentUUID(ctx, h.db, chi.URLParam(r, "studentId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "student not found")
+		return
+	}
+
+	due, err := effectiveDueDate(ctx, h.db, a.ID, studentID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	subs, err := h.listSubmissions(ctx, a.ID, studentID, claims.SchoolID, false)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"due_at":      due,
+		"submissions": subs,
+	})
+}
+
+// ---------- Missing work ----------
+
+// markMissingWork flags as missing every ungraded, unexcused grade for work
+// that was never submitted and is past the student's effective due date, on
+// published assignments that take submissions. Final grades are skipped.
+// With assignmentID set only that assignment is swept; with since set only
+// work whose effective due date is at or after since. It returns how many
+// grades were flagged.
+func markMissingWork(ctx context.Context, db *pgxpool.Pool, assignmentID *uuid.UUID, since *time.Time) (int, error) {
+	rows, err := db.Query(ctx, `
+		SELECT a.school_id, a.id, e.student_id
+		FROM assignments a
+		JOIN enrollments e ON e.course_id = a.course_id AND e.status = 'active'
+		LEFT JOIN grades g ON g.assignment_id = a.id AND g.student_id = e.student_id
+		WHERE a.accepts_submissions AND COALESCE(a.is_published, FALSE)
+		  AND ($1::uuid IS NULL OR a.id = $1)
+		  AND effective_due_date(a.id, e.student_id) < NOW()
+		  AND ($2::timestamptz IS NULL OR effective_due_date(a.id, e.student_id) >= $2)
+		  AND NOT EXISTS (
+			SELECT 1 FROM submissions s
+			WHERE s.assignment_id = a.id AND s.student_id = e.student_id AND s.status = 'submitted'
+		  )
+		  AND (g.id IS NULL OR (g.points_earned IS NULL
+		       AND NOT COALESCE(g.is_excused, FALSE) AND NOT COALESCE(g.is_missing, FALSE)))
+		ORDER BY a.school_id
+	`, assignmentID, since)
+	if err != nil {
+		return 0, err
+	}
+	bySchool := make(map[uuid.UUID][]gradeKey)
+	var schools []uuid.UUID
+	for rows.Next() {
+		var schoolID uuid.UUID
+		var k gradeKey
+		if err := rows.Scan(&schoolID, &k.AssignmentID, &k.StudentID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if _, ok := bySchool[schoolID]; !ok {
+			schools = append(schools, schoolID)
+		}
+		bySchool[schoolID] = append(bySchool[schoolID], k)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	flagged := 0
+	for _, schoolID := range schools {
+		n, err := flagMissing(ctx, db, schoolID, bySchool[schoolID])
+		if err != nil {
+			return flagged, err
+		}
+		flagged += n
+	}
+	return flagged, nil
+}
+
+// flagMissing sets the missing flag on one school's grades in a single transaction.
+func flagMissing(ctx context.Context, db *pgxpool.Pool, schoolID uuid.UUID, keys []gradeKey) (int, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	locked, err := gradeLockReasons(ctx, tx, keys, schoolID)
+	if err != nil {
+		return 0, err
+	}
+	flagged := 0
+	for _, k := range keys {
+		if _, final := locked[k]; final {
+			continue
+		}
+		changed, err := writeGradeFlags(ctx, tx, schoolID, nil, k.AssignmentID, k.StudentID,
+			false, nil, true, "not submitted by the due date")
+		if err != nil {
+			return 0, err
+		}
+		if changed {
+			flagged++
+		}
+	}
+	return flagged, tx.Commit(ctx)
+}
+
+// reflagStudentWork re-applies late and missing flags after a student's
+// due dates change: the latest attempt on each assignment is timed again
+// against the new effective due date, a missing flag is cleared where the
+// work is no longer overdue, and set where unsubmitted work on a published
+// assignment is now overdue, since the missing work sweep only looks at work
+// that fell due after its last run. courseID and assignmentID, when set,
+// narrow the assignments considered. Final grades are left alone. It returns
+// how many grades changed.
+func reflagStudentWork(ctx context.Context, q dbtx, schoolID uuid.UUID, userID *uuid.UUID,
+	studentID uuid.UUID, courseID, assignmentID *uuid.UUID) (int, error) {
+	type work struct {
+		key         gradeKey
+		due         *time.Time
+		submission  *uuid.UUID
+		submittedAt *time.Time
+		missing     bool
+		open        bool // published, ungraded, and not excused
+	}
+	rows, err := q.Query(ctx, `
+		SELECT a.id, effective_due_date(a.id, $1), ls.id, ls.submitted_at, COALESCE(g.is_missing, FALSE),
+		       COALESCE(a.is_published, FALSE) AND g.points_earned IS NULL AND NOT COALESCE(g.is_excused, FALSE)
+		FROM assignments a
+		JOIN enrollments e ON e.course_id = a.course_id AND e.student_id = $1 AND e.status = 'active'
+		LEFT JOIN LATERAL (
+			SELECT id, submitted_at FROM submissions x
+			WHERE x.assignment_id = a.id AND x.student_id = $1 AND x.status = 'submitted'
+			ORDER BY attempt DESC LIMIT 1
+		) ls ON TRUE
+		LEFT JOIN grades g ON g.assignment_id = a.id AND g.student_id = $1
+		WHERE a.accepts_submissions AND a.school_id = $2
+		  AND ($3::uuid IS NULL OR a.course_id = $3) AND ($4::uuid IS NULL OR a.id = $4)
+	`, studentID, schoolID, courseID, assignmentID)
+	if err != nil {
+		return 0, err
+	}
+	var items []work
+	var keys []gradeKey
+	for rows.Next() {
+		w := work{key: gradeKey{StudentID: studentID}}
+		if err := rows.Scan(&w.key.AssignmentID, &w.due, &w.submission, &w.submittedAt, &w.missing, &w.open); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		items = append(items, w)
+		keys = append(keys, w.key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	locked, err := gradeLockReasons(ctx, q, keys, schoolID)
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	for _, w := range items {
+		if _, final := locked[w.key]; final {
+			continue
+		}
+		var flagged bool
+		switch {
+		case w.submission != nil:
+			late, daysLate := lateness(*w.submittedAt, w.due)
+			if _, err := q.Exec(ctx, `
+				UPDATE submissions SET due_at = $1, is_late = $2, days_late = $3 WHERE id = $4
+			`, w.due, late, daysLate, *w.submission); err != nil {
+				return changed, err
+			}
+			flagged, err = writeGradeFlags(ctx, q, schoolID, userID, w.key.AssignmentID, studentID,
+				late, daysLate, false, "due date changed")
+		case w.missing && (w.due == nil || w.due.After(time.Now())):
+			flagged, err = writeGradeFlags(ctx, q, schoolID, userID, w.key.AssignmentID, studentID,
+				false, nil, false, "due date changed")
+		case !w.missing && w.open && w.due != nil && w.due.Before(time.Now()):
+			flagged, err = writeGradeFlags(ctx, q, schoolID, userID, w.key.AssignmentID, studentID,
+				false, nil, true, "not submitted by the due date")
+		}
+		if err != nil {
+			return changed, err
+		}
+		if flagged {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// reflagAssignmentWork runs reflagStudentWork on one assignment for every
+// student enrolled in its course, after its due date, publication, or
+// submission setting changes.
+func reflagAssignmentWork(ctx context.Context, q dbtx, schoolID uuid.UUID, userID *uuid.UUID,
+	assignmentID uuid.UUID) (int, error) {
+	rows, err := q.Query(ctx, `
+		SELECT e.student_id
+		FROM assignments a
+		JOIN enrollments e ON e.course_id = a.course_id AND e.status = 'active'
+		WHERE a.id = $1 AND a.school_id = $2
+	`, assignmentID, schoolID)
+	if err != nil {
+		return 0, err
+	}
+	var students []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		students = append(students, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, id := range students {
+		n, err := reflagStudentWork(ctx, q, schoolID, userID, id, nil, &assignmentID)
+		if err != nil {
+			return changed, err
+		}
+		changed += n
+	}
+	return changed, nil
+}
+
+// RunMissingWorkSweep flags missing work across all schools every interval
+// until ctx is cancelled. Each sweep is done by one replica and covers work
+// that fell due since the last completed sweep; the first covers everything.
+// Work that becomes overdue retroactively (a due date moved into the past, an
+// extension removed, an assignment published late) is flagged when that
+// change is saved, by reflagStudentWork.
+func (h *SubmissionsHandler) RunMissingWorkSweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.sweepMissingWork(ctx, interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweepMissingWork runs one missing work sweep unless another replica is
+// running one or finished one within half an interval.
+func (h *SubmissionsHandler) sweepMissingWork(ctx context.Context, interval time.Duration) {
+	run, runCtx, err := startBackgroundRun(ctx, h.db, "missing_work_sweep", interval/2)
+	if err != nil {
+		log.Printf("submissions: missing work sweep: %v", err)
+		return
+	}
+	if run == nil {
+		return
+	}
+	n, err := markMissingWork(runCtx, h.db, nil, run.lastRun)
+	run.finish(err == nil)
+	if err != nil {
+		log.Printf("submissions: missing work sweep: %v", err)
+	} else if n > 0 {
+		log.Printf("submissions: flagged %d missing grade(s)", n)
+	}
+}
@@ -244,6 +244,25 @@ func (h *SuperAdminHandler) UpdateSchool(w http.ResponseWriter, r *http.Request)
 
 	ctx := r.Context()
 
+	schoolUUID, err := uuid.Parse(schoolID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid school ID")
+		return
+	}
+
+	// Templates carried in settings are validated like any other template save.
+	var settings models.SchoolSettings
+	if req.Settings != nil {
+		if err := json.Unmarshal(req.Settings, &settings); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_settings", err.Error())
+			return
+		}
+		if err := validateSettingsTemplates(settings); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "invalid_template", err.Error())
+			return
+		}
+	}
+
 	// Read current state for audit log.
 	var oldName string
 	var oldAddress *string
@@ -261,9 +280,12 @@ func (h *SuperAdminHandler) UpdateSchool(w http.ResponseWriter, r *http.Request)
 	}
 	if req.Settings != nil {
 		h.db.Exec(ctx, `UPDATE schools SET settings = $1, updated_at = NOW() WHERE id = $2`, req.Settings, schoolID)
+		if err := syncSettingsTemplates(ctx, h.db, schoolUUID, claims.UserID, settings); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
 	}
 
-	schoolUUID, _ := uuid.Parse(schoolID)
 	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
 		SchoolID:   schoolUUID,
 		UserID:     &claims.UserID,
@@ -0,0 +1,552 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
+)
+
+// TemplatesHandler manages school-defined report card and document templates.
+// See services/templates.go for the markup and data contract.
+type TemplatesHandler struct {
+	db      *pgxpool.Pool
+	pdf     *services.PDFService
+	storage *services.StorageService
+}
+
+// NewTemplatesHandler creates a TemplatesHandler.
+func NewTemplatesHandler(db *pgxpool.Pool, pdf *services.PDFService, storage *services.StorageService) *TemplatesHandler {
+	return &TemplatesHandler{db: db, pdf: pdf, storage: storage}
+}
+
+// defaultTemplateName is the name of the single template lineage every
+// non-custom kind uses.
+const defaultTemplateName = "default"
+
+// resolvedTemplate is the template body chosen for one render. ID is nil for
+// a legacy settings template or the built-in (empty Body).
+type resolvedTemplate struct {
+	ID   *uuid.UUID
+	Body string
+}
+
+// resolveTemplate picks the template for a kind: the active version named
+// name, then the body in SchoolSettings (DocumentTemplates or
+// ReportCardTemplate) for schools that have not saved a version yet, then the
+// built-in.
+func resolveTemplate(ctx context.Context, db dbtx, school *models.School, kind, name string) (*resolvedTemplate, error) {
+	if name == "" {
+		name = defaultTemplateName
+	}
+
+	var t resolvedTemplate
+	var id uuid.UUID
+	err := db.QueryRow(ctx, `
+		SELECT id, body FROM document_templates
+		WHERE school_id = $1 AND kind = $2 AND name = $3 AND is_active
+	`, school.ID, kind, name).Scan(&id, &t.Body)
+	if err == nil {
+		t.ID = &id
+		return &t, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	if name == defaultTemplateName {
+		if kind == services.TemplateKindReportCard {
+			t.Body = school.Settings.ReportCardTemplate
+		} else {
+			t.Body = school.Settings.DocumentTemplates[kind]
+		}
+	}
+	return &t, nil
+}
+
+// snapshot encodes td as render_data for a render with this template. A body
+// that is not a saved version is recorded too, falling back to the built-in
+// for kind.
+func (t *resolvedTemplate) snapshot(kind string, td services.TemplateData) ([]byte, error) {
+	s := services.RenderSnapshot{TemplateData: td}
+	if t.ID == nil {
+		s.TemplateBody = t.Body
+		if s.TemplateBody == "" {
+			s.TemplateBody = services.BuiltinTemplate(kind)
+		}
+	}
+	return json.Marshal(s)
+}
+
+// templateVersion loads one template version by id.
+func templateVersion(ctx context.Context, db dbtx, id, schoolID uuid.UUID) (string, error) {
+	var body string
+	err := db.QueryRow(ctx, `
+		SELECT body FROM document_templates WHERE id = $1 AND school_id = $2
+	`, id, schoolID).Scan(&body)
+	return body, err
+}
+
+// saveTemplateVersion inserts the next version of a template lineage and,
+// if activate is set, makes it the active one. q should be a transaction.
+func saveTemplateVersion(ctx context.Context, q dbtx, schoolID, userID uuid.UUID, kind, name, body string, activate bool) (*models.DocumentTemplate, error) {
+	if activate {
+		if _, err := q.Exec(ctx, `
+			UPDATE document_templates SET is_active = FALSE
+			WHERE school_id = $1 AND kind = $2 AND name = $3 AND is_active
+		`, schoolID, kind, name); err != nil {
+			return nil, err
+		}
+	}
+
+	var t models.DocumentTemplate
+	err := q.QueryRow(ctx, `
+		INSERT INTO document_templates (school_id, kind, name, version, body, is_active, created_by)
+		SELECT $1, $2, $3, COALESCE(MAX(version), 0) + 1, $4, $5, $6
+		FROM document_templates
+		WHERE school_id = $1 AND kind = $2 AND name = $3
+		RETURNING id, short_id, school_id, kind, name, version, is_active, created_by, created_at
+	`, schoolID, kind, name, body, activate, userID).Scan(
+		&t.ID, &t.ShortID, &t.SchoolID, &t.Kind, &t.Name, &t.Version, &t.IsActive, &t.CreatedBy, &t.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	t.Body = body
+	return &t, nil
+}
+
+// validateSettingsTemplates checks the templates embedded in SchoolSettings.
+func validateSettingsTemplates(settings models.SchoolSettings) error {
+	if settings.ReportCardTemplate != "" {
+		if err := services.ValidateTemplate(services.TemplateKindReportCard, settings.ReportCardTemplate); err != nil {
+			return fmt.Errorf("report_card_template: %w", err)
+		}
+	}
+	for kind, body := range settings.DocumentTemplates {
+		if !validTemplateKind(kind) || kind == services.TemplateKindReportCard {
+			return fmt.Errorf("document_templates: unknown document type %q", kind)
+		}
+		if err := services.ValidateTemplate(kind, body); err != nil {
+			return fmt.Errorf("document_templates.%s: %w", kind, err)
+		}
+	}
+	return nil
+}
+
+// syncSettingsTemplates records templates saved through SchoolSettings as
+// new active versions, so they are versioned like any other save. Bodies
+// matching the active version are skipped.
+func syncSettingsTemplates(ctx context.Context, db *pgxpool.Pool, schoolID, userID uuid.UUID, settings models.SchoolSettings) error {
+	bodies := make(map[string]string, len(settings.DocumentTemplates)+1)
+	for kind, body := range settings.DocumentTemplates {
+		bodies[kind] = body
+	}
+	if settings.ReportCardTemplate != "" {
+		bodies[services.TemplateKindReportCard] = settings.ReportCardTemplate
+	}
+	if len(bodies) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for kind, body := range bodies {
+		if body == "" {
+			continue
+		}
+		var current string
+		err := tx.QueryRow(ctx, `
+			SELECT body FROM document_templates
+			WHERE school_id = $1 AND kind = $2 AND name = $3 AND is_active
+		`, schoolID, kind, defaultTemplateName).Scan(&current)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if current == body {
+			continue
+		}
+		if _, err := saveTemplateVersion(ctx, tx, schoolID, userID, kind, defaultTemplateName, body, true); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// ListTemplates returns every saved version, newest first, without bodies.
+// Optional filters: ?kind= and ?name=.
+func (h *TemplatesHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	q := r.URL.Query()
+
+	rows, err := h.db.Query(r.Context(), `
+		SELECT id, short_id, school_id, kind, name, version, is_active, created_by, created_at
+		FROM document_templates
+		WHERE school_id = $1
+		  AND ($2 = '' OR kind = $2)
+		  AND ($3 = '' OR name = $3)
+		ORDER BY kind, name, version DESC
+	`, claims.SchoolID, q.Get("kind"), q.Get("name"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	templates := []models.DocumentTemplate{}
+	for rows.Next() {
+		var t models.DocumentTemplate
+		if err := rows.Scan(&t.ID, &t.ShortID, &t.SchoolID, &t.Kind, &t.Name, &t.Version,
+			&t.IsActive, &t.CreatedBy, &t.CreatedAt); err != nil {
+			writeError(w, http.StatusInternalServerError, "scan_error", err.Error())
+			return
+		}
+		templates = append(templates, t)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"templates": templates})
+}
+
+// GetTemplate returns one version including its body.
+// templateId URL param is a short_id.
+func (h *TemplatesHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+
+	var t models.DocumentTemplate
+	err := h.db.QueryRow(r.Context(), `
+		SELECT id, short_id, school_id, kind, name, version, body, is_active, created_by, created_at
+		FROM document_templates
+		WHERE short_id = $1 AND school_id = $2
+	`, chi.URLParam(r, "templateId"), claims.SchoolID).Scan(
+		&t.ID, &t.ShortID, &t.SchoolID, &t.Kind, &t.Name, &t.Version, &t.Body,
+		&t.IsActive, &t.CreatedBy, &t.CreatedAt,
+	)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "template not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, t)
+}
+
+// GetBuiltinTemplate returns the compiled-in template for a kind, as a
+// starting point for a school's own.
+func (h *TemplatesHandler) GetBuiltinTemplate(w http.ResponseWriter, r *http.Request) {
+	kind := chi.URLParam(r, "kind")
+	if !validTemplateKind(kind) {
+		writeError(w, http.StatusNotFound, "not_found", "unknown template kind")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"kind": kind,
+		"body": services.BuiltinTemplate(kind),
+	})
+}
+
+func validTemplateKind(kind string) bool {
+	for _, k := range services.TemplateKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateTemplate validates a template and saves it as the next version of
+// its lineage. The new version becomes active unless activate is false.
+func (h *TemplatesHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		Kind     string `json:"kind" validate:"required,oneof=report_card enrollment_certificate attendance_letter academic_standing tuition_confirmation transcript custom"`
+		Name     string `json:"name" validate:"max=100"`
+		Body     string `json:"body" validate:"required"`
+		Activate *bool  `json:"activate"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if req.Name == "" {
+		req.Name = defaultTemplateName
+	}
+	if req.Kind != services.TemplateKindCustom && req.Name != defaultTemplateName {
+		writeError(w, http.StatusBadRequest, "validation_error", "only custom templates may be named")
+		return
+	}
+
+	if err := services.ValidateTemplate(req.Kind, req.Body); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_template", err.Error())
+		return
+	}
+
+	activate := req.Activate == nil || *req.Activate
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	t, err := saveTemplateVersion(ctx, tx, claims.SchoolID, claims.UserID, req.Kind, req.Name, req.Body, activate)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "template.create",
+		EntityType: "document_template",
+		EntityID:   &t.ID,
+		NewValue:   map[string]interface{}{"kind": t.Kind, "name": t.Name, "version": t.Version, "active": t.IsActive},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// ActivateTemplate makes a saved version the active one for its lineage,
+// including rolling back to an earlier version.
+// templateId URL param is a short_id.
+func (h *TemplatesHandler) ActivateTemplate(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var id uuid.UUID
+	var kind, name string
+	var version int
+	err := h.db.QueryRow(ctx, `
+		SELECT id, kind, name, version FROM document_templates WHERE short_id = $1 AND school_id = $2
+	`, chi.URLParam(r, "templateId"), claims.SchoolID).Scan(&id, &kind, &name, &version)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "template not found")
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE document_templates SET is_active = FALSE
+		WHERE school_id = $1 AND kind = $2 AND name = $3 AND is_active
+	`, claims.SchoolID, kind, name); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if _, err := tx.Exec(ctx, `UPDATE document_templates SET is_active = TRUE WHERE id = $1`, id); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "template.activate",
+		EntityType: "document_template",
+		EntityID:   &id,
+		NewValue:   map[string]interface{}{"kind": kind, "name": name, "version": version},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// PreviewTemplate renders an unsaved template against sample data with the
+// school's own name, address, branding, and signatory, and returns the PDF.
+// Validation errors are reported the same way as on save.
+func (h *TemplatesHandler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		Kind string `json:"kind" validate:"required,oneof=report_card enrollment_certificate attendance_letter academic_standing tuition_confirmation transcript custom"`
+		Body string `json:"body" validate:"required"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	if err := services.ValidateTemplate(req.Kind, req.Body); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_template", err.Error())
+		return
+	}
+
+	school, err := loadSchool(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+	assets := loadSchoolAssets(ctx, h.storage, school)
+
+	data, _ := services.SampleTemplateData(req.Kind)
+	data.School.Name = school.Name
+	data.School.Address = ""
+	if school.Address != nil {
+		data.School.Address = *school.Address
+	}
+	data.SignatoryName = school.Settings.SignatoryName
+	data.SignatoryTitle = school.Settings.SignatoryTitle
+
+	out, err := h.pdf.RenderTemplatePDF(req.Kind, req.Body, data, services.Branding{
+		PrimaryColor: school.Settings.PrimaryColor,
+		Logo:         assets.Logo,
+		Signature:    assets.Signature,
+	})
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_template", err.Error())
+		return
+	}
+
+	writeBrandingWarnings(w, assets)
+	writePDF(w, "preview.pdf", out)
+}
+
+// RenderDocument re-renders a stored document from the template version and
+// data snapshot it was generated with and returns the PDF. Branding images
+// are the school's current ones.
+// documentId URL param is the document's UUID.
+func (h *TemplatesHandler) RenderDocument(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+
+	var docType string
+	var templateID *uuid.UUID
+	var snapshot []byte
+	err := h.db.QueryRow(r.Context(), `
+		SELECT type, template_id, render_data FROM documents WHERE id = $1 AND school_id = $2
+	`, chi.URLParam(r, "documentId"), claims.SchoolID).Scan(&docType, &templateID, &snapshot)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "document not found")
+		return
+	}
+
+	h.rerender(w, r, docType, templateID, snapshot)
+}
+
+// RenderReportCard re-renders a stored report card; see RenderDocument.
+// reportCardId URL param is the report card's UUID.
+func (h *TemplatesHandler) RenderReportCard(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+
+	var templateID *uuid.UUID
+	var snapshot []byte
+	err := h.db.QueryRow(r.Context(), `
+		SELECT template_id, render_data FROM report_cards WHERE id = $1 AND school_id = $2
+	`, chi.URLParam(r, "reportCardId"), claims.SchoolID).Scan(&templateID, &snapshot)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "report card not found")
+		return
+	}
+
+	h.rerender(w, r, services.TemplateKindReportCard, templateID, snapshot)
+}
+
+func (h *TemplatesHandler) rerender(w http.ResponseWriter, r *http.Request, kind string, templateID *uuid.UUID, snapshot []byte) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	if snapshot == nil {
+		writeError(w, http.StatusConflict, "no_snapshot", "this was generated before render data was recorded")
+		return
+	}
+	var data services.RenderSnapshot
+	if err := json.Unmarshal(snapshot, &data); err != nil {
+		writeError(w, http.StatusInternalServerError, "snapshot_error", err.Error())
+		return
+	}
+
+	school, err := loadSchool(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	// A NULL template_id means the built-in or a legacy settings template,
+	// whose body the snapshot records. Snapshots taken before bodies were
+	// recorded fall back to today's body.
+	body := data.TemplateBody
+	if templateID != nil {
+		if body, err = templateVersion(ctx, h.db, *templateID, claims.SchoolID); err != nil {
+			writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+			return
+		}
+	} else if body == "" {
+		if t, err := resolveTemplate(ctx, h.db, school, kind, defaultTemplateName); err == nil && t.ID == nil {
+			body = t.Body
+		}
+	}
+
+	assets := loadSchoolAssets(ctx, h.storage, school)
+	out, err := h.pdf.RenderTemplatePDF(kind, body, data.TemplateData, services.Branding{
+		PrimaryColor: school.Settings.PrimaryColor,
+		Logo:         assets.Logo,
+		Signature:    assets.Signature,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "pdf_error", err.Error())
+		return
+	}
+
+	writeBrandingWarnings(w, assets)
+	writePDF(w, kind+".pdf", out)
+}
+
+// writeBrandingWarnings reports branding images left out of a rendered PDF
+// in X-Branding-Warning headers.
+func writeBrandingWarnings(w http.ResponseWriter, assets *schoolAssets) {
+	for _, warning := range assets.Warnings {
+		w.Header().Add("X-Branding-Warning", warning)
+	}
+}
+
+// writePDF writes PDF bytes as an inline response.
+func writePDF(w http.ResponseWriter, filename string, pdf []byte) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(pdf)
+}
@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/auth"
+	"github.com/pragma-proto/api/internal/middleware"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
+)
+
+// TermsHandler manages a school's academic terms (years, semesters, quarters).
+type TermsHandler struct {
+	db *pgxpool.Pool
+}
+
+// NewTermsHandler creates a TermsHandler.
+func NewTermsHandler(db *pgxpool.Pool) *TermsHandler {
+	return &TermsHandler{db: db}
+}
+
+// loadTerms returns every term for a school ordered by start date.
+func loadTerms(ctx context.Context, db dbtx, schoolID uuid.UUID) ([]models.Term, error) {
+	rows, err := db.Query(ctx, `
+		SELECT id, short_id, school_id, parent_id, name, term_type, start_date, end_date, is_closed, closed_at,
+		       created_at, updated_at
+		FROM terms
+		WHERE school_id = $1
+		ORDER BY start_date, term_type
+	`, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var terms []models.Term
+	for rows.Next() {
+		var t models.Term
+		if err := rows.Scan(&t.ID, &t.ShortID, &t.SchoolID, &t.ParentID, &t.Name, &t.TermType,
+			&t.StartDate, &t.EndDate, &t.IsClosed, &t.ClosedAt, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+	}
+	return terms, rows.Err()
+}
+
+// resolveTerm looks up a term by its short_id, scoped to a school.
+func resolveTerm(ctx context.Context, db *pgxpool.Pool, shortID string, schoolID uuid.UUID) (*models.Term, error) {
+	var t models.Term
+	err := db.QueryRow(ctx, `
+		SELECT id, short_id, school_id, parent_id, name, term_type, start_date, end_date, is_closed, closed_at,
+		       created_at, updated_at
+		FROM terms
+		WHERE short_id = $1 AND school_id = $2
+	`, shortID, schoolID).Scan(&t.ID, &t.ShortID, &t.SchoolID, &t.ParentID, &t.Name, &t.TermType,
+		&t.StartDate, &t.EndDate, &t.IsClosed, &t.ClosedAt, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// termPath returns the chain of terms from the school year down to t.
+func termPath(terms []models.Term, t *models.Term) []models.Term {
+	byID := make(map[uuid.UUID]models.Term, len(terms))
+	for _, x := range terms {
+		byID[x.ID] = x
+	}
+	path := []models.Term{*t}
+	for cur := t; cur.ParentID != nil; {
+		parent, ok := byID[*cur.ParentID]
+		if !ok {
+			break
+		}
+		path = append([]models.Term{parent}, path...)
+		cur = &parent
+	}
+	return path
+}
+
+// validateTermPlacement checks that a term with the given type and dates fits
+// under parent and does not overlap its siblings. excludeID skips the term
+// itself when updating.
+func validateTermPlacement(terms []models.Term, termType string, parent *models.Term, start, end time.Time, excludeID uuid.UUID) error {
+	if end.Before(start) {
+		return fmt.Errorf("end_date must be on or after start_date")
+	}
+
+	if want, nested := models.TermParentType[termType]; nested {
+		if parent == nil {
+			return fmt.Errorf("a %s must have a parent %s", termType, want)
+		}
+		if parent.TermType != want {
+			return fmt.Errorf("a %s must be nested under a %s, not a %s", termType, want, parent.TermType)
+		}
+		if start.Before(parent.StartDate) || end.After(parent.EndDate) {
+			return fmt.Errorf("dates must fall within %s (%s to %s)", parent.Name,
+				parent.StartDate.Format("2006-01-02"), parent.EndDate.Format("2006-01-02"))
+		}
+	} else if parent != nil {
+		return fmt.Errorf("a school year cannot have a parent term")
+	}
+
+	for _, s := range terms {
+		if s.ID == excludeID || s.TermType != termType {
+			continue
+		}
+		if (parent == nil) != (s.ParentID == nil) || (parent != nil && *s.ParentID != parent.ID) {
+			continue
+		}
+		if !start.After(s.EndDate) && !end.Before(s.StartDate) {
+			return fmt.Errorf("dates overlap %s", s.Name)
+		}
+	}
+	return nil
+}
+
+// ListTerms returns the school's terms as a tree of years → semesters → quarters.
+func (h *TermsHandler) ListTerms(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+
+	terms, err := loadTerms(r.Context(), h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	children := make(map[uuid.UUID][]models.Term)
+	for _, t := range terms {
+		if t.ParentID != nil {
+			children[*t.ParentID] = append(children[*t.ParentID], t)
+		}
+	}
+	var build func(t models.Term) models.Term
+	build = func(t models.Term) models.Term {
+		for _, c := range children[t.ID] {
+			t.Children = append(t.Children, build(c))
+		}
+		return t
+	}
+
+	tree := []models.Term{}
+	for _, t := range terms {
+		if t.ParentID == nil {
+			tree = append(tree, build(t))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"terms": tree})
+}
+
+// GetCurrentTerm resolves the most specific term containing today, or the
+// date given by ?date= (YYYY-MM-DD). The response includes the enclosing
+// year and semester in "path".
+func (h *TermsHandler) GetCurrentTerm(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+
+	at := time.Now()
+	if d, err := parseDateParam(r.URL.Query().Get("date")); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "date must be YYYY-MM-DD")
+		return
+	} else if d != nil {
+		at = *d
+	}
+
+	terms, err := loadTerms(r.Context(), h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	current := services.ResolveCurrentTerm(terms, at)
+	if current == nil {
+		writeError(w, http.StatusNotFound, "not_found", "no term covers this date")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"term": current,
+		"path": termPath(terms, current),
+	})
+}
+
+// CreateTerm adds a year, semester, or quarter (admin only).
+// parent_id is the parent term's short_id and is required for semesters and quarters.
+func (h *TermsHandler) CreateTerm(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		Name      string `json:"name" validate:"required,min=1,max=100"`
+		TermType  string `json:"term_type" validate:"required,oneof=year semester quarter"`
+		ParentID  string `json:"parent_id"`
+		StartDate string `json:"start_date" validate:"required"`
+		EndDate   string `json:"end_date" validate:"required"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	start, err1 := parseDateParam(req.StartDate)
+	end, err2 := parseDateParam(req.EndDate)
+	if err1 != nil || err2 != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "start_date and end_date must be YYYY-MM-DD")
+		return
+	}
+
+	terms, err := loadTerms(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	var parent *models.Term
+	if req.ParentID != "" {
+		for i := range terms {
+			if terms[i].ShortID == req.ParentID {
+				parent = &terms[i]
+				break
+			}
+		}
+		if parent == nil {
+			writeError(w, http.StatusNotFound, "not_found", "parent term not found")
+			return
+		}
+	}
+
+	if err := validateTermPlacement(terms, req.TermType, parent, *start, *end, uuid.Nil); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_term", err.Error())
+		return
+	}
+
+	var parentID *uuid.UUID
+	if parent != nil {
+		parentID = &parent.ID
+	}
+
+	var t models.Term
+	err = h.db.QueryRow(ctx, `
+		INSERT INTO terms (school_id, parent_id, name, term_type, start_date, end_date)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, short_id, school_id, parent_id, name, term_type, start_date, end_date, created_at, updated_at
+	`, claims.SchoolID, parentID, req.Name, req.TermType, start, end).Scan(
+		&t.ID, &t.ShortID, &t.SchoolID, &t.ParentID, &t.Name, &t.TermType,
+		&t.StartDate, &t.EndDate, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "term.create",
+		EntityType: "term",
+		EntityID:   &t.ID,
+		NewValue:   req,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// UpdateTerm renames a term or moves its dates (admin only). The new range
+// must still fit its parent and contain all of its child terms.
+// termId URL param is a short_id.
+func (h *TermsHandler) UpdateTerm(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	var req struct {
+		Name      string `json:"name" validate:"required,min=1,max=100"`
+		StartDate string `json:"start_date" validate:"required"`
+		EndDate   string `json:"end_date" validate:"required"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	start, err1 := parseDateParam(req.StartDate)
+	end, err2 := parseDateParam(req.EndDate)
+	if err1 != nil || err2 != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "start_date and end_date must be YYYY-MM-DD")
+		return
+	}
+
+	existing, err := resolveTerm(ctx, h.db, chi.URLParam(r, "termId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "term not found")
+		return
+	}
+
+	terms, err := loadTerms(ctx, h.db, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	var parent *models.Term
+	for i := range terms {
+		if existing.ParentID != nil && terms[i].ID == *existing.ParentID {
+			parent = &terms[i]
+		}
+	}
+
+	if err := validateTermPlacement(terms, existing.TermType, parent, *start, *end, existing.ID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_term", err.Error())
+		return
+	}
+	for _, c := range terms {
+		if c.ParentID != nil && *c.ParentID == existing.ID &&
+			(c.StartDate.Before(*start) || c.EndDate.After(*end)) {
+			writeError(w, http.StatusBadRequest, "invalid_term",
+				fmt.Sprintf("dates must still contain %s", c.Name))
+			return
+		}
+	}
+
+	_, err = h.db.Exec(ctx, `
+		UPDATE terms SET name = $1, start_date = $2, end_date = $3
+		WHERE id = $4 AND school_id = $5
+	`, req.Name, start, end, existing.ID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "term.update",
+		EntityType: "term",
+		EntityID:   &existing.ID,
+		OldValue: map[string]interface{}{
+			"name":       existing.Name,
+			"start_date": existing.StartDate.Format("2006-01-02"),
+			"end_date":   existing.EndDate.Format("2006-01-02"),
+		},
+		NewValue:  req,
+		IPAddress: r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// DeleteTerm removes a term that has no child terms, courses, or report cards (admin only).
+// termId URL param is a short_id.
+func (h *TermsHandler) DeleteTerm(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	existing, err := resolveTerm(ctx, h.db, chi.URLParam(r, "termId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "term not found")
+		return
+	}
+
+	var inUse bool
+	h.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM terms WHERE parent_id = $1)
+		    OR EXISTS (SELECT 1 FROM courses WHERE term_id = $1)
+		    OR EXISTS (SELECT 1 FROM report_cards WHERE term_id = $1)
+	`, existing.ID).Scan(&inUse)
+	if inUse {
+		writeError(w, http.StatusConflict, "term_in_use",
+			"term has child terms, courses, or report cards and cannot be deleted")
+		return
+	}
+
+	if _, err := h.db.Exec(ctx, `DELETE FROM terms WHERE id = $1 AND school_id = $2`,
+		existing.ID, claims.SchoolID); err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     "term.delete",
+		EntityType: "term",
+		EntityID:   &existing.ID,
+		OldValue:   existing,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// CloseTerm closes a term and every term nested in it (admin only). Grades on
+// assignments due within a closed term are final: changes to them become
+// grade change requests.
+// termId URL param is a short_id.
+func (h *TermsHandler) CloseTerm(w http.ResponseWriter, r *http.Request) {
+	h.setTermClosed(w, r, true)
+}
+
+// ReopenTerm reopens a closed term and the terms nested in it (admin only).
+// termId URL param is a short_id.
+func (h *TermsHandler) ReopenTerm(w http.ResponseWriter, r *http.Request) {
+	h.setTermClosed(w, r, false)
+}
+
+func (h *TermsHandler) setTermClosed(w http.ResponseWriter, r *http.Request, closed bool) {
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	ctx := r.Context()
+
+	existing, err := resolveTerm(ctx, h.db, chi.URLParam(r, "termId"), claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "term not found")
+		return
+	}
+
+	_, err = h.db.Exec(ctx, `
+		WITH RECURSIVE nested AS (
+			SELECT id FROM terms WHERE id = $1
+			UNION ALL
+			SELECT t.id FROM terms t JOIN nested n ON t.parent_id = n.id
+		)
+		UPDATE terms
+		SET is_closed = $2,
+		    closed_at = CASE WHEN $2 THEN NOW() END,
+		    closed_by = CASE WHEN $2 THEN $3::UUID END
+		WHERE id IN (SELECT id FROM nested) AND school_id = $4
+	`, existing.ID, closed, claims.UserID, claims.SchoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db_error", err.Error())
+		return
+	}
+
+	action := "term.close"
+	if !closed {
+		action = "term.reopen"
+	}
+	_ = middleware.WriteAuditLog(ctx, h.db, middleware.AuditEntry{
+		SchoolID:   claims.SchoolID,
+		UserID:     &claims.UserID,
+		Action:     action,
+		EntityType: "term",
+		EntityID:   &existing.ID,
+		OldValue:   map[string]bool{"is_closed": existing.IsClosed},
+		NewValue:   map[string]bool{"is_closed": closed},
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
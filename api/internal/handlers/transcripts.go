@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pragma-proto/api/internal/models"
+	"github.com/pragma-proto/api/internal/services"
+)
+
+// A transcript lists every completed course: enrollments marked completed,
+// and active enrollments in courses whose term has ended. Each course is
+// graded over its own term with the same pipeline as report cards. Courses
+// without a term are grouped by their academic year and semester labels.
+
+// transcriptCourse is one completed course and the period it belongs to.
+type transcriptCourse struct {
+	ShortID  string
+	Period   string
+	PeriodID string
+	Term     *models.Term
+}
+
+// buildTranscript assembles a student's complete academic record.
+func buildTranscript(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	grading *services.GradingService,
+	settings models.SchoolSettings,
+	student *models.Student,
+	schoolID uuid.UUID,
+) (*services.Transcript, error) {
+	rows, err := db.Query(ctx, `
+		SELECT c.short_id, c.academic_year, c.semester,
+		       t.id, t.short_id, t.name, t.term_type, t.start_date, t.end_date
+		FROM enrollments e
+		JOIN courses c ON c.id = e.course_id
+		LEFT JOIN terms t ON t.id = c.term_id
+		WHERE e.student_id = $1 AND c.school_id = $2
+		  AND (e.status = 'completed' OR (e.status = 'active' AND t.end_date < CURRENT_DATE))
+		ORDER BY c.academic_year, t.end_date NULLS LAST, t.start_date DESC, t.id, c.semester, c.name
+	`, student.ID, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	var courses []transcriptCourse
+	for rows.Next() {
+		var c transcriptCourse
+		var year string
+		var semester *string
+		var termID *uuid.UUID
+		var termShortID, termName, termType *string
+		var start, end *time.Time
+		if err := rows.Scan(&c.ShortID, &year, &semester,
+			&termID, &termShortID, &termName, &termType, &start, &end); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if termID != nil {
+			c.Term = &models.Term{ID: *termID, ShortID: *termShortID, Name: *termName, TermType: *termType,
+				StartDate: *start, EndDate: *end}
+			c.Period, c.PeriodID = *termName, termID.String()
+		} else {
+			c.Period = year
+			if semester != nil && *semester != "" {
+				c.Period += " " + *semester
+			}
+			c.PeriodID = c.Period
+		}
+		courses = append(courses, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	t := &services.Transcript{
+		CreditsRequired:  settings.GraduationCredits,
+		GraduationStatus: graduationStatus(student.EnrollmentStatus),
+	}
+	if len(courses) == 0 {
+		t.Cumulative = grading.CumulativeGPA(nil, settings.GPAMethod)
+		return t, nil
+	}
+
+	shortIDs := make([]string, len(courses))
+	for i, c := range courses {
+		shortIDs[i] = c.ShortID
+	}
+	bySID, err := loadCourseGradeSetups(ctx, db, settings, shortIDs, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	setups := make([]*courseGradeSetup, len(courses))
+	courseIDs := make([]uuid.UUID, len(courses))
+	for i, c := range courses {
+		setups[i] = bySID[c.ShortID]
+		courseIDs[i] = setups[i].ID
+	}
+	grades, err := loadStudentGrades(ctx, db, student.ID, courseIDs, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	scores, err := loadStudentStandardScores(ctx, db, student.ID, courseIDs, schoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group consecutive courses of the same period into terms.
+	var termGPAs []models.GPAResult
+	var graded []studentCourseGrade
+	flush := func() {
+		if len(t.Terms) == 0 {
+			return
+		}
+		term := &t.Terms[len(t.Terms)-1]
+		term.GPA = gpaFromCourseGrades(grading, graded, settings)
+		termGPAs = append(termGPAs, term.GPA)
+		term.Cumulative = grading.CumulativeGPA(termGPAs, settings.GPAMethod)
+		graded = nil
+	}
+	period := ""
+	for i, c := range courses {
+		if i == 0 || c.PeriodID != period {
+			flush()
+			t.Terms = append(t.Terms, services.TranscriptTerm{Name: c.Period})
+			period = c.PeriodID
+		}
+		g := setups[i].grade(grading, settings, student.ID, grades, scores[setups[i].ID], c.Term)
+		graded = append(graded, g)
+
+		term := &t.Terms[len(t.Terms)-1]
+		term.Courses = append(term.Courses, courseGradeRow(g))
+		t.CreditsAttempted += g.Credits
+		if creditEarned(grading, g, settings.GradingScale) {
+			term.CreditsEarned += g.Credits
+			t.CreditsEarned += g.Credits
+		}
+	}
+	flush()
+
+	t.Cumulative = grading.CumulativeGPA(termGPAs, settings.GPAMethod)
+	if t.GraduationStatus == "In progress" && t.CreditsRequired > 0 && t.CreditsEarned >= t.CreditsRequired {
+		t.GraduationStatus = "Credit requirements met"
+	}
+	return t, nil
+}
+
+// creditMasteryLevel is the mean proficiency a standards-based course needs
+// to earn credit: Developing, the standards counterpart of a passing letter.
+const creditMasteryLevel = 2
+
+// creditEarned reports whether a completed course earns its credits: a
+// passing letter grade, or for a standards-based course, evidence on at
+// least one standard and a mean mastery of creditMasteryLevel or better
+// across the assessed standards.
+func creditEarned(grading *services.GradingService, g studentCourseGrade, scale []models.LetterGradeMapping) bool {
+	if g.Mode == models.GradingModeStandards {
+		var sum float64
+		assessed := 0
+		for _, m := range g.Standards {
+			if m.Evidence > 0 {
+				sum += m.Score
+				assessed++
+			}
+		}
+		return assessed > 0 && sum/float64(assessed) >= creditMasteryLevel
+	}
+	return g.Calc != nil && grading.LetterToGradePoint(g.Calc.LetterGrade, scale) > 0
+}
+
+// graduationStatus describes a student's enrollment status on a transcript.
+func graduationStatus(enrollmentStatus string) string {
+	switch enrollmentStatus {
+	case "graduated":
+		return "Graduated"
+	case "withdrawn":
+		return "Withdrawn"
+	case "transferred":
+		return "Transferred"
+	}
+	return "In progress"
+}
@@ -12,7 +12,7 @@ func CORS(frontendOrigin string) func(http.Handler) http.Handler {
 		AllowedOrigins:   []string{frontendOrigin},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "X-Branding-Warning"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	})
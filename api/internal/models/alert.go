@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Alert severities, lowest first.
+const (
+	AlertLow    = "low"
+	AlertMedium = "medium"
+	AlertHigh   = "high"
+)
+
+// Alert statuses.
+const (
+	AlertOpen      = "open"
+	AlertDismissed = "dismissed"
+	AlertActioned  = "actioned"
+	AlertResolved  = "resolved" // the trend recovered before anyone acted
+)
+
+// Alert sources.
+const (
+	AlertSourceAI    = "ai"
+	AlertSourceRules = "rules"
+)
+
+// GradeTrend is the trajectory an alert was raised on: a student's
+// assignment percentages in one course, oldest first.
+type GradeTrend struct {
+	Scores  []float64 `json:"scores"`
+	Average float64   `json:"average"`
+	Recent  float64   `json:"recent"`  // mean of the last three scores
+	Earlier *float64  `json:"earlier"` // mean of the scores before those, if any
+	Missing int       `json:"missing"`
+}
+
+// StudentAlert flags a student whose grades in a course are trending badly.
+type StudentAlert struct {
+	ID         uuid.UUID  `json:"-" db:"id"`
+	ShortID    string     `json:"id" db:"short_id"`
+	SchoolID   uuid.UUID  `json:"school_id" db:"school_id"`
+	StudentID  uuid.UUID  `json:"student_id" db:"student_id"`
+	CourseID   uuid.UUID  `json:"-" db:"course_id"`
+	Severity   string     `json:"severity" db:"severity"`
+	Source     string     `json:"source" db:"source"`
+	Message    string     `json:"message" db:"message"`
+	Trend      GradeTrend `json:"trend" db:"trend"`
+	Status     string     `json:"status" db:"status"`
+	ActionNote *string    `json:"action_note,omitempty" db:"action_note"`
+	ResolvedBy *uuid.UUID `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Joined for display.
+	StudentName   string `json:"student_name"`
+	CourseShortID string `json:"course_id"`
+	CourseName    string `json:"course_name"`
+}
+
+// AlertSeverityRank orders severities for comparison; unknown values rank 0.
+func AlertSeverityRank(severity string) int {
+	switch severity {
+	case AlertLow:
+		return 1
+	case AlertMedium:
+		return 2
+	case AlertHigh:
+		return 3
+	}
+	return 0
+}
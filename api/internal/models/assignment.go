@@ -34,6 +34,13 @@ type Assignment struct {
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
 
+	// Extra-credit points count toward the earned total but not the possible total.
+	IsExtraCredit bool `json:"is_extra_credit" db:"is_extra_credit"`
+
+	// Students turn work in through the API; missing work is flagged
+	// automatically once the due date passes.
+	AcceptsSubmissions bool `json:"accepts_submissions" db:"accepts_submissions"`
+
 	// Joined when fetching with attachments.
 	Attachments []Attachment `json:"attachments,omitempty"`
 }
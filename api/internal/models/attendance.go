@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attendance status codes.
+const (
+	AttendancePresent = "present"
+	AttendanceAbsent  = "absent"
+	AttendanceTardy   = "tardy"
+	AttendanceExcused = "excused"
+)
+
+// AttendanceRecord is a single daily or per-period attendance mark.
+// CourseID is nil for daily (whole-day) records.
+type AttendanceRecord struct {
+	ID               uuid.UUID  `json:"-" db:"id"`
+	ShortID          string     `json:"id" db:"short_id"`
+	SchoolID         uuid.UUID  `json:"school_id" db:"school_id"`
+	StudentID        uuid.UUID  `json:"student_id" db:"student_id"`
+	CourseID         *uuid.UUID `json:"course_id,omitempty" db:"course_id"`
+	ScheduleBlockID  *uuid.UUID `json:"schedule_block_id,omitempty" db:"schedule_block_id"`
+	AttendanceDate   time.Time  `json:"attendance_date" db:"attendance_date"`
+	Status           string     `json:"status" db:"status"`
+	Note             *string    `json:"note,omitempty" db:"note"`
+	RecordedBy       uuid.UUID  `json:"recorded_by" db:"recorded_by"`
+	CorrectedBy      *uuid.UUID `json:"corrected_by,omitempty" db:"corrected_by"`
+	CorrectedAt      *time.Time `json:"corrected_at,omitempty" db:"corrected_at"`
+	CorrectionReason *string    `json:"correction_reason,omitempty" db:"correction_reason"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Joined fields.
+	CourseName string `json:"course_name,omitempty"`
+}
+
+// AttendanceSummary totals a student's attendance over a date range, one
+// status per school day. A daily record decides the day on its own; otherwise
+// the day is derived from that day's per-period records.
+type AttendanceSummary struct {
+	DaysRecorded int     `json:"days_recorded"`
+	Present      int     `json:"present"`
+	Absent       int     `json:"absent"`
+	Tardy        int     `json:"tardy"`
+	Excused      int     `json:"excused"`
+	Rate         float64 `json:"attendance_rate"` // present or tardy, as a percentage of non-excused days
+}
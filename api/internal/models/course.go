@@ -8,31 +8,54 @@ import (
 
 // Course is a class offered by a school.
 type Course struct {
-	ID           uuid.UUID `json:"id" db:"id"`
-	ShortID      string    `json:"short_id" db:"short_id"`
-	SchoolID     uuid.UUID `json:"school_id" db:"school_id"`
-	TeacherID    uuid.UUID `json:"teacher_id" db:"teacher_id"`
-	Name         string    `json:"name" db:"name"`
-	Subject      string    `json:"subject" db:"subject"`
-	Period       *string   `json:"period,omitempty" db:"period"`
-	Room         *string   `json:"room,omitempty" db:"room"`
-	AcademicYear string    `json:"academic_year" db:"academic_year"`
-	Semester     *string   `json:"semester,omitempty" db:"semester"`
-	IsActive     bool      `json:"is_active" db:"is_active"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	ID           uuid.UUID  `json:"id" db:"id"`
+	ShortID      string     `json:"short_id" db:"short_id"`
+	SchoolID     uuid.UUID  `json:"school_id" db:"school_id"`
+	TeacherID    uuid.UUID  `json:"teacher_id" db:"teacher_id"`
+	Name         string     `json:"name" db:"name"`
+	Subject      string     `json:"subject" db:"subject"`
+	Period       *string    `json:"period,omitempty" db:"period"`
+	Room         *string    `json:"room,omitempty" db:"room"`
+	AcademicYear string     `json:"academic_year" db:"academic_year"`
+	Semester     *string    `json:"semester,omitempty" db:"semester"`
+	TermID       *uuid.UUID `json:"-" db:"term_id"`
+	GradingMode  *string    `json:"grading_mode,omitempty" db:"grading_mode"` // nil uses the school default
+	MasteryRule  *string    `json:"mastery_rule,omitempty" db:"mastery_rule"`
+	Credits      float64    `json:"credits" db:"credits"`
+	CourseLevel  string     `json:"course_level" db:"course_level"`
+	IsActive     bool       `json:"is_active" db:"is_active"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
 
 	// Joined fields.
-	TeacherName     string `json:"teacher_name,omitempty"`
-	EnrollmentCount int    `json:"enrollment_count,omitempty"`
+	TeacherName     string  `json:"teacher_name,omitempty"`
+	EnrollmentCount int     `json:"enrollment_count,omitempty"`
+	TermShortID     *string `json:"term_id,omitempty"`
 }
 
+// Enrollment statuses.
+const (
+	EnrollmentActive      = "active"
+	EnrollmentDropped     = "dropped"
+	EnrollmentCompleted   = "completed"
+	EnrollmentTransferred = "transferred"
+)
+
 // Enrollment links a student to a course.
 type Enrollment struct {
-	ID         uuid.UUID  `json:"id" db:"id"`
-	StudentID  uuid.UUID  `json:"student_id" db:"student_id"`
-	CourseID   uuid.UUID  `json:"course_id" db:"course_id"`
-	SchoolID   uuid.UUID  `json:"school_id" db:"school_id"`
-	EnrolledAt time.Time  `json:"enrolled_at" db:"enrolled_at"`
-	DroppedAt  *time.Time `json:"dropped_at,omitempty" db:"dropped_at"`
-	Status     string     `json:"status" db:"status"`
+	ID            uuid.UUID  `json:"id" db:"id"`
+	StudentID     uuid.UUID  `json:"student_id" db:"student_id"`
+	CourseID      uuid.UUID  `json:"course_id" db:"course_id"`
+	SchoolID      uuid.UUID  `json:"school_id" db:"school_id"`
+	EnrolledAt    time.Time  `json:"enrolled_at" db:"enrolled_at"`
+	DroppedAt     *time.Time `json:"dropped_at,omitempty" db:"dropped_at"`
+	Status        string     `json:"status" db:"status"`
+	DropReason    *string    `json:"drop_reason,omitempty" db:"drop_reason"`
+	TransferredTo *uuid.UUID `json:"-" db:"transferred_to"`
+
+	// Joined for display.
+	StudentShortID       string  `json:"student_short_id"`
+	StudentName          string  `json:"student_name"`
+	StudentNumber        string  `json:"student_number"`
+	GradeLevel           string  `json:"grade_level"`
+	TransferredToShortID *string `json:"transferred_to,omitempty"`
 }
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Accommodation plans.
+const (
+	AccommodationIEP   = "iep"
+	Accommodation504   = "504"
+	AccommodationOther = "other"
+)
+
+// DueDateExtension moves one student's due date for a single assignment
+// (to DueDate, or by ExtraDays) or for every assignment in a course (by
+// ExtraDays).
+type DueDateExtension struct {
+	ID           uuid.UUID  `json:"-" db:"id"`
+	ShortID      string     `json:"id" db:"short_id"`
+	SchoolID     uuid.UUID  `json:"school_id" db:"school_id"`
+	StudentID    uuid.UUID  `json:"student_id" db:"student_id"`
+	AssignmentID *uuid.UUID `json:"assignment_id,omitempty" db:"assignment_id"`
+	CourseID     *uuid.UUID `json:"course_id,omitempty" db:"course_id"`
+	DueDate      *time.Time `json:"due_date,omitempty" db:"due_date"`
+	ExtraDays    *int       `json:"extra_days,omitempty" db:"extra_days"`
+	Reason       *string    `json:"reason,omitempty" db:"reason"`
+	GrantedBy    *uuid.UUID `json:"granted_by,omitempty" db:"granted_by"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Joined for display.
+	AssignmentTitle *string `json:"assignment_title,omitempty"`
+	CourseName      string  `json:"course_name"`
+}
+
+// Accommodation is a standing rule, usually from an IEP or 504 plan, that
+// adds ExtraDays to every assignment due between StartsOn and EndsOn.
+type Accommodation struct {
+	ID        uuid.UUID  `json:"-" db:"id"`
+	ShortID   string     `json:"id" db:"short_id"`
+	SchoolID  uuid.UUID  `json:"school_id" db:"school_id"`
+	StudentID uuid.UUID  `json:"student_id" db:"student_id"`
+	Plan      string     `json:"plan" db:"plan"`
+	ExtraDays int        `json:"extra_days" db:"extra_days"`
+	StartsOn  *time.Time `json:"starts_on,omitempty" db:"starts_on"`
+	EndsOn    *time.Time `json:"ends_on,omitempty" db:"ends_on"`
+	Notes     *string    `json:"notes,omitempty" db:"notes"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
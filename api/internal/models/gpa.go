@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Course levels.
+const (
+	CourseLevelRegular = "regular"
+	CourseLevelHonors  = "honors"
+	CourseLevelAP      = "ap"
+	CourseLevelIB      = "ib"
+)
+
+// GPA methods. Weighted GPA adds the course level's bonus to each passing
+// grade point; unweighted ignores course levels.
+const (
+	GPAUnweighted = "unweighted"
+	GPAWeighted   = "weighted"
+)
+
+// DefaultGPABonuses is the grade-point bonus per course level when a school
+// has not configured its own.
+var DefaultGPABonuses = map[string]float64{
+	CourseLevelHonors: 0.5,
+	CourseLevelAP:     1.0,
+	CourseLevelIB:     1.0,
+}
+
+// GPACourse is one course's contribution to a GPA.
+type GPACourse struct {
+	Calc    *GradeCalculation
+	Credits float64
+	Level   string
+}
+
+// GPAResult is a credit-weighted GPA over a set of courses. Quality points
+// are grade points × credits, kept so GPAs can be combined across terms.
+type GPAResult struct {
+	Method                string  `json:"method"`
+	GPA                   float64 `json:"gpa"` // Unweighted or Weighted per Method
+	Unweighted            float64 `json:"unweighted_gpa"`
+	Weighted              float64 `json:"weighted_gpa"`
+	CreditsAttempted      float64 `json:"credits_attempted"`
+	CreditsEarned         float64 `json:"credits_earned"`
+	QualityPoints         float64 `json:"quality_points"`
+	WeightedQualityPoints float64 `json:"weighted_quality_points"`
+}
+
+// StudentTermGPA is a student's stored GPA for one term together with the
+// cumulative GPA over every term of the same type ending on or before it.
+type StudentTermGPA struct {
+	ID        uuid.UUID `json:"-" db:"id"`
+	StudentID uuid.UUID `json:"student_id" db:"student_id"`
+	SchoolID  uuid.UUID `json:"school_id" db:"school_id"`
+	TermID    uuid.UUID `json:"-" db:"term_id"`
+	GPAResult
+
+	Cumulative GPAResult `json:"cumulative"`
+	ComputedAt time.Time `json:"computed_at" db:"computed_at"`
+
+	// Joined fields.
+	TermShortID string    `json:"term_id"`
+	TermName    string    `json:"term_name"`
+	TermType    string    `json:"term_type"`
+	TermEndDate time.Time `json:"term_end_date"`
+}
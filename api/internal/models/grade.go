@@ -18,14 +18,95 @@ type Grade struct {
 	GradedBy     *uuid.UUID `json:"graded_by,omitempty" db:"graded_by"`
 	GradedAt     *time.Time `json:"graded_at,omitempty" db:"graded_at"`
 	AISuggested  *float64   `json:"ai_suggested,omitempty" db:"ai_suggested"`
+	AIReasoning  *string    `json:"ai_reasoning,omitempty" db:"ai_reasoning"`
 	AIAccepted   *bool      `json:"ai_accepted,omitempty" db:"ai_accepted"`
 	IsExcused    bool       `json:"is_excused" db:"is_excused"`
 	IsMissing    bool       `json:"is_missing" db:"is_missing"`
 	IsLate       bool       `json:"is_late" db:"is_late"`
+	DaysLate     *int       `json:"days_late,omitempty" db:"days_late"`
 	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// GradeVersion is one entry in a grade's append-only history: the values
+// before and after a change, who made it, and why.
+type GradeVersion struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	GradeID         uuid.UUID  `json:"grade_id" db:"grade_id"`
+	AssignmentID    uuid.UUID  `json:"assignment_id" db:"assignment_id"`
+	AssignmentTitle *string    `json:"assignment_title,omitempty" db:"assignment_title"`
+	CourseID        *uuid.UUID `json:"-" db:"course_id"`
+	StudentID       uuid.UUID  `json:"student_id" db:"student_id"`
+	SchoolID        uuid.UUID  `json:"school_id" db:"school_id"`
+	Version         int        `json:"version" db:"version"`
+	OldPointsEarned *float64   `json:"old_points_earned" db:"old_points_earned"`
+	NewPointsEarned *float64   `json:"new_points_earned" db:"new_points_earned"`
+	OldIsExcused    bool       `json:"old_is_excused" db:"old_is_excused"`
+	NewIsExcused    bool       `json:"new_is_excused" db:"new_is_excused"`
+	OldIsMissing    bool       `json:"old_is_missing" db:"old_is_missing"`
+	NewIsMissing    bool       `json:"new_is_missing" db:"new_is_missing"`
+	OldIsLate       bool       `json:"old_is_late" db:"old_is_late"`
+	NewIsLate       bool       `json:"new_is_late" db:"new_is_late"`
+	OldDaysLate     *int       `json:"old_days_late,omitempty" db:"old_days_late"`
+	NewDaysLate     *int       `json:"new_days_late,omitempty" db:"new_days_late"`
+	Comment         *string    `json:"comment,omitempty" db:"comment"`
+	Reason          *string    `json:"reason,omitempty" db:"reason"`
+	ChangedBy       *uuid.UUID `json:"changed_by,omitempty" db:"changed_by"`
+	ChangedByName   string     `json:"changed_by_name,omitempty"`
+	ChangedAt       time.Time  `json:"changed_at" db:"changed_at"`
+}
+
+// Grade change request statuses.
+const (
+	GradeChangePending  = "pending"
+	GradeChangeApproved = "approved"
+	GradeChangeRejected = "rejected"
+)
+
+// GradeChangeRequest is a proposed change to a grade that is final because
+// its term is closed or a report card covering it is finalized. An admin
+// approves or rejects it; approval writes the grade.
+type GradeChangeRequest struct {
+	ID           uuid.UUID  `json:"-" db:"id"`
+	ShortID      string     `json:"id" db:"short_id"`
+	SchoolID     uuid.UUID  `json:"school_id" db:"school_id"`
+	AssignmentID uuid.UUID  `json:"assignment_id" db:"assignment_id"`
+	StudentID    uuid.UUID  `json:"student_id" db:"student_id"`
+	CourseID     uuid.UUID  `json:"-" db:"course_id"`
+	RequestedBy  uuid.UUID  `json:"requested_by" db:"requested_by"`
+	PointsEarned *float64   `json:"points_earned" db:"points_earned"`
+	Comment      *string    `json:"comment,omitempty" db:"comment"`
+	IsExcused    bool       `json:"is_excused" db:"is_excused"`
+	IsMissing    bool       `json:"is_missing" db:"is_missing"`
+	IsLate       bool       `json:"is_late" db:"is_late"`
+	DaysLate     *int       `json:"days_late,omitempty" db:"days_late"`
+	Reason       *string    `json:"reason,omitempty" db:"reason"`
+	LockedBy     string     `json:"locked_by" db:"locked_by"`
+	Status       string     `json:"status" db:"status"`
+	ReviewedBy   *uuid.UUID `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ReviewNote   *string    `json:"review_note,omitempty" db:"review_note"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+
+	// Rubric criterion scores saved when the request is approved.
+	RubricScores []RubricScore `json:"rubric_scores,omitempty" db:"rubric_scores"`
+
+	// Standard scores saved when the request is approved. A request holding
+	// them changes only those scores, not the grade.
+	StandardScores []StandardScoreChange `json:"standard_scores,omitempty" db:"standard_scores"`
+
+	// Report cards an approval has yet to regenerate, and why the last
+	// attempt failed.
+	PendingReportCards []uuid.UUID `json:"pending_report_cards,omitempty" db:"pending_report_cards"`
+	RegenerationError  *string     `json:"regeneration_error,omitempty" db:"regeneration_error"`
+
+	// Joined for display.
+	CourseShortID   string `json:"course_id"`
+	AssignmentTitle string `json:"assignment_title"`
+	StudentName     string `json:"student_name"`
+	RequestedByName string `json:"requested_by_name"`
+}
+
 // GradeCalculation holds the computed summary for a student in a course.
 type GradeCalculation struct {
 	StudentID    uuid.UUID `json:"student_id"`
@@ -36,6 +117,72 @@ type GradeCalculation struct {
 	PointsTotal  float64   `json:"points_total"`
 	// Per-category breakdowns.
 	CategoryBreakdown map[string]CategoryGrade `json:"category_breakdown"`
+	// Every change the course's grade policy made, in the order applied.
+	Adjustments []GradeAdjustment `json:"adjustments,omitempty"`
+}
+
+// Grade adjustment kinds.
+const (
+	AdjustDropped     = "dropped"
+	AdjustMissingZero = "missing_zero"
+	AdjustLatePenalty = "late_penalty"
+	AdjustExtraCredit = "extra_credit"
+	AdjustRounding    = "rounding"
+)
+
+// GradeAdjustment explains one change a GradePolicy made to a calculation.
+// AssignmentID is nil for course-level adjustments such as rounding.
+type GradeAdjustment struct {
+	Kind         string     `json:"kind"`
+	AssignmentID *uuid.UUID `json:"assignment_id,omitempty"`
+	Category     string     `json:"category,omitempty"`
+	Before       float64    `json:"before"` // points, or percentage for rounding
+	After        float64    `json:"after"`
+	Detail       string     `json:"detail"`
+}
+
+// Late penalty modes.
+const (
+	LatePenaltyPerDay = "per_day"
+	LatePenaltyFlat   = "flat"
+)
+
+// Rounding modes.
+const (
+	RoundHalfUp = "half_up"
+	RoundDown   = "down"
+	RoundUp     = "up"
+)
+
+// GradePolicy controls how a course turns grades into a course grade.
+// Stored as JSONB on courses; the zero value reproduces the plain calculation
+// (missing and ungraded work skipped, no penalties, no rounding).
+type GradePolicy struct {
+	// DropLowest maps category → how many of the lowest scores to drop.
+	// At least one graded assignment is always kept per category.
+	DropLowest map[string]int `json:"drop_lowest,omitempty" validate:"omitempty,dive,min=0,max=50"`
+
+	// MissingAsZero counts work flagged missing and not yet graded as zero.
+	MissingAsZero bool `json:"missing_as_zero,omitempty"`
+
+	LatePenalty *LatePenalty  `json:"late_penalty,omitempty"`
+	Rounding    *RoundingRule `json:"rounding,omitempty"`
+}
+
+// LatePenalty deducts a percentage of an assignment's max points from late
+// work, never below zero. per_day deducts Percent for each day late (a late
+// grade without days_late counts as one day), up to MaxPercent when set;
+// flat deducts Percent once.
+type LatePenalty struct {
+	Mode       string  `json:"mode" validate:"required,oneof=per_day flat"`
+	Percent    float64 `json:"percent" validate:"min=0,max=100"`
+	MaxPercent float64 `json:"max_percent,omitempty" validate:"min=0,max=100"`
+}
+
+// RoundingRule rounds the final percentage before the letter grade is looked up.
+type RoundingRule struct {
+	Places int    `json:"places" validate:"min=0,max=2"`
+	Mode   string `json:"mode" validate:"required,oneof=half_up down up"`
 }
 
 // CategoryGrade holds the grade summary within a single assignment category.
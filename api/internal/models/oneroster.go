@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OneRoster source kinds, named after the OneRoster files they come from.
+const (
+	OneRosterOrg             = "org"
+	OneRosterAcademicSession = "academicSession"
+	OneRosterUser            = "user"
+	OneRosterClass           = "class"
+	OneRosterEnrollment      = "enrollment"
+	OneRosterParentLink      = "parentLink"
+)
+
+// OneRoster import statuses.
+const (
+	OneRosterImportCompleted = "completed"
+	OneRosterImportFailed    = "failed"
+)
+
+// OneRosterImport records one OneRoster CSV bundle import and its report.
+// Dry runs are recorded too, with nothing saved.
+type OneRosterImport struct {
+	ID           uuid.UUID        `json:"-" db:"id"`
+	ShortID      string           `json:"id" db:"short_id"`
+	SchoolID     uuid.UUID        `json:"school_id" db:"school_id"`
+	OrgSourcedID *string          `json:"org_sourced_id,omitempty" db:"org_sourced_id"`
+	DryRun       bool             `json:"dry_run" db:"dry_run"`
+	Status       string           `json:"status" db:"status"`
+	ReportRaw    json.RawMessage  `json:"-" db:"report"`
+	Report       *OneRosterReport `json:"report"`
+	Error        *string          `json:"error,omitempty" db:"error"`
+	ImportedBy   uuid.UUID        `json:"imported_by" db:"imported_by"`
+	CreatedAt    time.Time        `json:"created_at" db:"created_at"`
+}
+
+// OneRosterReport summarizes an import per source kind. Modes holds each
+// file's manifest mode (bulk, delta, or absent). PendingCredentials counts
+// the school's active users who have no password yet and need a setup link.
+type OneRosterReport struct {
+	Modes              map[string]string                `json:"modes"`
+	Entities           map[string]*OneRosterEntityCount `json:"entities"`
+	Warnings           []OneRosterWarning               `json:"warnings,omitempty"`
+	PendingCredentials int                              `json:"pending_credentials"`
+}
+
+// OneRosterEntityCount counts what an import did with one kind of record.
+// Deactivated covers both tobedeleted rows and, in bulk files, records a
+// previous import created that the bundle no longer lists.
+type OneRosterEntityCount struct {
+	Created     int `json:"created"`
+	Updated     int `json:"updated"`
+	Unchanged   int `json:"unchanged"`
+	Deactivated int `json:"deactivated"`
+	Skipped     int `json:"skipped"`
+}
+
+// OneRosterWarning explains a skipped or partly imported row.
+type OneRosterWarning struct {
+	File      string `json:"file"`
+	SourcedID string `json:"sourced_id,omitempty"`
+	Message   string `json:"message"`
+}
@@ -19,6 +19,9 @@ type ReportCard struct {
 	PDFURL          *string    `json:"pdf_url,omitempty" db:"pdf_url"`
 	GeneratedBy     uuid.UUID  `json:"generated_by" db:"generated_by"`
 	GeneratedAt     time.Time  `json:"generated_at" db:"generated_at"`
+	TermID          *uuid.UUID `json:"-" db:"term_id"`
+	FinalizedAt     *time.Time `json:"finalized_at,omitempty" db:"finalized_at"`
+	SupersededBy    *uuid.UUID `json:"superseded_by,omitempty" db:"superseded_by"`
 }
 
 // Document is a generated official document (enrollment cert, attendance letter, etc.).
@@ -33,3 +36,42 @@ type Document struct {
 	ExpiresAt        *time.Time `json:"expires_at,omitempty" db:"expires_at"`
 	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
 }
+
+// Report card job statuses.
+const (
+	ReportJobPending   = "pending"
+	ReportJobRunning   = "running"
+	ReportJobCompleted = "completed"
+	ReportJobFailed    = "failed"
+)
+
+// ReportCardJob tracks a background batch of report cards.
+type ReportCardJob struct {
+	ID             uuid.UUID  `json:"-" db:"id"`
+	ShortID        string     `json:"id" db:"short_id"`
+	SchoolID       uuid.UUID  `json:"school_id" db:"school_id"`
+	TermID         *uuid.UUID `json:"-" db:"term_id"`
+	AcademicPeriod string     `json:"academic_period" db:"academic_period"`
+	GradeLevel     *string    `json:"grade_level,omitempty" db:"grade_level"`
+	Status         string     `json:"status" db:"status"`
+	TotalCount     int        `json:"total" db:"total_count"`
+	CompletedCount int        `json:"completed" db:"completed_count"`
+	FailedCount    int        `json:"failed" db:"failed_count"`
+	Bundle         bool       `json:"bundle" db:"bundle"`
+	BundleURL      *string    `json:"bundle_url,omitempty" db:"bundle_url"`
+	Error          *string    `json:"error,omitempty" db:"error"`
+	RequestedBy    uuid.UUID  `json:"requested_by" db:"requested_by"`
+	StartedAt      *time.Time `json:"started_at,omitempty" db:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ReportCardJobItem is one student's card within a batch job.
+type ReportCardJobItem struct {
+	ID           uuid.UUID  `json:"-" db:"id"`
+	JobID        uuid.UUID  `json:"-" db:"job_id"`
+	StudentID    uuid.UUID  `json:"student_id" db:"student_id"`
+	Status       string     `json:"status" db:"status"`
+	ReportCardID *uuid.UUID `json:"report_card_id,omitempty" db:"report_card_id"`
+	Error        *string    `json:"error,omitempty" db:"error"`
+}
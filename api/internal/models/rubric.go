@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rubric visibility: who besides the author can use a rubric.
+const (
+	RubricPrivate    = "private"
+	RubricDepartment = "department"
+	RubricSchool     = "school"
+)
+
+// Rubric is a reusable scoring guide made of criteria, each with scored
+// performance levels.
+type Rubric struct {
+	ID          uuid.UUID         `json:"-" db:"id"`
+	ShortID     string            `json:"id" db:"short_id"`
+	SchoolID    uuid.UUID         `json:"school_id" db:"school_id"`
+	Title       string            `json:"title" db:"title"`
+	Description *string           `json:"description,omitempty" db:"description"`
+	Visibility  string            `json:"visibility" db:"visibility"`
+	Department  *string           `json:"department,omitempty" db:"department"`
+	CreatedBy   uuid.UUID         `json:"created_by" db:"created_by"`
+	IsArchived  bool              `json:"is_archived" db:"is_archived"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" db:"updated_at"`
+	Criteria    []RubricCriterion `json:"criteria,omitempty"`
+
+	// Joined for display.
+	AuthorName string  `json:"author_name"`
+	MaxPoints  float64 `json:"max_points"`
+}
+
+// RubricCriterion is one row of a rubric.
+type RubricCriterion struct {
+	ID          uuid.UUID     `json:"id" db:"id"`
+	Position    int           `json:"position" db:"position"`
+	Title       string        `json:"title" db:"title"`
+	Description *string       `json:"description,omitempty" db:"description"`
+	Levels      []RubricLevel `json:"levels"`
+}
+
+// RubricLevel is one performance level of a criterion and the points it earns.
+type RubricLevel struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Position    int       `json:"position" db:"position"`
+	Title       string    `json:"title" db:"title"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	Points      float64   `json:"points" db:"points"`
+}
+
+// MaxPoints returns the most a criterion can earn.
+func (c RubricCriterion) MaxPoints() float64 {
+	var max float64
+	for _, l := range c.Levels {
+		if l.Points > max {
+			max = l.Points
+		}
+	}
+	return max
+}
+
+// RubricScore is a student's score on one criterion of an assignment's rubric.
+type RubricScore struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	AssignmentID uuid.UUID  `json:"assignment_id" db:"assignment_id"`
+	StudentID    uuid.UUID  `json:"student_id" db:"student_id"`
+	CriterionID  uuid.UUID  `json:"criterion_id" db:"criterion_id"`
+	LevelID      *uuid.UUID `json:"level_id,omitempty" db:"level_id"`
+	Points       float64    `json:"points" db:"points"`
+	Comment      *string    `json:"comment,omitempty" db:"comment"`
+	GradedBy     *uuid.UUID `json:"graded_by,omitempty" db:"graded_by"`
+	GradedAt     time.Time  `json:"graded_at" db:"graded_at"`
+}
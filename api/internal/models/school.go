@@ -25,6 +25,18 @@ type SchoolSettings struct {
 	GradingScale    []LetterGradeMapping `json:"grading_scale,omitempty"`
 	CategoryWeights map[string]float64   `json:"category_weights,omitempty"` // e.g., {"test": 0.4, "homework": 0.2}
 
+	// Standards-based grading defaults; courses may override mode and rule.
+	GradingMode  string  `json:"grading_mode,omitempty"`  // "points" (default) or "standards"
+	MasteryRule  string  `json:"mastery_rule,omitempty"`  // see Mastery* constants; default most_recent
+	MasteryDecay float64 `json:"mastery_decay,omitempty"` // weight of the newest score for decaying_average; default 0.65
+
+	// GPA
+	GPAMethod  string             `json:"gpa_method,omitempty"`  // "unweighted" (default) or "weighted"
+	GPABonuses map[string]float64 `json:"gpa_bonuses,omitempty"` // course level → grade-point bonus; see DefaultGPABonuses
+
+	// Transcripts
+	GraduationCredits float64 `json:"graduation_credits,omitempty"` // credits required to graduate; 0 for none
+
 	// Branding
 	PrimaryColor   string `json:"primary_color,omitempty"`
 	SecondaryColor string `json:"secondary_color,omitempty"`
@@ -39,6 +51,10 @@ type SchoolSettings struct {
 	// Report card
 	ReportCardTemplate string `json:"report_card_template,omitempty"`
 
+	// Logo image (R2 key) for generated PDFs; when empty, logo_url is used if
+	// it refers to an object in the school's storage.
+	LogoImageKey string `json:"logo_image_key,omitempty"`
+
 	// Signature image URL (R2 key) for official documents
 	SignatureImageURL string `json:"signature_image_url,omitempty"`
 	SignatoryName     string `json:"signatory_name,omitempty"`
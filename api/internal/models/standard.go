@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Grading modes.
+const (
+	GradingModePoints    = "points"
+	GradingModeStandards = "standards"
+)
+
+// Mastery rules: how a student's scores on one standard combine into a
+// single proficiency level.
+const (
+	MasteryMostRecent      = "most_recent"
+	MasteryHighest         = "highest"
+	MasteryDecayingAverage = "decaying_average"
+	MasteryMode            = "mode"
+)
+
+// DefaultMasteryDecay is the weight given to the newest score under the
+// decaying average rule.
+const DefaultMasteryDecay = 0.65
+
+// ProficiencyLabels names each level of the 1–4 proficiency scale.
+var ProficiencyLabels = map[int]string{
+	1: "Beginning",
+	2: "Developing",
+	3: "Proficient",
+	4: "Exceeding",
+}
+
+// Standard is a learning standard a school reports on.
+type Standard struct {
+	ID          uuid.UUID `json:"-" db:"id"`
+	ShortID     string    `json:"id" db:"short_id"`
+	SchoolID    uuid.UUID `json:"school_id" db:"school_id"`
+	Code        string    `json:"code" db:"code"`
+	Description string    `json:"description" db:"description"`
+	Subject     *string   `json:"subject,omitempty" db:"subject"`
+	GradeLevel  *string   `json:"grade_level,omitempty" db:"grade_level"`
+	IsActive    bool      `json:"is_active" db:"is_active"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// StandardScore is a student's 1–4 score on one standard for one assignment.
+type StandardScore struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	AssignmentID uuid.UUID  `json:"assignment_id" db:"assignment_id"`
+	StandardID   uuid.UUID  `json:"-" db:"standard_id"`
+	StudentID    uuid.UUID  `json:"student_id" db:"student_id"`
+	SchoolID     uuid.UUID  `json:"school_id" db:"school_id"`
+	Score        float64    `json:"score" db:"score"`
+	Comment      *string    `json:"comment,omitempty" db:"comment"`
+	GradedBy     *uuid.UUID `json:"graded_by,omitempty" db:"graded_by"`
+	GradedAt     time.Time  `json:"graded_at" db:"graded_at"`
+
+	// Joined: when the evidence counts — the assignment's due date, else GradedAt.
+	AssessedAt      time.Time `json:"assessed_at"`
+	StandardShortID string    `json:"standard_id"`
+}
+
+// StandardScoreChange is a standard score filed with a grade change request,
+// saved when the request is approved.
+type StandardScoreChange struct {
+	StandardID uuid.UUID `json:"standard_id"`
+	Score      float64   `json:"score"`
+	Comment    *string   `json:"comment,omitempty"`
+}
+
+// StandardMastery is a student's calculated proficiency on one standard.
+type StandardMastery struct {
+	StandardID  uuid.UUID `json:"-"`
+	ShortID     string    `json:"standard_id"`
+	Code        string    `json:"code"`
+	Description string    `json:"description"`
+	Score       float64   `json:"score"` // 1–4 per the mastery rule, 0 when no evidence
+	Level       int       `json:"level"` // Score rounded to the scale
+	Label       string    `json:"label"`
+	Evidence    int       `json:"evidence"` // number of scores counted
+}
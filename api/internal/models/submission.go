@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Submission statuses.
+const (
+	SubmissionDraft     = "draft"
+	SubmissionSubmitted = "submitted"
+)
+
+// Submission is one attempt by a student to turn in an assignment. A draft
+// collects uploads until the student submits it; each later turn-in is a new
+// attempt, so earlier attempts remain as the resubmission history.
+type Submission struct {
+	ID           uuid.UUID  `json:"-" db:"id"`
+	ShortID      string     `json:"id" db:"short_id"`
+	AssignmentID uuid.UUID  `json:"assignment_id" db:"assignment_id"`
+	StudentID    uuid.UUID  `json:"student_id" db:"student_id"`
+	SchoolID     uuid.UUID  `json:"school_id" db:"school_id"`
+	Attempt      int        `json:"attempt" db:"attempt"`
+	Status       string     `json:"status" db:"status"`
+	TextEntry    *string    `json:"text_entry,omitempty" db:"text_entry"`
+	SubmittedAt  *time.Time `json:"submitted_at,omitempty" db:"submitted_at"`
+	DueAt        *time.Time `json:"due_at,omitempty" db:"due_at"` // effective due date at submission
+	IsLate       bool       `json:"is_late" db:"is_late"`
+	DaysLate     *int       `json:"days_late,omitempty" db:"days_late"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+
+	Files []SubmissionFile `json:"files"`
+}
+
+// SubmissionFile is a file uploaded with a submission.
+type SubmissionFile struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	FileName  string    `json:"file_name" db:"file_name"`
+	FileKey   string    `json:"-" db:"file_key"` // R2 object key
+	FileSize  int64     `json:"file_size" db:"file_size"`
+	MIMEType  string    `json:"mime_type" db:"mime_type"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	// Populated on response — never stored.
+	DownloadURL string `json:"download_url,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DocumentTemplate is one immutable version of a school's template for a
+// report card or document type. Kind is "report_card" or a document type.
+// Name distinguishes several custom templates; other kinds use "default".
+type DocumentTemplate struct {
+	ID        uuid.UUID `json:"-" db:"id"`
+	ShortID   string    `json:"id" db:"short_id"`
+	SchoolID  uuid.UUID `json:"school_id" db:"school_id"`
+	Kind      string    `json:"kind" db:"kind"`
+	Name      string    `json:"name" db:"name"`
+	Version   int       `json:"version" db:"version"`
+	Body      string    `json:"body,omitempty" db:"body"`
+	IsActive  bool      `json:"is_active" db:"is_active"`
+	CreatedBy uuid.UUID `json:"created_by" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
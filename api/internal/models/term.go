@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Term types, from outermost to innermost.
+const (
+	TermYear     = "year"
+	TermSemester = "semester"
+	TermQuarter  = "quarter"
+)
+
+// TermParentType maps each nested term type to the type it must sit under.
+var TermParentType = map[string]string{
+	TermSemester: TermYear,
+	TermQuarter:  TermSemester,
+}
+
+// Term is an academic period (school year, semester, or quarter).
+// Dates are inclusive calendar days.
+type Term struct {
+	ID        uuid.UUID  `json:"-" db:"id"`
+	ShortID   string     `json:"id" db:"short_id"`
+	SchoolID  uuid.UUID  `json:"school_id" db:"school_id"`
+	ParentID  *uuid.UUID `json:"-" db:"parent_id"`
+	Name      string     `json:"name" db:"name"`
+	TermType  string     `json:"term_type" db:"term_type"`
+	StartDate time.Time  `json:"start_date" db:"start_date"`
+	EndDate   time.Time  `json:"end_date" db:"end_date"`
+	IsClosed  bool       `json:"is_closed" db:"is_closed"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Populated when listing terms as a tree.
+	Children []Term `json:"children,omitempty"`
+}
+
+// Contains reports whether t falls on or between the term's start and end dates.
+func (t *Term) Contains(at time.Time) bool {
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	start := time.Date(t.StartDate.Year(), t.StartDate.Month(), t.StartDate.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(t.EndDate.Year(), t.EndDate.Month(), t.EndDate.Day(), 0, 0, 0, 0, time.UTC)
+	return !day.Before(start) && !day.After(end)
+}
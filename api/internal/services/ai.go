@@ -6,25 +6,96 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"strings"
+
+	"github.com/pragma-proto/api/internal/models"
 )
 
-// AIService proxies requests to the Anthropic Claude API.
+// AIProvider is a language model backend. Handlers depend on this rather
+// than on a particular vendor, so tests and local development can use
+// FakeAIProvider instead of a live API.
+type AIProvider interface {
+	Complete(ctx context.Context, req AIRequest) (*AIResponse, error)
+}
+
+// AIRequest is one completion. System carries the instructions and Prompt
+// the (already anonymized) material to work on.
+type AIRequest struct {
+	System    string
+	Prompt    string
+	MaxTokens int  // 0 means 1024
+	JSON      bool // the reply should be JSON; AIResponse.JSON holds it when valid
+}
+
+// AIResponse is a completion's text and token usage. For JSON requests,
+// JSON is the reply with any surrounding prose or code fence removed, or
+// nil when the reply contained no valid JSON.
+type AIResponse struct {
+	Text  string
+	JSON  json.RawMessage
+	Usage AIUsage
+}
+
+// AIUsage counts the tokens a completion consumed.
+type AIUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Total returns input plus output tokens.
+func (u AIUsage) Total() int {
+	return u.InputTokens + u.OutputTokens
+}
+
+// jsonInstruction is appended to the system prompt of JSON requests.
+const jsonInstruction = "\n\nRespond with valid JSON only: no Markdown, code fences, or commentary."
+
+// extractJSON returns the JSON value in a reply, tolerating text around it
+// such as a Markdown code fence, or nil if there is none.
+func extractJSON(text string) json.RawMessage {
+	text = strings.TrimSpace(text)
+	if json.Valid([]byte(text)) {
+		return json.RawMessage(text)
+	}
+	start := strings.IndexAny(text, "[{")
+	if start < 0 {
+		return nil
+	}
+	closer := "]"
+	if text[start] == '{' {
+		closer = "}"
+	}
+	end := strings.LastIndex(text, closer)
+	if end < start || !json.Valid([]byte(text[start:end+1])) {
+		return nil
+	}
+	return json.RawMessage(text[start : end+1])
+}
+
+// ClaudeProvider calls the Anthropic Messages API.
 // All student names and PII are anonymized BEFORE being sent to Claude.
-type AIService struct {
+type ClaudeProvider struct {
 	apiKey  string
 	model   string
 	baseURL string
 	client  *http.Client
 }
 
-// NewAIService creates an AIService.
-func NewAIService(apiKey, model string) *AIService {
-	return &AIService{
+// DefaultClaudeBaseURL is the public Anthropic API.
+const DefaultClaudeBaseURL = "https://api.anthropic.com/v1"
+
+// NewClaudeProvider creates a ClaudeProvider. An empty baseURL uses
+// DefaultClaudeBaseURL; set it to point at a proxy or a local mock server.
+func NewClaudeProvider(apiKey, model, baseURL string) *ClaudeProvider {
+	if baseURL == "" {
+		baseURL = DefaultClaudeBaseURL
+	}
+	return &ClaudeProvider{
 		apiKey:  apiKey,
 		model:   model,
-		baseURL: "https://api.anthropic.com/v1",
+		baseURL: strings.TrimRight(baseURL, "/"),
 		client:  &http.Client{},
 	}
 }
@@ -33,6 +104,7 @@ func NewAIService(apiKey, model string) *AIService {
 type claudeRequest struct {
 	Model     string          `json:"model"`
 	MaxTokens int             `json:"max_tokens"`
+	System    string          `json:"system,omitempty"`
 	Messages  []claudeMessage `json:"messages"`
 }
 
@@ -46,34 +118,37 @@ type claudeResponse struct {
 		Type string `json:"type"`
 		Text string `json:"text"`
 	} `json:"content"`
-	Usage struct {
-		InputTokens  int `json:"input_tokens"`
-		OutputTokens int `json:"output_tokens"`
-	} `json:"usage"`
+	Usage AIUsage `json:"usage"`
 }
 
-// Complete sends a prompt to Claude and returns the text response and token count.
-func (s *AIService) Complete(ctx context.Context, systemPrompt, userPrompt string, maxTokens int) (response string, tokensUsed int, err error) {
+// Complete sends a prompt to Claude and returns the text response and token usage.
+func (s *ClaudeProvider) Complete(ctx context.Context, in AIRequest) (*AIResponse, error) {
+	maxTokens := in.MaxTokens
 	if maxTokens <= 0 {
 		maxTokens = 1024
 	}
+	system := in.System
+	if in.JSON {
+		system += jsonInstruction
+	}
 
 	reqBody := claudeRequest{
 		Model:     s.model,
 		MaxTokens: maxTokens,
+		System:    system,
 		Messages: []claudeMessage{
-			{Role: "user", Content: systemPrompt + "\n\n" + userPrompt},
+			{Role: "user", Content: in.Prompt},
 		},
 	}
 
 	bodyBytes, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", 0, fmt.Errorf("ai: marshal request: %w", err)
+		return nil, fmt.Errorf("ai: marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/messages", bytes.NewReader(bodyBytes))
 	if err != nil {
-		return "", 0, fmt.Errorf("ai: create request: %w", err)
+		return nil, fmt.Errorf("ai: create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", s.apiKey)
@@ -81,18 +156,18 @@ func (s *AIService) Complete(ctx context.Context, systemPrompt, userPrompt strin
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return "", 0, fmt.Errorf("ai: http request: %w", err)
+		return nil, fmt.Errorf("ai: http request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", 0, fmt.Errorf("ai: claude returned %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("ai: claude returned %d: %s", resp.StatusCode, string(body))
 	}
 
 	var claudeResp claudeResponse
 	if err := json.NewDecoder(resp.Body).Decode(&claudeResp); err != nil {
-		return "", 0, fmt.Errorf("ai: decode response: %w", err)
+		return nil, fmt.Errorf("ai: decode response: %w", err)
 	}
 
 	var parts []string
@@ -102,32 +177,63 @@ func (s *AIService) Complete(ctx context.Context, systemPrompt, userPrompt strin
 		}
 	}
 
-	total := claudeResp.Usage.InputTokens + claudeResp.Usage.OutputTokens
-	return strings.Join(parts, ""), total, nil
+	out := &AIResponse{Text: strings.Join(parts, ""), Usage: claudeResp.Usage}
+	if in.JSON {
+		out.JSON = extractJSON(out.Text)
+	}
+	return out, nil
+}
+
+// AIGateway is the only way handlers reach an AIProvider. Every request is
+// passed through a Redactor first, and placeholders in the reply are
+// restored before it is returned, so no PII is sent to the provider.
+type AIGateway struct {
+	provider AIProvider
 }
 
-// AnonymizeStudents replaces student names with anonymized placeholders.
-// names is a map of student_id → full_name. Returns the anonymized text
-// and a reverse map for de-anonymization.
-func AnonymizeStudents(text string, names map[string]string) (anonymized string, reverseMap map[string]string) {
-	reverseMap = make(map[string]string)
-	anonymized = text
-	i := 0
-	for _, name := range names {
-		i++
-		placeholder := fmt.Sprintf("Student %c", rune('A'+i-1))
-		reverseMap[placeholder] = name
-		anonymized = strings.ReplaceAll(anonymized, name, placeholder)
-	}
-	return anonymized, reverseMap
+// NewAIGateway wraps provider.
+func NewAIGateway(provider AIProvider) *AIGateway {
+	return &AIGateway{provider: provider}
 }
 
-// DeAnonymize restores original names in AI output using the reverse map.
-func DeAnonymize(text string, reverseMap map[string]string) string {
-	for placeholder, name := range reverseMap {
-		text = strings.ReplaceAll(text, placeholder, name)
+// AIResult is a completion made through the gateway.
+type AIResult struct {
+	*AIResponse
+	Sent       AIRequest   // the request as redacted and sent
+	Redactions []Redaction // what was redacted, without the values
+}
+
+// placeholderNote is added to the system prompt when anything was redacted.
+const placeholderNote = "\n\nBracketed placeholders such as [NAME_1] stand in for removed personal details. " +
+	"Use them unchanged where needed and do not guess what they hide."
+
+// Complete redacts req with rd and sends it to the provider. A nil rd still
+// removes emails, phone numbers, addresses, and dates of birth. Text and
+// JSON in the result have the original values restored.
+func (g *AIGateway) Complete(ctx context.Context, rd *Redactor, req AIRequest) (*AIResult, error) {
+	if rd == nil {
+		rd = NewRedactor(nil)
+	}
+	m := NewPIIMap()
+	sent := req
+	sent.System = rd.Redact(req.System, m)
+	sent.Prompt = rd.Redact(req.Prompt, m)
+	redactions := m.Redactions()
+	if len(redactions) > 0 {
+		sent.System += placeholderNote
+		log.Printf("ai: redacted %d values before sending (%s)", len(redactions), redactionSummary(m.RedactionCounts()))
+	}
+
+	resp, err := g.provider.Complete(ctx, sent)
+	if err != nil {
+		return nil, err
 	}
-	return text
+	restored := *resp
+	restored.Text = m.Restore(resp.Text)
+	if resp.JSON != nil {
+		restored.JSON = extractJSON(restored.Text)
+	}
+	return &AIResult{AIResponse: &restored, Sent: sent, Redactions: redactions}, nil
 }
 
 // GradingAssistantPrompt builds the system prompt for the grading assistant feature.
@@ -139,8 +245,8 @@ Rubric:
 
 Maximum points: %.2f
 
-For each student submission provided, respond with a JSON array where each element contains:
-- "student": the student identifier (e.g., "Student A")
+For each student submission provided, respond with only a JSON array, and no other text, where each element contains:
+- "student": the student identifier (e.g., "Student 1")
 - "suggested_points": a numeric score from 0 to %.2f
 - "reasoning": a brief, professional explanation of the score
 
@@ -149,6 +255,60 @@ Never include student names or any PII in your response.`,
 		rubric, maxPoints, maxPoints)
 }
 
+// GradingSuggestion is one element of the grading assistant's JSON reply.
+// Err is set when the element could not be read; Student is still filled
+// in when the element named one.
+type GradingSuggestion struct {
+	Student         string
+	SuggestedPoints *float64
+	Reasoning       string
+	Err             error
+}
+
+// ParseGradingSuggestions reads the JSON array requested by
+// GradingAssistantPrompt. Text around the array, such as a Markdown code
+// fence, is ignored. An error means no array could be read at all; a
+// malformed element only sets that suggestion's Err.
+func ParseGradingSuggestions(response string) ([]GradingSuggestion, error) {
+	start, end := strings.Index(response, "["), strings.LastIndex(response, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("ai: no JSON array in response")
+	}
+	var elems []json.RawMessage
+	if err := json.Unmarshal([]byte(response[start:end+1]), &elems); err != nil {
+		return nil, fmt.Errorf("ai: parse suggestions: %w", err)
+	}
+
+	suggestions := make([]GradingSuggestion, 0, len(elems))
+	for _, raw := range elems {
+		var elem struct {
+			Student         string          `json:"student"`
+			SuggestedPoints json.RawMessage `json:"suggested_points"`
+			Reasoning       string          `json:"reasoning"`
+		}
+		var sg GradingSuggestion
+		if err := json.Unmarshal(raw, &elem); err != nil {
+			sg.Err = fmt.Errorf("malformed suggestion: %w", err)
+			suggestions = append(suggestions, sg)
+			continue
+		}
+		sg.Student, sg.Reasoning = strings.TrimSpace(elem.Student), elem.Reasoning
+		switch {
+		case len(elem.SuggestedPoints) == 0 || string(elem.SuggestedPoints) == "null":
+			sg.Err = fmt.Errorf("suggested_points is missing")
+		default:
+			var points float64
+			if err := json.Unmarshal(elem.SuggestedPoints, &points); err != nil {
+				sg.Err = fmt.Errorf("suggested_points is not a number: %s", elem.SuggestedPoints)
+			} else {
+				sg.SuggestedPoints = &points
+			}
+		}
+		suggestions = append(suggestions, sg)
+	}
+	return suggestions, nil
+}
+
 // StudentInsightsPrompt builds the prompt for the at-risk student detection feature.
 func StudentInsightsPrompt() string {
 	return `You are an educational data analyst. Analyze the anonymized grade trajectory data provided.
@@ -175,3 +335,27 @@ Rules:
 - Use professional, warm, encouraging language
 - Return only the comment text, no JSON wrapper`
 }
+
+// RubricPromptText renders a stored rubric as plain text for
+// GradingAssistantPrompt, one criterion per block with its levels and points.
+func RubricPromptText(r *models.Rubric) string {
+	var b strings.Builder
+	b.WriteString(r.Title + "\n")
+	if r.Description != nil && *r.Description != "" {
+		b.WriteString(*r.Description + "\n")
+	}
+	for _, c := range r.Criteria {
+		fmt.Fprintf(&b, "\nCriterion: %s (up to %.2f points)\n", c.Title, c.MaxPoints())
+		if c.Description != nil && *c.Description != "" {
+			b.WriteString(*c.Description + "\n")
+		}
+		for _, l := range c.Levels {
+			fmt.Fprintf(&b, "- %s (%.2f points)", l.Title, l.Points)
+			if l.Description != nil && *l.Description != "" {
+				b.WriteString(": " + *l.Description)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// FakeAIProvider is a deterministic AIProvider for tests and local
+// development. It replies from a script instead of calling a model: the
+// first rule whose text appears in the request's system prompt or prompt
+// wins, then queued replies in order, then a fixed default. Every request
+// is recorded so a test can inspect what would have been sent.
+type FakeAIProvider struct {
+	mu      sync.Mutex
+	rules   []fakeAIRule
+	queue   []fakeAIReply
+	calls   []AIRequest
+	Default string // reply when nothing else matches; unmatched JSON requests get "[]"
+}
+
+type fakeAIRule struct {
+	contains string
+	reply    fakeAIReply
+}
+
+type fakeAIReply struct {
+	text string
+	err  error
+}
+
+// NewFakeAIProvider creates a FakeAIProvider that returns replies in order,
+// one per request, before falling back to its rules and default.
+func NewFakeAIProvider(replies ...string) *FakeAIProvider {
+	f := &FakeAIProvider{Default: "This is a placeholder response from the local AI provider."}
+	for _, r := range replies {
+		f.queue = append(f.queue, fakeAIReply{text: r})
+	}
+	return f
+}
+
+// On replies with text to any request whose system prompt or prompt
+// contains the given text.
+func (f *FakeAIProvider) On(contains, text string) *FakeAIProvider {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, fakeAIRule{contains: contains, reply: fakeAIReply{text: text}})
+	return f
+}
+
+// FailOn returns err for any request whose system prompt or prompt
+// contains the given text, as a stand-in for an unavailable model.
+func (f *FakeAIProvider) FailOn(contains string, err error) *FakeAIProvider {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, fakeAIRule{contains: contains, reply: fakeAIReply{err: err}})
+	return f
+}
+
+// Calls returns the requests received so far, oldest first.
+func (f *FakeAIProvider) Calls() []AIRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]AIRequest(nil), f.calls...)
+}
+
+// Complete returns the scripted reply for req. Token usage is estimated at
+// four characters per token so that it is stable across runs.
+func (f *FakeAIProvider) Complete(ctx context.Context, req AIRequest) (*AIResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.calls = append(f.calls, req)
+	reply, ok := fakeAIReply{}, false
+	for _, r := range f.rules {
+		if strings.Contains(req.System, r.contains) || strings.Contains(req.Prompt, r.contains) {
+			reply, ok = r.reply, true
+			break
+		}
+	}
+	if !ok && len(f.queue) > 0 {
+		reply, f.queue, ok = f.queue[0], f.queue[1:], true
+	}
+	if !ok {
+		reply.text = f.Default
+		if req.JSON {
+			reply.text = "[]"
+		}
+	}
+	f.mu.Unlock()
+
+	if reply.err != nil {
+		return nil, reply.err
+	}
+	resp := &AIResponse{
+		Text: reply.text,
+		Usage: AIUsage{
+			InputTokens:  (len(req.System) + len(req.Prompt) + 3) / 4,
+			OutputTokens: (len(reply.text) + 3) / 4,
+		},
+	}
+	if req.JSON {
+		resp.JSON = extractJSON(reply.text)
+	}
+	return resp, nil
+}
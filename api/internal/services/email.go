@@ -40,6 +40,26 @@ func (s *EmailService) SendPasswordReset(to, firstName, resetURL string) error {
 	return nil
 }
 
+// SendAccountSetup emails a new user a link to set their first password.
+func (s *EmailService) SendAccountSetup(to, firstName, schoolName, setupURL string) error {
+	body := fmt.Sprintf(`<p>Hello %s,</p>
+<p>An account has been created for you at %s. Click the link below to set your password. This link expires in 7 days.</p>
+<p><a href="%s">Set Password</a></p>
+<p>If you were not expecting this, please contact your school.</p>`, firstName, schoolName, setupURL)
+
+	params := &resend.SendEmailRequest{
+		From:    s.fromAddr,
+		To:      []string{to},
+		Subject: "Set up your account",
+		Html:    body,
+	}
+	_, err := s.client.Emails.Send(params)
+	if err != nil {
+		return fmt.Errorf("email: send account setup: %w", err)
+	}
+	return nil
+}
+
 // SendGradeUnlock notifies a student and their parents that grade access has been restored.
 func (s *EmailService) SendGradeUnlock(to []string, studentName string) error {
 	body := fmt.Sprintf(`<p>This is a notification that grade access has been restored for %s.</p>
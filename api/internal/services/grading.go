@@ -1,6 +1,12 @@
 package services
 
 import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
 	"github.com/pragma-proto/api/internal/models"
 )
 
@@ -15,15 +21,27 @@ func NewGradingService() *GradingService {
 // CalculateCourseGrade computes a student's overall grade for a course.
 // grades and assignments must correspond 1-to-1 (matched by assignment ID).
 // categoryWeights maps category name → fractional weight (must sum to 1.0 if provided).
+// When term is non-nil only assignments due within the term are counted.
+// policy may be nil for the plain calculation; every change it makes is
+// listed in the result's Adjustments.
+// CategoryBreakdown reports each category's points and its share of the final grade.
 func (s *GradingService) CalculateCourseGrade(
 	assignments []models.Assignment,
 	grades []models.Grade,
 	scale []models.LetterGradeMapping,
 	categoryWeights map[string]float64,
+	term *models.Term,
+	policy *models.GradePolicy,
 ) *models.GradeCalculation {
+	if term != nil {
+		assignments = s.AssignmentsInTerm(assignments, term)
+	}
 	if len(assignments) == 0 {
 		return nil
 	}
+	if policy == nil {
+		policy = &models.GradePolicy{}
+	}
 
 	// Map grades by assignment ID for fast lookup.
 	gradeByAssignment := make(map[string]*models.Grade, len(grades))
@@ -31,6 +49,82 @@ func (s *GradingService) CalculateCourseGrade(
 		gradeByAssignment[grades[i].AssignmentID.String()] = &grades[i]
 	}
 
+	var adjustments []models.GradeAdjustment
+	adjust := func(kind string, a models.Assignment, before, after float64, detail string) {
+		id := a.ID
+		adjustments = append(adjustments, models.GradeAdjustment{
+			Kind: kind, AssignmentID: &id, Category: a.Category,
+			Before: before, After: after, Detail: detail,
+		})
+	}
+
+	// Score each assignment, applying missing and late rules.
+	type scored struct {
+		a      models.Assignment
+		earned float64
+	}
+	byCat := make(map[string][]scored)
+	var bonus []scored
+
+	for _, a := range assignments {
+		g, ok := gradeByAssignment[a.ID.String()]
+		if ok && g.IsExcused {
+			continue
+		}
+
+		var earned float64
+		switch {
+		case ok && g.PointsEarned != nil:
+			earned = *g.PointsEarned
+		case ok && g.IsMissing && policy.MissingAsZero && !a.IsExtraCredit:
+			adjust(models.AdjustMissingZero, a, 0, 0, "missing work counted as zero")
+		default:
+			// Skip ungraded.
+			continue
+		}
+
+		if ok && g.IsLate && g.PointsEarned != nil && policy.LatePenalty != nil {
+			if pct := latePenaltyPercent(policy.LatePenalty, g.DaysLate); pct > 0 {
+				after := math.Max(0, earned-a.MaxPoints*pct/100)
+				adjust(models.AdjustLatePenalty, a, earned, after,
+					fmt.Sprintf("late penalty of %s%% of %s points", formatNumber(pct), formatNumber(a.MaxPoints)))
+				earned = after
+			}
+		}
+
+		if a.IsExtraCredit {
+			bonus = append(bonus, scored{a, earned})
+			continue
+		}
+		byCat[a.Category] = append(byCat[a.Category], scored{a, earned})
+	}
+
+	// Drop the lowest scores per category, always keeping at least one.
+	// Categories go in name order so the adjustments list is stable.
+	catNames := make([]string, 0, len(byCat))
+	for cat := range byCat {
+		catNames = append(catNames, cat)
+	}
+	sort.Strings(catNames)
+	for _, cat := range catNames {
+		items := byCat[cat]
+		n := policy.DropLowest[cat]
+		if n <= 0 {
+			continue
+		}
+		if n > len(items)-1 {
+			n = len(items) - 1
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			return scoreRatio(items[i].earned, items[i].a.MaxPoints) < scoreRatio(items[j].earned, items[j].a.MaxPoints)
+		})
+		for _, it := range items[:n] {
+			adjust(models.AdjustDropped, it.a, it.earned, 0,
+				fmt.Sprintf("dropped as one of the %d lowest %s scores", policy.DropLowest[cat], cat))
+		}
+		byCat[cat] = items[n:]
+	}
+
 	// Accumulate points per category.
 	type catAcc struct {
 		earned float64
@@ -38,17 +132,23 @@ func (s *GradingService) CalculateCourseGrade(
 	}
 	cats := make(map[string]*catAcc)
 
-	for _, a := range assignments {
-		g, ok := gradeByAssignment[a.ID.String()]
-		if !ok || g.IsExcused || g.PointsEarned == nil {
-			// Skip ungraded or excused.
-			continue
+	for cat, items := range byCat {
+		for _, it := range items {
+			if _, exists := cats[cat]; !exists {
+				cats[cat] = &catAcc{}
+			}
+			cats[cat].earned += it.earned * it.a.Weight
+			cats[cat].total += it.a.MaxPoints * it.a.Weight
 		}
-		if _, exists := cats[a.Category]; !exists {
-			cats[a.Category] = &catAcc{}
+	}
+	// Extra credit raises its category's earned points only, so it counts
+	// (and is reported) only in a category with graded regular work.
+	for _, it := range bonus {
+		if acc, exists := cats[it.a.Category]; exists {
+			acc.earned += it.earned * it.a.Weight
+			adjust(models.AdjustExtraCredit, it.a, 0, it.earned,
+				fmt.Sprintf("%s extra-credit points added to %s", formatNumber(it.earned), it.a.Category))
 		}
-		cats[a.Category].earned += *g.PointsEarned * a.Weight
-		cats[a.Category].total += a.MaxPoints * a.Weight
 	}
 
 	if len(cats) == 0 {
@@ -56,6 +156,7 @@ func (s *GradingService) CalculateCourseGrade(
 	}
 
 	var totalEarned, totalPoints float64
+	breakdown := make(map[string]models.CategoryGrade, len(cats))
 
 	if len(categoryWeights) > 0 {
 		// Weighted by category.
@@ -68,6 +169,16 @@ func (s *GradingService) CalculateCourseGrade(
 				totalEarned += (acc.earned / acc.total) * weight
 				totalPoints += weight
 			}
+			breakdown[cat] = categoryGrade(cat, acc.earned, acc.total, weight)
+		}
+		// Report each category's share of the final grade.
+		for cat, cg := range breakdown {
+			if totalPoints > 0 && cg.PointsTotal > 0 {
+				cg.Weight /= totalPoints
+			} else {
+				cg.Weight = 0
+			}
+			breakdown[cat] = cg
 		}
 	} else {
 		// Simple total-points method.
@@ -75,6 +186,13 @@ func (s *GradingService) CalculateCourseGrade(
 			totalEarned += acc.earned
 			totalPoints += acc.total
 		}
+		for cat, acc := range cats {
+			var share float64
+			if totalPoints > 0 {
+				share = acc.total / totalPoints
+			}
+			breakdown[cat] = categoryGrade(cat, acc.earned, acc.total, share)
+		}
 	}
 
 	var pct float64
@@ -82,26 +200,268 @@ func (s *GradingService) CalculateCourseGrade(
 		pct = (totalEarned / totalPoints) * 100
 	}
 
+	if r := policy.Rounding; r != nil {
+		rounded := roundPercent(pct, r)
+		if rounded != pct {
+			adjustments = append(adjustments, models.GradeAdjustment{
+				Kind: models.AdjustRounding, Before: pct, After: rounded,
+				Detail: fmt.Sprintf("rounded %s to %d decimal places", r.Mode, r.Places),
+			})
+		}
+		pct = rounded
+	}
+
 	letter := s.PercentToLetter(pct, scale)
 
-	return &models.GradeCalculation{
-		Percentage:  pct,
-		LetterGrade: letter,
-		PointsEarned: totalEarned,
-		PointsTotal:  totalPoints,
+	calc := &models.GradeCalculation{
+		CourseID:          assignments[0].CourseID,
+		Percentage:        pct,
+		LetterGrade:       letter,
+		PointsEarned:      totalEarned,
+		PointsTotal:       totalPoints,
+		CategoryBreakdown: breakdown,
+		Adjustments:       adjustments,
+	}
+	if len(grades) > 0 {
+		calc.StudentID = grades[0].StudentID
 	}
+	return calc
 }
 
-// CalculateGPA computes a GPA (4.0 scale) from a slice of course grade calculations.
-func (s *GradingService) CalculateGPA(grades []*models.GradeCalculation, scale []models.LetterGradeMapping) float64 {
-	if len(grades) == 0 {
+// categoryGrade summarises one category; weight is its share of the final grade.
+func categoryGrade(cat string, earned, total, weight float64) models.CategoryGrade {
+	cg := models.CategoryGrade{
+		Category:     cat,
+		PointsEarned: earned,
+		PointsTotal:  total,
+		Weight:       weight,
+	}
+	if total > 0 {
+		cg.Percentage = earned / total * 100
+	}
+	return cg
+}
+
+// latePenaltyPercent returns the percentage of max points a late grade loses.
+func latePenaltyPercent(p *models.LatePenalty, daysLate *int) float64 {
+	pct := p.Percent
+	if p.Mode == models.LatePenaltyPerDay {
+		days := 1
+		if daysLate != nil {
+			days = *daysLate
+		}
+		pct = p.Percent * float64(days)
+		if p.MaxPercent > 0 && pct > p.MaxPercent {
+			pct = p.MaxPercent
+		}
+	}
+	return math.Min(pct, 100)
+}
+
+// roundPercent applies a rounding rule to a percentage.
+func roundPercent(pct float64, r *models.RoundingRule) float64 {
+	f := math.Pow(10, float64(r.Places))
+	switch r.Mode {
+	case models.RoundDown:
+		return math.Floor(pct*f) / f
+	case models.RoundUp:
+		// Guard against float noise such as 89.00000000001 rounding up.
+		return math.Ceil(math.Round(pct*f*1e6)/1e6) / f
+	}
+	return math.Floor(pct*f+0.5) / f
+}
+
+func scoreRatio(earned, max float64) float64 {
+	if max <= 0 {
+		return 1
+	}
+	return earned / max
+}
+
+// formatNumber prints a float without trailing zeros.
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// AssignmentsInTerm returns the assignments due within term. Assignments
+// without a due date are placed by their creation date.
+func (s *GradingService) AssignmentsInTerm(assignments []models.Assignment, term *models.Term) []models.Assignment {
+	var out []models.Assignment
+	for _, a := range assignments {
+		at := a.CreatedAt
+		if a.DueDate != nil {
+			at = *a.DueDate
+		}
+		if term.Contains(at) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// CalculateMastery computes a student's proficiency on each of standards (in
+// the order given) from their scores under rule. Standards without evidence
+// are returned with a zero score. When term is non-nil only scores assessed
+// within the term are counted.
+func (s *GradingService) CalculateMastery(
+	standards []models.Standard,
+	scores []models.StandardScore,
+	rule string,
+	decay float64,
+	term *models.Term,
+) []models.StandardMastery {
+	byStandard := make(map[uuid.UUID][]models.StandardScore)
+	for _, sc := range scores {
+		if term != nil && !term.Contains(sc.AssessedAt) {
+			continue
+		}
+		byStandard[sc.StandardID] = append(byStandard[sc.StandardID], sc)
+	}
+
+	out := make([]models.StandardMastery, 0, len(standards))
+	for _, st := range standards {
+		m := models.StandardMastery{
+			StandardID:  st.ID,
+			ShortID:     st.ShortID,
+			Code:        st.Code,
+			Description: st.Description,
+		}
+		evidence := byStandard[st.ID]
+		if len(evidence) > 0 {
+			sort.SliceStable(evidence, func(i, j int) bool {
+				return evidence[i].AssessedAt.Before(evidence[j].AssessedAt)
+			})
+			values := make([]float64, len(evidence))
+			for i, e := range evidence {
+				values[i] = e.Score
+			}
+			m.Score = s.MasteryScore(values, rule, decay)
+			m.Level = ProficiencyLevel(m.Score)
+			m.Label = models.ProficiencyLabels[m.Level]
+			m.Evidence = len(values)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// MasteryScore combines chronologically ordered 1–4 scores under rule:
+//
+//   - most_recent: the latest score
+//   - highest: the best score
+//   - decaying_average: each new score weighted by decay against the running
+//     average, so recent work counts most
+//   - mode: the most frequent score, ties going to the most recent
+//
+// Unknown rules fall back to most_recent; decay outside (0, 1] uses
+// models.DefaultMasteryDecay.
+func (s *GradingService) MasteryScore(scores []float64, rule string, decay float64) float64 {
+	if len(scores) == 0 {
+		return 0
+	}
+	switch rule {
+	case models.MasteryHighest:
+		best := scores[0]
+		for _, v := range scores[1:] {
+			best = math.Max(best, v)
+		}
+		return best
+
+	case models.MasteryDecayingAverage:
+		if decay <= 0 || decay > 1 {
+			decay = models.DefaultMasteryDecay
+		}
+		avg := scores[0]
+		for _, v := range scores[1:] {
+			avg = avg*(1-decay) + v*decay
+		}
+		return math.Round(avg*100) / 100
+
+	case models.MasteryMode:
+		counts := make(map[float64]int)
+		var mode float64
+		best := 0
+		for _, v := range scores {
+			counts[v]++
+			// >= lets a later score win a tie.
+			if counts[v] >= best {
+				best = counts[v]
+				mode = v
+			}
+		}
+		return mode
+	}
+	return scores[len(scores)-1]
+}
+
+// ProficiencyLevel rounds a mastery score to the nearest level of the 1–4 scale.
+func ProficiencyLevel(score float64) int {
+	if score <= 0 {
 		return 0
 	}
-	var total float64
-	for _, g := range grades {
-		total += s.LetterToGradePoint(g.LetterGrade, scale)
+	level := int(math.Floor(score + 0.5))
+	if level < 1 {
+		return 1
 	}
-	return total / float64(len(grades))
+	if level > 4 {
+		return 4
+	}
+	return level
+}
+
+// CalculateGPA computes credit-weighted GPAs (4.0 scale) over the courses
+// that have a grade. The weighted GPA adds the course level's bonus to every
+// passing grade point; method picks which of the two is reported as GPA.
+// Courses worth zero credits do not count.
+func (s *GradingService) CalculateGPA(courses []models.GPACourse, scale []models.LetterGradeMapping, method string, bonuses map[string]float64) models.GPAResult {
+	if bonuses == nil {
+		bonuses = models.DefaultGPABonuses
+	}
+	var res models.GPAResult
+	for _, c := range courses {
+		if c.Calc == nil || c.Credits <= 0 {
+			continue
+		}
+		points := s.LetterToGradePoint(c.Calc.LetterGrade, scale)
+		weighted := points
+		if points > 0 {
+			weighted += bonuses[c.Level]
+			res.CreditsEarned += c.Credits
+		}
+		res.CreditsAttempted += c.Credits
+		res.QualityPoints += points * c.Credits
+		res.WeightedQualityPoints += weighted * c.Credits
+	}
+	return s.finishGPA(res, method)
+}
+
+// CumulativeGPA combines term GPAs by summing their credits and quality points.
+func (s *GradingService) CumulativeGPA(terms []models.GPAResult, method string) models.GPAResult {
+	var res models.GPAResult
+	for _, t := range terms {
+		res.CreditsAttempted += t.CreditsAttempted
+		res.CreditsEarned += t.CreditsEarned
+		res.QualityPoints += t.QualityPoints
+		res.WeightedQualityPoints += t.WeightedQualityPoints
+	}
+	return s.finishGPA(res, method)
+}
+
+// finishGPA derives the GPAs from summed credits and quality points.
+func (s *GradingService) finishGPA(res models.GPAResult, method string) models.GPAResult {
+	if method != models.GPAWeighted {
+		method = models.GPAUnweighted
+	}
+	res.Method = method
+	if res.CreditsAttempted > 0 {
+		res.Unweighted = res.QualityPoints / res.CreditsAttempted
+		res.Weighted = res.WeightedQualityPoints / res.CreditsAttempted
+	}
+	res.GPA = res.Unweighted
+	if method == models.GPAWeighted {
+		res.GPA = res.Weighted
+	}
+	return res
 }
 
 // PercentToLetter maps a percentage to a letter grade using the school's scale.
@@ -109,6 +469,16 @@ func (s *GradingService) PercentToLetter(pct float64, scale []models.LetterGrade
 	if len(scale) == 0 {
 		scale = models.DefaultLetterGrades
 	}
+	// Scores above the scale (extra credit) earn its top letter.
+	top := scale[0]
+	for _, m := range scale {
+		if m.MaxPercent > top.MaxPercent {
+			top = m
+		}
+	}
+	if pct > top.MaxPercent {
+		return top.Letter
+	}
 	for _, m := range scale {
 		if pct >= m.MinPercent && pct <= m.MaxPercent {
 			return m.Letter
@@ -0,0 +1,514 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pragma-proto/api/internal/models"
+)
+
+// work is one assignment and the student's grade on it, if any.
+type work struct {
+	category string
+	max      float64
+	earned   *float64 // nil: no points recorded
+	extra    bool
+	excused  bool
+	missing  bool
+	late     bool
+	daysLate *int
+	due      time.Time
+	ungraded bool // no grade row at all
+}
+
+func points(v float64) *float64 { return &v }
+func days(n int) *int           { return &n }
+
+// buildCourse turns work into the assignments and grades CalculateCourseGrade takes.
+func buildCourse(items []work) ([]models.Assignment, []models.Grade) {
+	courseID, studentID := uuid.New(), uuid.New()
+	var assignments []models.Assignment
+	var grades []models.Grade
+	for _, it := range items {
+		a := models.Assignment{
+			ID: uuid.New(), CourseID: courseID, Category: it.category,
+			MaxPoints: it.max, Weight: 1, IsExtraCredit: it.extra,
+		}
+		if !it.due.IsZero() {
+			due := it.due
+			a.DueDate = &due
+		}
+		assignments = append(assignments, a)
+		if it.ungraded {
+			continue
+		}
+		grades = append(grades, models.Grade{
+			AssignmentID: a.ID, StudentID: studentID, PointsEarned: it.earned,
+			IsExcused: it.excused, IsMissing: it.missing, IsLate: it.late, DaysLate: it.daysLate,
+		})
+	}
+	return assignments, grades
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestCalculateCourseGrade(t *testing.T) {
+	spring := &models.Term{
+		StartDate: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, 5, 29, 0, 0, 0, 0, time.UTC),
+	}
+	inTerm := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+	afterTerm := time.Date(2026, 6, 15, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		work        []work
+		weights     map[string]float64
+		term        *models.Term
+		policy      *models.GradePolicy
+		want        float64
+		letter      string
+		adjustments []string // kinds, in order
+	}{
+		{
+			name: "total points",
+			work: []work{
+				{category: "homework", max: 10, earned: points(8)},
+				{category: "test", max: 50, earned: points(45)},
+			},
+			want: 53.0 / 60 * 100, letter: "B+",
+		},
+		{
+			name: "excused and ungraded work is left out",
+			work: []work{
+				{category: "homework", max: 10, earned: points(8)},
+				{category: "homework", max: 10, excused: true},
+				{category: "homework", max: 10, ungraded: true},
+				{category: "homework", max: 10},
+			},
+			want: 80, letter: "B-",
+		},
+		{
+			name: "missing work is left out without the policy",
+			work: []work{
+				{category: "homework", max: 10, earned: points(10)},
+				{category: "homework", max: 10, missing: true},
+			},
+			want: 100, letter: "A",
+		},
+		{
+			name: "missing work counts as zero",
+			work: []work{
+				{category: "homework", max: 10, earned: points(10)},
+				{category: "homework", max: 10, missing: true},
+			},
+			policy:      &models.GradePolicy{MissingAsZero: true},
+			want:        50,
+			letter:      "F",
+			adjustments: []string{models.AdjustMissingZero},
+		},
+		{
+			name: "drop lowest by percentage, not points",
+			work: []work{
+				{category: "homework", max: 10, earned: points(5)},
+				{category: "homework", max: 100, earned: points(40)},
+				{category: "homework", max: 100, earned: points(90)},
+				{category: "test", max: 100, earned: points(20)},
+			},
+			policy:      &models.GradePolicy{DropLowest: map[string]int{"homework": 1}},
+			want:        115.0 / 210 * 100,
+			letter:      "F",
+			adjustments: []string{models.AdjustDropped},
+		},
+		{
+			name: "drop lowest always keeps one score",
+			work: []work{
+				{category: "quiz", max: 10, earned: points(3)},
+				{category: "quiz", max: 10, earned: points(9)},
+			},
+			policy:      &models.GradePolicy{DropLowest: map[string]int{"quiz": 5}},
+			want:        90,
+			letter:      "A-",
+			adjustments: []string{models.AdjustDropped},
+		},
+		{
+			name: "flat late penalty",
+			work: []work{
+				{category: "homework", max: 10, earned: points(9), late: true, daysLate: days(4)},
+			},
+			policy: &models.GradePolicy{LatePenalty: &models.LatePenalty{
+				Mode: models.LatePenaltyFlat, Percent: 10,
+			}},
+			want:        80,
+			letter:      "B-",
+			adjustments: []string{models.AdjustLatePenalty},
+		},
+		{
+			name: "per-day late penalty stops at its maximum",
+			work: []work{
+				{category: "homework", max: 10, earned: points(9), late: true, daysLate: days(3)},
+			},
+			policy: &models.GradePolicy{LatePenalty: &models.LatePenalty{
+				Mode: models.LatePenaltyPerDay, Percent: 5, MaxPercent: 10,
+			}},
+			want:        80,
+			letter:      "B-",
+			adjustments: []string{models.AdjustLatePenalty},
+		},
+		{
+			name: "per-day late penalty without days late counts one day",
+			work: []work{
+				{category: "homework", max: 10, earned: points(9), late: true},
+			},
+			policy: &models.GradePolicy{LatePenalty: &models.LatePenalty{
+				Mode: models.LatePenaltyPerDay, Percent: 5,
+			}},
+			want:        85,
+			letter:      "B",
+			adjustments: []string{models.AdjustLatePenalty},
+		},
+		{
+			name: "late penalty never goes below zero",
+			work: []work{
+				{category: "homework", max: 10, earned: points(1), late: true},
+				{category: "homework", max: 10, earned: points(10)},
+			},
+			policy: &models.GradePolicy{LatePenalty: &models.LatePenalty{
+				Mode: models.LatePenaltyFlat, Percent: 50,
+			}},
+			want:        50,
+			letter:      "F",
+			adjustments: []string{models.AdjustLatePenalty},
+		},
+		{
+			name: "on-time work is not penalized",
+			work: []work{
+				{category: "homework", max: 10, earned: points(9)},
+			},
+			policy: &models.GradePolicy{LatePenalty: &models.LatePenalty{
+				Mode: models.LatePenaltyFlat, Percent: 50,
+			}},
+			want: 90, letter: "A-",
+		},
+		{
+			name: "extra credit adds earned points only",
+			work: []work{
+				{category: "homework", max: 10, earned: points(7)},
+				{category: "homework", max: 5, earned: points(2), extra: true},
+			},
+			want:        90,
+			letter:      "A-",
+			adjustments: []string{models.AdjustExtraCredit},
+		},
+		{
+			name: "extra credit needs graded regular work in its category",
+			work: []work{
+				{category: "test", max: 10, earned: points(8)},
+				{category: "project", max: 5, earned: points(5), extra: true},
+			},
+			want: 80, letter: "B-",
+		},
+		{
+			name: "extra credit above the scale earns the top letter",
+			work: []work{
+				{category: "homework", max: 10, earned: points(10)},
+				{category: "homework", max: 5, earned: points(3), extra: true},
+			},
+			want:        130,
+			letter:      "A",
+			adjustments: []string{models.AdjustExtraCredit},
+		},
+		{
+			name: "missing extra credit is not counted as zero",
+			work: []work{
+				{category: "homework", max: 10, earned: points(9)},
+				{category: "homework", max: 5, missing: true, extra: true},
+			},
+			policy: &models.GradePolicy{MissingAsZero: true},
+			want:   90, letter: "A-",
+		},
+		{
+			name: "category weights",
+			work: []work{
+				{category: "homework", max: 10, earned: points(10)},
+				{category: "test", max: 60, earned: points(30)},
+			},
+			weights: map[string]float64{"homework": 0.2, "test": 0.8},
+			want:    60, letter: "D-",
+		},
+		{
+			name: "weights are renormalized over graded categories",
+			work: []work{
+				{category: "homework", max: 10, earned: points(8)},
+				{category: "test", max: 50, ungraded: true},
+			},
+			weights: map[string]float64{"homework": 0.25, "test": 0.75},
+			want:    80, letter: "B-",
+		},
+		{
+			name: "rounding half up reaches the next letter",
+			work: []work{
+				{category: "test", max: 200, earned: points(179)},
+			},
+			policy:      &models.GradePolicy{Rounding: &models.RoundingRule{Places: 0, Mode: models.RoundHalfUp}},
+			want:        90,
+			letter:      "A-",
+			adjustments: []string{models.AdjustRounding},
+		},
+		{
+			name: "rounding down",
+			work: []work{
+				{category: "test", max: 200, earned: points(179)},
+			},
+			policy:      &models.GradePolicy{Rounding: &models.RoundingRule{Places: 0, Mode: models.RoundDown}},
+			want:        89,
+			letter:      "B+",
+			adjustments: []string{models.AdjustRounding},
+		},
+		{
+			name: "rounding that changes nothing is not listed",
+			work: []work{
+				{category: "test", max: 100, earned: points(91)},
+			},
+			policy: &models.GradePolicy{Rounding: &models.RoundingRule{Places: 1, Mode: models.RoundUp}},
+			want:   91, letter: "A-",
+		},
+		{
+			name: "term leaves out work due after it",
+			work: []work{
+				{category: "homework", max: 10, earned: points(10), due: inTerm},
+				{category: "homework", max: 10, earned: points(0), due: afterTerm},
+			},
+			term: spring,
+			want: 100, letter: "A",
+		},
+	}
+	svc := NewGradingService()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assignments, grades := buildCourse(tc.work)
+			got := svc.CalculateCourseGrade(assignments, grades, nil, tc.weights, tc.term, tc.policy)
+			if got == nil {
+				t.Fatal("no grade calculated")
+			}
+			if !approx(got.Percentage, tc.want) || got.LetterGrade != tc.letter {
+				t.Errorf("got %v%% %s, want %v%% %s", got.Percentage, got.LetterGrade, tc.want, tc.letter)
+			}
+			var kinds []string
+			for _, adj := range got.Adjustments {
+				kinds = append(kinds, adj.Kind)
+			}
+			if len(kinds) != len(tc.adjustments) {
+				t.Fatalf("adjustments = %v, want %v", kinds, tc.adjustments)
+			}
+			for i := range kinds {
+				if kinds[i] != tc.adjustments[i] {
+					t.Errorf("adjustments = %v, want %v", kinds, tc.adjustments)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateCourseGradeNothingGraded(t *testing.T) {
+	svc := NewGradingService()
+	if got := svc.CalculateCourseGrade(nil, nil, nil, nil, nil, nil); got != nil {
+		t.Errorf("no assignments: got %+v", got)
+	}
+	assignments, grades := buildCourse([]work{{category: "homework", max: 10, ungraded: true}})
+	if got := svc.CalculateCourseGrade(assignments, grades, nil, nil, nil, nil); got != nil {
+		t.Errorf("nothing graded: got %+v", got)
+	}
+}
+
+func TestRoundPercent(t *testing.T) {
+	tests := []struct {
+		pct    float64
+		places int
+		mode   string
+		want   float64
+	}{
+		{89.5, 0, models.RoundHalfUp, 90},
+		{89.49, 0, models.RoundHalfUp, 89},
+		{100.0 / 3, 2, models.RoundHalfUp, 33.33},
+		{200.0 / 3, 2, models.RoundHalfUp, 66.67},
+		{200.0 / 3, 2, models.RoundDown, 66.66},
+		{100.0 / 3, 1, models.RoundUp, 33.4},
+		{89.00000000001, 0, models.RoundUp, 89}, // float noise is not rounded up
+		{88.2, 0, models.RoundUp, 89},
+	}
+	for _, tc := range tests {
+		got := roundPercent(tc.pct, &models.RoundingRule{Places: tc.places, Mode: tc.mode})
+		if !approx(got, tc.want) {
+			t.Errorf("roundPercent(%v, %d %s) = %v, want %v", tc.pct, tc.places, tc.mode, got, tc.want)
+		}
+	}
+}
+
+func TestCalculateGPA(t *testing.T) {
+	course := func(letter string, credits float64, level string) models.GPACourse {
+		return models.GPACourse{Calc: &models.GradeCalculation{LetterGrade: letter}, Credits: credits, Level: level}
+	}
+	tests := []struct {
+		name                   string
+		courses                []models.GPACourse
+		method                 string
+		bonuses                map[string]float64
+		gpa, unweighted, weigh float64
+		attempted, earned      float64
+	}{
+		{
+			name:    "credit-weighted",
+			courses: []models.GPACourse{course("A", 1, models.CourseLevelRegular), course("B", 0.5, models.CourseLevelRegular)},
+			method:  models.GPAUnweighted,
+			gpa:     5.5 / 1.5, unweighted: 5.5 / 1.5, weigh: 5.5 / 1.5,
+			attempted: 1.5, earned: 1.5,
+		},
+		{
+			name:    "honors and AP bonuses in the weighted GPA",
+			courses: []models.GPACourse{course("B", 1, models.CourseLevelHonors), course("A-", 1, models.CourseLevelAP)},
+			method:  models.GPAWeighted,
+			gpa:     (3.5 + 4.7) / 2, unweighted: (3.0 + 3.7) / 2, weigh: (3.5 + 4.7) / 2,
+			attempted: 2, earned: 2,
+		},
+		{
+			name:    "a failing grade earns no credit and no bonus",
+			courses: []models.GPACourse{course("F", 1, models.CourseLevelAP), course("A", 1, models.CourseLevelAP)},
+			method:  models.GPAWeighted,
+			gpa:     2.5, unweighted: 2, weigh: 2.5,
+			attempted: 2, earned: 1,
+		},
+		{
+			name: "ungraded and zero-credit courses do not count",
+			courses: []models.GPACourse{
+				course("A", 1, models.CourseLevelRegular),
+				course("F", 0, models.CourseLevelRegular),
+				{Credits: 1, Level: models.CourseLevelRegular},
+			},
+			method: models.GPAUnweighted,
+			gpa:    4, unweighted: 4, weigh: 4,
+			attempted: 1, earned: 1,
+		},
+		{
+			name:    "school bonuses replace the defaults",
+			courses: []models.GPACourse{course("B", 1, models.CourseLevelHonors)},
+			method:  models.GPAWeighted,
+			bonuses: map[string]float64{models.CourseLevelHonors: 1},
+			gpa:     4, unweighted: 3, weigh: 4,
+			attempted: 1, earned: 1,
+		},
+		{
+			name:    "an unknown method reports the unweighted GPA",
+			courses: []models.GPACourse{course("B", 1, models.CourseLevelHonors)},
+			method:  "best",
+			gpa:     3, unweighted: 3, weigh: 3.5,
+			attempted: 1, earned: 1,
+		},
+		{
+			name:   "no courses",
+			method: models.GPAWeighted,
+		},
+	}
+	svc := NewGradingService()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := svc.CalculateGPA(tc.courses, nil, tc.method, tc.bonuses)
+			if !approx(got.GPA, tc.gpa) || !approx(got.Unweighted, tc.unweighted) || !approx(got.Weighted, tc.weigh) {
+				t.Errorf("GPA %v (unweighted %v, weighted %v), want %v (%v, %v)",
+					got.GPA, got.Unweighted, got.Weighted, tc.gpa, tc.unweighted, tc.weigh)
+			}
+			if got.CreditsAttempted != tc.attempted || got.CreditsEarned != tc.earned {
+				t.Errorf("credits attempted %v earned %v, want %v and %v",
+					got.CreditsAttempted, got.CreditsEarned, tc.attempted, tc.earned)
+			}
+		})
+	}
+}
+
+func TestCumulativeGPA(t *testing.T) {
+	svc := NewGradingService()
+	fall := svc.CalculateGPA([]models.GPACourse{
+		{Calc: &models.GradeCalculation{LetterGrade: "A"}, Credits: 3},
+	}, nil, models.GPAUnweighted, nil)
+	spring := svc.CalculateGPA([]models.GPACourse{
+		{Calc: &models.GradeCalculation{LetterGrade: "C"}, Credits: 1},
+	}, nil, models.GPAUnweighted, nil)
+
+	got := svc.CumulativeGPA([]models.GPAResult{fall, spring}, models.GPAUnweighted)
+	if !approx(got.GPA, 14.0/4) || got.CreditsAttempted != 4 {
+		t.Errorf("cumulative GPA %v over %v credits, want 3.5 over 4", got.GPA, got.CreditsAttempted)
+	}
+}
+
+func TestCalculateMastery(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 2, d, 0, 0, 0, 0, time.UTC) }
+	standard := models.Standard{ID: uuid.New(), ShortID: "std1", Code: "MATH.1"}
+	unassessed := models.Standard{ID: uuid.New(), ShortID: "std2", Code: "MATH.2"}
+	evidence := func(scores ...float64) []models.StandardScore {
+		// Out of date order, so each rule must sort the evidence itself.
+		out := make([]models.StandardScore, len(scores))
+		for i, s := range scores {
+			out[len(scores)-1-i] = models.StandardScore{StandardID: standard.ID, Score: s, AssessedAt: day(i + 1)}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		scores []models.StandardScore
+		rule   string
+		decay  float64
+		term   *models.Term
+		want   float64
+		level  int
+	}{
+		{name: "most recent", scores: evidence(4, 2), rule: models.MasteryMostRecent, want: 2, level: 2},
+		{name: "highest", scores: evidence(2, 4, 3), rule: models.MasteryHighest, want: 4, level: 4},
+		{name: "decaying average", scores: evidence(2, 4), rule: models.MasteryDecayingAverage, decay: 0.65,
+			want: 3.3, level: 3},
+		{name: "decaying average rounds to two places", scores: evidence(1, 2, 4), rule: models.MasteryDecayingAverage,
+			decay: 0.5, want: 2.75, level: 3},
+		{name: "decay out of range uses the default", scores: evidence(2, 4), rule: models.MasteryDecayingAverage,
+			decay: 1.5, want: 3.3, level: 3},
+		{name: "mode", scores: evidence(3, 3, 2), rule: models.MasteryMode, want: 3, level: 3},
+		{name: "mode tie goes to the most recent", scores: evidence(3, 2, 3, 2), rule: models.MasteryMode,
+			want: 2, level: 2},
+		{name: "unknown rule is most recent", scores: evidence(1, 3), rule: "median", want: 3, level: 3},
+		{
+			name: "term leaves out other evidence", scores: evidence(1, 4), rule: models.MasteryHighest,
+			term: &models.Term{StartDate: day(1), EndDate: day(1)}, want: 1, level: 1,
+		},
+	}
+	svc := NewGradingService()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := svc.CalculateMastery([]models.Standard{standard, unassessed}, tc.scores, tc.rule, tc.decay, tc.term)
+			if len(got) != 2 || got[0].StandardID != standard.ID || got[1].StandardID != unassessed.ID {
+				t.Fatalf("standards out of order: %+v", got)
+			}
+			m := got[0]
+			if !approx(m.Score, tc.want) || m.Level != tc.level || m.Label != models.ProficiencyLabels[tc.level] {
+				t.Errorf("got %v level %d %q, want %v level %d", m.Score, m.Level, m.Label, tc.want, tc.level)
+			}
+			if none := got[1]; none.Score != 0 || none.Level != 0 || none.Evidence != 0 {
+				t.Errorf("standard without evidence = %+v", none)
+			}
+		})
+	}
+}
+
+func TestProficiencyLevel(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  int
+	}{
+		{0, 0}, {0.2, 1}, {1.49, 1}, {1.5, 2}, {2.5, 3}, {3.49, 3}, {3.5, 4}, {4, 4}, {5, 4},
+	}
+	for _, tc := range tests {
+		if got := ProficiencyLevel(tc.score); got != tc.want {
+			t.Errorf("ProficiencyLevel(%v) = %d, want %d", tc.score, got, tc.want)
+		}
+	}
+}
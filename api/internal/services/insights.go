@@ -0,0 +1,126 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pragma-proto/api/internal/models"
+)
+
+// MinTrendScores is the fewest graded assignments a trend is judged on.
+const MinTrendScores = 3
+
+// NewGradeTrend summarizes a student's assignment percentages in a course,
+// oldest first, with their count of missing assignments.
+func NewGradeTrend(scores []float64, missing int) models.GradeTrend {
+	t := models.GradeTrend{Scores: scores, Missing: missing}
+	if len(scores) == 0 {
+		return t
+	}
+	t.Average = meanPercent(scores)
+	split := max(0, len(scores)-3)
+	t.Recent = meanPercent(scores[split:])
+	if split > 0 {
+		earlier := meanPercent(scores[:split])
+		t.Earlier = &earlier
+	}
+	return t
+}
+
+func meanPercent(xs []float64) float64 {
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return math.Round(sum/float64(len(xs))*10) / 10
+}
+
+// RuleInsight is the local rules engine used when AI is off or fails. It
+// returns an empty severity when the trend is not concerning:
+//   - high: failing average (under 60%), a drop of 20+ points, or 3+ missing
+//   - medium: average under 70%, a drop of 10+ points, or 2 missing
+//   - low: the last three scores each lower than the one before
+func RuleInsight(t models.GradeTrend) (severity, message string) {
+	if len(t.Scores) < MinTrendScores && t.Missing < 2 {
+		return "", ""
+	}
+	var drop float64
+	if t.Earlier != nil {
+		drop = *t.Earlier - t.Recent
+	}
+
+	var reasons []string
+	rank := 0
+	raise := func(r int, reason string) {
+		rank = max(rank, r)
+		reasons = append(reasons, reason)
+	}
+	enough := len(t.Scores) >= MinTrendScores
+	switch {
+	case enough && t.Average < 60:
+		raise(3, fmt.Sprintf("failing average of %.1f%%", t.Average))
+	case enough && t.Average < 70:
+		raise(2, fmt.Sprintf("low average of %.1f%%", t.Average))
+	}
+	switch {
+	case drop >= 20:
+		raise(3, fmt.Sprintf("recent scores down %.1f points", drop))
+	case drop >= 10:
+		raise(2, fmt.Sprintf("recent scores down %.1f points", drop))
+	}
+	switch {
+	case t.Missing >= 3:
+		raise(3, fmt.Sprintf("%d missing assignments", t.Missing))
+	case t.Missing == 2:
+		raise(2, "2 missing assignments")
+	}
+	if n := len(t.Scores); rank == 0 && n >= 3 && t.Scores[n-1] < t.Scores[n-2] && t.Scores[n-2] < t.Scores[n-3] {
+		raise(1, "three declining scores in a row")
+	}
+	if rank == 0 {
+		return "", ""
+	}
+	severity = []string{"", models.AlertLow, models.AlertMedium, models.AlertHigh}[rank]
+	msg := strings.Join(reasons, "; ")
+	return severity, strings.ToUpper(msg[:1]) + msg[1:]
+}
+
+// InsightLine formats one anonymized trajectory for StudentInsightsPrompt.
+func InsightLine(label string, t models.GradeTrend) string {
+	scores := make([]string, len(t.Scores))
+	for i, s := range t.Scores {
+		scores[i] = fmt.Sprintf("%.0f", s)
+	}
+	return fmt.Sprintf("%s: scores %% oldest to newest [%s]; average %.1f%%; missing %d",
+		label, strings.Join(scores, ", "), t.Average, t.Missing)
+}
+
+// StudentInsight is one alert from the AI's reply to StudentInsightsPrompt.
+type StudentInsight struct {
+	Student  string `json:"student"`
+	Alert    string `json:"alert"`
+	Severity string `json:"severity"`
+}
+
+// ParseStudentInsights reads the AI's alerts, dropping any without a
+// student, a message, or a known severity.
+func ParseStudentInsights(raw json.RawMessage) ([]StudentInsight, error) {
+	if raw == nil {
+		return nil, fmt.Errorf("ai: no JSON in insights response")
+	}
+	var all []StudentInsight
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, fmt.Errorf("ai: parse insights: %w", err)
+	}
+	insights := all[:0]
+	for _, in := range all {
+		in.Severity = strings.ToLower(strings.TrimSpace(in.Severity))
+		if in.Student == "" || strings.TrimSpace(in.Alert) == "" || models.AlertSeverityRank(in.Severity) == 0 {
+			continue
+		}
+		insights = append(insights, in)
+	}
+	return insights, nil
+}
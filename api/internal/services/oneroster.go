@@ -0,0 +1,466 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OneRoster manifest file modes.
+const (
+	OneRosterBulk   = "bulk"
+	OneRosterDelta  = "delta"
+	OneRosterAbsent = "absent"
+)
+
+// OneRosterToBeDeleted is the status a delta file uses to remove a record.
+const OneRosterToBeDeleted = "tobedeleted"
+
+// OneRosterBundle is a parsed OneRoster 1.2 CSV bundle (a zip of CSV files).
+// Only the rostering files this API imports are read. Both the 1.1 layout
+// (role on users.csv) and the 1.2 layout (roles.csv) are accepted.
+type OneRosterBundle struct {
+	Manifest         map[string]string
+	Orgs             []OneRosterOrg
+	AcademicSessions []OneRosterSession
+	Users            []OneRosterUser
+	Roles            []OneRosterRole
+	Courses          []OneRosterCourse
+	Classes          []OneRosterClass
+	Enrollments      []OneRosterEnrollment
+
+	present map[string]bool // files found in the zip, without ".csv"
+}
+
+// OneRosterOrg is a row of orgs.csv.
+type OneRosterOrg struct {
+	SourcedID       string
+	Status          string
+	Name            string
+	Type            string
+	Identifier      string
+	ParentSourcedID string
+}
+
+// OneRosterSession is a row of academicSessions.csv.
+type OneRosterSession struct {
+	SourcedID       string
+	Status          string
+	Title           string
+	Type            string // schoolYear, semester, term, gradingPeriod
+	StartDate       time.Time
+	EndDate         time.Time
+	ParentSourcedID string
+	SchoolYear      string
+}
+
+// OneRosterUser is a row of users.csv. Role is empty in 1.2 bundles, which
+// list roles in roles.csv.
+type OneRosterUser struct {
+	SourcedID       string
+	Status          string
+	EnabledUser     bool
+	OrgSourcedIDs   []string
+	Role            string
+	Username        string
+	Identifier      string
+	GivenName       string
+	FamilyName      string
+	Email           string
+	Phone           string
+	AgentSourcedIDs []string
+	Grades          []string
+}
+
+// OneRosterRole is a row of roles.csv (1.2).
+type OneRosterRole struct {
+	UserSourcedID string
+	RoleType      string // primary or secondary
+	Role          string
+	OrgSourcedID  string
+}
+
+// OneRosterCourse is a row of courses.csv.
+type OneRosterCourse struct {
+	SourcedID    string
+	Status       string
+	Title        string
+	CourseCode   string
+	OrgSourcedID string
+	Subjects     []string
+}
+
+// OneRosterClass is a row of classes.csv.
+type OneRosterClass struct {
+	SourcedID       string
+	Status          string
+	Title           string
+	CourseSourcedID string
+	ClassCode       string
+	Location        string
+	SchoolSourcedID string
+	TermSourcedIDs  []string
+	Subjects        []string
+	Periods         []string
+}
+
+// OneRosterEnrollment is a row of enrollments.csv.
+type OneRosterEnrollment struct {
+	SourcedID       string
+	Status          string
+	ClassSourcedID  string
+	SchoolSourcedID string
+	UserSourcedID   string
+	Role            string
+	Primary         bool
+}
+
+// Mode returns the manifest mode for a file such as "users". Bundles without
+// a manifest are treated as bulk for the files they contain.
+func (b *OneRosterBundle) Mode(file string) string {
+	if mode, ok := b.Manifest["file."+file]; ok {
+		return strings.ToLower(mode)
+	}
+	if b.Manifest == nil && b.present[file] {
+		return OneRosterBulk
+	}
+	return OneRosterAbsent
+}
+
+// Present reports whether the bundle contains a file such as "users".
+func (b *OneRosterBundle) Present(file string) bool {
+	return b.present[file]
+}
+
+// ParseOneRosterBundle reads a OneRoster CSV zip. Files may sit at the top
+// level or inside one folder; unknown files are ignored.
+func ParseOneRosterBundle(data []byte) (*OneRosterBundle, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a zip file: %w", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[path.Base(f.Name)] = f
+	}
+
+	b := &OneRosterBundle{present: make(map[string]bool)}
+	read := func(name string) ([]oneRosterRow, error) {
+		f, ok := files[name]
+		if !ok {
+			return nil, nil
+		}
+		b.present[strings.TrimSuffix(name, ".csv")] = true
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		raw, err := io.ReadAll(io.LimitReader(rc, 256<<20))
+		if err != nil {
+			return nil, err
+		}
+		rows, err := DecodeSpreadsheet(SpreadsheetCSV, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if len(rows) == 0 {
+			return nil, nil
+		}
+		cols := make(map[string]int, len(rows[0]))
+		for i, h := range rows[0] {
+			cols[strings.TrimSpace(h)] = i
+		}
+		out := make([]oneRosterRow, 0, len(rows)-1)
+		for i, r := range rows[1:] {
+			out = append(out, oneRosterRow{file: name, line: i + 2, cols: cols, cells: r})
+		}
+		return out, nil
+	}
+
+	if rows, err := read("manifest.csv"); err != nil {
+		return nil, err
+	} else if b.present["manifest"] {
+		b.Manifest = make(map[string]string, len(rows))
+		for _, r := range rows {
+			b.Manifest[r.get("propertyName")] = r.get("value")
+		}
+	}
+
+	rows, err := read("orgs.csv")
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		b.Orgs = append(b.Orgs, OneRosterOrg{
+			SourcedID:       r.get("sourcedId"),
+			Status:          r.get("status"),
+			Name:            r.get("name"),
+			Type:            r.get("type"),
+			Identifier:      r.get("identifier"),
+			ParentSourcedID: r.get("parentSourcedId"),
+		})
+	}
+
+	if rows, err = read("academicSessions.csv"); err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		s := OneRosterSession{
+			SourcedID:       r.get("sourcedId"),
+			Status:          r.get("status"),
+			Title:           r.get("title"),
+			Type:            r.get("type"),
+			ParentSourcedID: r.get("parentSourcedId"),
+			SchoolYear:      r.get("schoolYear"),
+		}
+		if s.StartDate, err = r.date("startDate"); err != nil {
+			return nil, err
+		}
+		if s.EndDate, err = r.date("endDate"); err != nil {
+			return nil, err
+		}
+		b.AcademicSessions = append(b.AcademicSessions, s)
+	}
+
+	if rows, err = read("users.csv"); err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		b.Users = append(b.Users, OneRosterUser{
+			SourcedID:       r.get("sourcedId"),
+			Status:          r.get("status"),
+			EnabledUser:     !strings.EqualFold(r.get("enabledUser"), "false"),
+			OrgSourcedIDs:   r.list("orgSourcedIds"),
+			Role:            r.get("role"),
+			Username:        r.get("username"),
+			Identifier:      r.get("identifier"),
+			GivenName:       r.get("givenName"),
+			FamilyName:      r.get("familyName"),
+			Email:           r.get("email"),
+			Phone:           r.get("phone"),
+			AgentSourcedIDs: r.list("agentSourcedIds"),
+			Grades:          r.list("grades"),
+		})
+	}
+
+	if rows, err = read("roles.csv"); err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		b.Roles = append(b.Roles, OneRosterRole{
+			UserSourcedID: r.get("userSourcedId"),
+			RoleType:      r.get("roleType"),
+			Role:          r.get("role"),
+			OrgSourcedID:  r.get("orgSourcedId"),
+		})
+	}
+
+	if rows, err = read("courses.csv"); err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		b.Courses = append(b.Courses, OneRosterCourse{
+			SourcedID:    r.get("sourcedId"),
+			Status:       r.get("status"),
+			Title:        r.get("title"),
+			CourseCode:   r.get("courseCode"),
+			OrgSourcedID: r.get("orgSourcedId"),
+			Subjects:     r.list("subjects"),
+		})
+	}
+
+	if rows, err = read("classes.csv"); err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		b.Classes = append(b.Classes, OneRosterClass{
+			SourcedID:       r.get("sourcedId"),
+			Status:          r.get("status"),
+			Title:           r.get("title"),
+			CourseSourcedID: r.get("courseSourcedId"),
+			ClassCode:       r.get("classCode"),
+			Location:        r.get("location"),
+			SchoolSourcedID: r.get("schoolSourcedId"),
+			TermSourcedIDs:  r.list("termSourcedIds"),
+			Subjects:        r.list("subjects"),
+			Periods:         r.list("periods"),
+		})
+	}
+
+	if rows, err = read("enrollments.csv"); err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		b.Enrollments = append(b.Enrollments, OneRosterEnrollment{
+			SourcedID:       r.get("sourcedId"),
+			Status:          r.get("status"),
+			ClassSourcedID:  r.get("classSourcedId"),
+			SchoolSourcedID: r.get("schoolSourcedId"),
+			UserSourcedID:   r.get("userSourcedId"),
+			Role:            r.get("role"),
+			Primary:         strings.EqualFold(r.get("primary"), "true"),
+		})
+	}
+
+	return b, nil
+}
+
+// oneRosterRow reads cells from a CSV row by header name.
+type oneRosterRow struct {
+	file  string
+	line  int
+	cols  map[string]int
+	cells []string
+}
+
+func (r oneRosterRow) get(col string) string {
+	i, ok := r.cols[col]
+	if !ok || i >= len(r.cells) {
+		return ""
+	}
+	return strings.TrimSpace(r.cells[i])
+}
+
+// list splits a comma-separated list cell.
+func (r oneRosterRow) list(col string) []string {
+	var out []string
+	for _, v := range strings.Split(r.get(col), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func (r oneRosterRow) date(col string) (time.Time, error) {
+	v := r.get(col)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s line %d: %s must be YYYY-MM-DD", r.file, r.line, col)
+	}
+	return t, nil
+}
+
+// ---------- Gradebook export ----------
+
+// Result score statuses.
+const (
+	OneRosterScoreExempt       = "exempt"
+	OneRosterScoreFullyGraded  = "fully graded"
+	OneRosterScoreNotSubmitted = "not submitted"
+)
+
+// OneRosterCategory is a row of categories.csv.
+type OneRosterCategory struct {
+	SourcedID        string
+	DateLastModified time.Time
+	Title            string
+}
+
+// OneRosterLineItem is a row of lineItems.csv (one per assignment).
+type OneRosterLineItem struct {
+	SourcedID              string
+	DateLastModified       time.Time
+	Title                  string
+	Description            string
+	AssignDate             time.Time
+	DueDate                *time.Time
+	ClassSourcedID         string
+	CategorySourcedID      string
+	GradingPeriodSourcedID string
+	ResultValueMax         float64
+}
+
+// OneRosterResult is a row of results.csv (one per grade).
+type OneRosterResult struct {
+	SourcedID         string
+	DateLastModified  time.Time
+	LineItemSourcedID string
+	StudentSourcedID  string
+	ScoreStatus       string
+	Score             *float64
+	ScoreDate         time.Time
+	Comment           string
+}
+
+// EncodeOneRosterGradebook writes a OneRoster 1.2 gradebook bundle: a zip
+// with manifest.csv, categories.csv, lineItems.csv, and results.csv, all bulk.
+func EncodeOneRosterGradebook(categories []OneRosterCategory, lineItems []OneRosterLineItem, results []OneRosterResult) ([]byte, error) {
+	stamp := func(t time.Time) string { return t.UTC().Format("2006-01-02T15:04:05.000Z") }
+	day := func(t time.Time) string { return t.UTC().Format("2006-01-02") }
+	num := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+
+	manifest := [][]string{{"propertyName", "value"}, {"manifest.version", "1.0"}, {"oneroster.version", "1.2"}}
+	for _, f := range []string{"academicSessions", "classes", "courses", "demographics", "enrollments", "orgs", "resources", "users"} {
+		manifest = append(manifest, []string{"file." + f, OneRosterAbsent})
+	}
+	manifest = append(manifest,
+		[]string{"file.categories", OneRosterBulk},
+		[]string{"file.lineItems", OneRosterBulk},
+		[]string{"file.results", OneRosterBulk},
+		[]string{"source.systemName", "Pragma"},
+	)
+
+	cats := [][]string{{"sourcedId", "status", "dateLastModified", "title"}}
+	for _, c := range categories {
+		cats = append(cats, []string{c.SourcedID, "active", stamp(c.DateLastModified), c.Title})
+	}
+
+	items := [][]string{{"sourcedId", "status", "dateLastModified", "title", "description", "assignDate", "dueDate",
+		"classSourcedId", "categorySourcedId", "gradingPeriodSourcedId", "resultValueMin", "resultValueMax"}}
+	for _, li := range lineItems {
+		due := ""
+		if li.DueDate != nil {
+			due = day(*li.DueDate)
+		}
+		items = append(items, []string{li.SourcedID, "active", stamp(li.DateLastModified), li.Title, li.Description,
+			day(li.AssignDate), due, li.ClassSourcedID, li.CategorySourcedID, li.GradingPeriodSourcedID,
+			"0", num(li.ResultValueMax)})
+	}
+
+	res := [][]string{{"sourcedId", "status", "dateLastModified", "lineItemSourcedId", "studentSourcedId",
+		"scoreStatus", "score", "scoreDate", "comment"}}
+	for _, r := range results {
+		score := ""
+		if r.Score != nil {
+			score = num(*r.Score)
+		}
+		res = append(res, []string{r.SourcedID, "active", stamp(r.DateLastModified), r.LineItemSourcedID,
+			r.StudentSourcedID, r.ScoreStatus, score, day(r.ScoreDate), r.Comment})
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range []struct {
+		name string
+		rows [][]string
+	}{
+		{"manifest.csv", manifest},
+		{"categories.csv", cats},
+		{"lineItems.csv", items},
+		{"results.csv", res},
+	} {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(f.rows); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -2,16 +2,24 @@ package services
 
 import (
 	"bytes"
+	_ "embed"
 	"fmt"
-	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/go-pdf/fpdf"
+	qrcode "github.com/skip2/go-qrcode"
+
 	"github.com/pragma-proto/api/internal/models"
 )
 
 // PDFService generates PDF documents server-side.
-// It uses HTML templates rendered to bytes, which can be converted to PDF
-// via chromedp (headless Chrome) or a similar tool.
+// Rendering is pure Go (go-pdf/fpdf) with DejaVu Sans compiled into the binary,
+// so no headless browser or font files are needed at runtime, and names in
+// Greek, Cyrillic, Hebrew, Arabic, and other scripts it covers print as
+// written.
 type PDFService struct{}
 
 // NewPDFService creates a PDFService.
@@ -26,20 +34,50 @@ type ReportCardData struct {
 	StudentUser     *models.User
 	AcademicPeriod  string
 	GPA             float64
+	TermGPA         *models.GPAResult // nil to omit GPA detail
+	CumulativeGPA   *models.GPAResult
 	CourseGrades    []CourseGradeRow
 	TeacherComments string
 	AdminComments   string
+	Attendance      *models.AttendanceSummary
 	GeneratedAt     time.Time
 	IsFinalized     bool
+	Template        string // template body; empty for the built-in
+
+	// Image bytes (PNG, JPEG, or GIF); nil to omit.
+	Logo      []byte
+	Signature []byte
 }
 
 // CourseGradeRow is one line in a report card.
 type CourseGradeRow struct {
 	CourseName   string
 	TeacherName  string
+	Credits      float64
+	Level        string
 	Percentage   float64
 	LetterGrade  string
 	Comment      string
+	Standards    []models.StandardMastery // set for standards-based courses instead of a grade
+}
+
+// Transcript is a student's complete academic record, oldest term first.
+type Transcript struct {
+	Terms            []TranscriptTerm
+	Cumulative       models.GPAResult
+	CreditsAttempted float64
+	CreditsEarned    float64 // includes standards-based courses
+	CreditsRequired  float64 // 0 when the school sets no graduation requirement
+	GraduationStatus string
+}
+
+// TranscriptTerm is one term's completed courses.
+type TranscriptTerm struct {
+	Name          string
+	Courses       []CourseGradeRow
+	CreditsEarned float64
+	GPA           models.GPAResult
+	Cumulative    models.GPAResult // through this term
 }
 
 // DocumentData holds data for generating enrollment certs / attendance letters.
@@ -53,168 +91,639 @@ type DocumentData struct {
 	GeneratedAt      time.Time
 	ExpiresAt        *time.Time
 	CustomContent    string // for custom document type
+	Attendance       *models.AttendanceSummary // for attendance letters
 	SignatoryName    string
 	SignatoryTitle   string
+	Template         string // template body; empty for the built-in
+
+	// GPA detail for academic standing letters and transcripts; nil to omit.
+	TermGPA       *models.GPAResult
+	CumulativeGPA *models.GPAResult
+	Transcript    *Transcript
+
+	// Image bytes (PNG, JPEG, or GIF); nil to omit.
+	Logo      []byte
+	Signature []byte
 }
 
-// RenderReportCardHTML renders a report card as an HTML string.
-// In production this would be fed to chromedp to produce a PDF.
-func (s *PDFService) RenderReportCardHTML(data ReportCardData) ([]byte, error) {
-	const tmpl = `<!DOCTYPE html>
-<html>
-<head>
-<meta charset="utf-8">
-<title>Report Card — {{.Student.StudentNumber}}</title>
-<style>
-  body { font-family: Inter, sans-serif; font-size: 12pt; color: #1e293b; margin: 2cm; }
-  .header { display: flex; align-items: center; margin-bottom: 24px; }
-  .school-name { font-size: 20pt; font-weight: bold; }
-  .student-info { margin-bottom: 20px; }
-  table { width: 100%; border-collapse: collapse; margin-top: 16px; }
-  th { background: #f1f5f9; text-align: left; padding: 8px; border: 1px solid #e2e8f0; }
-  td { padding: 8px; border: 1px solid #e2e8f0; }
-  .gpa { font-size: 16pt; font-weight: bold; margin-top: 16px; }
-  .comments { margin-top: 24px; border-top: 1px solid #e2e8f0; padding-top: 16px; }
-  .footer { margin-top: 40px; font-size: 9pt; color: #64748b; }
-  .verification { font-size: 9pt; margin-top: 8px; }
-</style>
-</head>
-<body>
-<div class="header">
-  {{if .School.LogoURL}}<img src="{{.School.LogoURL}}" height="60" style="margin-right:16px">{{end}}
-  <div>
-    <div class="school-name">{{.School.Name}}</div>
-    <div>Report Card — {{.AcademicPeriod}}</div>
-  </div>
-</div>
-
-<div class="student-info">
-  <strong>Student:</strong> {{.StudentUser.FirstName}} {{.StudentUser.LastName}}<br>
-  <strong>Student Number:</strong> {{.Student.StudentNumber}}<br>
-  <strong>Grade Level:</strong> {{.Student.GradeLevel}}<br>
-  <strong>Generated:</strong> {{.GeneratedAt.Format "January 2, 2006"}}
-  {{if .IsFinalized}}<span style="color:#16a34a;font-weight:bold;"> — FINALIZED</span>{{end}}
-</div>
-
-<table>
-  <thead>
-    <tr>
-      <th>Course</th>
-      <th>Teacher</th>
-      <th>%</th>
-      <th>Grade</th>
-      <th>Comment</th>
-    </tr>
-  </thead>
-  <tbody>
-  {{range .CourseGrades}}
-    <tr>
-      <td>{{.CourseName}}</td>
-      <td>{{.TeacherName}}</td>
-      <td>{{printf "%.1f" .Percentage}}%</td>
-      <td><strong>{{.LetterGrade}}</strong></td>
-      <td>{{.Comment}}</td>
-    </tr>
-  {{end}}
-  </tbody>
-</table>
-
-<div class="gpa">Cumulative GPA: {{printf "%.3f" .GPA}}</div>
-
-{{if .TeacherComments}}
-<div class="comments">
-  <strong>Teacher Comments</strong><br>{{.TeacherComments}}
-</div>
-{{end}}
-
-{{if .AdminComments}}
-<div class="comments">
-  <strong>Administration Note</strong><br>{{.AdminComments}}
-</div>
-{{end}}
-
-<div class="footer">
-  <p>This document was generated on {{.GeneratedAt.Format "January 2, 2006 at 3:04 PM"}}.</p>
-  {{if .School.Settings.SignatoryName}}
-  <p>{{.School.Settings.SignatoryName}}, {{.School.Settings.SignatoryTitle}}</p>
-  {{end}}
-</div>
-</body>
-</html>`
-
-	t, err := template.New("report_card").Parse(tmpl)
-	if err != nil {
-		return nil, fmt.Errorf("pdf: parse report card template: %w", err)
+// pdfMargin is the page margin in millimetres (US Letter).
+const pdfMargin = 20.0
+
+// pdfFont is the UTF-8 font family every PDF is set in. Only its regular
+// and bold styles are loaded.
+const pdfFont = "DejaVuSans"
+
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	pdfFontRegular []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	pdfFontBold []byte
+)
+
+// pdfDoc wraps an fpdf document with the school's branding.
+type pdfDoc struct {
+	*fpdf.Fpdf
+	accent [3]int
+}
+
+func newPDFDoc(schoolName, accentHex, title string, generatedAt time.Time) *pdfDoc {
+	f := fpdf.New("P", "mm", "Letter", "")
+	f.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	f.SetAutoPageBreak(true, pdfMargin)
+	f.AliasNbPages("{nb}")
+	f.SetCreator("Pragma", true)
+	f.SetTitle(title, true)
+	f.SetAuthor(schoolName, true)
+
+	f.AddUTF8FontFromBytes(pdfFont, "", pdfFontRegular)
+	f.AddUTF8FontFromBytes(pdfFont, "B", pdfFontBold)
+
+	d := &pdfDoc{Fpdf: f, accent: [3]int{30, 41, 59}}
+	if rgb, ok := parseHexColor(accentHex); ok {
+		d.accent = rgb
+	}
+
+	f.SetFooterFunc(func() {
+		f.SetY(-pdfMargin + 5)
+		f.SetFont(pdfFont, "", 8)
+		f.SetTextColor(100, 116, 139)
+		f.CellFormat(0, 5, fmt.Sprintf("Generated %s", generatedAt.Format("January 2, 2006 at 3:04 PM")),
+			"", 0, "L", false, 0, "")
+		f.SetX(pdfMargin)
+		f.CellFormat(0, 5, fmt.Sprintf("Page %d of {nb}", f.PageNo()), "", 0, "R", false, 0, "")
+	})
+	return d
+}
+
+// text writes a wrapped paragraph at the current position.
+func (d *pdfDoc) text(style string, size float64, s string) {
+	d.SetFont(pdfFont, style, size)
+	d.SetTextColor(30, 41, 59)
+	d.MultiCell(0, size*0.45, s, "", "L", false)
+}
+
+// heading writes a bold line in the school's accent colour.
+func (d *pdfDoc) heading(size float64, s string, align string) {
+	d.SetFont(pdfFont, "B", size)
+	d.SetTextColor(d.accent[0], d.accent[1], d.accent[2])
+	d.CellFormat(0, size*0.5, s, "", 1, align, false, 0, "")
+}
+
+// image places an image with the given height at (x, y), keeping its aspect
+// ratio, and returns the width used. Unsupported or corrupt images are skipped.
+func (d *pdfDoc) image(name string, data []byte, x, y, h float64) float64 {
+	w := d.imageWidth(name, data, h)
+	if w > 0 {
+		d.ImageOptions(name, x, y, w, h, false, fpdf.ImageOptions{ImageType: imageType(data)}, 0, "")
+	}
+	return w
+}
+
+// imageWidth registers an image and returns its width at height h, or 0 when
+// it is unsupported or corrupt.
+func (d *pdfDoc) imageWidth(name string, data []byte, h float64) float64 {
+	kind := imageType(data)
+	if kind == "" {
+		return 0
+	}
+	info := d.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: kind}, bytes.NewReader(data))
+	if d.Err() || info == nil || info.Height() == 0 {
+		// A bad image must not fail the whole document.
+		d.ClearError()
+		return 0
+	}
+	return h * info.Width() / info.Height()
+}
+
+// ensureSpace starts a new page when fewer than h millimetres remain.
+func (d *pdfDoc) ensureSpace(h float64) bool {
+	_, pageH := d.GetPageSize()
+	if d.GetY()+h > pageH-pdfMargin {
+		d.AddPage()
+		return true
+	}
+	return false
+}
+
+// table draws a bordered table whose cells wrap. The header row repeats on
+// every page the table spans.
+func (d *pdfDoc) table(headers []string, widths []float64, aligns []string, rows [][]string) {
+	const cellPad = 1.5
+	lineH := 5.0
+
+	drawHeader := func() {
+		d.SetFont(pdfFont, "B", 9)
+		d.SetFillColor(241, 245, 249)
+		d.SetDrawColor(226, 232, 240)
+		d.SetTextColor(30, 41, 59)
+		for i, h := range headers {
+			d.CellFormat(widths[i], 7, h, "1", 0, aligns[i], true, 0, "")
+		}
+		d.Ln(-1)
+	}
+
+	d.ensureSpace(14)
+	drawHeader()
+	d.SetFont(pdfFont, "", 9)
+
+	for _, row := range rows {
+		// Row height follows the tallest wrapped cell.
+		lines := make([][][]byte, len(row))
+		maxLines := 1
+		for i, cell := range row {
+			lines[i] = d.SplitLines([]byte(cell), widths[i]-2*cellPad)
+			if len(lines[i]) > maxLines {
+				maxLines = len(lines[i])
+			}
+		}
+		rowH := float64(maxLines)*lineH + 2
+
+		if d.ensureSpace(rowH) {
+			drawHeader()
+			d.SetFont(pdfFont, "", 9)
+		}
+
+		x, y := d.GetX(), d.GetY()
+		for i := range row {
+			d.Rect(x, y, widths[i], rowH, "D")
+			for j, line := range lines[i] {
+				d.SetXY(x+cellPad, y+1+float64(j)*lineH)
+				d.CellFormat(widths[i]-2*cellPad, lineH, string(line), "", 0, aligns[i], false, 0, "")
+			}
+			x += widths[i]
+		}
+		d.SetXY(pdfMargin, y+rowH)
+	}
+	d.Ln(4)
+}
+
+// signature draws the signature image (if any) above a rule and the signatory's name.
+func (d *pdfDoc) signature(img []byte, name, title string) {
+	d.ensureSpace(40)
+	d.Ln(6)
+	if len(img) > 0 {
+		y := d.GetY()
+		if w := d.image("signature", img, pdfMargin, y, 16); w > 0 {
+			d.SetY(y + 17)
+		}
+	}
+	y := d.GetY()
+	d.SetDrawColor(100, 116, 139)
+	d.Line(pdfMargin, y, pdfMargin+70, y)
+	d.Ln(2)
+	if name != "" {
+		d.text("B", 10, name)
+		if title != "" {
+			d.text("", 10, title)
+		}
 	}
+}
 
+func (d *pdfDoc) bytes() ([]byte, error) {
 	var buf bytes.Buffer
-	if err := t.Execute(&buf, data); err != nil {
-		return nil, fmt.Errorf("pdf: execute report card template: %w", err)
+	if err := d.Output(&buf); err != nil {
+		return nil, err
 	}
 	return buf.Bytes(), nil
 }
 
-// RenderDocumentHTML renders an official document (enrollment cert, etc.) as HTML.
-func (s *PDFService) RenderDocumentHTML(data DocumentData) ([]byte, error) {
-	const tmpl = `<!DOCTYPE html>
-<html>
-<head>
-<meta charset="utf-8">
-<title>{{.DocumentType}} — {{.School.Name}}</title>
-<style>
-  body { font-family: Inter, sans-serif; font-size: 12pt; color: #1e293b; margin: 3cm; }
-  .school-header { text-align: center; margin-bottom: 40px; }
-  .school-name { font-size: 20pt; font-weight: bold; }
-  .title { font-size: 16pt; text-align: center; margin: 32px 0; font-weight: bold; text-transform: uppercase; letter-spacing: 0.05em; }
-  .body { line-height: 1.8; margin: 24px 0; }
-  .signature-block { margin-top: 60px; }
-  .verification { margin-top: 40px; font-size: 9pt; color: #64748b; border-top: 1px solid #e2e8f0; padding-top: 16px; }
-  .qr-hint { font-size: 8pt; }
-</style>
-</head>
-<body>
-<div class="school-header">
-  {{if .School.LogoURL}}<img src="{{.School.LogoURL}}" height="80"><br>{{end}}
-  <div class="school-name">{{.School.Name}}</div>
-  {{if .School.Address}}<div>{{.School.Address}}</div>{{end}}
-</div>
-
-<div class="title">{{.DocumentType}}</div>
-
-<div class="body">
-  <p>This is to certify that <strong>{{.StudentUser.FirstName}} {{.StudentUser.LastName}}</strong>
-  (Student Number: {{.Student.StudentNumber}}, Grade {{.Student.GradeLevel}})
-  is {{if eq .Student.EnrollmentStatus "active"}}currently enrolled{{else}}{{.Student.EnrollmentStatus}}{{end}}
-  at {{.School.Name}}.</p>
-
-  {{if .CustomContent}}<p>{{.CustomContent}}</p>{{end}}
-
-  <p>This document was issued on {{.GeneratedAt.Format "January 2, 2006"}}
-  {{if .ExpiresAt}}and is valid through {{.ExpiresAt.Format "January 2, 2006"}}{{end}}.</p>
-</div>
-
-<div class="signature-block">
-  <p>____________________________</p>
-  {{if .SignatoryName}}<p>{{.SignatoryName}}<br>{{.SignatoryTitle}}</p>{{end}}
-</div>
-
-<div class="verification">
-  <p><strong>Document Verification</strong></p>
-  <p>To verify the authenticity of this document, visit:<br>
-  <strong>{{.VerificationURL}}</strong></p>
-  <p class="qr-hint">Verification Code: {{.VerificationCode}}</p>
-</div>
-</body>
-</html>`
-
-	t, err := template.New("document").Parse(tmpl)
+// Branding is the school's visual identity applied around template output.
+type Branding struct {
+	PrimaryColor string
+	Logo         []byte // PNG, JPEG, or GIF; nil to omit
+	Signature    []byte
+}
+
+// RenderReportCardPDF renders a report card as a paginated PDF. The body
+// comes from data.Template, or the built-in template when that is empty.
+func (s *PDFService) RenderReportCardPDF(data ReportCardData) ([]byte, error) {
+	return s.RenderTemplatePDF(TemplateKindReportCard, data.Template, ReportCardTemplateData(data), Branding{
+		PrimaryColor: data.School.Settings.PrimaryColor,
+		Logo:         data.Logo,
+		Signature:    data.Signature,
+	})
+}
+
+// RenderDocumentPDF renders an official document (enrollment cert, etc.) with
+// a verification QR code linking to VerificationURL. The body comes from
+// data.Template, or the built-in template when that is empty.
+func (s *PDFService) RenderDocumentPDF(data DocumentData) ([]byte, error) {
+	return s.RenderTemplatePDF(data.DocumentType, data.Template, DocumentTemplateData(data), Branding{
+		PrimaryColor: data.School.Settings.PrimaryColor,
+		Logo:         data.Logo,
+		Signature:    data.Signature,
+	})
+}
+
+// RenderTemplatePDF renders template data for a kind. Report cards get a
+// left-aligned header; documents get a centred letterhead and a verification
+// block. Everything between comes from the template, so a stored TemplateData
+// snapshot and template version reproduce the original text exactly.
+func (s *PDFService) RenderTemplatePDF(kind, body string, td TemplateData, b Branding) ([]byte, error) {
+	if body == "" {
+		body = BuiltinTemplate(kind)
+	}
+	markup, err := ExecuteTemplate(body, td)
 	if err != nil {
-		return nil, fmt.Errorf("pdf: parse document template: %w", err)
+		return nil, fmt.Errorf("pdf: %s template: %w", kind, err)
 	}
 
-	var buf bytes.Buffer
-	if err := t.Execute(&buf, data); err != nil {
-		return nil, fmt.Errorf("pdf: execute document template: %w", err)
+	if kind == TemplateKindReportCard {
+		d := newPDFDoc(td.School.Name, b.PrimaryColor, "Report Card — "+td.AcademicPeriod, td.GeneratedAt)
+		d.AddPage()
+
+		// Header: logo beside school name.
+		x := pdfMargin
+		if w := d.image("logo", b.Logo, pdfMargin, pdfMargin, 18); w > 0 {
+			x += w + 5
+		}
+		d.SetXY(x, pdfMargin+5)
+		d.heading(18, td.School.Name, "L")
+		d.SetY(pdfMargin + 24)
+
+		d.markup(markup, b.Signature, td.SignatoryName, td.SignatoryTitle)
+
+		out, err := d.bytes()
+		if err != nil {
+			return nil, fmt.Errorf("pdf: render report card: %w", err)
+		}
+		return out, nil
 	}
-	return buf.Bytes(), nil
+
+	d := newPDFDoc(td.School.Name, b.PrimaryColor, td.DocumentTitle+" — "+td.School.Name, td.GeneratedAt)
+	d.AddPage()
+
+	pageW, _ := d.GetPageSize()
+
+	// Centred letterhead.
+	const logoH = 22.0
+	if w := d.imageWidth("logo", b.Logo, logoH); w > 0 {
+		d.image("logo", b.Logo, (pageW-w)/2, pdfMargin, logoH)
+		d.SetY(pdfMargin + logoH + 4)
+	}
+	d.heading(18, td.School.Name, "C")
+	if td.School.Address != "" {
+		d.SetFont(pdfFont, "", 10)
+		d.SetTextColor(71, 85, 105)
+		d.CellFormat(0, 5, td.School.Address, "", 1, "C", false, 0, "")
+	}
+	d.Ln(14)
+
+	d.markup(markup, b.Signature, td.SignatoryName, td.SignatoryTitle)
+
+	// Verification block: QR code beside the URL and code.
+	d.ensureSpace(40)
+	d.Ln(10)
+	y := d.GetY()
+	d.SetDrawColor(226, 232, 240)
+	d.Line(pdfMargin, y, pageW-pdfMargin, y)
+	y += 4
+	textX := pdfMargin
+	if td.VerificationURL != "" {
+		if png, err := qrcode.Encode(td.VerificationURL, qrcode.Medium, 256); err == nil {
+			if w := d.image("qr", png, pdfMargin, y, 28); w > 0 {
+				textX += w + 6
+			}
+		}
+	}
+	d.SetXY(textX, y+2)
+	d.SetFont(pdfFont, "B", 9)
+	d.SetTextColor(71, 85, 105)
+	d.CellFormat(0, 5, "Document Verification", "", 2, "L", false, 0, "")
+	d.SetFont(pdfFont, "", 9)
+	d.CellFormat(0, 5, "Scan the code or visit:", "", 2, "L", false, 0, "")
+	d.CellFormat(0, 5, td.VerificationURL, "", 2, "L", false, 0, td.VerificationURL)
+	d.SetFont(pdfFont, "", 8)
+	d.CellFormat(0, 5, "Verification Code: "+td.VerificationCode, "", 2, "L", false, 0, "")
+
+	out, err := d.bytes()
+	if err != nil {
+		return nil, fmt.Errorf("pdf: render document: %w", err)
+	}
+	return out, nil
+}
+
+// markup lays out template output; see templates.go for the syntax.
+func (d *pdfDoc) markup(src string, sigImage []byte, sigName, sigTitle string) {
+	var para []string
+	var rows [][]string
+	flushPara := func() {
+		if len(para) > 0 {
+			d.text("", 11, strings.Join(para, "\n"))
+			d.Ln(4)
+			para = nil
+		}
+	}
+	flushTable := func() {
+		if len(rows) > 0 {
+			d.autoTable(rows)
+			rows = nil
+		}
+	}
+
+	for _, line := range strings.Split(src, "\n") {
+		// Only ASCII blanks are trimmed; escaped values keep their leading no-break space.
+		t := strings.Trim(line, " \t")
+		if !strings.HasPrefix(t, "|") {
+			flushTable()
+		}
+		switch {
+		case t == "":
+			flushPara()
+		case strings.HasPrefix(t, "|"):
+			flushPara()
+			cells := strings.Split(strings.Trim(t, "|"), "|")
+			for i := range cells {
+				cells[i] = strings.TrimSpace(cells[i])
+			}
+			rows = append(rows, cells)
+		case strings.HasPrefix(t, "### "), strings.HasPrefix(t, "**"):
+			flushPara()
+			d.text("B", 11, strings.Trim(strings.TrimPrefix(t, "### "), "*"))
+			d.Ln(1)
+		case strings.HasPrefix(t, "## "):
+			flushPara()
+			d.ensureSpace(16)
+			d.Ln(2)
+			d.heading(12, strings.TrimPrefix(t, "## "), "L")
+			d.Ln(2)
+		case strings.HasPrefix(t, "# "):
+			flushPara()
+			d.heading(15, strings.ToUpper(strings.TrimPrefix(t, "# ")), "C")
+			d.Ln(10)
+		case t == "---":
+			flushPara()
+			pageW, _ := d.GetPageSize()
+			y := d.GetY() + 2
+			d.SetDrawColor(226, 232, 240)
+			d.Line(pdfMargin, y, pageW-pdfMargin, y)
+			d.SetY(y + 4)
+		case t == "@signature":
+			flushPara()
+			d.signature(sigImage, sigName, sigTitle)
+		case t == "@pagebreak":
+			flushPara()
+			d.AddPage()
+		default:
+			para = append(para, t)
+		}
+	}
+	flushPara()
+	flushTable()
+}
+
+// DocumentTitle turns a document type such as "enrollment_certificate" into
+// "Enrollment Certificate". Transcripts are titled "Official Transcript".
+func DocumentTitle(docType string) string {
+	if docType == TemplateKindTranscript {
+		return "Official Transcript"
+	}
+	words := strings.Split(docType, "_")
+	for i, w := range words {
+		if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// autoTable sizes columns to their content and draws rows[0] as the header.
+// Numeric columns are right-aligned.
+func (d *pdfDoc) autoTable(rows [][]string) {
+	pageW, _ := d.GetPageSize()
+	avail := pageW - 2*pdfMargin
+	cols := len(rows[0])
+	for i, r := range rows {
+		for len(r) < cols {
+			r = append(r, "")
+		}
+		rows[i] = r[:cols]
+	}
+
+	natural := make([]float64, cols)
+	aligns := make([]string, cols)
+	for c := 0; c < cols; c++ {
+		numeric := len(rows) > 1
+		for i, r := range rows {
+			style := ""
+			if i == 0 {
+				style = "B"
+			}
+			d.SetFont(pdfFont, style, 9)
+			if w := d.GetStringWidth(r[c]) + 4; w > natural[c] {
+				natural[c] = w
+			}
+			if i > 0 && r[c] != "" && !isNumericCell(r[c]) {
+				numeric = false
+			}
+		}
+		aligns[c] = "L"
+		if numeric {
+			aligns[c] = "R"
+		}
+	}
+
+	// Columns narrower than an even share keep their natural width; the
+	// rest split what remains in proportion to their content.
+	widths := make([]float64, cols)
+	var total float64
+	for _, w := range natural {
+		total += w
+	}
+	if total <= avail {
+		for c, w := range natural {
+			widths[c] = w * avail / total
+		}
+	} else {
+		share := avail / float64(cols)
+		remaining, wide := avail, 0.0
+		for c, w := range natural {
+			if w <= share {
+				widths[c] = w
+				remaining -= w
+			} else {
+				wide += w
+			}
+		}
+		for c, w := range natural {
+			if w > share {
+				widths[c] = w * remaining / wide
+			}
+		}
+	}
+
+	d.table(rows[0], widths, aligns, rows[1:])
+}
+
+// isNumericCell reports whether a table cell holds a number, percentage, or dash.
+func isNumericCell(s string) bool {
+	s = strings.TrimSuffix(s, "%")
+	if s == "—" || s == "-" {
+		return true
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// ReportCardTemplateData maps report card data onto the template contract.
+func ReportCardTemplateData(data ReportCardData) TemplateData {
+	td := baseTemplateData(data.School, data.Student, data.StudentUser, data.Attendance, data.GeneratedAt)
+	td.SignatoryName = data.School.Settings.SignatoryName
+	td.SignatoryTitle = data.School.Settings.SignatoryTitle
+	td.AcademicPeriod = data.AcademicPeriod
+	td.GPA = data.GPA
+	td.TermGPA = templateGPA(data.TermGPA)
+	td.CumulativeGPA = templateGPA(data.CumulativeGPA)
+	td.TeacherComments = data.TeacherComments
+	td.AdminComments = data.AdminComments
+	td.IsFinalized = data.IsFinalized
+	td.Courses = make([]TemplateCourse, 0, len(data.CourseGrades))
+	for _, g := range data.CourseGrades {
+		c := templateCourse(g)
+		td.Courses = append(td.Courses, c)
+		if c.StandardsBased {
+			td.StandardsCourses = append(td.StandardsCourses, c)
+		} else {
+			td.PointsCourses = append(td.PointsCourses, c)
+		}
+	}
+	return td
+}
+
+// DocumentTemplateData maps document data onto the template contract.
+func DocumentTemplateData(data DocumentData) TemplateData {
+	td := baseTemplateData(data.School, data.Student, data.StudentUser, data.Attendance, data.GeneratedAt)
+	td.SignatoryName = data.SignatoryName
+	td.SignatoryTitle = data.SignatoryTitle
+	td.DocumentType = data.DocumentType
+	td.DocumentTitle = DocumentTitle(data.DocumentType)
+	td.VerificationCode = data.VerificationCode
+	td.VerificationURL = data.VerificationURL
+	td.ExpiresAt = data.ExpiresAt
+	td.CustomContent = data.CustomContent
+	td.TermGPA = templateGPA(data.TermGPA)
+	td.CumulativeGPA = templateGPA(data.CumulativeGPA)
+	if t := data.Transcript; t != nil {
+		td.Transcript = &TemplateTranscript{
+			Terms:            make([]TemplateTranscriptTerm, 0, len(t.Terms)),
+			CreditsAttempted: t.CreditsAttempted,
+			CreditsEarned:    t.CreditsEarned,
+			CreditsRequired:  t.CreditsRequired,
+			GraduationStatus: t.GraduationStatus,
+		}
+		for _, term := range t.Terms {
+			tt := TemplateTranscriptTerm{
+				Name:          term.Name,
+				CreditsEarned: term.CreditsEarned,
+				GPA:           *templateGPA(&term.GPA),
+				Cumulative:    *templateGPA(&term.Cumulative),
+			}
+			for _, g := range term.Courses {
+				tt.Courses = append(tt.Courses, templateCourse(g))
+			}
+			td.Transcript.Terms = append(td.Transcript.Terms, tt)
+		}
+		td.CumulativeGPA = templateGPA(&t.Cumulative)
+	}
+	return td
+}
+
+func templateCourse(g CourseGradeRow) TemplateCourse {
+	c := TemplateCourse{
+		Name:           g.CourseName,
+		Teacher:        g.TeacherName,
+		Credits:        g.Credits,
+		Level:          g.Level,
+		Percentage:     g.Percentage,
+		LetterGrade:    g.LetterGrade,
+		Graded:         g.LetterGrade != "N/A",
+		Comment:        g.Comment,
+		StandardsBased: g.Standards != nil,
+	}
+	for _, m := range g.Standards {
+		c.Standards = append(c.Standards, TemplateStandard{
+			Code:        m.Code,
+			Description: m.Description,
+			Score:       m.Score,
+			Level:       m.Level,
+			Label:       m.Label,
+			Assessed:    m.Evidence > 0,
+		})
+	}
+	return c
+}
+
+func templateGPA(g *models.GPAResult) *TemplateGPA {
+	if g == nil {
+		return nil
+	}
+	return &TemplateGPA{
+		Method:           g.Method,
+		GPA:              g.GPA,
+		Unweighted:       g.Unweighted,
+		Weighted:         g.Weighted,
+		CreditsAttempted: g.CreditsAttempted,
+		CreditsEarned:    g.CreditsEarned,
+	}
+}
+
+func baseTemplateData(school *models.School, student *models.Student, user *models.User, att *models.AttendanceSummary, generatedAt time.Time) TemplateData {
+	td := TemplateData{
+		School: TemplateSchool{Name: school.Name},
+		Student: TemplateStudent{
+			FirstName:        user.FirstName,
+			LastName:         user.LastName,
+			FullName:         user.FirstName + " " + user.LastName,
+			StudentNumber:    student.StudentNumber,
+			GradeLevel:       student.GradeLevel,
+			EnrollmentStatus: student.EnrollmentStatus,
+		},
+		GeneratedAt: generatedAt,
+	}
+	if school.Address != nil {
+		td.School.Address = *school.Address
+	}
+	if att != nil {
+		td.Attendance = &TemplateAttendance{
+			DaysRecorded: att.DaysRecorded,
+			Present:      att.Present,
+			Absent:       att.Absent,
+			Tardy:        att.Tardy,
+			Excused:      att.Excused,
+			Rate:         att.Rate,
+		}
+	}
+	return td
+}
+
+// imageType maps sniffed image bytes to an fpdf image type, or "" if unsupported.
+func imageType(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	switch http.DetectContentType(data) {
+	case "image/png":
+		return "PNG"
+	case "image/jpeg":
+		return "JPG"
+	case "image/gif":
+		return "GIF"
+	}
+	return ""
+}
+
+// parseHexColor parses "#rrggbb" or "#rgb".
+func parseHexColor(s string) ([3]int, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return [3]int{}, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return [3]int{}, false
+	}
+	return [3]int{int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff)}, true
 }
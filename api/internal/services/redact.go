@@ -0,0 +1,302 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Redaction kinds, also used in placeholders such as [NAME_1].
+const (
+	RedactName          = "NAME"
+	RedactEmail         = "EMAIL"
+	RedactPhone         = "PHONE"
+	RedactAddress       = "ADDRESS"
+	RedactDateOfBirth   = "DOB"
+	RedactStudentNumber = "STUDENT_NUMBER"
+)
+
+// RosterPerson is one person whose details must never reach an AI provider.
+type RosterPerson struct {
+	FirstName     string
+	LastName      string
+	Phone         string
+	StudentNumber string
+}
+
+// Redactor finds PII in text bound for an AI provider: names from the
+// school roster (first, last, or full), emails, phone numbers (common
+// formats, plus roster numbers written any way), street addresses, dates of
+// birth, and student numbers exactly as on the roster. A Redactor is
+// read-only once built and safe to share; the placeholders for one AI call
+// live in a PIIMap.
+type Redactor struct {
+	names   map[string]bool // lowercased name parts
+	numbers map[string]bool // lowercased student numbers
+	phones  map[string]bool // roster phone numbers, digits only
+	now     time.Time
+}
+
+// nameStopWords are roster name parts that are also common English words.
+// They match only when capitalized (Will, not will), so ordinary prose keeps
+// them; other name parts match in any case.
+var nameStopWords = map[string]bool{
+	"an": true, "and": true, "as": true, "at": true, "be": true, "by": true, "do": true, "go": true,
+	"he": true, "in": true, "is": true, "it": true, "me": true, "my": true, "no": true, "of": true,
+	"on": true, "or": true, "so": true, "the": true, "to": true, "up": true, "us": true, "we": true,
+	"april": true, "august": true, "autumn": true, "bell": true, "best": true, "bill": true,
+	"black": true, "brown": true, "case": true, "chase": true, "dawn": true, "day": true,
+	"drew": true, "faith": true, "field": true, "ford": true, "frank": true, "grace": true,
+	"gray": true, "green": true, "grey": true, "hall": true, "hill": true, "hope": true,
+	"hunt": true, "ivy": true, "jack": true, "joy": true, "june": true, "king": true,
+	"lane": true, "little": true, "long": true, "love": true, "major": true, "mark": true,
+	"may": true, "miles": true, "page": true, "park": true, "price": true, "rich": true,
+	"rose": true, "sky": true, "small": true, "star": true, "summer": true, "sunny": true,
+	"white": true, "will": true, "winter": true, "wood": true, "young": true,
+}
+
+// NewRedactor indexes a roster. Name parts shorter than two letters are
+// ignored.
+func NewRedactor(roster []RosterPerson) *Redactor {
+	rd := &Redactor{
+		names:   make(map[string]bool),
+		numbers: make(map[string]bool),
+		phones:  make(map[string]bool),
+		now:     time.Now(),
+	}
+	for _, p := range roster {
+		for _, part := range nameTokenRE.FindAllString(p.FirstName+" "+p.LastName, -1) {
+			if len([]rune(part)) >= 2 {
+				rd.names[strings.ToLower(part)] = true
+			}
+		}
+		if d := digitsOnly(p.Phone); len(d) >= 7 {
+			rd.phones[d] = true
+		}
+		if n := strings.TrimSpace(p.StudentNumber); n != "" {
+			rd.numbers[strings.ToLower(n)] = true
+		}
+	}
+	return rd
+}
+
+var (
+	emailRE   = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phoneRE   = regexp.MustCompile(`(?:\+?1[\s.-]?)?(?:\(\d{3}\)\s?|\b\d{3}[\s.-])\d{3}[\s.-]\d{4}\b`)
+	digitsRE  = regexp.MustCompile(`\+?\d[\d\s().-]{5,}\d`)
+	addressRE = regexp.MustCompile(`\b\d{1,6}\s+(?:[A-Z][A-Za-z]*\.?\s+){1,4}` +
+		`(?:Street|St|Avenue|Ave|Road|Rd|Lane|Ln|Drive|Dr|Boulevard|Blvd|Court|Ct|Way|Place|Pl|` +
+		`Terrace|Ter|Circle|Cir|Parkway|Pkwy|Highway|Hwy)\b\.?` +
+		`(?:,?\s+(?:Apt|Unit|Suite|#)\.?\s*[A-Za-z0-9-]+)?|(?i:\bP\.?\s?O\.?\s+Box\s+\d+)`)
+	dateRE = regexp.MustCompile(`(?i)\b\d{1,2}[/.-]\d{1,2}[/.-](?:\d{4}|\d{2})\b|\b\d{4}-\d{1,2}-\d{1,2}\b|` +
+		`\b(?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.?\s+\d{1,2}(?:st|nd|rd|th)?,?\s+\d{4}\b|` +
+		`\b\d{1,2}(?:st|nd|rd|th)?\s+(?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.?,?\s+\d{4}\b`)
+	birthRE      = regexp.MustCompile(`(?i)\b(?:born|birth|birthday|dob|d\.o\.b)\b[^.\n]{0,25}$`)
+	yearRE       = regexp.MustCompile(`\d{4}`)
+	numberRE     = regexp.MustCompile(`[A-Za-z0-9][A-Za-z0-9-]*[A-Za-z0-9]`)
+	nameTokenRE  = regexp.MustCompile(`\p{L}+`)
+	nameGapRE    = regexp.MustCompile(`^[\s'’-]{1,3}$`)
+	namePrefixRE = regexp.MustCompile(`(?:^|[^\p{L}])(\p{L}['’])$`)
+	restoreRE    = regexp.MustCompile(`\[(?:NAME|EMAIL|PHONE|ADDRESS|DOB|STUDENT_NUMBER)_\d+\]`)
+)
+
+// digitsOnly strips everything but digits.
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// Redact replaces the PII in text with placeholders recorded in m, reusing
+// a placeholder when the same value appears again.
+func (rd *Redactor) Redact(text string, m *PIIMap) string {
+	text = replaceMatches(text, emailRE, m, func(string, string) string { return RedactEmail })
+	text = replaceMatches(text, addressRE, m, func(string, string) string { return RedactAddress })
+	text = replaceMatches(text, phoneRE, m, func(string, string) string { return RedactPhone })
+	if len(rd.phones) > 0 {
+		text = replaceMatches(text, digitsRE, m, func(match, _ string) string {
+			d := digitsOnly(match)
+			if rd.phones[d] || (len(d) == 11 && d[0] == '1' && rd.phones[d[1:]]) {
+				return RedactPhone
+			}
+			return ""
+		})
+	}
+	text = replaceMatches(text, dateRE, m, func(match, before string) string {
+		if birthRE.MatchString(before) {
+			return RedactDateOfBirth
+		}
+		// A bare date is treated as a birth date when its year is one a
+		// student or young staff member could have been born in.
+		if y := yearRE.FindString(match); y != "" {
+			year, _ := strconv.Atoi(y)
+			if year >= rd.now.Year()-25 && year <= rd.now.Year()-3 {
+				return RedactDateOfBirth
+			}
+		}
+		return ""
+	})
+	if len(rd.numbers) > 0 {
+		text = replaceMatches(text, numberRE, m, func(match, _ string) string {
+			if rd.numbers[strings.ToLower(match)] {
+				return RedactStudentNumber
+			}
+			return ""
+		})
+	}
+	return rd.redactNames(text, m)
+}
+
+// redactNames replaces runs of roster name parts, so "Jane", "doe", and
+// "Jane Doe" each become a single placeholder. Stop words count only when
+// capitalized.
+func (rd *Redactor) redactNames(text string, m *PIIMap) string {
+	if len(rd.names) == 0 {
+		return text
+	}
+	type span struct{ start, end int }
+	var spans []span
+	for _, loc := range nameTokenRE.FindAllStringIndex(text, -1) {
+		tok := text[loc[0]:loc[1]]
+		lower := strings.ToLower(tok)
+		if !rd.names[lower] || (nameStopWords[lower] && !unicode.IsUpper([]rune(tok)[0])) {
+			continue
+		}
+		if loc[0] > 0 && (text[loc[0]-1] == '[' || text[loc[0]-1] == '_') {
+			continue // one of our own placeholders
+		}
+		if n := len(spans); n > 0 && nameGapRE.MatchString(text[spans[n-1].end:loc[0]]) {
+			spans[n-1].end = loc[1]
+			continue
+		}
+		start, window := loc[0], max(0, loc[0]-8)
+		if p := namePrefixRE.FindStringSubmatchIndex(text[window:start]); p != nil {
+			start = window + p[2] // take in the O' of O'Brien
+		}
+		spans = append(spans, span{start, loc[1]})
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		b.WriteString(text[last:s.start])
+		b.WriteString(m.placeholder(RedactName, text[s.start:s.end]))
+		last = s.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// replaceMatches replaces each match of re for which kind returns a
+// non-empty kind. kind also sees the text just before the match.
+func replaceMatches(text string, re *regexp.Regexp, m *PIIMap, kind func(match, before string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		match := text[loc[0]:loc[1]]
+		k := kind(match, text[max(0, loc[0]-40):loc[0]])
+		if k == "" {
+			continue
+		}
+		b.WriteString(text[last:loc[0]])
+		b.WriteString(m.placeholder(k, match))
+		last = loc[1]
+	}
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Redaction records one redacted value by kind and placeholder. The value
+// itself is never included, so redactions are safe to log.
+type Redaction struct {
+	Kind        string `json:"kind"`
+	Placeholder string `json:"placeholder"`
+	Count       int    `json:"count"`
+}
+
+// PIIMap holds the placeholders issued while redacting one AI call so the
+// provider's reply can be restored.
+type PIIMap struct {
+	originals  map[string]string // placeholder → original
+	byOriginal map[string]string // kind + original → placeholder
+	counts     map[string]int    // per kind, for numbering
+	redactions []Redaction
+}
+
+// NewPIIMap creates an empty PIIMap.
+func NewPIIMap() *PIIMap {
+	return &PIIMap{
+		originals:  make(map[string]string),
+		byOriginal: make(map[string]string),
+		counts:     make(map[string]int),
+	}
+}
+
+func (m *PIIMap) placeholder(kind, original string) string {
+	key := kind + "\x00" + strings.ToLower(original)
+	if p, ok := m.byOriginal[key]; ok {
+		for i := range m.redactions {
+			if m.redactions[i].Placeholder == p {
+				m.redactions[i].Count++
+			}
+		}
+		return p
+	}
+	m.counts[kind]++
+	p := fmt.Sprintf("[%s_%d]", kind, m.counts[kind])
+	m.byOriginal[key] = p
+	m.originals[p] = original
+	m.redactions = append(m.redactions, Redaction{Kind: kind, Placeholder: p, Count: 1})
+	return p
+}
+
+// Restore puts the original values back in place of placeholders.
+func (m *PIIMap) Restore(text string) string {
+	if len(m.originals) == 0 {
+		return text
+	}
+	return restoreRE.ReplaceAllStringFunc(text, func(p string) string {
+		if original, ok := m.originals[p]; ok {
+			return original
+		}
+		return p
+	})
+}
+
+// Redactions lists what was redacted, in the order first seen.
+func (m *PIIMap) Redactions() []Redaction {
+	return append([]Redaction{}, m.redactions...)
+}
+
+// RedactionCounts totals redacted values by kind.
+func (m *PIIMap) RedactionCounts() map[string]int {
+	counts := make(map[string]int, len(m.counts))
+	for k, n := range m.counts {
+		counts[k] = n
+	}
+	return counts
+}
+
+// redactionSummary formats counts by kind for a log line, e.g. "EMAIL=1 NAME=3".
+func redactionSummary(counts map[string]int) string {
+	kinds := make([]string, 0, len(counts))
+	for k := range counts {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, len(kinds))
+	for i, k := range kinds {
+		parts[i] = fmt.Sprintf("%s=%d", k, counts[k])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func testRedactor() *Redactor {
+	rd := NewRedactor([]RosterPerson{
+		{FirstName: "Jane", LastName: "Doe", Phone: "(555) 123-4567", StudentNumber: "S-1042"},
+		{FirstName: "Will", LastName: "Hunt"},
+		{FirstName: "Siobhan", LastName: "O'Brien"},
+	})
+	rd.now = time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	return rd
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"full name", "Jane Doe turned in her essay.", "[NAME_1] turned in her essay."},
+		{"name parts in any case", "jane and DOE", "[NAME_1] and [NAME_2]"},
+		{"stop words only when capitalized", "Will Hunt will hunt for it.", "[NAME_1] will hunt for it."},
+		{"name with an apostrophe prefix", "Ask O'Brien.", "Ask [NAME_1]."},
+		{"email", "Write to jane.doe@example.org today", "Write to [EMAIL_1] today"},
+		{"phone", "Call 555-987-6543.", "Call [PHONE_1]."},
+		{"phone in parentheses", "Call (555) 987-6543.", "Call [PHONE_1]."},
+		{"roster phone without separators", "Text 5551234567.", "Text [PHONE_1]."},
+		{"roster phone with country code", "Text 15551234567.", "Text [PHONE_1]."},
+		{"other bare digits", "Order 5559990000 shipped.", "Order 5559990000 shipped."},
+		{"address", "She lives at 42 Maple Street, Apt 3.", "She lives at [ADDRESS_1]."},
+		{"PO box", "Mail to PO Box 12.", "Mail to [ADDRESS_1]."},
+		{"date in a student's birth years", "Due 3/14/2012.", "Due [DOB_1]."},
+		{"written date in a student's birth years", "On March 4, 2015 we met.", "On [DOB_1] we met."},
+		{"ordinary date", "Due 10/20/2026.", "Due 10/20/2026."},
+		{"date after a birth keyword", "DOB: 1990-01-02", "DOB: [DOB_1]"},
+		{"student number exactly", "S-1042, not S-10420", "[STUDENT_NUMBER_1], not S-10420"},
+		{"student number in any case", "ID s-1042", "ID [STUDENT_NUMBER_1]"},
+		{"nothing to redact", "The class read chapter 4.", "The class read chapter 4."},
+	}
+	rd := testRedactor()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewPIIMap()
+			got := rd.Redact(tc.text, m)
+			if got != tc.want {
+				t.Errorf("Redact(%q) = %q, want %q", tc.text, got, tc.want)
+			}
+			if restored := m.Restore(got); restored != tc.text {
+				t.Errorf("Restore(%q) = %q, want %q", got, restored, tc.text)
+			}
+		})
+	}
+}
+
+func TestRedactReusesPlaceholders(t *testing.T) {
+	rd := testRedactor()
+	m := NewPIIMap()
+	got := rd.Redact("Jane Doe wrote to Jane. Jane Doe replied.", m)
+	if want := "[NAME_1] wrote to [NAME_2]. [NAME_1] replied."; got != want {
+		t.Fatalf("Redact = %q, want %q", got, want)
+	}
+	// A later call with the same map keeps the numbering.
+	got = rd.Redact("jane doe emailed jane.doe@example.org", m)
+	if want := "[NAME_1] emailed [EMAIL_1]"; got != want {
+		t.Fatalf("second Redact = %q, want %q", got, want)
+	}
+
+	want := []Redaction{
+		{Kind: RedactName, Placeholder: "[NAME_1]", Count: 3},
+		{Kind: RedactName, Placeholder: "[NAME_2]", Count: 1},
+		{Kind: RedactEmail, Placeholder: "[EMAIL_1]", Count: 1},
+	}
+	redactions := m.Redactions()
+	if len(redactions) != len(want) {
+		t.Fatalf("Redactions() = %+v, want %+v", redactions, want)
+	}
+	for i := range want {
+		if redactions[i] != want[i] {
+			t.Errorf("Redactions()[%d] = %+v, want %+v", i, redactions[i], want[i])
+		}
+	}
+	counts := m.RedactionCounts()
+	if len(counts) != 2 || counts[RedactName] != 2 || counts[RedactEmail] != 1 {
+		t.Errorf("RedactionCounts() = %v", counts)
+	}
+	if got := redactionSummary(counts); got != "EMAIL=1 NAME=2" {
+		t.Errorf("redactionSummary = %q", got)
+	}
+}
+
+func TestRestoreLeavesUnknownPlaceholders(t *testing.T) {
+	m := NewPIIMap()
+	testRedactor().Redact("Jane", m)
+	if got := m.Restore("[NAME_1] and [NAME_2]"); got != "Jane and [NAME_2]" {
+		t.Errorf("Restore = %q", got)
+	}
+}
+
+func TestRedactWithoutRoster(t *testing.T) {
+	rd := NewRedactor(nil)
+	got := rd.Redact("Jane Doe, jane@example.org, 5551234567", NewPIIMap())
+	if want := "Jane Doe, [EMAIL_1], 5551234567"; got != want {
+		t.Errorf("Redact = %q, want %q", got, want)
+	}
+}
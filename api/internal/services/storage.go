@@ -1,9 +1,14 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"net/http"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -17,6 +22,7 @@ type StorageService struct {
 	client     *s3.Client
 	bucket     string
 	presigner  *s3.PresignClient
+	endpoint   *url.URL // for recognising this bucket's object URLs
 }
 
 // NewStorageService initialises the R2 client.
@@ -31,6 +37,11 @@ func NewStorageService(accountID, accessKey, secretKey, bucketName, endpoint str
 		return nil, fmt.Errorf("storage: load config: %w", err)
 	}
 
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("storage: parse endpoint: %w", err)
+	}
+
 	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
 		o.BaseEndpoint = aws.String(endpoint)
 		o.UsePathStyle = true
@@ -40,6 +51,7 @@ func NewStorageService(accountID, accessKey, secretKey, bucketName, endpoint str
 		client:    client,
 		bucket:    bucketName,
 		presigner: s3.NewPresignClient(client),
+		endpoint:  endpointURL,
 	}, nil
 }
 
@@ -85,6 +97,23 @@ func (s *StorageService) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// Copy duplicates an object within the bucket.
+func (s *StorageService) Copy(ctx context.Context, srcKey, dstKey string) error {
+	segments := strings.Split(srcKey, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(s.bucket),
+		CopySource: aws.String(s.bucket + "/" + strings.Join(segments, "/")),
+		Key:        aws.String(dstKey),
+	})
+	if err != nil {
+		return fmt.Errorf("storage: copy %q to %q: %w", srcKey, dstKey, err)
+	}
+	return nil
+}
+
 // ObjectKey builds a scoped R2 key for a school.
 // category is one of: attachments, reports, ids, documents.
 func ObjectKey(schoolID, category, filename string) string {
@@ -124,13 +153,11 @@ func (s *StorageService) HeadObject(ctx context.Context, key string) (*s3.HeadOb
 
 // PutObject uploads bytes directly (used for server-generated PDFs and QR codes).
 func (s *StorageService) PutObject(ctx context.Context, key string, body []byte, contentType string) error {
-	r := http.NoBody
-	_ = r
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      aws.String(s.bucket),
 		Key:         aws.String(key),
 		ContentType: aws.String(contentType),
-		Body:        bytesReader(body),
+		Body:        bytes.NewReader(body),
 	})
 	if err != nil {
 		return fmt.Errorf("storage: put object %q: %w", key, err)
@@ -138,21 +165,107 @@ func (s *StorageService) PutObject(ctx context.Context, key string, body []byte,
 	return nil
 }
 
-// bytesReader wraps a byte slice in an io.Reader.
-type bytesReaderWrapper struct {
-	data []byte
-	pos  int
+// PutFile uploads a file from disk, for archives too large to build in memory.
+func (s *StorageService) PutFile(ctx context.Context, key string, f *os.File, contentType string) error {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("storage: put object %q: %w", key, err)
+	}
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+		Body:        f,
+	})
+	if err != nil {
+		return fmt.Errorf("storage: put object %q: %w", key, err)
+	}
+	return nil
+}
+
+// OpenObject streams an object's body. The caller closes it.
+func (s *StorageService) OpenObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: get object %q: %w", key, err)
+	}
+	return out.Body, nil
+}
+
+// GetObject downloads an object's bytes (used for small files such as
+// branding images).
+func (s *StorageService) GetObject(ctx context.Context, key string) ([]byte, error) {
+	body, err := s.OpenObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("storage: read object %q: %w", key, err)
+	}
+	return b, nil
 }
 
-func bytesReader(b []byte) *bytesReaderWrapper {
-	return &bytesReaderWrapper{data: b}
+// MaxAssetBytes caps logos and signature images pulled into generated PDFs.
+const MaxAssetBytes = 5 << 20
+
+// AssetKey resolves a branding image reference (a school's logo_url, or a
+// key stored in its settings) to an object key under the school's own
+// prefix. The reference may be the key itself or a URL for an object in
+// this bucket, path-style under the storage endpoint as presigned links
+// are, or on the bucket's virtual host; any query string is ignored. Other
+// URLs are refused, since external images are never fetched.
+func (s *StorageService) AssetKey(schoolID, ref string) (string, error) {
+	key := ref
+	if u, err := url.Parse(ref); err == nil && u.Scheme != "" {
+		if s.endpoint == nil {
+			return "", fmt.Errorf("storage: asset %q is not in this bucket", ref)
+		}
+		base := strings.TrimSuffix(s.endpoint.Path, "/")
+		switch {
+		case strings.EqualFold(u.Host, s.endpoint.Host) && strings.HasPrefix(u.Path, base+"/"+s.bucket+"/"):
+			key = strings.TrimPrefix(u.Path, base+"/"+s.bucket+"/")
+		case strings.EqualFold(u.Host, s.bucket+"."+s.endpoint.Host) && strings.HasPrefix(u.Path, base+"/"):
+			key = strings.TrimPrefix(u.Path, base+"/")
+		default:
+			return "", fmt.Errorf("storage: asset %q is not in this bucket; upload it as a branding image", ref)
+		}
+	}
+
+	prefix := fmt.Sprintf("school-%s/", schoolID) // as built by ObjectKey
+	if !strings.HasPrefix(key, prefix) || path.Clean(key) != key || strings.Contains(key, "..") {
+		return "", fmt.Errorf("storage: asset %q is not an object under %s", ref, prefix)
+	}
+	return key, nil
 }
 
-func (r *bytesReaderWrapper) Read(p []byte) (int, error) {
-	if r.pos >= len(r.data) {
-		return 0, fmt.Errorf("EOF")
+// FetchAsset loads a branding image from R2, given any reference AssetKey
+// accepts.
+func (s *StorageService) FetchAsset(ctx context.Context, schoolID, ref string) ([]byte, error) {
+	key, err := s.AssetKey(schoolID, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: fetch asset %q: %w", key, err)
+	}
+	defer out.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(out.Body, MaxAssetBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("storage: fetch asset %q: %w", key, err)
+	}
+	if len(body) > MaxAssetBytes {
+		return nil, fmt.Errorf("storage: fetch asset %q: larger than %d bytes", key, MaxAssetBytes)
 	}
-	n := copy(p, r.data[r.pos:])
-	r.pos += n
-	return n, nil
+	return body, nil
 }
@@ -0,0 +1,609 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// School-editable templates
+//
+// A template is a Go text/template that produces layout markup. The PDF
+// renderer always draws the letterhead (logo, school name), the page footer,
+// and — for documents — the verification block; the template controls
+// everything in between.
+//
+// Markup, one construct per line:
+//
+//	# Title                  centred title
+//	## Section               section heading in the school's accent colour
+//	**Bold line**            a bold line
+//	| a | b | c |            table row; consecutive rows form a table and the first is the header
+//	---                      horizontal rule
+//	@signature               signature image, rule, and signatory name/title
+//	@pagebreak               start a new page
+//	(blank line)             paragraph break
+//
+// Any other line is body text. Data values are escaped so they cannot
+// introduce markup of their own.
+//
+// Templates run sandboxed: only the functions in templateFuncs are available,
+// {{define}}, {{block}}, and {{template}} are rejected, and execution is
+// bounded by output size, loop iterations, and time. Every range body is
+// instrumented to count its iterations, so a runaway loop (for example a
+// range over a large int) stops with an error rather than running on.
+
+// Template kinds: report cards plus each document type.
+const (
+	TemplateKindReportCard = "report_card"
+	TemplateKindTranscript = "transcript"
+	TemplateKindCustom     = "custom"
+)
+
+// TemplateKinds lists every kind a school may define templates for.
+var TemplateKinds = []string{
+	TemplateKindReportCard,
+	"enrollment_certificate",
+	"attendance_letter",
+	"academic_standing",
+	"tuition_confirmation",
+	TemplateKindTranscript,
+	TemplateKindCustom,
+}
+
+// Sandbox limits.
+const (
+	MaxTemplateSize      = 64 << 10
+	maxTemplateOutput    = 256 << 10
+	maxTemplateLoopSteps = 100_000 // range iterations per execution, nested loops included
+	templateTimeout      = 2 * time.Second
+)
+
+// TemplateData is the data contract exposed to templates as ".". Document
+// fields are empty for report cards and report card fields are empty for
+// documents.
+type TemplateData struct {
+	School         TemplateSchool
+	Student        TemplateStudent
+	GeneratedAt    time.Time
+	SignatoryName  string
+	SignatoryTitle string
+	Attendance     *TemplateAttendance // nil when attendance is not part of the document
+
+	// GPA for the report card's period, or the current GPA on an academic
+	// standing letter, and the cumulative GPA over recorded terms. Either is
+	// nil when not available.
+	TermGPA       *TemplateGPA
+	CumulativeGPA *TemplateGPA
+
+	// Documents.
+	DocumentType     string
+	DocumentTitle    string
+	VerificationCode string
+	VerificationURL  string
+	ExpiresAt        *time.Time
+	CustomContent    string
+	Transcript       *TemplateTranscript // transcripts only
+
+	// Report cards. Courses lists every course; PointsCourses and
+	// StandardsCourses split it by grading mode. GPA is TermGPA.GPA.
+	AcademicPeriod   string
+	GPA              float64
+	Courses          []TemplateCourse
+	PointsCourses    []TemplateCourse
+	StandardsCourses []TemplateCourse
+	TeacherComments  string
+	AdminComments    string
+	IsFinalized      bool
+}
+
+// RenderSnapshot is what a generated report card or document stores in
+// render_data so it can be re-rendered exactly. TemplateBody is set when no
+// saved template version was used: the built-in and legacy settings bodies
+// change over time, so the body rendered is recorded with the data.
+type RenderSnapshot struct {
+	TemplateData
+	TemplateBody string `json:",omitempty"`
+}
+
+// TemplateSchool is the school as seen by templates.
+type TemplateSchool struct {
+	Name    string
+	Address string
+}
+
+// TemplateStudent is the student as seen by templates.
+type TemplateStudent struct {
+	FirstName        string
+	LastName         string
+	FullName         string
+	StudentNumber    string
+	GradeLevel       string
+	EnrollmentStatus string
+}
+
+// TemplateCourse is one report card course row. Graded is false when nothing
+// in the course has been graded yet; LetterGrade is then "N/A". Courses in
+// standards mode have StandardsBased set and report Standards instead.
+type TemplateCourse struct {
+	Name           string
+	Teacher        string
+	Credits        float64
+	Level          string // regular, honors, ap, or ib
+	Percentage     float64
+	LetterGrade    string
+	Graded         bool
+	Comment        string
+	StandardsBased bool
+	Standards      []TemplateStandard
+}
+
+// TemplateStandard is a student's mastery of one standard. Assessed is false
+// when there is no evidence yet.
+type TemplateStandard struct {
+	Code        string
+	Description string
+	Score       float64 // 1–4
+	Level       int
+	Label       string // e.g. "Proficient"
+	Assessed    bool
+}
+
+// TemplateGPA mirrors models.GPAResult. GPA is the school's chosen method.
+type TemplateGPA struct {
+	Method           string // weighted or unweighted
+	GPA              float64
+	Unweighted       float64
+	Weighted         float64
+	CreditsAttempted float64
+	CreditsEarned    float64
+}
+
+// TemplateTranscript is a student's full academic record. The overall
+// cumulative GPA is the top-level CumulativeGPA.
+type TemplateTranscript struct {
+	Terms            []TemplateTranscriptTerm
+	CreditsAttempted float64
+	CreditsEarned    float64
+	CreditsRequired  float64 // 0 when the school sets no graduation requirement
+	GraduationStatus string
+}
+
+// TemplateTranscriptTerm is one term's completed courses with the term GPA
+// and the cumulative GPA through the term. CreditsEarned includes
+// standards-based courses, which carry no GPA.
+type TemplateTranscriptTerm struct {
+	Name          string
+	Courses       []TemplateCourse
+	CreditsEarned float64
+	GPA           TemplateGPA
+	Cumulative    TemplateGPA
+}
+
+// TemplateAttendance mirrors models.AttendanceSummary.
+type TemplateAttendance struct {
+	DaysRecorded int
+	Present      int
+	Absent       int
+	Tardy        int
+	Excused      int
+	Rate         float64
+}
+
+// templateFuncs is the complete set of functions templates may call, on top
+// of text/template's builtins (and, or, not, eq, printf, len, index, ...).
+var templateFuncs = template.FuncMap{
+	// date formats a time.Time or *time.Time; the layout is optional.
+	"date": func(t interface{}, layout ...string) string {
+		l := "January 2, 2006"
+		if len(layout) > 0 {
+			l = layout[0]
+		}
+		switch v := t.(type) {
+		case time.Time:
+			return v.Format(l)
+		case *time.Time:
+			if v != nil {
+				return v.Format(l)
+			}
+		}
+		return ""
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	// pct formats 91.234 as "91.2%".
+	"pct": func(f float64) string { return strconv.FormatFloat(f, 'f', 1, 64) + "%" },
+	// fixed formats a number with the given decimal places, clamped to 0–6.
+	"fixed": func(f float64, places int) string {
+		return strconv.FormatFloat(f, 'f', min(max(places, 0), 6), 64)
+	},
+}
+
+// loopTickFunc is called at the top of every range iteration. Its name is
+// not in templateFuncs, so a template body cannot call it itself.
+const loopTickFunc = "sandboxLoopTick"
+
+// loopTick is the action inserted into each range body.
+var loopTick = template.Must(template.New("tick").
+	Funcs(template.FuncMap{loopTickFunc: func() string { return "" }}).
+	Parse("{{" + loopTickFunc + "}}")).Tree.Root.Nodes[0]
+
+// ParseTemplate parses and checks a template body against the sandbox rules.
+func ParseTemplate(body string) (*template.Template, error) {
+	if len(body) > MaxTemplateSize {
+		return nil, fmt.Errorf("template is larger than %d bytes", MaxTemplateSize)
+	}
+	t, err := template.New("body").Funcs(templateFuncs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Templates()) > 1 {
+		return nil, errors.New("{{define}} and {{block}} are not allowed")
+	}
+	if t.Tree != nil && t.Tree.Root != nil {
+		if err := checkTemplateNode(t.Tree.Root); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// checkTemplateNode rejects constructs that could escape the sandbox's limits
+// and instruments each range body with loopTick.
+func checkTemplateNode(n parse.Node) error {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, c := range n.Nodes {
+			if err := checkTemplateNode(c); err != nil {
+				return err
+			}
+		}
+	case *parse.TemplateNode:
+		return errors.New("{{template}} is not allowed")
+	case *parse.IfNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		if err := checkBranch(&n.BranchNode); err != nil {
+			return err
+		}
+		if n.List == nil {
+			n.List = &parse.ListNode{NodeType: parse.NodeList, Pos: n.Pos}
+		}
+		n.List.Nodes = append([]parse.Node{loopTick}, n.List.Nodes...)
+	}
+	return nil
+}
+
+func checkBranch(b *parse.BranchNode) error {
+	if err := checkTemplateNode(b.List); err != nil {
+		return err
+	}
+	if b.ElseList != nil {
+		return checkTemplateNode(b.ElseList)
+	}
+	return nil
+}
+
+// errTemplateOutput is returned once a template writes more than maxTemplateOutput.
+var errTemplateOutput = fmt.Errorf("template output exceeds %d bytes", maxTemplateOutput)
+
+// errTemplateLoops is returned once a template runs more than maxTemplateLoopSteps iterations.
+var errTemplateLoops = fmt.Errorf("template loops run more than %d times", maxTemplateLoopSteps)
+
+// errTemplateTimeout is returned once a template runs past templateTimeout.
+var errTemplateTimeout = fmt.Errorf("template took longer than %s", templateTimeout)
+
+type limitedBuffer struct{ bytes.Buffer }
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > maxTemplateOutput {
+		return 0, errTemplateOutput
+	}
+	return b.Buffer.Write(p)
+}
+
+// ExecuteTemplate runs a template body in the sandbox and returns its markup.
+// Execution stops at the first range iteration past the loop budget or the
+// deadline, so a rejected template never keeps running in the background.
+func ExecuteTemplate(body string, data TemplateData) (string, error) {
+	t, err := ParseTemplate(body)
+	if err != nil {
+		return "", err
+	}
+
+	steps, deadline := 0, time.Now().Add(templateTimeout)
+	t.Funcs(template.FuncMap{loopTickFunc: func() (string, error) {
+		steps++
+		if steps > maxTemplateLoopSteps {
+			return "", errTemplateLoops
+		}
+		if steps%1000 == 0 && time.Now().After(deadline) {
+			return "", errTemplateTimeout
+		}
+		return "", nil
+	}})
+
+	var buf limitedBuffer
+	if err := t.Execute(&buf, escapeTemplateData(data)); err != nil {
+		for _, limit := range []error{errTemplateLoops, errTemplateTimeout, errTemplateOutput} {
+			if errors.Is(err, limit) {
+				return "", limit
+			}
+		}
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ValidateTemplate checks that a template parses and renders for kind against
+// both a fully populated and a sparse sample, so nil fields are caught at
+// save time rather than when a parent requests a document.
+func ValidateTemplate(kind, body string) error {
+	if strings.TrimSpace(body) == "" {
+		return errors.New("template is empty")
+	}
+	full, sparse := SampleTemplateData(kind)
+	for _, sample := range []TemplateData{full, sparse} {
+		if _, err := ExecuteTemplate(body, sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeTemplateData neutralises markup in every free-text value.
+func escapeTemplateData(d TemplateData) TemplateData {
+	line := func(s string) string { return escapeMarkup(strings.Join(strings.Fields(s), " ")) }
+
+	d.School.Name = line(d.School.Name)
+	d.School.Address = line(d.School.Address)
+	d.Student.FirstName = line(d.Student.FirstName)
+	d.Student.LastName = line(d.Student.LastName)
+	d.Student.FullName = line(d.Student.FullName)
+	d.Student.StudentNumber = line(d.Student.StudentNumber)
+	d.Student.GradeLevel = line(d.Student.GradeLevel)
+	d.SignatoryName = line(d.SignatoryName)
+	d.SignatoryTitle = line(d.SignatoryTitle)
+	d.AcademicPeriod = line(d.AcademicPeriod)
+	d.CustomContent = escapeMarkup(d.CustomContent)
+	d.TeacherComments = escapeMarkup(d.TeacherComments)
+	d.AdminComments = escapeMarkup(d.AdminComments)
+
+	escapeCourses := func(in []TemplateCourse) []TemplateCourse {
+		out := make([]TemplateCourse, len(in))
+		for i, c := range in {
+			c.Name = line(c.Name)
+			c.Teacher = line(c.Teacher)
+			c.Comment = line(c.Comment)
+			standards := make([]TemplateStandard, len(c.Standards))
+			for j, st := range c.Standards {
+				st.Code = line(st.Code)
+				st.Description = line(st.Description)
+				standards[j] = st
+			}
+			c.Standards = standards
+			out[i] = c
+		}
+		return out
+	}
+	d.Courses = escapeCourses(d.Courses)
+	d.PointsCourses = escapeCourses(d.PointsCourses)
+	d.StandardsCourses = escapeCourses(d.StandardsCourses)
+	if d.Transcript != nil {
+		t := *d.Transcript
+		t.GraduationStatus = line(t.GraduationStatus)
+		terms := make([]TemplateTranscriptTerm, len(t.Terms))
+		for i, term := range t.Terms {
+			term.Name = line(term.Name)
+			term.Courses = escapeCourses(term.Courses)
+			terms[i] = term
+		}
+		t.Terms = terms
+		d.Transcript = &t
+	}
+	return d
+}
+
+// escapeMarkup swaps table pipes for broken bars and prefixes lines that
+// would otherwise read as markup with a no-break space.
+func escapeMarkup(s string) string {
+	s = strings.ReplaceAll(strings.ReplaceAll(s, "\r", ""), "|", "¦")
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		t := strings.TrimSpace(l)
+		if strings.HasPrefix(t, "#") || strings.HasPrefix(t, "@") ||
+			strings.HasPrefix(t, "**") || strings.HasPrefix(t, "---") {
+			lines[i] = "\u00a0" + t
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SampleTemplateData returns a fully populated and a sparse example of the
+// data a template of the given kind receives, for validation and previews.
+func SampleTemplateData(kind string) (full, sparse TemplateData) {
+	now := time.Now()
+	expires := now.AddDate(0, 6, 0)
+	base := TemplateData{
+		School:         TemplateSchool{Name: "Riverside Academy", Address: "100 River Road, Springfield"},
+		Student:        TemplateStudent{FirstName: "Jordan", LastName: "Rivera", FullName: "Jordan Rivera", StudentNumber: "S-0001", GradeLevel: "10", EnrollmentStatus: "active"},
+		GeneratedAt:    now,
+		SignatoryName:  "Alex Morgan",
+		SignatoryTitle: "Principal",
+	}
+
+	full = base
+	full.Attendance = &TemplateAttendance{DaysRecorded: 90, Present: 84, Absent: 3, Tardy: 2, Excused: 1, Rate: 96.6}
+	full.TermGPA = &TemplateGPA{Method: "weighted", GPA: 3.62, Unweighted: 3.42, Weighted: 3.62, CreditsAttempted: 3.5, CreditsEarned: 3.5}
+	full.CumulativeGPA = &TemplateGPA{Method: "weighted", GPA: 3.55, Unweighted: 3.38, Weighted: 3.55, CreditsAttempted: 14, CreditsEarned: 14}
+	if kind == TemplateKindReportCard {
+		full.AcademicPeriod = "Fall Semester 2025"
+		full.GPA = full.TermGPA.GPA
+		full.Courses = []TemplateCourse{
+			{Name: "Algebra II", Teacher: "Sam Lee", Credits: 1, Level: "honors", Percentage: 91.3, LetterGrade: "A-", Graded: true, Comment: "Consistent effort."},
+			{Name: "World History", Teacher: "Pat Kim", Credits: 1, Level: "ap", Percentage: 84.0, LetterGrade: "B", Graded: true},
+			{Name: "Art", Teacher: "Robin Diaz", Credits: 0.5, Level: "regular", LetterGrade: "N/A"},
+			{Name: "Science", Teacher: "Casey Park", Credits: 1, Level: "regular", LetterGrade: "N/A", StandardsBased: true, Standards: []TemplateStandard{
+				{Code: "SCI.1", Description: "Plans and carries out investigations", Score: 3.2, Level: 3, Label: "Proficient", Assessed: true},
+				{Code: "SCI.2", Description: "Analyzes and interprets data"},
+			}},
+		}
+		for _, c := range full.Courses {
+			if c.StandardsBased {
+				full.StandardsCourses = append(full.StandardsCourses, c)
+			} else {
+				full.PointsCourses = append(full.PointsCourses, c)
+			}
+		}
+		full.TeacherComments = "Jordan has made steady progress this term."
+		full.AdminComments = "Congratulations on making the honor roll."
+		full.IsFinalized = true
+	} else {
+		full.DocumentType = kind
+		full.DocumentTitle = DocumentTitle(kind)
+		full.VerificationCode = "SAMPLE-CODE"
+		full.VerificationURL = "https://example.com/verify/SAMPLE-CODE"
+		full.ExpiresAt = &expires
+		full.CustomContent = "Additional details provided by the school."
+	}
+	if kind == TemplateKindTranscript {
+		full.ExpiresAt = nil
+		full.Transcript = &TemplateTranscript{
+			Terms: []TemplateTranscriptTerm{
+				{
+					Name: "Fall Semester 2024",
+					Courses: []TemplateCourse{
+						{Name: "Geometry", Teacher: "Sam Lee", Credits: 1, Level: "regular", Percentage: 88.2, LetterGrade: "B+", Graded: true},
+						{Name: "English 9", Teacher: "Pat Kim", Credits: 1, Level: "honors", Percentage: 93.5, LetterGrade: "A", Graded: true},
+					},
+					CreditsEarned: 2,
+					GPA:           TemplateGPA{Method: "weighted", GPA: 3.9, Unweighted: 3.65, Weighted: 3.9, CreditsAttempted: 2, CreditsEarned: 2},
+					Cumulative:    TemplateGPA{Method: "weighted", GPA: 3.9, Unweighted: 3.65, Weighted: 3.9, CreditsAttempted: 2, CreditsEarned: 2},
+				},
+				{
+					Name: "Spring Semester 2025",
+					Courses: []TemplateCourse{
+						{Name: "Biology", Teacher: "Casey Park", Credits: 1, Level: "ap", Percentage: 85.0, LetterGrade: "B", Graded: true},
+						{Name: "Art", Teacher: "Robin Diaz", Credits: 0.5, Level: "regular", LetterGrade: "N/A", StandardsBased: true},
+					},
+					CreditsEarned: 1.5,
+					GPA:           TemplateGPA{Method: "weighted", GPA: 4.0, Unweighted: 3.0, Weighted: 4.0, CreditsAttempted: 1, CreditsEarned: 1},
+					Cumulative:    TemplateGPA{Method: "weighted", GPA: 3.93, Unweighted: 3.43, Weighted: 3.93, CreditsAttempted: 3, CreditsEarned: 3},
+				},
+			},
+			CreditsAttempted: 3.5,
+			CreditsEarned:    3.5,
+			CreditsRequired:  24,
+			GraduationStatus: "In progress",
+		}
+		full.CumulativeGPA = &full.Transcript.Terms[1].Cumulative
+	}
+
+	sparse = base
+	sparse.DocumentType = full.DocumentType
+	sparse.DocumentTitle = full.DocumentTitle
+	sparse.AcademicPeriod = full.AcademicPeriod
+	return full, sparse
+}
+
+// BuiltinTemplate returns the compiled-in template for a kind, used when a
+// school has not defined its own.
+func BuiltinTemplate(kind string) string {
+	switch kind {
+	case TemplateKindReportCard:
+		return builtinReportCardTemplate
+	case TemplateKindTranscript:
+		return builtinTranscriptTemplate
+	}
+	return builtinDocumentTemplate
+}
+
+const builtinReportCardTemplate = `## Report Card — {{.AcademicPeriod}}
+Student: {{.Student.FullName}}
+Student Number: {{.Student.StudentNumber}}
+Grade Level: {{.Student.GradeLevel}}
+{{if .IsFinalized}}**FINALIZED**{{end}}
+
+{{if .PointsCourses}}
+| Course | Teacher | Credits | % | Grade | Comment |
+{{range .PointsCourses}}| {{.Name}}{{if and .Level (ne .Level "regular")}} ({{upper .Level}}){{end}} | {{.Teacher}} | {{fixed .Credits 1}} | {{if .Graded}}{{pct .Percentage}}{{else}}—{{end}} | {{.LetterGrade}} | {{.Comment}} |
+{{end}}
+{{with .TermGPA}}## GPA: {{fixed .GPA 3}} ({{.Method}}), {{fixed .CreditsEarned 1}} credits earned{{end}}
+{{with .CumulativeGPA}}## Cumulative GPA: {{fixed .GPA 3}} ({{.Method}}), {{fixed .CreditsEarned 1}} credits earned{{end}}
+{{end}}
+{{range .StandardsCourses}}
+## {{.Name}} — Standards
+Teacher: {{.Teacher}}
+
+| Standard | Description | Score | Proficiency |
+{{range .Standards}}| {{.Code}} | {{.Description}} | {{if .Assessed}}{{fixed .Score 2}}{{else}}—{{end}} | {{if .Assessed}}{{.Level}} – {{.Label}}{{else}}Not yet assessed{{end}} |
+{{end}}
+{{end}}
+{{with .Attendance}}
+## Attendance
+| Days Recorded | Present | Tardy | Absent | Excused | Rate |
+| {{.DaysRecorded}} | {{.Present}} | {{.Tardy}} | {{.Absent}} | {{.Excused}} | {{pct .Rate}} |
+{{end}}
+{{if .TeacherComments}}
+## Teacher Comments
+{{.TeacherComments}}
+{{end}}
+{{if .AdminComments}}
+## Administration Note
+{{.AdminComments}}
+{{end}}
+@signature
+`
+
+const builtinDocumentTemplate = `# {{.DocumentTitle}}
+
+This is to certify that {{.Student.FullName}} (Student Number: {{.Student.StudentNumber}}, Grade {{.Student.GradeLevel}}) is {{if eq .Student.EnrollmentStatus "active"}}currently enrolled{{else}}{{.Student.EnrollmentStatus}}{{end}} at {{.School.Name}}.
+{{if and (eq .DocumentType "attendance_letter") .Attendance}}
+{{with .Attendance}}Of {{.DaysRecorded}} school days on record, the student was present on {{.Present}}, tardy on {{.Tardy}}, absent on {{.Absent}}, and excused on {{.Excused}}, for an attendance rate of {{pct .Rate}}.{{end}}
+{{end}}
+{{if eq .DocumentType "academic_standing"}}
+{{with .TermGPA}}The student's current grade point average is {{fixed .GPA 3}} ({{.Method}}).{{end}}{{with .CumulativeGPA}} Their cumulative grade point average is {{fixed .GPA 3}} ({{.Method}}) over {{fixed .CreditsEarned 1}} credits earned.{{end}}
+{{end}}
+{{if .CustomContent}}
+{{.CustomContent}}
+{{end}}
+
+This document was issued on {{date .GeneratedAt}}{{if .ExpiresAt}} and is valid through {{date .ExpiresAt}}{{end}}.
+
+@signature
+`
+
+const builtinTranscriptTemplate = `# {{.DocumentTitle}}
+Student: {{.Student.FullName}}
+Student Number: {{.Student.StudentNumber}}
+Grade Level: {{.Student.GradeLevel}}
+{{with .Transcript}}
+{{range .Terms}}
+## {{.Name}}
+| Course | Level | Credits | % | Grade |
+{{range .Courses}}| {{.Name}} | {{upper .Level}} | {{fixed .Credits 1}} | {{if .Graded}}{{pct .Percentage}}{{else}}—{{end}} | {{if .StandardsBased}}Standards-based{{else}}{{.LetterGrade}}{{end}} |
+{{end}}
+Term GPA: {{fixed .GPA.GPA 3}} — Cumulative GPA: {{fixed .Cumulative.GPA 3}} — Credits earned: {{fixed .CreditsEarned 1}}
+{{else}}
+No completed courses are on record.
+{{end}}
+---
+{{with $.CumulativeGPA}}**Cumulative GPA: {{fixed .GPA 3}} ({{.Method}}); unweighted {{fixed .Unweighted 3}}**{{end}}
+**Credits earned: {{fixed .CreditsEarned 1}}{{if .CreditsRequired}} of {{fixed .CreditsRequired 1}} required{{end}}**
+**Graduation status: {{.GraduationStatus}}**
+{{end}}
+
+This transcript was issued on {{date .GeneratedAt}}.
+
+@signature
+`
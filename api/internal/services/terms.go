@@ -0,0 +1,31 @@
+package services
+
+import (
+	"time"
+
+	"github.com/pragma-proto/api/internal/models"
+)
+
+// termDepth ranks term types so the most specific term wins.
+var termDepth = map[string]int{
+	models.TermYear:     0,
+	models.TermSemester: 1,
+	models.TermQuarter:  2,
+}
+
+// ResolveCurrentTerm returns the most specific term containing at, preferring a
+// quarter over its semester and a semester over its year. Returns nil when no
+// term covers the date.
+func ResolveCurrentTerm(terms []models.Term, at time.Time) *models.Term {
+	var best *models.Term
+	for i := range terms {
+		t := &terms[i]
+		if !t.Contains(at) {
+			continue
+		}
+		if best == nil || termDepth[t.TermType] > termDepth[best.TermType] {
+			best = t
+		}
+	}
+	return best
+}